@@ -1,11 +1,19 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -15,11 +23,78 @@ import (
 )
 
 func main() {
+	// Open the append-only file every mutation is recorded to, if enabled
+	aofPath := os.Getenv("AOF_PATH")
+	var aofFile *os.File
+	var aof *memory.AOFWriter
+	if aofPath != "" {
+		var err error
+		aofFile, err = os.OpenFile(aofPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			log.Fatalf("Failed to open append-only file %s: %v", aofPath, err)
+		}
+		aof, err = memory.NewAOFWriter(aofFile, memory.FsyncPolicy(os.Getenv("AOF_FSYNC")))
+		if err != nil {
+			log.Fatalf("Invalid AOF_FSYNC: %v", err)
+		}
+	}
+
 	// Create IStore instance
-	var memoryStore store.IStore = memory.NewMemoryStore()
+	memoryStore := memory.NewMemoryStoreWithOptions(memory.Options{
+		ListSampleInterval:    getEnvDurationOrDefault("LIST_SAMPLE_INTERVAL", 0),
+		LockMetrics:           getEnvBoolOrDefault("LOCK_METRICS", false),
+		LockHoldThreshold:     getEnvDurationOrDefault("LOCK_HOLD_THRESHOLD", 0),
+		SoftDeleteWindow:      getEnvDurationOrDefault("SOFT_DELETE_WINDOW", 0),
+		MaxMemoryBytes:        int64(getEnvIntOrDefault("MAX_MEMORY_BYTES", 0)),
+		MaxKeys:               getEnvIntOrDefault("MAX_KEYS", 0),
+		OnFull:                getEnvFullPolicy("ON_FULL"),
+		MaxListItemBytes:      getEnvIntOrDefault("MAX_LIST_ITEM_BYTES", 0),
+		CompressThreshold:     getEnvIntOrDefault("COMPRESS_THRESHOLD", 0),
+		ListPushTimes:         getEnvBoolOrDefault("LIST_PUSH_TIMES", false),
+		EventBufferSize:       getEnvIntOrDefault("EVENT_BUFFER_SIZE", 0),
+		KeyspaceNotifications: getEnvBoolOrDefault("KEYSPACE_NOTIFICATIONS", false),
+		ReadIndex:             getEnvBoolOrDefault("READ_INDEX", false),
+		AOF:                   aof,
+		TTLDefaults: store.TTLDefaults{
+			Rules:           getEnvTTLRules("TTL_DEFAULTS"),
+			FallbackSeconds: getEnvIntOrDefault("DEFAULT_TTL_SECONDS", 0),
+		},
+	})
+
+	// Rebuild the store from the append-only file, which holds every write, or else
+	// from the snapshot saved on the last shutdown, if any
+	snapshotPath := os.Getenv("SNAPSHOT_PATH")
+	switch {
+	case aofPath != "":
+		if err := replayAOF(memoryStore, aofPath); err != nil {
+			log.Fatalf("Failed to replay append-only file %s: %v", aofPath, err)
+		}
+	case snapshotPath != "":
+		if err := loadSnapshot(memoryStore, snapshotPath); err != nil {
+			log.Fatalf("Failed to load snapshot from %s: %v", snapshotPath, err)
+		}
+	}
 
 	// Create API handler
-	handler := api.NewHandler(memoryStore)
+	handler := api.NewHandler(memoryStore,
+		api.WithContentType(getEnvOrDefault("RESPONSE_CONTENT_TYPE", "")),
+		api.WithCommandLog(getEnvLogWriter("COMMAND_LOG")),
+		api.WithAccessLog(getEnvLogWriter("ACCESS_LOG")),
+		api.WithErrorVerbosity(getEnvErrorVerbosity("ERROR_VERBOSITY")),
+		api.WithMaxJSONDepth(getEnvIntOrDefault("MAX_JSON_DEPTH", 0)),
+		api.WithFlush(getEnvBoolOrDefault("ENABLE_FLUSH", false)),
+	)
+	// Let browser pages from CORS_ALLOWED_ORIGINS call the API, answering their
+	// preflight requests before any other middleware can reject them
+	handler.Use(handler.CORSMiddleware(getEnvList("CORS_ALLOWED_ORIGINS")))
+	// Require ADMIN_TOKEN on the admin UI and admin API when it is set
+	handler.Use(handler.AdminAuthMiddleware(os.Getenv("ADMIN_TOKEN")))
+	// Reject requests beyond the concurrency limit instead of queueing them
+	handler.Use(handler.BulkheadMiddleware(getEnvIntOrDefault("MAX_CONCURRENT_REQUESTS", 0), 0))
+	// Keep a single client IP from taking up all request slots
+	handler.Use(handler.PerIPLimitMiddleware(getEnvIntOrDefault("MAX_REQUESTS_PER_IP", 0), 0))
+	// Keep a single client IP from sending requests faster than RATE_LIMIT_RPS
+	handler.Use(handler.RateLimitMiddleware(getEnvIntOrDefault("RATE_LIMIT_RPS", 0), getEnvIntOrDefault("RATE_LIMIT_BURST", 0)))
 	// Setup routes
 	routes := handler.SetupRoutes()
 
@@ -28,7 +103,12 @@ func main() {
 	server := &http.Server{
 		Addr:    ":" + port,
 		Handler: routes,
+		// Request bodies are bounded by the handlers, headers are bounded here.
+		ReadHeaderTimeout: 10 * time.Second,
 	}
+	// End event streams and blocking pops on shutdown, which would otherwise keep it
+	// waiting
+	server.RegisterOnShutdown(handler.Close)
 	go func() {
 		log.Printf("starting server on port %s", port)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -42,19 +122,255 @@ func main() {
 
 	log.Println("Server is shutting down...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	timeout := getEnvDurationOrDefault("SHUTDOWN_TIMEOUT", 10*time.Second)
+	// Workers are stopped after the server has drained, in the order given.
+	shutdownErr := shutdown(server, timeout, memoryStore.StopListSampler, memoryStore.StopTTLWorker, memoryStore.StopLockWatchdog)
+
+	// Save the snapshot even if draining timed out, so the data is not lost
+	if snapshotPath != "" {
+		if err := saveSnapshot(memoryStore, snapshotPath); err != nil {
+			log.Printf("Failed to save snapshot to %s: %v", snapshotPath, err)
+		} else {
+			log.Printf("saved snapshot to %s", snapshotPath)
+		}
+	}
+
+	if aof != nil {
+		if err := aof.Close(); err != nil {
+			log.Printf("Failed to sync append-only file %s: %v", aofPath, err)
+		}
+		aofFile.Close()
+	}
 
-	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown with error: %v", err)
+	if shutdownErr != nil {
+		log.Fatalf("Server forced to shutdown with error: %v", shutdownErr)
 	}
 
 	log.Println("Server exited gracefully")
 }
 
+// shutdown stops the server from accepting new requests, waits for in-flight requests
+// to finish and then stops the background workers in order, all within timeout.
+// Workers are stopped even if draining the server times out, but shutdown does not
+// wait for them past the timeout.
+func shutdown(server *http.Server, timeout time.Duration, stopWorkers ...func()) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	serverErr := server.Shutdown(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, stop := range stopWorkers {
+			stop()
+		}
+	}()
+
+	select {
+	case <-done:
+		return serverErr
+	case <-ctx.Done():
+		if serverErr != nil {
+			return serverErr
+		}
+		return ctx.Err()
+	}
+}
+
+// loadSnapshot restores the snapshot at path into memoryStore. A missing file is not an
+// error, as there is nothing to restore on the first start.
+func loadSnapshot(memoryStore *memory.MemoryStore, path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = memoryStore.RestoreSnapshot(f)
+	if errors.Is(err, memory.ErrChecksumMismatch) {
+		// The other keys were restored, so the server can still start.
+		log.Printf("warning: snapshot %s has corrupted entries: %v", path, err)
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("loaded snapshot from %s", path)
+	return nil
+}
+
+// saveSnapshot writes a snapshot of memoryStore to path. It is written to a temporary
+// file in the same directory first and renamed over path, so a crash while saving
+// leaves the previous snapshot in place.
+func saveSnapshot(memoryStore *memory.MemoryStore, path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := memoryStore.Snapshot(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// replayAOF rebuilds memoryStore from the append-only file at path. A record cut short
+// by a crash is not replayed and is truncated away, so new records start on a line of
+// their own.
+func replayAOF(memoryStore *memory.MemoryStore, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := memoryStore.ReplayAOF(bytes.NewReader(data)); err != nil {
+		return err
+	}
+	if complete := bytes.LastIndexByte(data, '\n') + 1; complete < len(data) {
+		log.Printf("truncating %d bytes of an incomplete record from %s", len(data)-complete, path)
+		if err := os.Truncate(path, int64(complete)); err != nil {
+			return err
+		}
+	}
+	log.Printf("replayed append-only file %s", path)
+	return nil
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid duration for %s: %v", key, err)
+	}
+	return d
+}
+
+func getEnvIntOrDefault(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("Invalid integer for %s: %v", key, err)
+	}
+	return n
+}
+
+func getEnvBoolOrDefault(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("Invalid boolean for %s: %v", key, err)
+	}
+	return b
+}
+
+// getEnvList returns the comma separated values of the variable, trimmed of spaces,
+// or nil if it is not set.
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvLogWriter returns the writer a log goes to: stdout for "stdout", the file at
+// the given path otherwise, appended to, or nil if the variable is not set.
+func getEnvLogWriter(key string) io.Writer {
+	switch value := os.Getenv(key); value {
+	case "":
+		return nil
+	case "stdout":
+		return os.Stdout
+	default:
+		f, err := os.OpenFile(value, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			log.Fatalf("Invalid log file for %s: %v", key, err)
+		}
+		return f
+	}
+}
+
+func getEnvErrorVerbosity(key string) api.ErrorVerbosity {
+	switch verbosity := api.ErrorVerbosity(os.Getenv(key)); verbosity {
+	case "", api.ErrorVerbosityDev, api.ErrorVerbosityProduction:
+		return verbosity
+	default:
+		log.Fatalf("Invalid error verbosity for %s: %q must be %q or %q", key, verbosity, api.ErrorVerbosityDev, api.ErrorVerbosityProduction)
+		return ""
+	}
+}
+
+func getEnvFullPolicy(key string) memory.FullPolicy {
+	switch policy := memory.FullPolicy(os.Getenv(key)); policy {
+	case "", memory.FullReject, memory.FullEvict:
+		return policy
+	default:
+		log.Fatalf("Invalid full policy for %s: %q must be %q or %q", key, policy, memory.FullReject, memory.FullEvict)
+		return ""
+	}
+}
+
+func getEnvTTLRules(key string) []store.TTLRule {
+	rules, err := parseTTLRules(os.Getenv(key))
+	if err != nil {
+		log.Fatalf("Invalid TTL rules for %s: %v", key, err)
+	}
+	return rules
+}
+
+// parseTTLRules parses a comma separated list of prefix=seconds pairs, such as
+// "session:=1800,config:=0", keeping their order.
+func parseTTLRules(value string) ([]store.TTLRule, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var rules []store.TTLRule
+	for _, pair := range strings.Split(value, ",") {
+		i := strings.LastIndex(pair, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("%q is not a prefix=seconds pair", pair)
+		}
+
+		seconds, err := strconv.Atoi(pair[i+1:])
+		if err != nil || seconds < 0 {
+			return nil, fmt.Errorf("%q must have a non-negative number of seconds", pair)
+		}
+		rules = append(rules, store.TTLRule{Prefix: pair[:i], TTLSeconds: seconds})
+	}
+	return rules, nil
+}
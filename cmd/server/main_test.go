@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+// startServer serves handler on a random local port.
+func startServer(t *testing.T, handler http.Handler) (*http.Server, string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	server := &http.Server{Handler: handler}
+	go server.Serve(listener)
+
+	return server, "http://" + listener.Addr().String()
+}
+
+func TestShutdown_StopsWorkersInOrder(t *testing.T) {
+	server, _ := startServer(t, http.NotFoundHandler())
+
+	var mu sync.Mutex
+	var order []string
+	worker := func(name string) func() {
+		return func() {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+		}
+	}
+
+	if err := shutdown(server, time.Second, worker("sampler"), worker("ttl")); err != nil {
+		t.Fatalf("Expected clean shutdown, got %v", err)
+	}
+
+	if len(order) != 2 || order[0] != "sampler" || order[1] != "ttl" {
+		t.Errorf("Expected workers stopped in order [sampler ttl], got %v", order)
+	}
+}
+
+func TestShutdown_RespectsTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	started := make(chan struct{})
+	server, url := startServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	go http.Get(url)
+	<-started
+
+	stopped := make(chan struct{})
+	begin := time.Now()
+	err := shutdown(server, 200*time.Millisecond, func() { close(stopped) })
+	elapsed := time.Since(begin)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if elapsed > time.Second {
+		t.Errorf("Expected shutdown to return after about 200ms, took %v", elapsed)
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Error("Expected workers to be stopped even when draining times out")
+	}
+}
+
+func TestParseTTLRules(t *testing.T) {
+	rules, err := parseTTLRules("session:=1800,config:=0,a=b:=5")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(rules) != 3 || rules[0].Prefix != "session:" || rules[0].TTLSeconds != 1800 ||
+		rules[1].Prefix != "config:" || rules[1].TTLSeconds != 0 || rules[2].Prefix != "a=b:" {
+		t.Errorf("Expected rules in order, got %+v", rules)
+	}
+
+	for _, value := range []string{"session:", "session:=soon", "session:=-1"} {
+		if _, err := parseTTLRules(value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestSaveLoadSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.snapshot")
+	ctx := context.Background()
+
+	// Nothing to load on the first start
+	empty := memory.NewMemoryStore()
+	defer empty.StopTTLWorker()
+	if err := loadSnapshot(empty, path); err != nil {
+		t.Fatalf("Expected a missing snapshot to be ignored, got %v", err)
+	}
+
+	saved := memory.NewMemoryStore()
+	defer saved.StopTTLWorker()
+	saved.Set(ctx, "user", "alice", 0)
+	if err := saveSnapshot(saved, path); err != nil {
+		t.Fatalf("Failed to save snapshot: %v", err)
+	}
+
+	loaded := memory.NewMemoryStore()
+	defer loaded.StopTTLWorker()
+	if err := loadSnapshot(loaded, path); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if got, _ := loaded.Get(ctx, "user"); got != "alice" {
+		t.Errorf("Expected alice, got %q", got)
+	}
+
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("Expected only the snapshot file to be left, got %d entries", len(entries))
+	}
+}
+
+func TestReplayAOF_TruncatesTornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.aof")
+	ctx := context.Background()
+
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	aof, _ := memory.NewAOFWriter(f, memory.FsyncAlways)
+	written := memory.NewMemoryStoreWithOptions(memory.Options{AOF: aof})
+	defer written.StopTTLWorker()
+	written.Set(ctx, "user", "alice", 0)
+	f.WriteString(`{"op":"set","key":"to`)
+	f.Close()
+
+	replayed := memory.NewMemoryStore()
+	defer replayed.StopTTLWorker()
+	if err := replayAOF(replayed, path); err != nil {
+		t.Fatalf("Failed to replay: %v", err)
+	}
+	if got, _ := replayed.Get(ctx, "user"); got != "alice" {
+		t.Errorf("Expected alice, got %q", got)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.HasSuffix(string(data), "\n") || strings.Contains(string(data), `"key":"to`) {
+		t.Errorf("Expected the torn record to be truncated, got %q", data)
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/mo-mohamed/acronis-memory-store
 
 go 1.21.1
+
+require golang.org/x/time v0.10.0
@@ -0,0 +1,330 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// Orderings accepted by TopKeysHandler.
+const (
+	TopBySize   = "size"
+	TopByTTL    = "ttl"
+	TopByAccess = "access"
+)
+
+const (
+	// defaultTopKeys is the number of keys TopKeysHandler and SampleKeysHandler return
+	// when n is not given.
+	defaultTopKeys = 10
+	// maxTopKeys bounds n for TopKeysHandler and SampleKeysHandler.
+	maxTopKeys = 1000
+)
+
+// TopKeysHandler returns the largest, longest lived or most accessed keys
+// GET /api/v1/admin/top?by={size|ttl|access}&n={n}
+func (h *Handler) TopKeysHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	by := TopBySize
+	if query.Has("by") {
+		by = query.Get("by")
+	}
+
+	n := defaultTopKeys
+	if query.Has("n") {
+		var err error
+		if n, err = strconv.Atoi(query.Get("n")); err != nil || n <= 0 || n > maxTopKeys {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("N must be an integer between 1 and %d", maxTopKeys))
+			return
+		}
+	}
+
+	var top func(ctx context.Context, n int) ([]store.KeySize, error)
+	switch by {
+	case TopBySize:
+		top = h.store.TopKeysBySize
+	case TopByTTL:
+		top = h.store.TopKeysByTTL
+	case TopByAccess:
+		top = h.store.TopKeysByAccess
+	default:
+		h.writeError(w, http.StatusBadRequest, "By must be one of size, ttl or access")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	keys, err := h.withoutUploads(ctx, n, top)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get top keys: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, TopKeysResponse{By: by, Keys: keys})
+}
+
+// SampleKeysHandler returns a uniform random sample of live keys with their sizes
+// GET /api/v1/admin/sample?n={n}
+func (h *Handler) SampleKeysHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	n := defaultTopKeys
+	if query := r.URL.Query(); query.Has("n") {
+		var err error
+		if n, err = strconv.Atoi(query.Get("n")); err != nil || n <= 0 || n > maxTopKeys {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("N must be an integer between 1 and %d", maxTopKeys))
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	keys, err := h.withoutUploads(ctx, n, h.store.RandomKeys)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to sample keys: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, SampleKeysResponse{Keys: keys})
+}
+
+// ExportHandler dumps the live keys matching a glob pattern with their type, value and TTL
+// GET /api/v1/admin/export?pattern={pattern}
+func (h *Handler) ExportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	pattern := r.URL.Query().Get("pattern")
+	if pattern == "" {
+		h.writeError(w, http.StatusBadRequest, "Pattern is required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	keys, err := h.store.ExportPattern(ctx, pattern)
+	if err != nil {
+		if errors.Is(err, store.ErrInvalidPattern) {
+			h.writeError(w, http.StatusBadRequest, "Invalid pattern")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to export keys: %v", err))
+		return
+	}
+
+	dumps := make([]store.KeyDump, 0, len(keys))
+	for _, dump := range keys {
+		if !reservedKey(dump.Key) {
+			dumps = append(dumps, dump)
+		}
+	}
+
+	h.writeSuccess(w, ExportResponse{Pattern: pattern, Keys: dumps})
+}
+
+// withoutUploads returns up to n keys picked by pick, leaving out uploads in progress.
+// It asks pick for as many more keys as there are uploads, so up to n others remain.
+func (h *Handler) withoutUploads(ctx context.Context, n int, pick func(ctx context.Context, n int) ([]store.KeySize, error)) ([]store.KeySize, error) {
+	uploads, err := h.store.Keys(ctx, uploadKeyPattern)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := pick(ctx, n+len(uploads))
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make([]store.KeySize, 0, n)
+	for _, key := range keys {
+		if !reservedKey(key.Key) && len(visible) < n {
+			visible = append(visible, key)
+		}
+	}
+	return visible, nil
+}
+
+// VerifyHandler checks every live key against the checksum taken when it was written
+// GET /api/v1/admin/verify
+func (h *Handler) VerifyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	problems, err := h.store.VerifyIntegrity(ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to verify keys: %v", err))
+		return
+	}
+	if problems == nil {
+		problems = []string{}
+	}
+
+	h.writeSuccess(w, VerifyResponse{OK: len(problems) == 0, Problems: problems})
+}
+
+// maxTTLBuckets bounds the number of buckets TTLHistogramHandler accepts.
+const maxTTLBuckets = 100
+
+// TTLHistogramHandler counts live keys by remaining TTL. Buckets default to 1m, 1h and 24h
+// GET /api/v1/admin/ttl-histogram[?buckets={duration},{duration},...]
+func (h *Handler) TTLHistogramHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var buckets []time.Duration
+	if query := r.URL.Query().Get("buckets"); query != "" {
+		fields := strings.Split(query, ",")
+		if len(fields) > maxTTLBuckets {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("At most %d buckets are allowed", maxTTLBuckets))
+			return
+		}
+		for _, field := range fields {
+			d, err := time.ParseDuration(strings.TrimSpace(field))
+			if err != nil {
+				h.writeError(w, http.StatusBadRequest, "Buckets must be durations such as 1m or 24h")
+				return
+			}
+			buckets = append(buckets, d)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	histogram, err := h.store.TTLHistogram(ctx, buckets)
+	if err != nil {
+		if errors.Is(err, store.ErrInvalidBuckets) {
+			h.writeError(w, http.StatusBadRequest, "Buckets must be positive and distinct")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get TTL histogram: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, TTLHistogramResponse{Buckets: histogram})
+}
+
+// HealthHandler reports the status of the store's background workers
+// GET /api/v1/admin/health
+func (h *Handler) HealthHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	workers, err := h.store.Health(ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get health: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, HealthResponse{Ready: failingWorker(workers) == nil, Workers: workers})
+}
+
+// ConfigHandler returns the configuration the store is running with, such as its TTL defaults
+// GET /api/v1/admin/config
+func (h *Handler) ConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	config, err := h.store.Config(ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get config: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, config)
+}
+
+// PauseTTLWorkerHandler stops expired keys from being swept until the worker is resumed
+// POST /api/v1/admin/ttl-worker/pause
+func (h *Handler) PauseTTLWorkerHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h.store.PauseTTLWorker()
+	h.writeSuccess(w, TTLWorkerResponse{Paused: true})
+}
+
+// ResumeTTLWorkerHandler resumes sweeping expired keys after PauseTTLWorkerHandler
+// POST /api/v1/admin/ttl-worker/resume
+func (h *Handler) ResumeTTLWorkerHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h.store.ResumeTTLWorker()
+	h.writeSuccess(w, TTLWorkerResponse{Paused: false})
+}
+
+// ReadyHandler reports whether the server is ready to serve traffic. It fails while
+// any background worker is failing, so data is not silently lost to a broken worker.
+// GET /readyz
+func (h *Handler) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	workers, err := h.store.Health(ctx)
+	if err != nil {
+		h.writeError(w, http.StatusServiceUnavailable, fmt.Sprintf("Failed to get health: %v", err))
+		return
+	}
+
+	if failing := failingWorker(workers); failing != nil {
+		h.writeError(w, http.StatusServiceUnavailable, fmt.Sprintf("Worker %s is failing: %s", failing.Name, failing.LastError))
+		return
+	}
+
+	h.writeSuccess(w, map[string]bool{"ready": true})
+}
+
+// failingWorker returns the first unhealthy worker, or nil if all are healthy.
+func failingWorker(workers []store.WorkerHealth) *store.WorkerHealth {
+	for i := range workers {
+		if !workers[i].Healthy {
+			return &workers[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+func TestHandler_Readiness(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	mux := NewHandler(memoryStore).SetupRoutes()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 before any failure, got %d", w.Code)
+	}
+
+	// Simulate a persistence backend failing to write.
+	memoryStore.ReportWorker("snapshot", errors.New("write snapshot: no space left on device"))
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 while a worker is failing, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "no space left on device") {
+		t.Errorf("Expected the worker error in the response, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/admin/health", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var resp struct {
+		Data HealthResponse `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Data.Ready || len(resp.Data.Workers) == 0 {
+		t.Fatalf("Expected not ready with workers reported, got %+v", resp.Data)
+	}
+	snapshot := resp.Data.Workers[len(resp.Data.Workers)-1]
+	if snapshot.Name != "snapshot" || snapshot.Healthy || snapshot.LastErrorAt == nil {
+		t.Errorf("Expected the failing snapshot worker, got %+v", snapshot)
+	}
+
+	memoryStore.ReportWorker("snapshot", nil)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 once the worker recovers, got %d", w.Code)
+	}
+}
+
+func TestHandler_ConfigTTLDefaults(t *testing.T) {
+	memoryStore := memory.NewMemoryStoreWithOptions(memory.Options{TTLDefaults: store.TTLDefaults{
+		Rules:           []store.TTLRule{{Prefix: "session:", TTLSeconds: 1800}, {Prefix: "config:", TTLSeconds: 0}},
+		FallbackSeconds: 60,
+	}})
+	defer memoryStore.StopTTLWorker()
+
+	mux := NewHandler(memoryStore).SetupRoutes()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/admin/config", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var resp struct {
+		Data store.StoreConfig `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	defaults := resp.Data.TTLDefaults
+	if len(defaults.Rules) != 2 || defaults.Rules[0].Prefix != "session:" || defaults.Rules[0].TTLSeconds != 1800 || defaults.FallbackSeconds != 60 {
+		t.Errorf("Expected the configured TTL defaults, got %+v", defaults)
+	}
+
+	// A Set without a TTL gets the default of its prefix.
+	payload, _ := json.Marshal(SetRequest{Key: "session:1", Value: "v"})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/api/v1/keys", bytes.NewReader(payload)))
+
+	entries, _ := memoryStore.GetEntries(context.Background(), []string{"session:1"})
+	if entries[0].TTLSeconds != 1800 {
+		t.Errorf("Expected the session default TTL, got %d", entries[0].TTLSeconds)
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"crypto/subtle"
+	"embed"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+//go:embed adminui
+var adminUI embed.FS
+
+// adminPathPrefixes are the paths guarded by AdminAuthMiddleware: the admin web UI and
+// the admin API.
+var adminPathPrefixes = []string{"/admin/", "/api/v1/admin/"}
+
+// AdminUIHandler serves the embedded admin web UI under /admin/. The UI is a static
+// page that browses and edits keys through the JSON API.
+func (h *Handler) AdminUIHandler() http.Handler {
+	files, err := fs.Sub(adminUI, "adminui")
+	if err != nil {
+		// The directory is embedded at build time, so this only fails on a broken build.
+		panic(err)
+	}
+	return http.StripPrefix("/admin/", http.FileServer(http.FS(files)))
+}
+
+// AdminAuthMiddleware returns a Middleware that requires token on admin requests, given
+// either as a bearer token or as the password of HTTP basic auth so that browsers can
+// prompt for it. Other requests pass through. An empty token disables the check.
+func (h *Handler) AdminAuthMiddleware(token string) Middleware {
+	return func(next http.Handler) http.Handler {
+		if token == "" {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isAdminPath(r.URL.Path) && !hasAdminToken(r, token) {
+				w.Header().Set("WWW-Authenticate", `Basic realm="admin"`)
+				h.writeError(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isAdminPath(path string) bool {
+	for _, prefix := range adminPathPrefixes {
+		if strings.HasPrefix(path, prefix) || path == strings.TrimSuffix(prefix, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAdminToken(r *http.Request, token string) bool {
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		_, given, ok = r.BasicAuth()
+	}
+	return ok && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+func TestHandler_AdminUI(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	mux := NewHandler(memoryStore).SetupRoutes()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/admin/", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Expected an HTML page, got content type %q", ct)
+	}
+	page := w.Body.String()
+
+	// Every endpoint the page calls must be served by the API.
+	for _, path := range []string{"/api/v1/keys", "/api/v1/lists/", "/any", "/len"} {
+		if !strings.Contains(page, path) {
+			t.Errorf("Expected the page to use %s", path)
+		}
+	}
+
+	ctx := context.Background()
+	memoryStore.Set(ctx, "user:1", "alice", 0)
+	memoryStore.Push(ctx, "jobs", "a")
+	memoryStore.Push(ctx, "jobs", "b")
+
+	requests := []struct {
+		method string
+		path   string
+		body   string
+		want   *regexp.Regexp
+	}{
+		{"GET", "/api/v1/keys", "", regexp.MustCompile(`"keys":\["jobs","user:1"\]`)},
+		{"GET", "/api/v1/keys?pattern=user:*", "", regexp.MustCompile(`"keys":\["user:1"\]`)},
+		{"GET", "/api/v1/keys/user:1", "", regexp.MustCompile(`"value":"alice"`)},
+		{"GET", "/api/v1/keys/jobs/any", "", regexp.MustCompile(`"type":"list"`)},
+		{"GET", "/api/v1/lists/jobs/len", "", regexp.MustCompile(`"length":2`)},
+		{"POST", "/api/v1/keys", `{"key":"user:2","value":"bob","ttl_seconds":0}`, regexp.MustCompile(`"success":true`)},
+		{"DELETE", "/api/v1/keys/user:2", "", regexp.MustCompile(`"success":true`)},
+	}
+	for _, req := range requests {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(req.method, req.path, bytes.NewBufferString(req.body)))
+		if w.Code != http.StatusOK {
+			t.Errorf("%s %s: expected status 200, got %d: %s", req.method, req.path, w.Code, w.Body.String())
+			continue
+		}
+		if !req.want.MatchString(w.Body.String()) {
+			t.Errorf("%s %s: expected body matching %s, got %s", req.method, req.path, req.want, w.Body.String())
+		}
+	}
+}
+
+func TestHandler_AdminAuth(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	handler := NewHandler(memoryStore)
+	handler.Use(handler.AdminAuthMiddleware("secret"))
+	mux := handler.SetupRoutes()
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		want   int
+	}{
+		{"UI without token", "/admin/", "", http.StatusUnauthorized},
+		{"admin API without token", "/api/v1/admin/config", "", http.StatusUnauthorized},
+		{"wrong bearer token", "/admin/", "Bearer nope", http.StatusUnauthorized},
+		{"bearer token", "/admin/", "Bearer secret", http.StatusOK},
+		{"basic auth password", "/api/v1/admin/config", "Basic " + basicAuth("admin", "secret"), http.StatusOK},
+		{"non admin path", "/api/v1/time", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, w.Code)
+			}
+			if tt.want == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("Expected a WWW-Authenticate challenge")
+			}
+		})
+	}
+}
+
+func basicAuth(user, password string) string {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth(user, password)
+	return strings.TrimPrefix(req.Header.Get("Authorization"), "Basic ")
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// keyHeader carries a base64 encoded key for keys that cannot be expressed in a URL path.
+const keyHeader = "X-Key"
+
+var errInvalidKeyEncoding = errors.New("invalid key encoding")
+
+// requestKey returns the key addressed by a request. A base64 encoded X-Key header
+// takes precedence over fallback, which is the key taken from the path or body.
+func requestKey(r *http.Request, fallback string) (string, error) {
+	encoded := r.Header.Get(keyHeader)
+	if encoded == "" {
+		return fallback, nil
+	}
+	return decodeKey(encoded)
+}
+
+func decodeKey(encoded string) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errInvalidKeyEncoding
+	}
+	return string(key), nil
+}
+
+// postOrKeyOperation serves POST requests with next and everything else as a regular
+// key operation, so a key that shares its name with a fixed endpoint stays reachable.
+func (h *Handler) postOrKeyOperation(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.keyOperation(w, r)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// patternOrKeyOperation serves requests carrying a pattern query parameter with next and
+// everything else as a regular key operation, so a key that shares its name with a
+// fixed endpoint stays reachable.
+func (h *Handler) patternOrKeyOperation(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !r.URL.Query().Has("pattern") {
+			h.keyOperation(w, r)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// decodeBinaryKey reads a BinaryKeyRequest body and returns the decoded key,
+// writing an error response if the body or key is invalid.
+func (h *Handler) decodeBinaryKey(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	var req BinaryKeyRequest
+	if !h.decodeJSON(w, r, &req) {
+		return "", "", false
+	}
+
+	key, err := decodeKey(req.Key)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "Key must be base64 encoded")
+		return "", "", false
+	}
+
+	if key == "" {
+		h.writeError(w, http.StatusBadRequest, "Key is required")
+		return "", "", false
+	}
+
+	return key, req.Key, true
+}
+
+// BinaryGetHandler handles GET operations for base64 encoded keys
+// POST /api/v1/keys/get
+func (h *Handler) BinaryGetHandler(w http.ResponseWriter, r *http.Request) {
+	key, encoded, ok := h.decodeBinaryKey(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	value, err := h.store.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) {
+			h.writeError(w, http.StatusNotFound, "Key not found")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get key: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, map[string]string{"key": encoded, "value": value})
+}
+
+// BinaryRemoveHandler handles DELETE operations for base64 encoded keys
+// POST /api/v1/keys/delete
+func (h *Handler) BinaryRemoveHandler(w http.ResponseWriter, r *http.Request) {
+	key, _, ok := h.decodeBinaryKey(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	fence, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.Remove(ctx, key, fence...); err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		if errors.Is(err, store.ErrKeyNotFound) {
+			h.writeError(w, http.StatusNotFound, "Key not found")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to remove key: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, map[string]string{"message": "Key removed successfully"})
+}
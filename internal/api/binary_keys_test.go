@@ -0,0 +1,116 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+func TestHandler_BinaryKeys(t *testing.T) {
+	var memoryStore store.IStore = memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	handler := NewHandler(memoryStore)
+	mux := handler.SetupRoutes()
+
+	binaryKey := "hash/\x00\xff\x10/with/slashes\x00"
+	encoded := base64.StdEncoding.EncodeToString([]byte(binaryKey))
+
+	// Set with the key in the X-Key header
+	payloadBytes, _ := json.Marshal(SetRequest{Value: "binary_value", TTLSeconds: 60})
+	req := httptest.NewRequest("POST", "/api/v1/keys", bytes.NewReader(payloadBytes))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Key", encoded)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 on set, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if value, err := memoryStore.Get(context.Background(), binaryKey); err != nil || value != "binary_value" {
+		t.Fatalf("Expected raw key to be stored, got value=%q err=%v", value, err)
+	}
+
+	// Get with the key in the X-Key header
+	req = httptest.NewRequest("GET", "/api/v1/keys/", nil)
+	req.Header.Set("X-Key", encoded)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 on header get, got %d", w.Code)
+	}
+
+	// Get with the key in the body
+	payloadBytes, _ = json.Marshal(BinaryKeyRequest{Key: encoded})
+	req = httptest.NewRequest("POST", "/api/v1/keys/get", bytes.NewReader(payloadBytes))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 on body get, got %d", w.Code)
+	}
+
+	var getResponse Response
+	if err := json.NewDecoder(w.Body).Decode(&getResponse); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	data := getResponse.Data.(map[string]any)
+	if data["value"] != "binary_value" || data["key"] != encoded {
+		t.Errorf("Expected encoded key and 'binary_value', got %v", data)
+	}
+
+	// Delete with the key in the body
+	req = httptest.NewRequest("POST", "/api/v1/keys/delete", bytes.NewReader(payloadBytes))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 on body delete, got %d", w.Code)
+	}
+
+	if _, err := memoryStore.Get(context.Background(), binaryKey); err == nil {
+		t.Error("Expected key to be removed")
+	}
+}
+
+func TestHandler_BinaryKeysErrors(t *testing.T) {
+	var memoryStore store.IStore = memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	handler := NewHandler(memoryStore)
+	mux := handler.SetupRoutes()
+
+	t.Run("invalid base64", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/keys/get", bytes.NewReader([]byte(`{"key":"not base64!"}`)))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("key named like an endpoint stays reachable", func(t *testing.T) {
+		memoryStore.Set(context.Background(), "get", "plain", 60)
+
+		r := httptest.NewRequest("GET", "/api/v1/keys/get", nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	// defaultMaxBodyBytes bounds the size of a JSON request body.
+	defaultMaxBodyBytes = 1 << 20
+
+	// defaultBodyReadTimeout bounds how long a handler waits for the request body.
+	defaultBodyReadTimeout = 10 * time.Second
+
+	// defaultMaxJSONDepth bounds the nesting of objects and arrays in a JSON request body.
+	defaultMaxJSONDepth = 32
+)
+
+// WithMaxJSONDepth sets how deeply objects and arrays may be nested in a JSON request
+// body, the body's own object counting as the first level, so a value set with
+// {"key": ..., "value": ...} may nest depth-1 levels. Deeper bodies are rejected
+// before they are decoded, as a small but deeply nested body is expensive to decode
+// and to store. A depth of 0 keeps the default of 32.
+func WithMaxJSONDepth(depth int) HandlerOption {
+	return func(h *Handler) {
+		if depth > 0 {
+			h.maxJSONDepth = depth
+		}
+	}
+}
+
+// readBody reads the whole request body, allowing at most limit bytes and
+// h.bodyReadTimeout to receive it. If the body cannot be read completely it
+// writes an error response and returns false:
+//   - 413 if the body is larger than limit
+//   - 400 if the body ends before its Content-Length or does not arrive in time
+func (h *Handler) readBody(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, bool) {
+	if r.ContentLength > limit {
+		h.writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body exceeds %d bytes", limit))
+		return nil, false
+	}
+
+	// Without a deadline a client that sends less than its Content-Length would
+	// keep the handler waiting for the rest of the body. Recorders used in tests
+	// do not support deadlines, which is fine as their bodies never block.
+	rc := http.NewResponseController(w)
+	deadlineSet := rc.SetReadDeadline(time.Now().Add(h.bodyReadTimeout)) == nil
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if deadlineSet && err == nil {
+		// The deadline is kept on failure so the server does not block
+		// discarding the rest of the body before sending the error response.
+		rc.SetReadDeadline(time.Time{})
+	}
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			h.writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body exceeds %d bytes", limit))
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			h.writeError(w, http.StatusBadRequest, "Request body is shorter than its Content-Length")
+		case errors.Is(err, os.ErrDeadlineExceeded):
+			h.writeError(w, http.StatusBadRequest, "Timed out reading request body")
+		default:
+			h.writeError(w, http.StatusBadRequest, "Failed to read request body")
+		}
+		return nil, false
+	}
+
+	return body, true
+}
+
+// keyedRequest is a request body that names the keys it operates on, so decodeJSON
+// can reject reserved keys. Every body decodeJSON accepts implements it; bodies whose
+// keys are in the path, or that name none, return nil.
+type keyedRequest interface {
+	requestKeys() []string
+}
+
+// decodeJSON reads the request body and unmarshals it into v. It writes an error
+// response and returns false if the body cannot be read, is nested deeper than
+// h.maxJSONDepth, is not valid JSON or names a reserved key.
+func (h *Handler) decodeJSON(w http.ResponseWriter, r *http.Request, v keyedRequest) bool {
+	body, ok := h.readBody(w, r, h.maxBodyBytes)
+	if !ok {
+		return false
+	}
+
+	if exceedsJSONDepth(body, h.maxJSONDepth) {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("JSON payload exceeds the maximum nesting depth of %d", h.maxJSONDepth))
+		return false
+	}
+
+	if err := unmarshalJSON(body, v); err != nil {
+		h.writeError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return false
+	}
+	noteBodyKey(r, body)
+
+	return h.allowedKeys(w, v.requestKeys()...)
+}
+
+// unmarshalJSON unmarshals body into v like json.Unmarshal, except that numbers
+// decoded into interface values are kept as json.Number. Values are stored as the
+// JSON they are marshaled back to, so a number keeps its exact literal rather than
+// going through a float64, which would round integers beyond 2^53.
+func unmarshalJSON(body []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("invalid data after top-level value")
+	}
+	return nil
+}
+
+// exceedsJSONDepth reports whether objects and arrays are nested more than limit levels
+// deep in body. It only tracks brackets outside of strings and stops at the first one
+// past the limit, so it is cheap even for hostile input; invalid JSON is left to the
+// decoder to reject.
+func exceedsJSONDepth(body []byte, limit int) bool {
+	depth := 0
+	inString, escaped := false, false
+	for _, c := range body {
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			switch c {
+			case '\\':
+				escaped = true
+			case '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			if depth++; depth > limit {
+				return true
+			}
+		case c == '}' || c == ']':
+			depth--
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+// sendPartialBody sends a request declaring a longer Content-Length than the body
+// it writes. If closeWrite is set the client closes its side after the partial body,
+// otherwise it keeps the connection open as a stalled client would.
+func sendPartialBody(t *testing.T, server *httptest.Server, closeWrite bool) (int, string) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	body := `{"key": "partial"`
+	request := "POST /api/v1/keys HTTP/1.1\r\n" +
+		"Host: localhost\r\n" +
+		"Content-Type: application/json\r\n" +
+		"Content-Length: 100\r\n\r\n" + body
+	if _, err := conn.Write([]byte(request)); err != nil {
+		t.Fatalf("Failed to write request: %v", err)
+	}
+
+	if closeWrite {
+		conn.(*net.TCPConn).CloseWrite()
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var response Response
+	json.NewDecoder(resp.Body).Decode(&response)
+	return resp.StatusCode, response.Error
+}
+
+func TestHandler_TruncatedBody(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	handler := NewHandler(memoryStore)
+	handler.bodyReadTimeout = 200 * time.Millisecond
+	server := httptest.NewServer(handler.SetupRoutes())
+	defer server.Close()
+
+	status, message := sendPartialBody(t, server, true)
+	if status != http.StatusBadRequest || message != "Request body is shorter than its Content-Length" {
+		t.Errorf("Expected 400 for truncated body, got %d: %s", status, message)
+	}
+
+	start := time.Now()
+	status, message = sendPartialBody(t, server, false)
+	if status != http.StatusBadRequest || message != "Timed out reading request body" {
+		t.Errorf("Expected 400 for stalled body, got %d: %s", status, message)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected stalled body to time out quickly, took %v", elapsed)
+	}
+}
+
+func TestHandler_BodyReadErrors(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	handler := NewHandler(memoryStore)
+	handler.maxBodyBytes = 64
+	mux := handler.SetupRoutes()
+
+	tests := []struct {
+		name   string
+		body   io.Reader
+		status int
+	}{
+		{"short read", io.MultiReader(strings.NewReader(`{"key":`), iotest.ErrReader(io.ErrUnexpectedEOF)), http.StatusBadRequest},
+		{"too large", strings.NewReader(`{"key": "k", "value": "` + strings.Repeat("x", 100) + `"}`), http.StatusRequestEntityTooLarge},
+		{"invalid json", strings.NewReader(`{"key": `), http.StatusBadRequest},
+		{"trailing data", strings.NewReader(`{"key": "k", "value": "v"} {}`), http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/v1/keys", tt.body)
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// nestedJSON returns depth nested arrays around a string containing brackets, which
+// must not count towards the depth.
+func nestedJSON(depth int) string {
+	return strings.Repeat("[", depth) + `"[{\"]"` + strings.Repeat("]", depth)
+}
+
+func TestHandler_MaxJSONDepth(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	mux := NewHandler(memoryStore, WithMaxJSONDepth(5)).SetupRoutes()
+
+	// The body's object is the first level, leaving 4 for the value
+	w := httptest.NewRecorder()
+	body := `{"key": "deep", "value": ` + nestedJSON(4) + `}`
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/keys", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 at the maximum depth, got %d: %s", w.Code, w.Body.String())
+	}
+
+	requests := map[string]string{
+		"/api/v1/keys":       `{"key": "deep", "value": ` + nestedJSON(5) + `}`,
+		"/api/v1/lists/push": `{"key": "deep-list", "item": ` + nestedJSON(5) + `}`,
+	}
+	for path, body := range requests {
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", path, strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 past the maximum depth on %s, got %d", path, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), "maximum nesting depth of 5") {
+			t.Errorf("Expected the depth limit in the error on %s, got %s", path, w.Body.String())
+		}
+	}
+
+	if got, _ := memoryStore.Get(context.Background(), "deep"); got != `[[[["[{\"]"]]]]` {
+		t.Errorf("Expected only the value within the limit stored, got %q", got)
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"net/http"
+)
+
+// Operations advertised by GET /api/v1/capabilities. They name the operations added
+// after the core key and list endpoints, so clients can tell whether a server
+// supports one before relying on it.
+const (
+	CapIncr          = "incr"
+	CapIncrCeiling   = "incr_ceiling"
+	CapDecr          = "decr"
+	CapDecrFloor     = "decr_floor"
+	CapGetSet        = "getset"
+	CapSetNX         = "setnx"
+	CapSetIfType     = "set_if_type"
+	CapListBatch     = "list_batch"
+	CapPipelineGet   = "pipeline_get"
+	CapRateIncr      = "rate_incr"
+	CapReserve       = "reserve"
+	CapExport        = "export"
+	CapTTLWorkerCtrl = "ttl_worker_control"
+	CapFlush         = "flush"
+	CapMSetChunked   = "mset_chunked"
+	CapVerify        = "verify"
+	CapDeleteMatch   = "delete_matching"
+	CapHashes        = "hashes"
+	CapSets          = "sets"
+	CapBLPop         = "blpop"
+	CapPubSub        = "pubsub"
+)
+
+// Capabilities lists the operations the server supports. It is the one place to
+// advertise a new operation; handlers report these unless configured otherwise with
+// WithCapabilities.
+var Capabilities = []string{
+	CapIncr,
+	CapIncrCeiling,
+	CapDecr,
+	CapDecrFloor,
+	CapGetSet,
+	CapSetNX,
+	CapSetIfType,
+	CapListBatch,
+	CapPipelineGet,
+	CapRateIncr,
+	CapReserve,
+	CapExport,
+	CapTTLWorkerCtrl,
+	CapFlush,
+	CapMSetChunked,
+	CapVerify,
+	CapDeleteMatch,
+	CapHashes,
+	CapSets,
+	CapBLPop,
+	CapPubSub,
+}
+
+// WithCapabilities replaces Capabilities as the operations the server advertises.
+func WithCapabilities(operations []string) HandlerOption {
+	return func(h *Handler) {
+		h.capabilities = operations
+	}
+}
+
+// CapabilitiesHandler lists the operations the server supports
+// GET /api/v1/capabilities
+func (h *Handler) CapabilitiesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	operations := h.capabilities
+	if operations == nil {
+		operations = []string{}
+	}
+	h.writeSuccess(w, CapabilitiesResponse{APIVersion: APIVersion, Operations: operations})
+}
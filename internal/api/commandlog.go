@@ -0,0 +1,97 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// commandLog writes one human-readable line per request served, for tailing while
+// debugging clients. It is not meant to be parsed or replayed.
+type commandLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// WithCommandLog writes a line per executed operation to w, giving its time, method
+// and path, key and resulting status, e.g.
+//
+//	2024-01-15T10:30:00.123Z POST /api/v1/keys key="user:123" 200 OK
+//
+// Requests rejected by middlewares, such as the bulkhead, are not logged. Lines are
+// written one at a time, so w does not have to be safe for concurrent use.
+func WithCommandLog(w io.Writer) HandlerOption {
+	return func(h *Handler) {
+		if w != nil {
+			h.commandLog = &commandLog{w: w}
+		}
+	}
+}
+
+// commandEntry collects what is logged about a request while it is served.
+type commandEntry struct {
+	key string
+}
+
+type commandEntryKey struct{}
+
+// logCommands wraps next to write a command log line per request, if enabled.
+func (h *Handler) logCommands(next http.Handler) http.Handler {
+	if h.commandLog == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry := &commandEntry{}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), commandEntryKey{}, entry)))
+
+		key := "-"
+		if entry.key != "" {
+			key = fmt.Sprintf("%q", entry.key)
+		}
+		h.commandLog.mu.Lock()
+		defer h.commandLog.mu.Unlock()
+		fmt.Fprintf(h.commandLog.w, "%s %s %s key=%s %d %s\n", time.Now().UTC().Format(time.RFC3339Nano),
+			r.Method, r.URL.Path, key, rec.status, http.StatusText(rec.status))
+	})
+}
+
+// noteKey records the key a request operates on for the command log.
+func noteKey(r *http.Request, key string) {
+	if entry, ok := r.Context().Value(commandEntryKey{}).(*commandEntry); ok {
+		entry.key = key
+	}
+}
+
+// noteBodyKey records the key field of a JSON request body for the command log.
+func noteBodyKey(r *http.Request, body []byte) {
+	if _, ok := r.Context().Value(commandEntryKey{}).(*commandEntry); !ok {
+		return
+	}
+	var keyed struct {
+		Key string `json:"key"`
+	}
+	if json.Unmarshal(body, &keyed) == nil && keyed.Key != "" {
+		noteKey(r, keyed.Key)
+	}
+}
+
+// statusRecorder remembers the status code written through it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to set deadlines.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
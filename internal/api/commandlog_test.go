@@ -0,0 +1,60 @@
+package api
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+func TestHandler_CommandLog(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	var log bytes.Buffer
+	mux := NewHandler(memoryStore, WithCommandLog(&log)).SetupRoutes()
+
+	requests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"POST", "/api/v1/keys", `{"key":"user:1","value":"alice","ttl_seconds":60}`},
+		{"GET", "/api/v1/keys/user:1", ""},
+		{"POST", "/api/v1/lists/push", `{"key":"jobs","item":"a"}`},
+		{"GET", "/api/v1/lists/jobs/len", ""},
+		{"DELETE", "/api/v1/keys/user:1", ""},
+		{"GET", "/api/v1/keys/user:1", ""},
+		{"GET", "/api/v1/time", ""},
+	}
+	for _, req := range requests {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, strings.NewReader(req.body)))
+	}
+
+	want := []string{
+		`POST /api/v1/keys key="user:1" 200 OK`,
+		`GET /api/v1/keys/user:1 key="user:1" 200 OK`,
+		`POST /api/v1/lists/push key="jobs" 200 OK`,
+		`GET /api/v1/lists/jobs/len key="jobs" 200 OK`,
+		`DELETE /api/v1/keys/user:1 key="user:1" 200 OK`,
+		`GET /api/v1/keys/user:1 key="user:1" 404 Not Found`,
+		`GET /api/v1/time key=- 200 OK`,
+	}
+	lines := strings.Split(strings.TrimSuffix(log.String(), "\n"), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("Expected %d lines, got %d:\n%s", len(want), len(lines), log.String())
+	}
+
+	timestamp := regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?Z `)
+	for i, line := range lines {
+		if !timestamp.MatchString(line) {
+			t.Errorf("Expected line %d to start with a timestamp, got %q", i, line)
+		}
+		if got := timestamp.ReplaceAllString(line, ""); got != want[i] {
+			t.Errorf("Expected line %d to be %q, got %q", i, want[i], got)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// corsAllowedMethods are the methods browsers may use in cross-origin requests.
+const corsAllowedMethods = "GET, HEAD, POST, PUT, DELETE, OPTIONS"
+
+// corsAllowedHeaders are the request headers browsers may send in cross-origin requests.
+var corsAllowedHeaders = strings.Join([]string{"Authorization", "Content-Type", FenceTokenHeader, RequestIDHeader, keyHeader, "Last-Event-ID"}, ", ")
+
+// corsExposedHeaders are the response headers scripts may read in cross-origin responses.
+var corsExposedHeaders = strings.Join([]string{versionHeader, RequestIDHeader, "Retry-After", "Deprecation", "Sunset"}, ", ")
+
+// CORSMiddleware returns a Middleware applying CORS with the given origins.
+func (h *Handler) CORSMiddleware(allowedOrigins []string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return h.CORS(next, allowedOrigins)
+	}
+}
+
+// CORS wraps next to let browser pages from allowedOrigins call the API, such as an
+// admin UI served from another host. Origins are given as sent by browsers, e.g.
+// "https://admin.example.com"; "*" allows any origin. Preflight requests from allowed
+// origins are answered with 204 No Content without reaching next, so add CORS before
+// middlewares that reject requests, such as AdminAuthMiddleware. Requests from other
+// origins are served without CORS headers, which makes browsers withhold the response
+// from the page. No allowed origins disables CORS.
+func (h *Handler) CORS(next http.Handler, allowedOrigins []string) http.Handler {
+	if len(allowedOrigins) == 0 {
+		return next
+	}
+	anyOrigin := slices.Contains(allowedOrigins, "*")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !anyOrigin && !slices.Contains(allowedOrigins, origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Add("Vary", "Origin")
+		if anyOrigin {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			header.Set("Access-Control-Allow-Methods", corsAllowedMethods)
+			header.Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+			header.Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		header.Set("Access-Control-Expose-Headers", corsExposedHeaders)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+func TestHandler_CORSPreflight(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+	handler := NewHandler(memoryStore)
+	handler.Use(handler.CORSMiddleware([]string{"https://admin.example.com"}))
+	// Preflights are answered before the admin token is checked
+	handler.Use(handler.AdminAuthMiddleware("secret"))
+	routes := handler.SetupRoutes()
+
+	req := httptest.NewRequest("OPTIONS", "/api/v1/admin/health", nil)
+	req.Header.Set("Origin", "https://admin.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	req.Header.Set("Access-Control-Request-Headers", "authorization")
+	w := httptest.NewRecorder()
+	routes.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", w.Code)
+	}
+	for name, want := range map[string]string{
+		"Access-Control-Allow-Origin":  "https://admin.example.com",
+		"Access-Control-Allow-Methods": corsAllowedMethods,
+		"Access-Control-Allow-Headers": corsAllowedHeaders,
+		"Vary":                         "Origin",
+	} {
+		if got := w.Header().Get(name); got != want {
+			t.Errorf("Expected %s %q, got %q", name, want, got)
+		}
+	}
+
+	// Preflights from other origins get no CORS headers
+	req.Header.Set("Origin", "https://evil.example.com")
+	w = httptest.NewRecorder()
+	routes.ServeHTTP(w, req)
+	if w.Code == http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Expected the preflight of another origin not to be allowed, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestHandler_CORSRequest(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	for _, tc := range []struct {
+		name    string
+		allowed []string
+		origin  string
+		want    string
+	}{
+		{"allowed origin", []string{"https://a.example.com", "https://b.example.com"}, "https://b.example.com", "https://b.example.com"},
+		{"any origin", []string{"*"}, "https://b.example.com", "*"},
+		{"other origin", []string{"https://a.example.com"}, "https://b.example.com", ""},
+		{"same origin", []string{"*"}, "", ""},
+		{"disabled", nil, "https://b.example.com", ""},
+	} {
+		handler := NewHandler(memoryStore)
+		handler.Use(handler.CORSMiddleware(tc.allowed))
+		routes := handler.SetupRoutes()
+
+		req := httptest.NewRequest("GET", "/api/v1/time", nil)
+		if tc.origin != "" {
+			req.Header.Set("Origin", tc.origin)
+		}
+		w := httptest.NewRecorder()
+		routes.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected the request served, got %d", tc.name, w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != tc.want {
+			t.Errorf("%s: expected Access-Control-Allow-Origin %q, got %q", tc.name, tc.want, got)
+		}
+		if exposed := w.Header().Get("Access-Control-Expose-Headers"); (tc.want != "") != (exposed == corsExposedHeaders) {
+			t.Errorf("%s: unexpected Access-Control-Expose-Headers %q", tc.name, exposed)
+		}
+	}
+}
+
+func TestHandler_CORSDisabled(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+	handler := NewHandler(memoryStore)
+
+	routes := handler.SetupRoutes()
+	if wrapped := handler.CORS(routes, nil); wrapped != http.Handler(routes) {
+		t.Error("Expected no allowed origins to leave the handler unwrapped")
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// IncrHandler adds a delta to an integer key, optionally only if it stays at or below a ceiling
+// POST /api/v1/keys/{key}/incr
+func (h *Handler) IncrHandler(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req IncrRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	fence, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+
+	value, applied := int64(0), true
+	var err error
+	if req.Ceiling != nil {
+		value, applied, err = h.store.IncrWithCeiling(ctx, key, req.Delta, *req.Ceiling, fence...)
+	} else {
+		value, err = h.store.Increment(ctx, key, req.Delta, fence...)
+	}
+	if err != nil {
+		h.writeCounterError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, CounterResponse{Value: value, Applied: applied})
+}
+
+// DecrHandler decrements an integer key, optionally only if it stays at or above a floor
+// POST /api/v1/keys/{key}/decr
+func (h *Handler) DecrHandler(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req DecrRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.Delta <= 0 {
+		h.writeError(w, http.StatusBadRequest, "Delta must be greater than 0")
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	fence, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+
+	value, applied := int64(0), true
+	var err error
+	if req.Floor != nil {
+		value, applied, err = h.store.DecrWithFloor(ctx, key, req.Delta, *req.Floor, fence...)
+	} else {
+		value, err = h.store.Decrement(ctx, key, req.Delta, fence...)
+	}
+	if err != nil {
+		h.writeCounterError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, CounterResponse{Value: value, Applied: applied})
+}
+
+// writeCounterError writes the response for a failed counter operation.
+func (h *Handler) writeCounterError(w http.ResponseWriter, err error) {
+	if h.writeRejected(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, store.ErrTypeMismatch):
+		h.writeError(w, http.StatusConflict, "Key does not hold a string")
+	case errors.Is(err, store.ErrNotInteger):
+		h.writeError(w, http.StatusConflict, "Value is not an integer")
+	case errors.Is(err, store.ErrIntegerOverflow):
+		h.writeError(w, http.StatusConflict, "Result would overflow")
+	default:
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update counter: %v", err))
+	}
+}
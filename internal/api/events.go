@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// EventsHandler replays the buffered keyspace events after a cursor and then streams
+// new ones as Server-Sent Events, until the client disconnects or the handler is
+// closed. The cursor is the since query parameter or, when reconnecting, the standard
+// Last-Event-ID header.
+// GET /api/v1/events?since={seq}
+func (h *Handler) EventsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	cursor := r.URL.Query().Get("since")
+	if cursor == "" {
+		cursor = r.Header.Get("Last-Event-ID")
+	}
+	var since uint64
+	if cursor != "" {
+		var err error
+		if since, err = strconv.ParseUint(cursor, 10, 64); err != nil {
+			h.writeError(w, http.StatusBadRequest, "since must be a non-negative integer")
+			return
+		}
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	ctx := r.Context()
+	for {
+		page, err := h.store.EventsSince(ctx, since)
+		if err != nil {
+			return
+		}
+
+		if page.Gap {
+			fmt.Fprintf(w, "event: gap\ndata: {\"since\":%d}\n\n", since)
+		}
+		for _, e := range page.Events {
+			writeEvent(w, e)
+			since = e.Seq
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		select {
+		case <-page.Next:
+		case <-ctx.Done():
+			return
+		case <-h.closed:
+			return
+		}
+	}
+}
+
+// writeEvent writes e as a Server-Sent Event named after its type, with its sequence
+// number as the event ID.
+func writeEvent(w http.ResponseWriter, e store.KeyEvent) {
+	data, _ := json.Marshal(e)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, e.Type, data)
+}
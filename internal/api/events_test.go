@@ -0,0 +1,181 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+// sseEvent is a Server-Sent Event as read from an event stream.
+type sseEvent struct {
+	id, name, data string
+}
+
+// openEvents connects to the event stream with the given cursor and returns a function
+// reading the next n events, and one disconnecting.
+func openEvents(t *testing.T, url, since string) (func(n int) []sseEvent, func()) {
+	t.Helper()
+	return openStream(t, url+"/api/v1/events?since="+since)
+}
+
+// openStream connects to the Server-Sent Events stream at url and returns a function
+// reading the next n events, and one disconnecting.
+func openStream(t *testing.T, url string) (func(n int) []sseEvent, func()) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected a 200 event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	read := func(n int) []sseEvent {
+		t.Helper()
+
+		var events []sseEvent
+		var e sseEvent
+		deadline := time.AfterFunc(2*time.Second, cancel)
+		defer deadline.Stop()
+		for len(events) < n && lines.Scan() {
+			line := lines.Text()
+			switch {
+			case line == "":
+				events = append(events, e)
+				e = sseEvent{}
+			case strings.HasPrefix(line, "id: "):
+				e.id = line[len("id: "):]
+			case strings.HasPrefix(line, "event: "):
+				e.name = line[len("event: "):]
+			case strings.HasPrefix(line, "data: "):
+				e.data = line[len("data: "):]
+			}
+		}
+		if len(events) < n {
+			t.Fatalf("Expected %d events, got %d", n, len(events))
+		}
+		return events
+	}
+	disconnect := func() {
+		cancel()
+		resp.Body.Close()
+	}
+	return read, disconnect
+}
+
+func TestEventsHandler_Resume(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+	server := httptest.NewServer(NewHandler(memoryStore).SetupRoutes())
+	defer server.Close()
+	ctx := context.Background()
+
+	memoryStore.Set(ctx, "a", "1", 0)
+
+	read, disconnect := openEvents(t, server.URL, "0")
+	events := read(1)
+	var first store.KeyEvent
+	json.Unmarshal([]byte(events[0].data), &first)
+	if events[0].id != "1" || events[0].name != store.EventSet || first.Key != "a" || first.Seq != 1 {
+		t.Errorf("Expected the buffered set of a, got %+v", events[0])
+	}
+
+	// Live events are streamed as they happen
+	memoryStore.Push(ctx, "queue", "job")
+	if events := read(1); events[0].id != "2" || !strings.Contains(events[0].data, `"key":"queue"`) {
+		t.Errorf("Expected the live push to queue, got %+v", events[0])
+	}
+	disconnect()
+
+	// Changes made while disconnected are replayed after reconnecting with the cursor
+	memoryStore.Remove(ctx, "a")
+	memoryStore.Set(ctx, "b", "2", 0)
+
+	read, disconnect = openEvents(t, server.URL, "2")
+	defer disconnect()
+	events = read(2)
+	if events[0].id != "3" || events[0].name != store.EventDel || events[1].id != "4" || events[1].name != store.EventSet {
+		t.Errorf("Expected del a and set b after the cursor, got %+v", events)
+	}
+}
+
+func TestEventsHandler_Gap(t *testing.T) {
+	memoryStore := memory.NewMemoryStoreWithOptions(memory.Options{EventBufferSize: 2})
+	defer memoryStore.StopTTLWorker()
+	server := httptest.NewServer(NewHandler(memoryStore).SetupRoutes())
+	defer server.Close()
+	ctx := context.Background()
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		memoryStore.Set(ctx, key, "v", 0)
+	}
+
+	read, disconnect := openEvents(t, server.URL, "1")
+	defer disconnect()
+	events := read(3)
+	if events[0].name != "gap" || events[0].data != `{"since":1}` {
+		t.Errorf("Expected a gap marker first, got %+v", events[0])
+	}
+	if events[1].id != "3" || events[2].id != "4" {
+		t.Errorf("Expected the buffered events 3 and 4 after the gap, got %+v", events[1:])
+	}
+}
+
+// startClosableServer serves handler's routes with Close registered to run on
+// shutdown, as the server command does.
+func startClosableServer(t *testing.T, handler *Handler) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(handler.SetupRoutes())
+	server.Config.RegisterOnShutdown(handler.Close)
+	server.Start()
+	return server
+}
+
+// shutdownServer shuts server down and fails the test unless it drains its open
+// requests within a second.
+func shutdownServer(t *testing.T, server *httptest.Server) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := server.Config.Shutdown(ctx); err != nil {
+		t.Errorf("Expected shutdown to end the open requests, got %v", err)
+	}
+}
+
+func TestEventsHandler_Shutdown(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+	server := startClosableServer(t, NewHandler(memoryStore))
+	defer server.Close()
+
+	_, disconnect := openEvents(t, server.URL, "0")
+	defer disconnect()
+
+	shutdownServer(t, server)
+}
+
+func TestEventsHandler_InvalidCursor(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+	mux := NewHandler(memoryStore).SetupRoutes()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/events?since=-1", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
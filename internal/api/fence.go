@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// FenceTokenHeader carries the fence token of a write. Writes with a token lower than
+// the latest one issued or seen for the key are rejected with 409 Conflict.
+const FenceTokenHeader = "X-Fence-Token"
+
+// fence returns the write options carrying the request's fence token, if it has one.
+// It writes an error response and returns false if the token is malformed.
+func (h *Handler) fence(w http.ResponseWriter, r *http.Request) ([]store.WriteOption, bool) {
+	header := r.Header.Get(FenceTokenHeader)
+	if header == "" {
+		return nil, true
+	}
+
+	token, err := strconv.ParseUint(header, 10, 64)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, FenceTokenHeader+" must be a non-negative integer")
+		return nil, false
+	}
+	return []store.WriteOption{store.Fence(token)}, true
+}
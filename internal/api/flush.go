@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WithFlush enables POST /api/v1/flush, which deletes every key. It is disabled by
+// default so a stray request cannot wipe a production store; enable it for test and
+// development servers.
+func WithFlush(enabled bool) HandlerOption {
+	return func(h *Handler) {
+		h.flushEnabled = enabled
+	}
+}
+
+// FlushHandler deletes every key in the store, if enabled with WithFlush
+// POST /api/v1/flush
+func (h *Handler) FlushHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if !h.flushEnabled {
+		h.writeError(w, http.StatusForbidden, "Flush is disabled on this server")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.store.FlushAll(ctx); err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to flush store: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, map[string]string{"message": "Store flushed successfully"})
+}
@@ -3,8 +3,14 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/mo-mohamed/acronis-memory-store/internal/store"
@@ -12,219 +18,1871 @@ import (
 
 type Handler struct {
 	store store.IStore
+
+	// uploadTTLSeconds is the lifetime of an idle chunked upload.
+	uploadTTLSeconds int
+
+	// maxUploadBytes bounds the total size of the chunks of an upload.
+	maxUploadBytes int64
+
+	// maxBodyBytes bounds the size of JSON request bodies.
+	maxBodyBytes int64
+
+	// bodyReadTimeout bounds how long a handler waits for a request body.
+	bodyReadTimeout time.Duration
+
+	// maxJSONDepth bounds the nesting of JSON request bodies, see WithMaxJSONDepth.
+	maxJSONDepth int
+
+	// contentType is the Content-Type header of every response.
+	contentType string
+
+	// middlewares wrap all routes, see Use.
+	middlewares []Middleware
+
+	// commandLog records every operation served if set, see WithCommandLog.
+	commandLog *commandLog
+
+	// accessLog records every request served if set, see WithAccessLog.
+	accessLog *slog.Logger
+
+	// deprecations are the endpoints reported deprecated, see DeprecatedRoutes.
+	deprecations []Deprecation
+
+	// capabilities are the operations advertised to clients, see Capabilities.
+	capabilities []string
+
+	// flushEnabled allows deleting every key through FlushHandler, see WithFlush.
+	flushEnabled bool
+
+	// errorVerbosity controls the detail of error responses, see WithErrorVerbosity.
+	errorVerbosity ErrorVerbosity
+
+	// errorLog records the errors withheld from clients.
+	errorLog *log.Logger
+
+	// closed is closed by Close to end long-lived requests.
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// defaultContentType is the Content-Type of responses unless configured otherwise.
+const defaultContentType = "application/json; charset=utf-8"
+
+// HandlerOption configures a Handler.
+type HandlerOption func(*Handler)
+
+// WithContentType sets the Content-Type header sent with every response.
+// An empty contentType keeps the default, application/json; charset=utf-8.
+func WithContentType(contentType string) HandlerOption {
+	return func(h *Handler) {
+		if contentType != "" {
+			h.contentType = contentType
+		}
+	}
+}
+
+func NewHandler(s store.IStore, opts ...HandlerOption) *Handler {
+	h := &Handler{
+		store:            s,
+		uploadTTLSeconds: defaultUploadTTLSeconds,
+		maxUploadBytes:   defaultMaxUploadBytes,
+		maxBodyBytes:     defaultMaxBodyBytes,
+		bodyReadTimeout:  defaultBodyReadTimeout,
+		maxJSONDepth:     defaultMaxJSONDepth,
+		contentType:      defaultContentType,
+		deprecations:     DeprecatedRoutes,
+		capabilities:     Capabilities,
+		errorVerbosity:   ErrorVerbosityDev,
+		errorLog:         log.Default(),
+		closed:           make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// SetHandler handles SET operations
+// POST /api/v1/keys
+func (h *Handler) SetHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req SetRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	key, err := requestKey(r, req.Key)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "Key must be base64 encoded")
+		return
+	}
+	req.Key = key
+
+	if req.Key == "" {
+		h.writeError(w, http.StatusBadRequest, "Key is required")
+		return
+	}
+	if !h.allowedKeys(w, req.Key) {
+		return
+	}
+
+	if req.TTLSeconds < 0 {
+		h.writeError(w, http.StatusBadRequest, "TTL must be >= 0 (0 = no expiration)")
+		return
+	}
+
+	for _, tag := range req.Tags {
+		if tag == "" {
+			h.writeError(w, http.StatusBadRequest, "Tags must not be empty")
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	opts, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+	if req.Compress != nil && !*req.Compress {
+		opts = append(opts, store.NoCompress())
+	}
+	if len(req.Tags) > 0 {
+		opts = append(opts, store.Tags(req.Tags...))
+	}
+
+	if req.NX && returnPrevious(r) {
+		h.writeError(w, http.StatusBadRequest, "return=previous cannot be combined with nx")
+		return
+	}
+
+	if query := r.URL.Query(); query.Has("if_ttl_below") {
+		threshold, err := strconv.Atoi(query.Get("if_ttl_below"))
+		if err != nil || threshold < 0 {
+			h.writeError(w, http.StatusBadRequest, "if_ttl_below must be a non-negative integer")
+			return
+		}
+		if req.NX || returnPrevious(r) || query.Has("if_type") {
+			h.writeError(w, http.StatusBadRequest, "if_ttl_below cannot be combined with nx, return=previous or if_type")
+			return
+		}
+
+		set, err := h.store.SetIfExpiringWithin(ctx, req.Key, req.Value, req.TTLSeconds, threshold, opts...)
+		if err != nil {
+			if h.writeRejected(w, err) {
+				return
+			}
+			h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to set key: %v", err))
+			return
+		}
+		h.writeSuccess(w, map[string]bool{"set": set})
+		return
+	}
+
+	if query := r.URL.Query(); query.Has("if_type") {
+		expectedType := query.Get("if_type")
+		if expectedType != store.TypeString {
+			h.writeError(w, http.StatusBadRequest, "if_type must be string")
+			return
+		}
+		if req.NX || returnPrevious(r) {
+			h.writeError(w, http.StatusBadRequest, "if_type cannot be combined with nx or return=previous")
+			return
+		}
+
+		set, err := h.store.SetIfType(ctx, req.Key, req.Value, req.TTLSeconds, expectedType, opts...)
+		if err != nil {
+			if h.writeRejected(w, err) {
+				return
+			}
+			h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to set key: %v", err))
+			return
+		}
+		h.writeSuccess(w, map[string]bool{"set": set})
+		return
+	}
+
+	if req.NX {
+		set, token, err := h.store.SetNXFenced(ctx, req.Key, req.Value, req.TTLSeconds, opts...)
+		if err != nil {
+			if h.writeRejected(w, err) {
+				return
+			}
+			h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to set key: %v", err))
+			return
+		}
+		h.writeSuccess(w, SetNXResponse{Set: set, FenceToken: token})
+		return
+	}
+
+	if returnPrevious(r) {
+		previous, err := h.store.SetReturningPrevious(ctx, req.Key, req.Value, req.TTLSeconds, opts...)
+		if err != nil {
+			if h.writeRejected(w, err) {
+				return
+			}
+			h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to set key: %v", err))
+			return
+		}
+		h.writeSuccess(w, PreviousResponse{Message: "Key set successfully", Previous: previous})
+		return
+	}
+
+	if err := h.store.Set(ctx, req.Key, req.Value, req.TTLSeconds, opts...); err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to set key: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, map[string]string{"message": "Key set successfully"})
+}
+
+// GetHandler handles GET operations
+// GET /api/v1/keys/{key}
+func (h *Handler) GetHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	key, err := requestKey(r, r.URL.Path[len("/api/v1/keys/"):])
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "Key must be base64 encoded")
+		return
+	}
+	if key == "" {
+		h.writeError(w, http.StatusBadRequest, "Key is required")
+		return
+	}
+	if !h.allowedKeys(w, key) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	value, err := h.store.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) {
+			h.writeError(w, http.StatusNotFound, "Key not found")
+			return
+		}
+		if errors.Is(err, store.ErrTypeMismatch) {
+			h.writeError(w, http.StatusConflict, "Key does not hold a string")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get key: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, map[string]string{"key": key, "value": value})
+}
+
+// ExistsHandler reports whether a key of any type exists with the status code alone:
+// 200 if it does and 404 if not
+// HEAD /api/v1/keys/{key}
+func (h *Handler) ExistsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodHead {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	key, err := requestKey(r, r.URL.Path[len("/api/v1/keys/"):])
+	if err != nil || key == "" || reservedKey(key) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	exists, err := h.store.Exists(ctx, key)
+	switch {
+	case err != nil:
+		w.WriteHeader(http.StatusInternalServerError)
+	case !exists:
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// GetAnyHandler returns the value of a string or list key along with its type
+// GET /api/v1/keys/{key}/any
+func (h *Handler) GetAnyHandler(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	value, kind, err := h.store.GetAny(ctx, key)
+	if err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) {
+			h.writeError(w, http.StatusNotFound, "Key not found")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get key: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, GetAnyResponse{Key: key, Type: kind, Value: value})
+}
+
+// GetRawHandler returns the value of a string key in its original JSON structure
+// GET /api/v1/keys/{key}/raw
+func (h *Handler) GetRawHandler(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	value, err := h.store.GetRaw(ctx, key)
+	if err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) {
+			h.writeError(w, http.StatusNotFound, "Key not found")
+			return
+		}
+		if errors.Is(err, store.ErrTypeMismatch) {
+			h.writeError(w, http.StatusConflict, "Key does not hold a string")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get key: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, RawResponse{Key: key, Value: value})
+}
+
+// MultiGetHandler returns the type, value and TTL of several keys at once
+// POST /api/v1/keys/multiget
+func (h *Handler) MultiGetHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req MultiGetRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if len(req.Keys) == 0 {
+		h.writeError(w, http.StatusBadRequest, "Keys are required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	entries, err := h.store.GetEntries(ctx, req.Keys)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get keys: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, MultiGetResponse{Entries: entries})
+}
+
+// MSetHandler sets several string keys at once
+// POST /api/v1/keys/mset
+func (h *Handler) MSetHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req MSetRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if len(req.Pairs) == 0 {
+		h.writeError(w, http.StatusBadRequest, "Pairs are required")
+		return
+	}
+	if _, ok := req.Pairs[""]; ok {
+		h.writeError(w, http.StatusBadRequest, "Keys must not be empty")
+		return
+	}
+
+	if req.TTLSeconds < 0 {
+		h.writeError(w, http.StatusBadRequest, "TTL must be >= 0 (0 = no expiration)")
+		return
+	}
+	if req.ChunkSize < 0 {
+		h.writeError(w, http.StatusBadRequest, "Chunk size must be >= 0 (0 = all at once)")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	written, err := h.store.MSetChunked(ctx, req.Pairs, req.TTLSeconds, req.ChunkSize)
+	if err != nil {
+		status, message, ok := rejection(err)
+		if !ok {
+			status, message = http.StatusInternalServerError, fmt.Sprintf("Failed to set keys: %v", err)
+		}
+		if h.hidesError(status) {
+			h.writeInternalError(w, message)
+			return
+		}
+		// Keys written before the failure are kept, so the client is told how many.
+		h.writeJSON(w, status, Response{
+			Success: false,
+			Error:   fmt.Sprintf("%s after writing %d of %d keys", message, written, len(req.Pairs)),
+			Data:    MSetResponse{Count: written},
+		})
+		return
+	}
+
+	h.writeSuccess(w, MSetResponse{Count: written})
+}
+
+// MGetHandler returns the values of several string keys at once, leaving out missing ones
+// POST /api/v1/keys/mget
+func (h *Handler) MGetHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req MGetRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if len(req.Keys) == 0 {
+		h.writeError(w, http.StatusBadRequest, "Keys are required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	values, err := h.store.MGet(ctx, req.Keys)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get keys: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, MGetResponse{Values: values})
+}
+
+// KeysInfoHandler returns the size, type, TTL and hits of several keys at once
+// POST /api/v1/keys/info
+func (h *Handler) KeysInfoHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req KeysInfoRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if len(req.Keys) == 0 {
+		h.writeError(w, http.StatusBadRequest, "Keys are required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	infos, err := h.store.KeysInfo(ctx, req.Keys)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get keys: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, KeysInfoResponse{Keys: infos})
+}
+
+// UpdateHandler handles UPDATE operations
+// PUT /api/v1/keys/{key}
+func (h *Handler) UpdateHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	key, err := requestKey(r, r.URL.Path[len("/api/v1/keys/"):])
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "Key must be base64 encoded")
+		return
+	}
+	if key == "" {
+		h.writeError(w, http.StatusBadRequest, "Key is required")
+		return
+	}
+	if !h.allowedKeys(w, key) {
+		return
+	}
+
+	var req UpdateRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	fence, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.Update(ctx, key, req.Value, fence...); err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		if errors.Is(err, store.ErrKeyNotFound) {
+			h.writeError(w, http.StatusNotFound, "Key not found")
+			return
+		}
+		if errors.Is(err, store.ErrTypeMismatch) {
+			h.writeError(w, http.StatusConflict, "Key does not hold a string")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update key: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, map[string]string{"message": "Key updated successfully"})
+}
+
+// GetSetHandler replaces the value of a string key and returns the previous value
+// POST /api/v1/keys/{key}/getset
+func (h *Handler) GetSetHandler(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req GetSetRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	fence, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+
+	previous, err := h.store.GetSet(ctx, key, req.Value, fence...)
+	if err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		if errors.Is(err, store.ErrKeyNotFound) {
+			h.writeError(w, http.StatusNotFound, "Key not found")
+			return
+		}
+		if errors.Is(err, store.ErrTypeMismatch) {
+			h.writeError(w, http.StatusConflict, "Key does not hold a string")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to set key: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, GetSetResponse{Key: key, Previous: previous})
+}
+
+// RemoveHandler handles DELETE operations
+// DELETE /api/v1/keys/{key}
+func (h *Handler) RemoveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	key, err := requestKey(r, r.URL.Path[len("/api/v1/keys/"):])
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "Key must be base64 encoded")
+		return
+	}
+	if key == "" {
+		h.writeError(w, http.StatusBadRequest, "Key is required")
+		return
+	}
+	if !h.allowedKeys(w, key) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	fence, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+
+	if r.URL.Query().Get("soft") == "true" {
+		if returnPrevious(r) || r.URL.Query().Has("if_value") {
+			h.writeError(w, http.StatusBadRequest, "soft cannot be combined with return=previous or if_value")
+			return
+		}
+		if err := h.store.SoftRemove(ctx, key, fence...); err != nil {
+			if h.writeRejected(w, err) {
+				return
+			}
+			if errors.Is(err, store.ErrKeyNotFound) {
+				h.writeError(w, http.StatusNotFound, "Key not found")
+				return
+			}
+			h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to remove key: %v", err))
+			return
+		}
+		h.writeSuccess(w, map[string]string{"message": "Key removed successfully"})
+		return
+	}
+
+	if r.URL.Query().Has("if_value") {
+		if returnPrevious(r) {
+			h.writeError(w, http.StatusBadRequest, "return=previous cannot be combined with if_value")
+			return
+		}
+		h.compareAndDelete(ctx, w, key, r.URL.Query().Get("if_value"))
+		return
+	}
+
+	if returnPrevious(r) {
+		previous, err := h.store.RemoveReturningPrevious(ctx, key, fence...)
+		if err != nil {
+			if h.writeRejected(w, err) {
+				return
+			}
+			if errors.Is(err, store.ErrKeyNotFound) {
+				h.writeError(w, http.StatusNotFound, "Key not found")
+				return
+			}
+			h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to remove key: %v", err))
+			return
+		}
+		h.writeSuccess(w, PreviousResponse{Message: "Key removed successfully", Previous: previous})
+		return
+	}
+
+	if err := h.store.Remove(ctx, key, fence...); err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		if errors.Is(err, store.ErrKeyNotFound) {
+			h.writeError(w, http.StatusNotFound, "Key not found")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to remove key: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, map[string]string{"message": "Key removed successfully"})
+}
+
+// compareAndDelete removes key only if its value matches expected.
+func (h *Handler) compareAndDelete(ctx context.Context, w http.ResponseWriter, key, expected string) {
+	deleted, err := h.store.CompareAndDelete(ctx, key, expected)
+	if err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		if errors.Is(err, store.ErrKeyNotFound) {
+			h.writeError(w, http.StatusNotFound, "Key not found")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to remove key: %v", err))
+		return
+	}
+
+	if !deleted {
+		h.writeError(w, http.StatusConflict, "Value does not match")
+		return
+	}
+
+	h.writeSuccess(w, map[string]string{"message": "Key removed successfully"})
+}
+
+// TTLHandler returns the remaining TTL of a key
+// GET /api/v1/keys/{key}/ttl
+func (h *Handler) TTLHandler(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	ttl, err := h.store.TTL(ctx, key)
+	if err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) {
+			h.writeError(w, http.StatusNotFound, "Key not found")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get TTL: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, TTLResponse{TTLSeconds: ttl})
+}
+
+// ExpireHandler changes the TTL of an existing key
+// PUT /api/v1/keys/{key}/ttl
+func (h *Handler) ExpireHandler(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodPut {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req ExpireRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.TTLSeconds < 0 {
+		h.writeError(w, http.StatusBadRequest, "TTL must be >= 0 (0 = no expiration)")
+		return
+	}
+
+	if req.IfValue != nil && returnPrevious(r) {
+		h.writeError(w, http.StatusBadRequest, "return=previous cannot be combined with if_value")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	fence, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+
+	var err error
+	var previous *store.KeyEntry
+	var token uint64
+	updated := true
+	switch {
+	case req.IfValue != nil:
+		updated, err = h.store.CompareAndExpire(ctx, key, *req.IfValue, req.TTLSeconds, fence...)
+	case returnPrevious(r):
+		previous, err = h.store.ExpireReturningPrevious(ctx, key, req.TTLSeconds, fence...)
+	default:
+		token, err = h.store.ExpireFenced(ctx, key, req.TTLSeconds, fence...)
+	}
+
+	if err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		if errors.Is(err, store.ErrKeyNotFound) {
+			h.writeError(w, http.StatusNotFound, "Key not found")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update TTL: %v", err))
+		return
+	}
+
+	if !updated {
+		h.writeError(w, http.StatusConflict, "Value does not match")
+		return
+	}
+
+	if returnPrevious(r) {
+		h.writeSuccess(w, PreviousResponse{Message: "TTL updated successfully", Previous: previous})
+		return
+	}
+
+	h.writeSuccess(w, ExpireResponse{Message: "TTL updated successfully", FenceToken: token})
+}
+
+// returnPrevious reports whether the request asks for the value it replaced via ?return=previous.
+func returnPrevious(r *http.Request) bool {
+	return r.URL.Query().Get("return") == "previous"
+}
+
+// RestoreHandler restores a soft deleted key within its recovery window
+// POST /api/v1/keys/{key}/restore
+func (h *Handler) RestoreHandler(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	fence, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.Restore(ctx, key, fence...); err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, store.ErrKeyNotFound):
+			h.writeError(w, http.StatusNotFound, "No soft deleted key to restore")
+		case errors.Is(err, store.ErrKeyExists):
+			h.writeError(w, http.StatusConflict, "Key was set again after it was deleted")
+		default:
+			h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to restore key: %v", err))
+		}
+		return
+	}
+
+	h.writeSuccess(w, map[string]string{"message": "Key restored successfully"})
+}
+
+// CountPatternHandler counts the keys matching a glob pattern, leaving out uploads in progress
+// GET /api/v1/keys/count?pattern={pattern}
+func (h *Handler) CountPatternHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	pattern := r.URL.Query().Get("pattern")
+	if pattern == "" {
+		h.writeError(w, http.StatusBadRequest, "Pattern is required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	p, err := store.CompilePattern(pattern)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "Invalid pattern")
+		return
+	}
+
+	count, err := h.store.CountPattern(ctx, pattern)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to count keys: %v", err))
+		return
+	}
+	uploads, err := h.uploadsMatching(ctx, p)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to count keys: %v", err))
+		return
+	}
+	count = max(count-len(uploads), 0)
+
+	h.writeSuccess(w, PatternCountResponse{Pattern: pattern, Count: count})
+}
+
+// KeysHandler lists the keys matching a glob pattern, or all keys if none is given,
+// leaving out uploads in progress
+// GET /api/v1/keys[?pattern={pattern}]
+func (h *Handler) KeysHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	pattern := r.URL.Query().Get("pattern")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	keys, err := h.store.Keys(ctx, pattern)
+	if err != nil {
+		if errors.Is(err, store.ErrInvalidPattern) {
+			h.writeError(w, http.StatusBadRequest, "Invalid pattern")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list keys: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, KeysResponse{Pattern: pattern, Keys: visibleKeys(keys)})
+}
+
+// DeleteExpiringHandler deletes all keys expiring within the given number of seconds,
+// except uploads in progress
+// DELETE /api/v1/keys?expiring_within={seconds}
+func (h *Handler) DeleteExpiringHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	if !query.Has("expiring_within") {
+		h.writeError(w, http.StatusBadRequest, "Expiring_within is required")
+		return
+	}
+	seconds, err := strconv.Atoi(query.Get("expiring_within"))
+	if err != nil || seconds < 0 {
+		h.writeError(w, http.StatusBadRequest, "Expiring_within must be a non-negative number of seconds")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	uploads, err := h.store.Keys(ctx, uploadKeyPattern)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete keys: %v", err))
+		return
+	}
+
+	var deleted int
+	if len(uploads) == 0 {
+		deleted, err = h.store.DeleteExpiringWithin(ctx, time.Duration(seconds)*time.Second)
+	} else {
+		deleted, err = h.deleteExpiringOneByOne(ctx, seconds)
+	}
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete keys: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, DeleteExpiringResponse{ExpiringWithin: seconds, Deleted: deleted})
+}
+
+// deleteExpiringOneByOne deletes the keys other than uploads whose remaining TTL,
+// rounded up, is at most seconds, one at a time. DeleteExpiringHandler falls back to
+// it while uploads are in progress, so they are not deleted with the other keys.
+func (h *Handler) deleteExpiringOneByOne(ctx context.Context, seconds int) (int, error) {
+	keys, err := h.store.Keys(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+	infos, err := h.store.KeysInfo(ctx, visibleKeys(keys))
+	if err != nil {
+		return 0, err
+	}
+
+	expiring := make([]string, 0, len(infos))
+	for _, info := range infos {
+		if info.TTLSeconds >= 0 && info.TTLSeconds <= seconds {
+			expiring = append(expiring, info.Key)
+		}
+	}
+	return eachVisibleKey(expiring, func(key string) error { return h.store.Remove(ctx, key) })
+}
+
+// DeleteMatchingHandler deletes all keys matching a glob pattern, except uploads in progress
+// DELETE /api/v1/keys?pattern={pattern}
+func (h *Handler) DeleteMatchingHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	pattern := query.Get("pattern")
+	if pattern == "" {
+		h.writeError(w, http.StatusBadRequest, "Pattern is required")
+		return
+	}
+	if query.Has("expiring_within") {
+		h.writeError(w, http.StatusBadRequest, "Pattern and expiring_within cannot be combined")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	p, err := store.CompilePattern(pattern)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "Invalid pattern")
+		return
+	}
+	uploads, err := h.uploadsMatching(ctx, p)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete keys: %v", err))
+		return
+	}
+
+	var deleted int
+	if len(uploads) == 0 {
+		deleted, err = h.store.DeleteMatching(ctx, pattern)
+	} else {
+		var keys []string
+		if keys, err = h.store.Keys(ctx, pattern); err == nil {
+			deleted, err = eachVisibleKey(keys, func(key string) error { return h.store.Remove(ctx, key) })
+		}
+	}
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete keys: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, DeleteMatchingResponse{Pattern: pattern, Deleted: deleted})
+}
+
+// ExpirePatternHandler changes the TTL of all keys matching a glob pattern, except uploads
+// in progress
+// POST /api/v1/keys/expire?pattern={pattern}
+func (h *Handler) ExpirePatternHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	pattern := r.URL.Query().Get("pattern")
+	if pattern == "" {
+		h.writeError(w, http.StatusBadRequest, "Pattern is required")
+		return
+	}
+
+	var req ExpirePatternRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.TTLSeconds < 0 {
+		h.writeError(w, http.StatusBadRequest, "TTL must be >= 0 (0 = no expiration)")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	p, err := store.CompilePattern(pattern)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "Invalid pattern")
+		return
+	}
+	uploads, err := h.uploadsMatching(ctx, p)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update TTL: %v", err))
+		return
+	}
+
+	var count int
+	if len(uploads) == 0 {
+		count, err = h.store.ExpirePattern(ctx, pattern, req.TTLSeconds)
+	} else {
+		var keys []string
+		if keys, err = h.store.Keys(ctx, pattern); err == nil {
+			count, err = eachVisibleKey(keys, func(key string) error { return h.store.Expire(ctx, key, req.TTLSeconds) })
+		}
+	}
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update TTL: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, PatternCountResponse{Pattern: pattern, Count: count})
+}
+
+// PushHandler handles PUSH operations for lists
+// POST /api/v1/lists/push
+func (h *Handler) PushHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req PushRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.MaxLen < 0 {
+		h.writeError(w, http.StatusBadRequest, "max_len must be >= 0 (0 = no cap)")
+		return
+	}
+	if req.ReturnEvicted && req.MaxLen == 0 {
+		h.writeError(w, http.StatusBadRequest, "return_evicted requires max_len")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	fence, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.store.PushItem(ctx, req.Key, req.Item, store.PushOptions{Resurrect: req.Resurrect, MaxLen: req.MaxLen}, fence...)
+	if err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		if errors.Is(err, store.ErrKeyPendingDelete) {
+			h.writeError(w, http.StatusConflict, "Key is pending soft delete, restore it or push with resurrect")
+			return
+		}
+		if errors.Is(err, store.ErrTypeMismatch) {
+			h.writeError(w, http.StatusConflict, "Key does not hold a list")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to push item: %v", err))
+		return
+	}
+
+	if req.ReturnEvicted {
+		if res.Evicted == nil {
+			res.Evicted = []string{}
+		}
+		h.writeSuccess(w, PushResponse{Message: "Item pushed successfully", Seq: res.Seq, Evicted: res.Evicted})
+		return
+	}
+
+	h.writeSuccess(w, map[string]any{"message": "Item pushed successfully", "seq": res.Seq})
+}
+
+// RPushHandler handles RPUSH operations for lists
+// POST /api/v1/lists/rpush
+func (h *Handler) RPushHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req RPushRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	fence, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.store.PushItem(ctx, req.Key, req.Item, store.PushOptions{Tail: true}, fence...)
+	if err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		if errors.Is(err, store.ErrKeyPendingDelete) {
+			h.writeError(w, http.StatusConflict, "Key is pending soft delete, restore it before pushing")
+			return
+		}
+		if errors.Is(err, store.ErrTypeMismatch) {
+			h.writeError(w, http.StatusConflict, "Key does not hold a list")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to push item: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, map[string]any{"message": "Item pushed successfully", "seq": res.Seq})
+}
+
+// LSetHandler replaces a list with the given items, or with nx only creates it if absent
+// POST /api/v1/lists/set
+func (h *Handler) LSetHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req LSetRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.Key == "" {
+		h.writeError(w, http.StatusBadRequest, "Key is required")
+		return
+	}
+
+	if len(req.Items) == 0 {
+		h.writeError(w, http.StatusBadRequest, "Items are required")
+		return
+	}
+
+	if req.TTLSeconds < 0 {
+		h.writeError(w, http.StatusBadRequest, "TTL must be >= 0 (0 = no expiration)")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	fence, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+
+	if req.NX {
+		set, err := h.store.LInitNX(ctx, req.Key, req.Items, req.TTLSeconds, fence...)
+		if err != nil {
+			if h.writeRejected(w, err) {
+				return
+			}
+			h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to set list: %v", err))
+			return
+		}
+		h.writeSuccess(w, map[string]bool{"set": set})
+		return
+	}
+
+	if err := h.store.LSet(ctx, req.Key, req.Items, req.TTLSeconds, fence...); err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to set list: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, map[string]string{"message": "List set successfully"})
+}
+
+// LMoveAllHandler moves all items of one list onto the front of another
+// POST /api/v1/lists/moveall
+func (h *Handler) LMoveAllHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req LMoveAllRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.Src == "" || req.Dst == "" {
+		h.writeError(w, http.StatusBadRequest, "Src and dst are required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	fence, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+
+	moved, err := h.store.LMoveAll(ctx, req.Src, req.Dst, fence...)
+	if err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, store.ErrKeyNotFound):
+			h.writeError(w, http.StatusNotFound, "Key not found")
+		case errors.Is(err, store.ErrTypeMismatch):
+			h.writeError(w, http.StatusConflict, "Src and dst must be lists")
+		case errors.Is(err, store.ErrKeyPendingDelete):
+			h.writeError(w, http.StatusConflict, "Dst is pending soft delete")
+		default:
+			h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to move items: %v", err))
+		}
+		return
+	}
+
+	h.writeSuccess(w, LMoveAllResponse{Moved: moved})
 }
 
-func NewHandler(s store.IStore) *Handler {
-	return &Handler{store: s}
+// PopHandler handles POP operations for lists
+// POST /api/v1/lists/pop
+func (h *Handler) PopHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req PopRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	fence, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := h.store.PopItem(ctx, req.Key, fence...)
+	if err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		if errors.Is(err, store.ErrKeyNotFound) {
+			h.writeError(w, http.StatusNotFound, "Key not found")
+			return
+		}
+		if errors.Is(err, store.ErrEmptyList) {
+			h.writeError(w, http.StatusBadRequest, "List is empty")
+			return
+		}
+		if errors.Is(err, store.ErrTypeMismatch) {
+			h.writeError(w, http.StatusConflict, "Key does not hold a list")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to pop item: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, map[string]any{"key": req.Key, "value": item.Value, "seq": item.Seq})
 }
 
-// SetHandler handles SET operations
-// POST /api/v1/keys
-func (h *Handler) SetHandler(w http.ResponseWriter, r *http.Request) {
+// RPopHandler handles RPOP operations for lists
+// POST /api/v1/lists/rpop
+func (h *Handler) RPopHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	var req SetRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, http.StatusBadRequest, "Invalid JSON payload")
+	var req PopRequest
+	if !h.decodeJSON(w, r, &req) {
 		return
 	}
 
-	if req.Key == "" {
-		h.writeError(w, http.StatusBadRequest, "Key is required")
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	fence, ok := h.fence(w, r)
+	if !ok {
 		return
 	}
 
-	if req.TTLSeconds < 0 {
-		h.writeError(w, http.StatusBadRequest, "TTL must be >= 0 (0 = no expiration)")
+	item, err := h.store.RPopItem(ctx, req.Key, fence...)
+	if err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		if errors.Is(err, store.ErrKeyNotFound) {
+			h.writeError(w, http.StatusNotFound, "Key not found")
+			return
+		}
+		if errors.Is(err, store.ErrEmptyList) {
+			h.writeError(w, http.StatusBadRequest, "List is empty")
+			return
+		}
+		if errors.Is(err, store.ErrTypeMismatch) {
+			h.writeError(w, http.StatusConflict, "Key does not hold a list")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to pop item: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, map[string]any{"key": req.Key, "value": item.Value, "seq": item.Seq})
+}
+
+// maxBLPopTimeout bounds how long a BLPOP request may hold its connection open.
+const maxBLPopTimeout = 5 * time.Minute
+
+// BLPopHandler handles blocking POP operations for lists, waiting up to
+// timeout_seconds for an item if the list is empty or missing
+// POST /api/v1/lists/blpop
+func (h *Handler) BLPopHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req BLPopRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	timeout := time.Duration(req.TimeoutSeconds * float64(time.Second))
+	if timeout <= 0 || timeout > maxBLPopTimeout {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("timeout_seconds must be positive and at most %d", int(maxBLPopTimeout.Seconds())))
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Stop waiting if the client goes away, or answer as if timed out if the handler is
+	// closed, so a waiting request does not hold up the server shutdown.
+	ctx, cancel := context.WithTimeout(r.Context(), timeout+5*time.Second)
 	defer cancel()
+	ctx, cancelClosed := h.untilClosed(ctx)
+	defer cancelClosed()
 
-	if err := h.store.Set(ctx, req.Key, req.Value, req.TTLSeconds); err != nil {
-		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to set key: %v", err))
+	fence, ok := h.fence(w, r)
+	if !ok {
 		return
 	}
 
-	h.writeSuccess(w, map[string]string{"message": "Key set successfully"})
+	value, err := h.store.BLPop(ctx, req.Key, timeout, fence...)
+	if err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, store.ErrTimeout), errors.Is(context.Cause(ctx), errHandlerClosed):
+			h.writeError(w, http.StatusRequestTimeout, "Timed out waiting for an item")
+		case errors.Is(err, store.ErrTypeMismatch):
+			h.writeError(w, http.StatusConflict, "Key does not hold a list")
+		default:
+			h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to pop item: %v", err))
+		}
+		return
+	}
+
+	h.writeSuccess(w, map[string]any{"key": req.Key, "value": value})
 }
 
-// GetHandler handles GET operations
-// GET /api/v1/keys/{key}
-func (h *Handler) GetHandler(w http.ResponseWriter, r *http.Request) {
+// ListHistoryHandler returns the recorded depth samples of a list
+// GET /api/v1/lists/{key}/history
+func (h *Handler) ListHistoryHandler(w http.ResponseWriter, r *http.Request, key string) {
 	if r.Method != http.MethodGet {
 		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	key := r.URL.Path[len("/api/v1/keys/"):]
-	if key == "" {
-		h.writeError(w, http.StatusBadRequest, "Key is required")
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	samples, err := h.store.ListDepthHistory(ctx, key)
+	if err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) {
+			h.writeError(w, http.StatusNotFound, "No history recorded for list")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get list history: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, ListHistoryResponse{Key: key, Samples: samples})
+}
+
+// LLenHandler returns the number of items in a list
+// GET /api/v1/lists/{key}/len
+func (h *Handler) LLenHandler(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	value, err := h.store.Get(ctx, key)
+	length, err := h.store.LLen(ctx, key)
 	if err != nil {
-		if err.Error() == "key not found" {
+		if errors.Is(err, store.ErrKeyNotFound) {
 			h.writeError(w, http.StatusNotFound, "Key not found")
 			return
 		}
-		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get key: %v", err))
+		if errors.Is(err, store.ErrTypeMismatch) {
+			h.writeError(w, http.StatusConflict, "Key does not hold a list")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get list length: %v", err))
 		return
 	}
 
-	h.writeSuccess(w, map[string]string{"key": key, "value": value})
+	h.writeSuccess(w, LLenResponse{Key: key, Length: length})
 }
 
-// UpdateHandler handles UPDATE operations
-// PUT /api/v1/keys/{key}
-func (h *Handler) UpdateHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
+// LRangeHandler returns a range of list items, with their sequence numbers and push
+// times if with_meta is set
+// GET /api/v1/lists/{key}/range?start={start}&stop={stop}[&reverse=true][&with_meta=true]
+func (h *Handler) LRangeHandler(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodGet {
 		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	key := r.URL.Path[len("/api/v1/keys/"):]
-	if key == "" {
-		h.writeError(w, http.StatusBadRequest, "Key is required")
+	start, stop := 0, -1
+	query := r.URL.Query()
+	var err error
+	if query.Has("start") {
+		if start, err = strconv.Atoi(query.Get("start")); err != nil {
+			h.writeError(w, http.StatusBadRequest, "Start must be an integer")
+			return
+		}
+	}
+	if query.Has("stop") {
+		if stop, err = strconv.Atoi(query.Get("stop")); err != nil {
+			h.writeError(w, http.StatusBadRequest, "Stop must be an integer")
+			return
+		}
+	}
+
+	reverse, withMeta := query.Get("reverse") == "true", query.Get("with_meta") == "true"
+	if reverse && withMeta {
+		h.writeError(w, http.StatusBadRequest, "With_meta cannot be combined with reverse")
 		return
 	}
 
-	var req UpdateRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, http.StatusBadRequest, "Invalid JSON payload")
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if withMeta {
+		items, err := h.store.LRangeWithMeta(ctx, key, start, stop)
+		if err != nil {
+			h.writeRangeError(w, err)
+			return
+		}
+		h.writeSuccess(w, LRangeMetaResponse{Key: key, Items: listItemsMeta(items)})
+		return
+	}
+
+	rangePage := h.store.LRangePage
+	if reverse {
+		rangePage = h.store.LRangeReverse
+	}
+
+	page, err := rangePage(ctx, key, start, stop)
+	if err != nil {
+		h.writeRangeError(w, err)
+		return
+	}
+
+	h.writeSuccess(w, LRangeResponse{Key: key, ListPage: page})
+}
+
+func (h *Handler) writeRangeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, store.ErrKeyNotFound) {
+		h.writeError(w, http.StatusNotFound, "Key not found")
+		return
+	}
+	if errors.Is(err, store.ErrTypeMismatch) {
+		h.writeError(w, http.StatusConflict, "Key does not hold a list")
+		return
+	}
+	h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get list range: %v", err))
+}
+
+// listItemsMeta converts list items to their view, leaving out unknown push times.
+func listItemsMeta(items []store.ListItem) []ListItemMeta {
+	views := make([]ListItemMeta, len(items))
+	for i, item := range items {
+		views[i] = ListItemMeta{Value: item.Value, Seq: item.Seq}
+		if !item.PushedAt.IsZero() {
+			pushedAt := item.PushedAt.UTC()
+			views[i].PushedAt = &pushedAt
+		}
+	}
+	return views
+}
+
+// LClearHandler removes every item of a list, keeping the key and its TTL
+// POST /api/v1/lists/{key}/clear
+func (h *Handler) LClearHandler(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	if err := h.store.Update(ctx, key, req.Value); err != nil {
-		if err.Error() == "key not found" {
+	fence, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.LClear(ctx, key, fence...); err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		if errors.Is(err, store.ErrKeyNotFound) {
 			h.writeError(w, http.StatusNotFound, "Key not found")
 			return
 		}
-		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update key: %v", err))
+		if errors.Is(err, store.ErrTypeMismatch) {
+			h.writeError(w, http.StatusConflict, "Key does not hold a list")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to clear list: %v", err))
 		return
 	}
 
-	h.writeSuccess(w, map[string]string{"message": "Key updated successfully"})
+	h.writeSuccess(w, map[string]string{"message": "List cleared successfully"})
 }
 
-// RemoveHandler handles DELETE operations
-// DELETE /api/v1/keys/{key}
-func (h *Handler) RemoveHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
+// LTrimHandler trims a list to a range of its items, optionally returning the removed items
+// POST /api/v1/lists/{key}/trim?start={start}&stop={stop}[&return_removed=true]
+func (h *Handler) LTrimHandler(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodPost {
 		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	key := r.URL.Path[len("/api/v1/keys/"):]
-	if key == "" {
-		h.writeError(w, http.StatusBadRequest, "Key is required")
+	query := r.URL.Query()
+	if !query.Has("start") || !query.Has("stop") {
+		h.writeError(w, http.StatusBadRequest, "Start and stop are required")
+		return
+	}
+	start, err := strconv.Atoi(query.Get("start"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "Start must be an integer")
+		return
+	}
+	stop, err := strconv.Atoi(query.Get("stop"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "Stop must be an integer")
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	if err := h.store.Remove(ctx, key); err != nil {
-		if err.Error() == "key not found" {
+	fence, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+
+	removed, err := h.store.LTrimReturn(ctx, key, start, stop, fence...)
+	if err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		if errors.Is(err, store.ErrKeyNotFound) {
 			h.writeError(w, http.StatusNotFound, "Key not found")
 			return
 		}
-		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to remove key: %v", err))
+		if errors.Is(err, store.ErrTypeMismatch) {
+			h.writeError(w, http.StatusConflict, "Key does not hold a list")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to trim list: %v", err))
 		return
 	}
 
-	h.writeSuccess(w, map[string]string{"message": "Key removed successfully"})
+	if query.Get("return_removed") == "true" {
+		h.writeSuccess(w, LTrimResponse{Key: key, Removed: removed})
+		return
+	}
+
+	h.writeSuccess(w, map[string]string{"message": "List trimmed successfully"})
 }
 
-// PushHandler handles PUSH operations for lists
-// POST /api/v1/lists/push
-func (h *Handler) PushHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+// StatsHandler returns runtime statistics of the store
+// GET /api/v1/stats
+func (h *Handler) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
 		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	var req PushRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, http.StatusBadRequest, "Invalid JSON payload")
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	stats, err := h.store.Stats(ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get stats: %v", err))
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	h.writeSuccess(w, stats)
+}
 
-	if err := h.store.Push(ctx, req.Key, req.Item); err != nil {
-		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to push item: %v", err))
+// TimeHandler returns the server's current time so clients can account for clock skew
+// GET /api/v1/time
+func (h *Handler) TimeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	h.writeSuccess(w, map[string]string{"message": "Item pushed successfully"})
+	now := time.Now().UTC()
+	h.writeSuccess(w, TimeResponse{Time: now.Format(time.RFC3339Nano), UnixMs: now.UnixMilli()})
 }
 
-// PopHandler handles POP operations for lists
-// POST /api/v1/lists/pop
-func (h *Handler) PopHandler(w http.ResponseWriter, r *http.Request) {
+// RateIncrHandler counts a hit against a sliding window rate limit
+// POST /api/v1/ratelimit
+func (h *Handler) RateIncrHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	var req PopRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, http.StatusBadRequest, "Invalid JSON payload")
+	var req RateIncrRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.Key == "" {
+		h.writeError(w, http.StatusBadRequest, "Key is required")
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if req.WindowMs <= 0 || req.Limit <= 0 {
+		h.writeError(w, http.StatusBadRequest, "Window and limit must be greater than 0")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	value, err := h.store.Pop(ctx, req.Key)
+	count, allowed, err := h.store.RateIncr(ctx, req.Key, time.Duration(req.WindowMs)*time.Millisecond, req.Limit)
 	if err != nil {
-		if err.Error() == "key not found" {
-			h.writeError(w, http.StatusNotFound, "Key not found")
-			return
-		}
-		if err.Error() == "empty list" {
-			h.writeError(w, http.StatusBadRequest, "List is empty")
-			return
-		}
-		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to pop item: %v", err))
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to count request: %v", err))
 		return
 	}
 
-	h.writeSuccess(w, map[string]string{"key": req.Key, "value": value})
+	h.writeSuccess(w, RateIncrResponse{Key: req.Key, Count: count, Limit: req.Limit, Allowed: allowed})
 }
 
-// SetupRoutes sets up all the HTTP routes
-func (h *Handler) SetupRoutes() *http.ServeMux {
+// SetupRoutes sets up all the HTTP routes, wrapped in the middlewares added with Use,
+// the version headers and request IDs
+func (h *Handler) SetupRoutes() http.Handler {
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("/api/v1/keys", h.SetHandler)
+	mux.HandleFunc("/api/v1/keys", h.keysCollection)
 	// This is for GET, PUT and DELETE
 	mux.HandleFunc("/api/v1/keys/", h.keyOperation)
+	// Binary-safe variants taking the key in the request body
+	mux.HandleFunc("/api/v1/keys/get", h.postOrKeyOperation(h.BinaryGetHandler))
+	mux.HandleFunc("/api/v1/keys/delete", h.postOrKeyOperation(h.BinaryRemoveHandler))
+	mux.HandleFunc("/api/v1/keys/multiget", h.postOrKeyOperation(h.MultiGetHandler))
+	mux.HandleFunc("/api/v1/keys/mset", h.postOrKeyOperation(h.MSetHandler))
+	mux.HandleFunc("/api/v1/keys/mget", h.postOrKeyOperation(h.MGetHandler))
+	mux.HandleFunc("/api/v1/keys/info", h.postOrKeyOperation(h.KeysInfoHandler))
+	mux.HandleFunc("/api/v1/keys/expire", h.postOrKeyOperation(h.ExpirePatternHandler))
+	mux.HandleFunc("/api/v1/keys/count", h.patternOrKeyOperation(h.CountPatternHandler))
 
 	mux.HandleFunc("/api/v1/lists/push", h.PushHandler)
 	mux.HandleFunc("/api/v1/lists/pop", h.PopHandler)
+	mux.HandleFunc("/api/v1/lists/rpush", h.RPushHandler)
+	mux.HandleFunc("/api/v1/lists/rpop", h.RPopHandler)
+	mux.HandleFunc("/api/v1/lists/blpop", h.BLPopHandler)
+	mux.HandleFunc("/api/v1/lists/set", h.LSetHandler)
+	mux.HandleFunc("/api/v1/lists/moveall", h.LMoveAllHandler)
+	mux.HandleFunc("/api/v1/lists/ack", h.AckHandler)
+	mux.HandleFunc("/api/v1/lists/batch", h.ListBatchHandler)
+	// This is for per-list operations addressed by key
+	mux.HandleFunc("/api/v1/lists/", h.listOperation)
+
+	// This is for whole hashes and their fields, addressed by key
+	mux.HandleFunc("/api/v1/hashes/", h.hashOperation)
+	// This is for per-set operations addressed by key
+	mux.HandleFunc("/api/v1/sets/", h.setOperation)
+
+	mux.HandleFunc("/api/v1/read/pipeline", h.PipelineGetHandler)
 
-	return mux
+	mux.HandleFunc("/api/v1/tags/", h.tagOperation)
+
+	mux.HandleFunc("/api/v1/ratelimit", h.RateIncrHandler)
+	mux.HandleFunc("/api/v1/stats", h.StatsHandler)
+	mux.HandleFunc("/api/v1/events", h.EventsHandler)
+	mux.HandleFunc("/api/v1/subscribe", h.SubscribeHandler)
+	mux.HandleFunc("/api/v1/publish", h.PublishHandler)
+	mux.HandleFunc("/api/v1/time", h.TimeHandler)
+	mux.HandleFunc("/api/v1/capabilities", h.CapabilitiesHandler)
+	mux.HandleFunc("/api/v1/flush", h.FlushHandler)
+	mux.HandleFunc("/api/v1/admin/top", h.TopKeysHandler)
+	mux.HandleFunc("/api/v1/admin/sample", h.SampleKeysHandler)
+	mux.HandleFunc("/api/v1/admin/export", h.ExportHandler)
+	mux.HandleFunc("/api/v1/admin/verify", h.VerifyHandler)
+	mux.HandleFunc("/api/v1/admin/ttl-histogram", h.TTLHistogramHandler)
+	mux.HandleFunc("/api/v1/admin/health", h.HealthHandler)
+	mux.HandleFunc("/api/v1/admin/config", h.ConfigHandler)
+	mux.HandleFunc("/api/v1/admin/ttl-worker/pause", h.PauseTTLWorkerHandler)
+	mux.HandleFunc("/api/v1/admin/ttl-worker/resume", h.ResumeTTLWorkerHandler)
+	mux.HandleFunc("/readyz", h.ReadyHandler)
+
+	mux.Handle("/admin/", h.AdminUIHandler())
+
+	return h.requestIDs(h.versionHeaders(Chain(h.middlewares...)(h.logCommands(mux))))
+}
+
+// keysCollection lists keys on GET, deletes expiring keys on DELETE and sets a key on
+// POST, as they share the collection path.
+func (h *Handler) keysCollection(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.KeysHandler(w, r)
+	case http.MethodDelete:
+		if r.URL.Query().Has("pattern") {
+			h.DeleteMatchingHandler(w, r)
+			return
+		}
+		h.DeleteExpiringHandler(w, r)
+	default:
+		h.SetHandler(w, r)
+	}
 }
 
 // keyOperation handles GET, PUT and DELETE operations for keys as the request path is the same.
+// Sub-resources of a key ({key}/upload/..., {key}/ttl, {key}/any, {key}/raw, {key}/restore,
+// {key}/getset, {key}/incr, {key}/decr) are dispatched separately.
 func (h *Handler) keyOperation(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path[len("/api/v1/keys/"):]
+	// Every key addressed here, including an upload's target, starts the path.
+	if !h.allowedKeys(w, path) {
+		return
+	}
+
+	if key, sub, ok := splitUploadPath(path); ok {
+		noteKey(r, key)
+		h.uploadOperation(w, r, key, sub)
+		return
+	}
+
+	if key, ok := strings.CutSuffix(path, "/ttl"); ok && key != "" {
+		noteKey(r, key)
+		if r.Method == http.MethodGet {
+			h.TTLHandler(w, r, key)
+			return
+		}
+		h.ExpireHandler(w, r, key)
+		return
+	}
+
+	if key, ok := strings.CutSuffix(path, "/any"); ok && key != "" {
+		noteKey(r, key)
+		h.GetAnyHandler(w, r, key)
+		return
+	}
+
+	if key, ok := strings.CutSuffix(path, "/raw"); ok && key != "" {
+		noteKey(r, key)
+		h.GetRawHandler(w, r, key)
+		return
+	}
+
+	if key, ok := strings.CutSuffix(path, "/restore"); ok && key != "" {
+		noteKey(r, key)
+		h.RestoreHandler(w, r, key)
+		return
+	}
+
+	if key, ok := strings.CutSuffix(path, "/getset"); ok && key != "" {
+		noteKey(r, key)
+		h.GetSetHandler(w, r, key)
+		return
+	}
+
+	if key, ok := strings.CutSuffix(path, "/incr"); ok && key != "" {
+		noteKey(r, key)
+		h.IncrHandler(w, r, key)
+		return
+	}
+
+	if key, ok := strings.CutSuffix(path, "/decr"); ok && key != "" {
+		noteKey(r, key)
+		h.DecrHandler(w, r, key)
+		return
+	}
+
+	noteKey(r, path)
+
 	switch r.Method {
 	case http.MethodGet:
 		h.GetHandler(w, r)
+	case http.MethodHead:
+		h.ExistsHandler(w, r)
 	case http.MethodPut:
 		h.UpdateHandler(w, r)
 	case http.MethodDelete:
@@ -234,21 +1892,101 @@ func (h *Handler) keyOperation(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// listOperation handles operations on a single list addressed as /api/v1/lists/{key}/{operation}.
+func (h *Handler) listOperation(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path[len("/api/v1/lists/"):]
+	if !h.allowedKeys(w, path) {
+		return
+	}
+
+	i := strings.LastIndex(path, "/")
+	if i <= 0 {
+		h.writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	key, operation := path[:i], path[i+1:]
+	noteKey(r, key)
+
+	switch operation {
+	case "history":
+		h.ListHistoryHandler(w, r, key)
+	case "len":
+		h.LLenHandler(w, r, key)
+	case "range":
+		h.LRangeHandler(w, r, key)
+	case "trim":
+		h.LTrimHandler(w, r, key)
+	case "clear":
+		h.LClearHandler(w, r, key)
+	case "reserve":
+		h.ReserveHandler(w, r, key)
+	default:
+		h.writeError(w, http.StatusNotFound, "Not found")
+	}
+}
+
 // writeJSON is a helper function to write JSON responses
 func (h *Handler) writeJSON(w http.ResponseWriter, statusCode int, response Response) {
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", h.contentType)
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(response)
 }
 
 // writeError is a helper function to write error responses
 func (h *Handler) writeError(w http.ResponseWriter, statusCode int, message string) {
+	if h.hidesError(statusCode) {
+		h.writeInternalError(w, message)
+		return
+	}
 	h.writeJSON(w, statusCode, Response{
 		Success: false,
 		Error:   message,
 	})
 }
 
+// writeRateLimited writes a 429 response telling the client to retry after retryAfter,
+// both in the Retry-After header (in whole seconds) and in the body (in milliseconds).
+func (h *Handler) writeRateLimited(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int((retryAfter + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+	h.writeJSON(w, http.StatusTooManyRequests, Response{
+		Success:      false,
+		Error:        "Too many requests",
+		Code:         CodeRateLimited,
+		RetryAfterMs: max(retryAfter.Milliseconds(), 1),
+	})
+}
+
+// writeRejected writes a response and returns true if err is a store refusal of a
+// write: 409 for a stale fence token, 413 for a value over the store's size limit and
+// 507 once the store key or memory limit is reached.
+func (h *Handler) writeRejected(w http.ResponseWriter, err error) bool {
+	status, message, ok := rejection(err)
+	if ok {
+		h.writeError(w, status, message)
+	}
+	return ok
+}
+
+// rejection returns the status and message writeRejected answers err with, and false
+// if err is not a store refusal of a write.
+func rejection(err error) (int, string, bool) {
+	switch {
+	case errors.Is(err, store.ErrStaleFence):
+		return http.StatusConflict, "Stale fence token", true
+	case errors.Is(err, store.ErrUnknownFence):
+		return http.StatusBadRequest, "Fence token was never issued", true
+	case errors.Is(err, store.ErrValueTooLarge):
+		return http.StatusRequestEntityTooLarge, "Value exceeds the maximum size", true
+	case errors.Is(err, store.ErrOutOfMemory):
+		return http.StatusInsufficientStorage, "Store is out of memory", true
+	case errors.Is(err, store.ErrTooManyKeys):
+		return http.StatusInsufficientStorage, "Store key limit reached", true
+	default:
+		return 0, "", false
+	}
+}
+
 // writeSuccess is a helper function to write success responses
 func (h *Handler) writeSuccess(w http.ResponseWriter, data any) {
 	h.writeJSON(w, http.StatusOK, Response{
@@ -2,11 +2,15 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/mo-mohamed/acronis-memory-store/internal/store"
 	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
@@ -120,6 +124,25 @@ func TestHandler_ListOperations(t *testing.T) {
 	if data["value"] != "item1" {
 		t.Errorf("Expected value 'item1', got %v", data["value"])
 	}
+
+	req = httptest.NewRequest("POST", "/api/v1/lists/pop", bytes.NewReader(payloadBytes))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a pop from an empty list, got %d", w.Code)
+	}
+
+	var emptyResponse Response
+	if err := json.NewDecoder(w.Body).Decode(&emptyResponse); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if emptyResponse.Success || emptyResponse.Error != "List is empty" {
+		t.Errorf("Expected error 'List is empty', got %+v", emptyResponse)
+	}
 }
 
 func TestHandler_UpdateAndRemove(t *testing.T) {
@@ -264,3 +287,710 @@ func TestHandler_TTLValidation(t *testing.T) {
 		}
 	})
 }
+
+func TestHandler_ListHistory(t *testing.T) {
+	memoryStore := memory.NewMemoryStoreWithOptions(memory.Options{ListSampleInterval: 10 * time.Millisecond})
+	defer memoryStore.StopTTLWorker()
+	defer memoryStore.StopListSampler()
+
+	handler := NewHandler(memoryStore)
+	mux := handler.SetupRoutes()
+
+	memoryStore.Push(context.Background(), "jobs", "job1")
+	time.Sleep(50 * time.Millisecond)
+
+	req := httptest.NewRequest("GET", "/api/v1/lists/jobs/history", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Data ListHistoryResponse `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if len(response.Data.Samples) == 0 || response.Data.Samples[0].Depth != 1 {
+		t.Errorf("Expected samples with depth 1, got %+v", response.Data.Samples)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/lists/unknown/history", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestHandler_ContentType(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	mux := NewHandler(memoryStore).SetupRoutes()
+
+	for _, path := range []string{"/api/v1/stats", "/api/v1/keys/missing", "/readyz"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+
+		if got := w.Header().Get("Content-Type"); got != "application/json; charset=utf-8" {
+			t.Errorf("%s: expected Content-Type 'application/json; charset=utf-8', got %q", path, got)
+		}
+	}
+
+	mux = NewHandler(memoryStore, WithContentType("application/json; charset=UTF-8")).SetupRoutes()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/stats", nil))
+	if got := w.Header().Get("Content-Type"); got != "application/json; charset=UTF-8" {
+		t.Errorf("Expected configured Content-Type, got %q", got)
+	}
+}
+
+func TestHandler_Time(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	mux := NewHandler(memoryStore).SetupRoutes()
+
+	before := time.Now()
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/time", nil))
+	after := time.Now()
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var resp struct {
+		Data TimeResponse `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	serverTime, err := time.Parse(time.RFC3339Nano, resp.Data.Time)
+	if err != nil {
+		t.Fatalf("Expected an RFC3339 time, got %q: %v", resp.Data.Time, err)
+	}
+	if serverTime.Before(before) || serverTime.After(after) {
+		t.Errorf("Expected time between %v and %v, got %v", before, after, serverTime)
+	}
+	if resp.Data.UnixMs != serverTime.UnixMilli() {
+		t.Errorf("Expected unix_ms %d to match time, got %d", serverTime.UnixMilli(), resp.Data.UnixMs)
+	}
+}
+
+func TestHandler_OutOfMemory(t *testing.T) {
+	// Room for one key holding a 100 byte value, but not two.
+	memoryStore := memory.NewMemoryStoreWithOptions(memory.Options{MaxMemoryBytes: 300})
+	defer memoryStore.StopTTLWorker()
+
+	mux := NewHandler(memoryStore).SetupRoutes()
+
+	set := func(key string) int {
+		payload, _ := json.Marshal(SetRequest{Key: key, Value: strings.Repeat("v", 100), TTLSeconds: 60})
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/keys", bytes.NewReader(payload)))
+		return w.Code
+	}
+
+	if code := set("first"); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if code := set("second"); code != http.StatusInsufficientStorage {
+		t.Errorf("Expected status 507 once full, got %d", code)
+	}
+
+	payload, _ := json.Marshal(PushRequest{Key: "jobs", Item: strings.Repeat("j", 100)})
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/lists/push", bytes.NewReader(payload)))
+	if w.Code != http.StatusInsufficientStorage {
+		t.Errorf("Expected push to return 507 once full, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/keys/first", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected reads to work once full, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/v1/keys/first", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected deletes to work once full, got %d", w.Code)
+	}
+
+	if code := set("second"); code != http.StatusOK {
+		t.Errorf("Expected status 200 after a delete, got %d", code)
+	}
+}
+
+func TestHandler_ListItemTooLarge(t *testing.T) {
+	memoryStore := memory.NewMemoryStoreWithOptions(memory.Options{MaxListItemBytes: 10})
+	defer memoryStore.StopTTLWorker()
+
+	mux := NewHandler(memoryStore).SetupRoutes()
+
+	push := func(item string) int {
+		payload, _ := json.Marshal(PushRequest{Key: "jobs", Item: item})
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/lists/push", bytes.NewReader(payload)))
+		return w.Code
+	}
+
+	if code := push(strings.Repeat("j", 10)); code != http.StatusOK {
+		t.Errorf("Expected status 200 for an item at the limit, got %d", code)
+	}
+	if code := push(strings.Repeat("j", 11)); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413 for an item over the limit, got %d", code)
+	}
+
+	payload, _ := json.Marshal(LSetRequest{Key: "other", Items: []any{strings.Repeat("j", 11)}})
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/lists/set", bytes.NewReader(payload)))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected list set to return 413, got %d", w.Code)
+	}
+
+	payload, _ = json.Marshal(SetRequest{Key: "blob", Value: strings.Repeat("b", 100)})
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/keys", bytes.NewReader(payload)))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected string values to ignore the list item limit, got %d", w.Code)
+	}
+}
+
+func TestHandler_PushMaxLen(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	mux := NewHandler(memoryStore).SetupRoutes()
+
+	push := func(req PushRequest) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/lists/push", bytes.NewReader(payload)))
+		return w
+	}
+
+	push(PushRequest{Key: "jobs", Item: "a", MaxLen: 2})
+	push(PushRequest{Key: "jobs", Item: "b", MaxLen: 2})
+	w := push(PushRequest{Key: "jobs", Item: "c", MaxLen: 2, ReturnEvicted: true})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var resp struct {
+		Data PushResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp.Data.Evicted) != 1 || resp.Data.Evicted[0] != "a" {
+		t.Errorf("Expected evicted [a], got %v", resp.Data.Evicted)
+	}
+
+	w = push(PushRequest{Key: "jobs", Item: "d", MaxLen: 5, ReturnEvicted: true})
+	if !strings.Contains(w.Body.String(), `"evicted":[]`) {
+		t.Errorf("Expected an empty evicted list, got %s", w.Body.String())
+	}
+
+	for _, req := range []PushRequest{
+		{Key: "jobs", Item: "x", MaxLen: -1},
+		{Key: "jobs", Item: "x", ReturnEvicted: true},
+	} {
+		if w := push(req); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %+v, got %d", req, w.Code)
+		}
+	}
+
+	// Resurrecting a soft deleted list caps it like any other push
+	memoryStore.SoftRemove(context.Background(), "jobs")
+	w = push(PushRequest{Key: "jobs", Item: "x", MaxLen: 2, Resurrect: true, ReturnEvicted: true})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d %s", w.Code, w.Body.String())
+	}
+	resp.Data = PushResponse{}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !reflect.DeepEqual(resp.Data.Evicted, []string{"c", "b"}) {
+		t.Errorf("Expected evicted [c b], got %v", resp.Data.Evicted)
+	}
+}
+
+func TestHandler_RPushRPop(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	mux := NewHandler(memoryStore).SetupRoutes()
+
+	post := func(path string, body any) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", path, bytes.NewReader(payload)))
+		return w
+	}
+
+	for i, item := range []string{"first", "second"} {
+		w := post("/api/v1/lists/rpush", RPushRequest{Key: "jobs", Item: item})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for rpush, got %d", w.Code)
+		}
+		if seq := fmt.Sprintf(`"seq":%d`, i+1); !strings.Contains(w.Body.String(), seq) {
+			t.Errorf("Expected rpush to return %s, got %s", seq, w.Body.String())
+		}
+	}
+
+	w := post("/api/v1/lists/rpop", PopRequest{Key: "jobs"})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"value":"second"`) || !strings.Contains(w.Body.String(), `"seq":2`) {
+		t.Errorf("Expected rpop to return the last item with seq 2, got %d %s", w.Code, w.Body.String())
+	}
+	w = post("/api/v1/lists/pop", PopRequest{Key: "jobs"})
+	if !strings.Contains(w.Body.String(), `"value":"first"`) || !strings.Contains(w.Body.String(), `"seq":1`) {
+		t.Errorf("Expected pop to return the first item with seq 1, got %s", w.Body.String())
+	}
+
+	for _, path := range []string{"/api/v1/lists/rpop", "/api/v1/lists/pop"} {
+		if w := post(path, PopRequest{Key: "jobs"}); w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "List is empty") {
+			t.Errorf("Expected status 400 for %s on an empty list, got %d %s", path, w.Code, w.Body.String())
+		}
+		if w := post(path, PopRequest{Key: "missing"}); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for %s on a missing list, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestHandler_TypeMismatch(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+	ctx := context.Background()
+
+	memoryStore.Set(ctx, "string", "value", 0)
+	memoryStore.Push(ctx, "list", "item")
+	mux := NewHandler(memoryStore).SetupRoutes()
+
+	send := func(method, path string, body any) *httptest.ResponseRecorder {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewReader(payload)))
+		return w
+	}
+
+	for _, tc := range []struct {
+		name, method, path string
+		body               any
+		message            string
+	}{
+		{"push", "POST", "/api/v1/lists/push", PushRequest{Key: "string", Item: "item"}, "Key does not hold a list"},
+		{"rpush", "POST", "/api/v1/lists/rpush", RPushRequest{Key: "string", Item: "item"}, "Key does not hold a list"},
+		{"pop", "POST", "/api/v1/lists/pop", PopRequest{Key: "string"}, "Key does not hold a list"},
+		{"rpop", "POST", "/api/v1/lists/rpop", PopRequest{Key: "string"}, "Key does not hold a list"},
+		{"trim", "POST", "/api/v1/lists/string/trim?start=0&stop=0", nil, "Key does not hold a list"},
+		{"len", "GET", "/api/v1/lists/string/len", nil, "Key does not hold a list"},
+		{"reserve", "POST", "/api/v1/lists/string/reserve", nil, "Key does not hold a list"},
+		{"get", "GET", "/api/v1/keys/list", nil, "Key does not hold a string"},
+		{"update", "PUT", "/api/v1/keys/list", UpdateRequest{Value: "value"}, "Key does not hold a string"},
+	} {
+		w := send(tc.method, tc.path, tc.body)
+		if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), tc.message) {
+			t.Errorf("%s: expected status 409 %q, got %d %s", tc.name, tc.message, w.Code, w.Body.String())
+		}
+	}
+
+	// Mismatched operations leave both keys as they were
+	if got, _ := memoryStore.Get(ctx, "string"); got != "value" {
+		t.Errorf("Expected the string unchanged, got %q", got)
+	}
+	if n, _ := memoryStore.LLen(ctx, "list"); n != 1 {
+		t.Errorf("Expected the list unchanged, got %d items", n)
+	}
+}
+
+func TestHandler_LRange(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	mux := NewHandler(memoryStore).SetupRoutes()
+	ctx := context.Background()
+	for _, item := range []string{"c", "b", "a"} {
+		memoryStore.Push(ctx, "jobs", item)
+	}
+	memoryStore.Set(ctx, "name", "value", 0)
+
+	tests := []struct {
+		path   string
+		status int
+		items  []string
+	}{
+		{"/api/v1/lists/jobs/range", http.StatusOK, []string{"a", "b", "c"}},
+		{"/api/v1/lists/jobs/range?start=0&stop=1", http.StatusOK, []string{"a", "b"}},
+		{"/api/v1/lists/jobs/range?start=-2&stop=100", http.StatusOK, []string{"b", "c"}},
+		{"/api/v1/lists/jobs/range?start=5&stop=10", http.StatusOK, []string{}},
+		{"/api/v1/lists/jobs/range?start=x", http.StatusBadRequest, nil},
+		{"/api/v1/lists/missing/range", http.StatusNotFound, nil},
+		{"/api/v1/lists/name/range", http.StatusConflict, nil},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.status, w.Code)
+			continue
+		}
+		if tt.items == nil {
+			continue
+		}
+		var resp struct {
+			Data LRangeResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		if resp.Data.Items == nil || strings.Join(resp.Data.Items, ",") != strings.Join(tt.items, ",") {
+			t.Errorf("%s: expected items %v, got %v", tt.path, tt.items, resp.Data.Items)
+		}
+	}
+}
+
+func TestHandler_DeleteExpiringValidation(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	mux := NewHandler(memoryStore).SetupRoutes()
+
+	for _, path := range []string{"/api/v1/keys", "/api/v1/keys?expiring_within=abc", "/api/v1/keys?expiring_within=-1"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("DELETE", path, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("DELETE %s: expected status 400, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestHandler_KeyLimit(t *testing.T) {
+	memoryStore := memory.NewMemoryStoreWithOptions(memory.Options{MaxKeys: 1})
+	defer memoryStore.StopTTLWorker()
+
+	mux := NewHandler(memoryStore).SetupRoutes()
+
+	set := func(key, value string) int {
+		payload, _ := json.Marshal(SetRequest{Key: key, Value: value})
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/keys", bytes.NewReader(payload)))
+		return w.Code
+	}
+
+	if code := set("first", "a"); code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if code := set("second", "a"); code != http.StatusInsufficientStorage {
+		t.Errorf("Expected status 507 for a new key at the cap, got %d", code)
+	}
+	if code := set("first", "b"); code != http.StatusOK {
+		t.Errorf("Expected status 200 updating an existing key at the cap, got %d", code)
+	}
+}
+
+func TestHandler_Exists(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	ctx := context.Background()
+	memoryStore.Set(ctx, "string", "v", 0)
+	memoryStore.Push(ctx, "list", "item")
+
+	mux := NewHandler(memoryStore).SetupRoutes()
+
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"string", http.StatusOK},
+		{"list", http.StatusOK},
+		{"missing", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("HEAD", "/api/v1/keys/"+tt.key, nil))
+		if w.Code != tt.want {
+			t.Errorf("HEAD %s: expected status %d, got %d", tt.key, tt.want, w.Code)
+		}
+		if w.Body.Len() != 0 {
+			t.Errorf("HEAD %s: expected no body, got %q", tt.key, w.Body.String())
+		}
+	}
+}
+
+func TestHandler_TTL(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	ctx := context.Background()
+	memoryStore.Set(ctx, "session", "abc", 60)
+	memoryStore.Set(ctx, "config", "on", 0)
+
+	mux := NewHandler(memoryStore).SetupRoutes()
+
+	tests := []struct {
+		key      string
+		wantCode int
+		wantTTL  int
+	}{
+		{"session", http.StatusOK, 60},
+		{"config", http.StatusOK, -1},
+		{"missing", http.StatusNotFound, 0},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/keys/"+tt.key+"/ttl", nil))
+		if w.Code != tt.wantCode {
+			t.Errorf("GET %s/ttl: expected status %d, got %d", tt.key, tt.wantCode, w.Code)
+			continue
+		}
+		if tt.wantCode != http.StatusOK {
+			continue
+		}
+
+		var resp struct {
+			Data TTLResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		if resp.Data.TTLSeconds != tt.wantTTL {
+			t.Errorf("GET %s/ttl: expected ttl_seconds %d, got %d", tt.key, tt.wantTTL, resp.Data.TTLSeconds)
+		}
+	}
+}
+
+func TestHandler_GetRaw(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	mux := NewHandler(memoryStore).SetupRoutes()
+
+	payload := `{"key": "user", "value": {"name": "alice", "age": 30}}`
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/keys", strings.NewReader(payload)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/keys/user/raw", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var resp struct {
+		Data RawResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if string(resp.Data.Value) != `{"age":30,"name":"alice"}` {
+		t.Errorf("Expected the original JSON object, got %s", resp.Data.Value)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/keys/missing/raw", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestHandler_Incr(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	memoryStore.Set(context.Background(), "name", "alice", 0)
+	mux := NewHandler(memoryStore).SetupRoutes()
+
+	incr := func(key, body string) (int, CounterResponse) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/keys/"+key+"/incr", strings.NewReader(body)))
+		var resp struct {
+			Data CounterResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, resp.Data
+	}
+
+	if code, data := incr("hits", `{"delta": 3}`); code != http.StatusOK || data.Value != 3 {
+		t.Errorf("Expected 200 and 3, got %d and %+v", code, data)
+	}
+	if code, data := incr("hits", `{"delta": -1}`); code != http.StatusOK || data.Value != 2 {
+		t.Errorf("Expected 200 and 2, got %d and %+v", code, data)
+	}
+	if code, _ := incr("name", `{"delta": 1}`); code != http.StatusConflict {
+		t.Errorf("Expected 409 for a non-integer value, got %d", code)
+	}
+}
+
+func TestHandler_Decr(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	ctx := context.Background()
+	memoryStore.Set(ctx, "stock", "2", 0)
+	memoryStore.Set(ctx, "name", "alice", 0)
+
+	mux := NewHandler(memoryStore).SetupRoutes()
+
+	tests := []struct {
+		key, body   string
+		wantCode    int
+		wantValue   int64
+		wantApplied bool
+	}{
+		{"stock", `{"delta": 2, "floor": 0}`, http.StatusOK, 0, true},
+		{"stock", `{"delta": 1, "floor": 0}`, http.StatusOK, 0, false},
+		{"stock", `{"delta": 0, "floor": 0}`, http.StatusBadRequest, 0, false},
+		{"stock", `{"delta": 1}`, http.StatusOK, -1, true},
+		{"name", `{"delta": 1, "floor": 0}`, http.StatusConflict, 0, false},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/keys/"+tt.key+"/decr", strings.NewReader(tt.body)))
+		if w.Code != tt.wantCode {
+			t.Errorf("%s %s: expected status %d, got %d", tt.key, tt.body, tt.wantCode, w.Code)
+			continue
+		}
+		if tt.wantCode != http.StatusOK {
+			continue
+		}
+
+		var resp struct {
+			Data CounterResponse `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		if resp.Data.Value != tt.wantValue || resp.Data.Applied != tt.wantApplied {
+			t.Errorf("%s %s: expected %d applied=%v, got %+v", tt.key, tt.body, tt.wantValue, tt.wantApplied, resp.Data)
+		}
+	}
+}
+
+func TestHandler_Flush(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	ctx := context.Background()
+	memoryStore.Set(ctx, "name", "alice", 0)
+
+	flush := func(mux http.Handler) int {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/flush", nil))
+		return w.Code
+	}
+
+	if code := flush(NewHandler(memoryStore).SetupRoutes()); code != http.StatusForbidden {
+		t.Errorf("Expected 403 while flush is disabled, got %d", code)
+	}
+	if _, err := memoryStore.Get(ctx, "name"); err != nil {
+		t.Errorf("Expected the key to survive a disabled flush, got %v", err)
+	}
+
+	if code := flush(NewHandler(memoryStore, WithFlush(true)).SetupRoutes()); code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", code)
+	}
+	if _, err := memoryStore.Get(ctx, "name"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected the key to be flushed, got %v", err)
+	}
+}
+
+func TestHandler_SetCompressFalse(t *testing.T) {
+	memoryStore := memory.NewMemoryStoreWithOptions(memory.Options{CompressThreshold: 16})
+	defer memoryStore.StopTTLWorker()
+
+	mux := NewHandler(memoryStore).SetupRoutes()
+	value := strings.Repeat("token", 100)
+	compress := false
+
+	for _, req := range []SetRequest{
+		{Key: "compressed", Value: value},
+		{Key: "plain", Value: value, Compress: &compress},
+	} {
+		payload, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/keys", bytes.NewReader(payload)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+	}
+
+	sizes := map[string]int{}
+	keys, _ := memoryStore.TopKeysBySize(context.Background(), 10)
+	for _, k := range keys {
+		sizes[k.Key] = k.SizeBytes
+	}
+	if sizes["compressed"] >= len(value) {
+		t.Errorf("Expected the value to be stored compressed, got size %d", sizes["compressed"])
+	}
+	if sizes["plain"] < len(value) {
+		t.Errorf("Expected a compress-false value to be stored uncompressed, got size %d", sizes["plain"])
+	}
+
+	for _, key := range []string{"compressed", "plain"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/keys/"+key, nil))
+		var resp Response
+		json.NewDecoder(w.Body).Decode(&resp)
+		if data, _ := resp.Data.(map[string]any); data["value"] != value {
+			t.Errorf("Expected %s to round-trip, got %v", key, resp.Data)
+		}
+	}
+}
+
+func TestHandler_BLPopShutdown(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	handler := NewHandler(memoryStore)
+	started := make(chan struct{})
+	handler.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			next.ServeHTTP(w, r)
+		})
+	})
+	server := startClosableServer(t, handler)
+	defer server.Close()
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Post(server.URL+"/api/v1/lists/blpop", "application/json",
+			strings.NewReader(`{"key":"jobs","timeout_seconds":60}`))
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	<-started
+
+	// The waiting request is answered as timed out instead of holding up the shutdown
+	shutdownServer(t, server)
+	if code := <-status; code != http.StatusRequestTimeout {
+		t.Errorf("Expected status 408 for a BLPOP waiting on shutdown, got %d", code)
+	}
+}
+
+func TestHandler_RequestCancelled(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+	mux := NewHandler(memoryStore).SetupRoutes()
+
+	// A request whose client has gone away does not reach the store
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest("POST", "/api/v1/keys", strings.NewReader(`{"key":"gone","value":"v"}`)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code == http.StatusOK {
+		t.Errorf("Expected the cancelled request to fail, got %d", w.Code)
+	}
+	if exists, _ := memoryStore.Exists(context.Background(), "gone"); exists {
+		t.Error("Expected the cancelled request not to store the key")
+	}
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// hashOperation routes requests for a whole hash and for its fields. The field is
+// taken after the last "/fields/" of the path, so keys may contain slashes.
+func (h *Handler) hashOperation(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path[len("/api/v1/hashes/"):]
+	if !h.allowedKeys(w, path) {
+		return
+	}
+
+	i := strings.LastIndex(path, "/fields/")
+	if i < 0 {
+		if path == "" {
+			h.writeError(w, http.StatusBadRequest, "Key is required")
+			return
+		}
+		noteKey(r, path)
+		h.HGetAllHandler(w, r, path)
+		return
+	}
+
+	key, field := path[:i], path[i+len("/fields/"):]
+	if key == "" || field == "" {
+		h.writeError(w, http.StatusBadRequest, "Key and field are required")
+		return
+	}
+	noteKey(r, key)
+
+	switch r.Method {
+	case http.MethodGet:
+		h.HGetHandler(w, r, key, field)
+	case http.MethodPut:
+		h.HSetHandler(w, r, key, field)
+	case http.MethodDelete:
+		h.HDelHandler(w, r, key, field)
+	default:
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// HSetHandler sets a field of a hash, creating the hash if needed
+// PUT /api/v1/hashes/{key}/fields/{field}
+func (h *Handler) HSetHandler(w http.ResponseWriter, r *http.Request, key, field string) {
+	var req HSetRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	fence, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.store.HSet(ctx, key, field, req.Value, fence...)
+	if err != nil {
+		if errors.Is(err, store.ErrKeyPendingDelete) {
+			h.writeError(w, http.StatusConflict, "Key is pending soft delete, restore it before setting fields")
+			return
+		}
+		h.writeHashError(w, err, "set field")
+		return
+	}
+
+	h.writeSuccess(w, HSetResponse{Key: key, Field: field, Created: created})
+}
+
+// HGetHandler returns the value of a field of a hash
+// GET /api/v1/hashes/{key}/fields/{field}
+func (h *Handler) HGetHandler(w http.ResponseWriter, r *http.Request, key, field string) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	value, err := h.store.HGet(ctx, key, field)
+	if err != nil {
+		h.writeHashError(w, err, "get field")
+		return
+	}
+
+	h.writeSuccess(w, HGetResponse{Key: key, Field: field, Value: value})
+}
+
+// HDelHandler removes a field from a hash
+// DELETE /api/v1/hashes/{key}/fields/{field}
+func (h *Handler) HDelHandler(w http.ResponseWriter, r *http.Request, key, field string) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	fence, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.store.HDel(ctx, key, field, fence...)
+	if err != nil {
+		h.writeHashError(w, err, "delete field")
+		return
+	}
+
+	h.writeSuccess(w, HDelResponse{Key: key, Field: field, Deleted: deleted})
+}
+
+// HGetAllHandler returns every field of a hash with its value
+// GET /api/v1/hashes/{key}
+func (h *Handler) HGetAllHandler(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	fields, err := h.store.HGetAll(ctx, key)
+	if err != nil {
+		h.writeHashError(w, err, "get hash")
+		return
+	}
+
+	h.writeSuccess(w, HGetAllResponse{Key: key, Fields: fields})
+}
+
+// writeHashError writes the response for a failed hash operation.
+func (h *Handler) writeHashError(w http.ResponseWriter, err error, action string) {
+	if h.writeRejected(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, store.ErrKeyNotFound):
+		h.writeError(w, http.StatusNotFound, "Key not found")
+	case errors.Is(err, store.ErrFieldNotFound):
+		h.writeError(w, http.StatusNotFound, "Field not found")
+	case errors.Is(err, store.ErrTypeMismatch):
+		h.writeError(w, http.StatusConflict, "Key does not hold a hash")
+	default:
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to %s: %v", action, err))
+	}
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// CodeRateLimited is the error code of 429 responses.
+const CodeRateLimited = "RATE_LIMITED"
+
+// defaultBulkheadRetryAfter is the retry delay suggested to clients rejected by the bulkhead.
+const defaultBulkheadRetryAfter = 100 * time.Millisecond
+
+// BulkheadMiddleware returns a Middleware applying Bulkhead with the given limits.
+func (h *Handler) BulkheadMiddleware(maxConcurrent int, retryAfter time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return h.Bulkhead(next, maxConcurrent, retryAfter)
+	}
+}
+
+// Bulkhead wraps next so that at most maxConcurrent requests are served at once.
+// Requests beyond the limit are rejected immediately with a 429 response asking the
+// client to retry after retryAfter, instead of queueing up behind slow requests.
+// A maxConcurrent of 0 or less disables the limit. A retryAfter of 0 defaults to 100ms.
+func (h *Handler) Bulkhead(next http.Handler, maxConcurrent int, retryAfter time.Duration) http.Handler {
+	if maxConcurrent <= 0 {
+		return next
+	}
+	if retryAfter <= 0 {
+		retryAfter = defaultBulkheadRetryAfter
+	}
+
+	slots := make(chan struct{}, maxConcurrent)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+			next.ServeHTTP(w, r)
+		default:
+			h.writeRateLimited(w, retryAfter)
+		}
+	})
+}
+
+// PerIPLimitMiddleware returns a Middleware applying PerIPLimit with the given limits.
+func (h *Handler) PerIPLimitMiddleware(maxPerIP int, retryAfter time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return h.PerIPLimit(next, maxPerIP, retryAfter)
+	}
+}
+
+// PerIPLimit wraps next so that each client IP has at most maxPerIP requests served at
+// once, keeping one client from exhausting the server while others are unaffected.
+// Requests beyond the limit are rejected like by Bulkhead. Clients are told apart by
+// the remote address of the connection, so behind a proxy all clients share the
+// proxy's limit. A maxPerIP of 0 or less disables the limit. A retryAfter of 0
+// defaults to 100ms.
+func (h *Handler) PerIPLimit(next http.Handler, maxPerIP int, retryAfter time.Duration) http.Handler {
+	if maxPerIP <= 0 {
+		return next
+	}
+	if retryAfter <= 0 {
+		retryAfter = defaultBulkheadRetryAfter
+	}
+
+	limiter := &ipLimiter{max: maxPerIP, inflight: make(map[string]int)}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := clientIP(r)
+		if !limiter.acquire(ip) {
+			h.writeRateLimited(w, retryAfter)
+			return
+		}
+		defer limiter.release(ip)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ipLimiter counts the requests in flight per client IP. IPs are forgotten as soon as
+// their last request finishes, so idle clients take no memory.
+type ipLimiter struct {
+	mu       sync.Mutex
+	max      int
+	inflight map[string]int
+}
+
+func (l *ipLimiter) acquire(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inflight[ip] >= l.max {
+		return false
+	}
+	l.inflight[ip]++
+	return true
+}
+
+func (l *ipLimiter) release(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inflight[ip]--; l.inflight[ip] <= 0 {
+		delete(l.inflight, ip)
+	}
+}
+
+// rateLimiterSweepInterval is how often RateLimit forgets the clients it no longer
+// needs to remember.
+const rateLimiterSweepInterval = time.Minute
+
+// RateLimitMiddleware returns a Middleware applying RateLimit with the given limits.
+func (h *Handler) RateLimitMiddleware(rps int, burst int) Middleware {
+	return func(next http.Handler) http.Handler {
+		return h.RateLimit(next, rps, burst)
+	}
+}
+
+// RateLimit wraps next so that each client IP is served at most rps requests per
+// second on average, with bursts of up to burst requests, using a token bucket per
+// client. Requests beyond the limit are rejected with a 429 response whose Retry-After
+// is the time until the client's next request would be served. Unlike PerIPLimit, it
+// bounds how often a client sends requests rather than how many it has in flight.
+// Clients are told apart like by PerIPLimit. An rps of 0 or less disables the limit; a
+// burst of 0 or less defaults to rps.
+func (h *Handler) RateLimit(next http.Handler, rps int, burst int) http.Handler {
+	if rps <= 0 {
+		return next
+	}
+	if burst <= 0 {
+		burst = rps
+	}
+
+	limiters := &rateLimiters{
+		limit:     rate.Limit(rps),
+		burst:     burst,
+		clients:   make(map[string]*rate.Limiter),
+		lastSweep: time.Now(),
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if retryAfter, ok := limiters.allow(clientIP(r), time.Now()); !ok {
+			h.writeRateLimited(w, retryAfter)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimiters holds a token bucket per client IP. Buckets that have refilled are
+// swept away periodically, as a new bucket would behave the same, so clients that
+// stopped sending requests take no memory.
+type rateLimiters struct {
+	mu        sync.Mutex
+	limit     rate.Limit
+	burst     int
+	clients   map[string]*rate.Limiter
+	lastSweep time.Time
+}
+
+// allow takes a token from the bucket of ip, or returns false with the time until the
+// bucket has one again.
+func (l *rateLimiters) allow(ip string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= rateLimiterSweepInterval {
+		l.sweep(now)
+	}
+
+	limiter, ok := l.clients[ip]
+	if !ok {
+		limiter = rate.NewLimiter(l.limit, l.burst)
+		l.clients[ip] = limiter
+	}
+
+	reservation := limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return delay, false
+	}
+	return 0, true
+}
+
+// sweep forgets the clients whose buckets are full. The caller must hold l.mu.
+func (l *rateLimiters) sweep(now time.Time) {
+	for ip, limiter := range l.clients {
+		if limiter.TokensAt(now) >= float64(l.burst) {
+			delete(l.clients, ip)
+		}
+	}
+	l.lastSweep = now
+}
+
+// clientIP returns the IP of the client that sent r, without the port.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
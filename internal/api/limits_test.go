@@ -0,0 +1,224 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+	"golang.org/x/time/rate"
+)
+
+func TestHandler_Bulkhead(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+	handler := NewHandler(memoryStore)
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		handler.writeSuccess(w, nil)
+	})
+	limited := handler.Bulkhead(slow, 1, 250*time.Millisecond)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		limited.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+	<-entered
+
+	w := httptest.NewRecorder()
+	limited.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	close(release)
+	wg.Wait()
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d", w.Code)
+	}
+
+	if retryAfter := w.Header().Get("Retry-After"); retryAfter != "1" {
+		t.Errorf("Expected Retry-After header 1, got %q", retryAfter)
+	}
+
+	var body map[string]any
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if body["success"] != false || body["code"] != "RATE_LIMITED" || body["retry_after_ms"] != float64(250) {
+		t.Errorf("Unexpected 429 body: %v", body)
+	}
+}
+
+func TestHandler_BulkheadDisabled(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+	handler := NewHandler(memoryStore)
+
+	routes := handler.SetupRoutes()
+	if limited := handler.Bulkhead(routes, 0, 0); limited != http.Handler(routes) {
+		t.Error("Expected a limit of 0 to leave the handler unwrapped")
+	}
+}
+
+func TestHandler_PerIPLimit(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+	handler := NewHandler(memoryStore)
+
+	var entered sync.WaitGroup
+	entered.Add(3)
+	release := make(chan struct{})
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered.Done()
+		<-release
+		handler.writeSuccess(w, nil)
+	})
+	limited := handler.PerIPLimit(slow, 3, 0)
+
+	request := func(addr string) *http.Request {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = addr
+		return r
+	}
+
+	// Fill the limit of one IP, from different source ports
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(port int) {
+			defer wg.Done()
+			limited.ServeHTTP(httptest.NewRecorder(), request(fmt.Sprintf("10.0.0.1:%d", 40000+port)))
+		}(i)
+	}
+	entered.Wait()
+
+	rejected := 0
+	for i := 0; i < 10; i++ {
+		w := httptest.NewRecorder()
+		limited.ServeHTTP(w, request("10.0.0.1:50000"))
+		if w.Code == http.StatusTooManyRequests {
+			rejected++
+		}
+	}
+	if rejected != 10 {
+		t.Errorf("Expected every request over the limit to get 429, got %d of 10", rejected)
+	}
+
+	// Another IP is unaffected
+	entered.Add(1)
+	other := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		limited.ServeHTTP(w, request("10.0.0.2:40000"))
+		other <- w.Code
+	}()
+	entered.Wait()
+
+	close(release)
+	wg.Wait()
+	if code := <-other; code != http.StatusOK {
+		t.Errorf("Expected another IP to be served, got %d", code)
+	}
+
+	// The limit frees up once requests finish
+	entered.Add(1)
+	w := httptest.NewRecorder()
+	limited.ServeHTTP(w, request("10.0.0.1:50000"))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the IP to be served again after its requests finished, got %d", w.Code)
+	}
+}
+
+func TestIPLimiter_ForgetsIdleIPs(t *testing.T) {
+	limiter := &ipLimiter{max: 2, inflight: make(map[string]int)}
+
+	for _, ip := range []string{"10.0.0.1", "10.0.0.1", "10.0.0.2"} {
+		if !limiter.acquire(ip) {
+			t.Fatalf("Expected %s to be admitted", ip)
+		}
+	}
+	if limiter.acquire("10.0.0.1") {
+		t.Error("Expected 10.0.0.1 to be over its limit")
+	}
+
+	limiter.release("10.0.0.1")
+	limiter.release("10.0.0.1")
+	limiter.release("10.0.0.2")
+	if len(limiter.inflight) != 0 {
+		t.Errorf("Expected idle IPs to be forgotten, still tracking %v", limiter.inflight)
+	}
+}
+
+func TestHandler_RateLimit(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+	handler := NewHandler(memoryStore)
+	handler.Use(handler.RateLimitMiddleware(1, 3))
+	routes := handler.SetupRoutes()
+
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/time", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		routes.ServeHTTP(w, req)
+		return w
+	}
+
+	// The burst is served, the requests past it are rejected
+	for i := 0; i < 3; i++ {
+		if w := request("10.0.0.1:1234"); w.Code != http.StatusOK {
+			t.Fatalf("Expected request %d of the burst to be served, got %d", i+1, w.Code)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		w := request("10.0.0.1:5678")
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected a 429 past the burst, got %d", w.Code)
+		}
+		if got := w.Header().Get("Retry-After"); got != "1" {
+			t.Errorf("Expected Retry-After 1, got %q", got)
+		}
+		var body map[string]any
+		json.NewDecoder(w.Body).Decode(&body)
+		if body["code"] != CodeRateLimited || body["retry_after_ms"].(float64) <= 0 || body["retry_after_ms"].(float64) > 1000 {
+			t.Errorf("Unexpected 429 body: %v", body)
+		}
+	}
+
+	// Other clients have their own bucket
+	if w := request("10.0.0.2:1234"); w.Code != http.StatusOK {
+		t.Errorf("Expected another client to be served, got %d", w.Code)
+	}
+}
+
+func TestRateLimiters_Refill(t *testing.T) {
+	start := time.Now()
+	limiters := &rateLimiters{limit: 10, burst: 2, clients: map[string]*rate.Limiter{}, lastSweep: start}
+
+	limiters.allow("10.0.0.1", start)
+	limiters.allow("10.0.0.1", start)
+	if retryAfter, ok := limiters.allow("10.0.0.1", start); ok || retryAfter != 100*time.Millisecond {
+		t.Errorf("Expected to wait 100ms for the next token, got %v %v", retryAfter, ok)
+	}
+	// Rejected requests do not take tokens
+	if _, ok := limiters.allow("10.0.0.1", start.Add(100*time.Millisecond)); !ok {
+		t.Error("Expected a request to be served once a token is back")
+	}
+
+	// Refilled buckets are forgotten by the next sweep, others are kept
+	limiters.allow("10.0.0.2", start.Add(rateLimiterSweepInterval-time.Millisecond))
+	limiters.allow("10.0.0.3", start.Add(rateLimiterSweepInterval))
+	if _, ok := limiters.clients["10.0.0.1"]; ok || len(limiters.clients) != 2 {
+		t.Errorf("Expected only the idle client to be forgotten, got %v", limiters.clients)
+	}
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// maxListBatchOps bounds the number of operations in a single list batch.
+const maxListBatchOps = 1000
+
+// Error codes of failed list batch operations.
+const (
+	CodeKeyRequired   = "KEY_REQUIRED"
+	CodeInvalidOp     = "INVALID_OP"
+	CodeKeyNotFound   = "KEY_NOT_FOUND"
+	CodeTypeMismatch  = "TYPE_MISMATCH"
+	CodeEmptyList     = "EMPTY_LIST"
+	CodePendingDelete = "PENDING_DELETE"
+	CodeStaleFence    = "STALE_FENCE"
+	CodeUnknownFence  = "UNKNOWN_FENCE"
+	CodeOutOfMemory   = "OUT_OF_MEMORY"
+	CodeTooManyKeys   = "TOO_MANY_KEYS"
+	CodeValueTooLarge = "VALUE_TOO_LARGE"
+	CodeInvalidValue  = "INVALID_VALUE"
+	CodeInternal      = "INTERNAL"
+)
+
+// ListBatchHandler runs push and pop operations on many lists in one request
+// POST /api/v1/lists/batch
+func (h *Handler) ListBatchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req ListBatchRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if len(req) == 0 {
+		h.writeError(w, http.StatusBadRequest, "Operations are required")
+		return
+	}
+	if len(req) > maxListBatchOps {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("At most %d operations are allowed per batch", maxListBatchOps))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	fence, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+
+	// Invalid operations get their result here, the rest are sent to the store together.
+	results := make([]ListBatchResult, len(req))
+	var ops []store.ListOp
+	var indexes []int
+	for i, op := range req {
+		results[i] = ListBatchResult{Key: op.Key, Op: op.Op}
+		switch {
+		case op.Key == "":
+			results[i].Code, results[i].Error = CodeKeyRequired, "Key is required"
+		case op.Op != store.ListOpPush && op.Op != store.ListOpPop:
+			results[i].Code, results[i].Error = CodeInvalidOp, "Op must be push or pop"
+		default:
+			ops = append(ops, op)
+			indexes = append(indexes, i)
+		}
+	}
+
+	if len(ops) > 0 {
+		storeResults, err := h.store.ListBatch(ctx, ops, fence...)
+		if err != nil {
+			h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to run list batch: %v", err))
+			return
+		}
+
+		for j, res := range storeResults {
+			result := &results[indexes[j]]
+			if res.Err != nil {
+				result.Code, result.Error = listOpError(res.Err)
+				continue
+			}
+			result.Success = true
+			if result.Op == store.ListOpPop {
+				item := res.Item
+				result.Item = &item
+			}
+		}
+	}
+
+	h.writeSuccess(w, ListBatchResponse{Results: results})
+}
+
+// listOpError returns the code and message reported for a failed list batch operation.
+func listOpError(err error) (string, string) {
+	switch {
+	case errors.Is(err, store.ErrKeyNotFound):
+		return CodeKeyNotFound, "Key not found"
+	case errors.Is(err, store.ErrTypeMismatch):
+		return CodeTypeMismatch, "Key does not hold a list"
+	case errors.Is(err, store.ErrEmptyList):
+		return CodeEmptyList, "List is empty"
+	case errors.Is(err, store.ErrKeyPendingDelete):
+		return CodePendingDelete, "Key is pending soft delete"
+	case errors.Is(err, store.ErrStaleFence):
+		return CodeStaleFence, "Stale fence token"
+	case errors.Is(err, store.ErrUnknownFence):
+		return CodeUnknownFence, "Fence token was never issued"
+	case errors.Is(err, store.ErrOutOfMemory):
+		return CodeOutOfMemory, "Store is out of memory"
+	case errors.Is(err, store.ErrTooManyKeys):
+		return CodeTooManyKeys, "Store key limit reached"
+	case errors.Is(err, store.ErrValueTooLarge):
+		return CodeValueTooLarge, "Item exceeds the maximum size"
+	case errors.Is(err, store.ErrMarshalFailed):
+		return CodeInvalidValue, "Item cannot be stored"
+	case errors.Is(err, store.ErrInvalidListOp):
+		return CodeInvalidOp, "Op must be push or pop"
+	}
+	return CodeInternal, err.Error()
+}
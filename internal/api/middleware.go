@@ -0,0 +1,24 @@
+package api
+
+import "net/http"
+
+// Middleware wraps a handler with behavior that runs around every request, such as
+// limits, logging or authentication.
+type Middleware func(http.Handler) http.Handler
+
+// Chain composes middlewares into one. The first middleware is the outermost: it
+// sees the request first and the response last.
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+// Use adds middlewares that SetupRoutes applies once around all routes, in the order
+// they are added, the first being the outermost.
+func (h *Handler) Use(middlewares ...Middleware) {
+	h.middlewares = append(h.middlewares, middlewares...)
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+// recordingMiddleware appends name to calls before and after serving the request.
+func recordingMiddleware(name string, calls *[]string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*calls = append(*calls, name+":before")
+			next.ServeHTTP(w, r)
+			*calls = append(*calls, name+":after")
+		})
+	}
+}
+
+func TestChain_Order(t *testing.T) {
+	var calls []string
+	handler := Chain(
+		recordingMiddleware("outer", &calls),
+		recordingMiddleware("inner", &calls),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	want := "outer:before,inner:before,handler,inner:after,outer:after"
+	if got := strings.Join(calls, ","); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	calls = nil
+	Chain()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if got := strings.Join(calls, ","); got != "handler" {
+		t.Errorf("Expected an empty chain to call the handler only, got %s", got)
+	}
+}
+
+func TestHandler_UseRunsOncePerRequest(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	var calls []string
+	handler := NewHandler(memoryStore)
+	handler.Use(recordingMiddleware("first", &calls), recordingMiddleware("second", &calls))
+	handler.Use(recordingMiddleware("third", &calls))
+	mux := handler.SetupRoutes()
+
+	// Both a fixed route and a key route dispatched through a sub-handler.
+	for _, path := range []string{"/api/v1/stats", "/api/v1/keys/missing"} {
+		calls = nil
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+
+		want := "first:before,second:before,third:before,third:after,second:after,first:after"
+		if got := strings.Join(calls, ","); got != want {
+			t.Errorf("%s: expected %s, got %s", path, want, got)
+		}
+	}
+}
+
+func TestHandler_VersionHeaders(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	mux := NewHandler(memoryStore, WithDeprecations([]Deprecation{
+		{Method: "POST", Path: "/api/v1/keys/get", Since: since, Sunset: sunset},
+		{Path: "/api/v1/lists/", Since: since},
+	})).SetupRoutes()
+
+	tests := []struct {
+		method, path       string
+		deprecated, sunset string
+	}{
+		{"GET", "/api/v1/stats", "", ""},
+		{"GET", "/api/v1/keys/get", "", ""},
+		{"POST", "/api/v1/keys/get", "@1704067200", "Mon, 01 Jul 2024 00:00:00 GMT"},
+		{"GET", "/api/v1/lists/queue/len", "@1704067200", ""},
+		{"GET", "/no/such/route", "", ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader("{}")))
+
+		if got := w.Header().Get("X-API-Version"); got != APIVersion {
+			t.Errorf("%s %s: expected X-API-Version %s, got %q", tt.method, tt.path, APIVersion, got)
+		}
+		if got := w.Header().Get("Deprecation"); got != tt.deprecated {
+			t.Errorf("%s %s: expected Deprecation %q, got %q", tt.method, tt.path, tt.deprecated, got)
+		}
+		if got := w.Header().Get("Sunset"); got != tt.sunset {
+			t.Errorf("%s %s: expected Sunset %q, got %q", tt.method, tt.path, tt.sunset, got)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// maxPipelineReads bounds the number of reads in a single read pipeline.
+const maxPipelineReads = 1000
+
+// CodeIndexOutOfRange is the error code of pipeline reads past the end of a list.
+const CodeIndexOutOfRange = "INDEX_OUT_OF_RANGE"
+
+// PipelineGetHandler runs string reads and list index reads on many keys in one
+// request, against a consistent view of the store
+// POST /api/v1/read/pipeline
+func (h *Handler) PipelineGetHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req PipelineGetRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if len(req) == 0 {
+		h.writeError(w, http.StatusBadRequest, "Reads are required")
+		return
+	}
+	if len(req) > maxPipelineReads {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("At most %d reads are allowed per pipeline", maxPipelineReads))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	// Reads without a key get their result here, the rest are sent to the store together.
+	results := make([]PipelineReadResult, len(req))
+	var specs []store.ReadSpec
+	var indexes []int
+	for i, spec := range req {
+		results[i] = PipelineReadResult{Key: spec.Key, Index: spec.Index}
+		if spec.Key == "" {
+			results[i].Code, results[i].Error = CodeKeyRequired, "Key is required"
+			continue
+		}
+		specs = append(specs, spec)
+		indexes = append(indexes, i)
+	}
+
+	if len(specs) > 0 {
+		storeResults, err := h.store.PipelineGet(ctx, specs)
+		if err != nil {
+			h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to run read pipeline: %v", err))
+			return
+		}
+
+		for j, res := range storeResults {
+			result := &results[indexes[j]]
+			if res.Err != nil {
+				result.Code, result.Error = readError(res.Err, result.Index != nil)
+				continue
+			}
+			value := res.Value
+			result.Success, result.Value = true, &value
+		}
+	}
+
+	h.writeSuccess(w, PipelineGetResponse{Results: results})
+}
+
+// readError returns the code and message reported for a failed pipeline read, listRead
+// telling a list index read from a string read.
+func readError(err error, listRead bool) (string, string) {
+	switch {
+	case errors.Is(err, store.ErrKeyNotFound):
+		return CodeKeyNotFound, "Key not found"
+	case errors.Is(err, store.ErrTypeMismatch) && listRead:
+		return CodeTypeMismatch, "Key does not hold a list"
+	case errors.Is(err, store.ErrTypeMismatch):
+		return CodeTypeMismatch, "Key does not hold a string"
+	case errors.Is(err, store.ErrIndexOutOfRange):
+		return CodeIndexOutOfRange, "Index out of range"
+	}
+	return CodeInternal, err.Error()
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// SubscribeHandler streams the messages published on a channel as Server-Sent Events,
+// until the client disconnects or the handler is closed. Only messages published after subscribing are sent.
+// GET /api/v1/subscribe?channel={channel}
+func (h *Handler) SubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	channel := r.URL.Query().Get("channel")
+	if channel == "" {
+		h.writeError(w, http.StatusBadRequest, "Channel is required")
+		return
+	}
+
+	messages, unsubscribe := h.store.Subscribe(channel)
+	defer unsubscribe()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ctx := r.Context()
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			data, _ := json.Marshal(msg)
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		case <-h.closed:
+			return
+		}
+	}
+}
+
+// PublishHandler publishes a message on a channel to its current subscribers
+// POST /api/v1/publish
+func (h *Handler) PublishHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req PublishRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.Channel == "" {
+		h.writeError(w, http.StatusBadRequest, "Channel is required")
+		return
+	}
+
+	receivers, err := h.store.Publish(req.Channel, req.Payload)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to publish message: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, PublishResponse{Channel: req.Channel, Receivers: receivers})
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+func TestPubSubHandlers(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+	server := httptest.NewServer(NewHandler(memoryStore).SetupRoutes())
+	defer server.Close()
+
+	// The subscription is in place once the stream is open
+	read, disconnect := openStream(t, server.URL+"/api/v1/subscribe?channel=orders")
+
+	resp, err := http.Post(server.URL+"/api/v1/publish", "application/json",
+		bytes.NewBufferString(`{"channel":"orders","payload":{"id":42}}`))
+	if err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	var published struct {
+		Data PublishResponse `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&published)
+	resp.Body.Close()
+	if published.Data.Receivers != 1 {
+		t.Errorf("Expected 1 receiver, got %+v", published.Data)
+	}
+
+	e := read(1)[0]
+	var msg store.Message
+	if err := json.Unmarshal([]byte(e.data), &msg); err != nil || e.name != "message" || msg.Channel != "orders" || string(msg.Payload) != `{"id":42}` {
+		t.Errorf("Expected the published message, got %+v (err %v)", e, err)
+	}
+
+	// Disconnecting unsubscribes
+	disconnect()
+	deadline := time.Now().Add(2 * time.Second)
+	for n, _ := memoryStore.Publish("orders", "ping"); n != 0; n, _ = memoryStore.Publish("orders", "ping") {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the subscription to end with the connection")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	for _, body := range []string{`{"payload":1}`, `{"channel":""}`} {
+		resp, _ := http.Post(server.URL+"/api/v1/publish", "application/json", bytes.NewBufferString(body))
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected a 400 for %s, got %d", body, resp.StatusCode)
+		}
+	}
+	resp, _ = http.Get(server.URL + "/api/v1/subscribe")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected a 400 without a channel, got %d", resp.StatusCode)
+	}
+}
+
+func TestSubscribeHandler_Shutdown(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+	server := startClosableServer(t, NewHandler(memoryStore))
+	defer server.Close()
+
+	_, disconnect := openStream(t, server.URL+"/api/v1/subscribe?channel=orders")
+	defer disconnect()
+
+	shutdownServer(t, server)
+	if n, _ := memoryStore.Publish("orders", "ping"); n != 0 {
+		t.Errorf("Expected shutdown to end the subscription, got %d receivers", n)
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// RequestIDHeader carries the ID of a request, so client and server logs can be
+// correlated. The server echoes it back in every response.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the length of request IDs accepted from clients.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestID returns the ID of the request being served with ctx, or "" outside of
+// a request.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithAccessLog writes a structured JSON line per request to w once it is served,
+// giving its request ID, method, path, client IP, status and duration, e.g.
+//
+//	{"time":"2024-01-15T10:30:00.123Z","level":"INFO","msg":"request","request_id":"3f2b...","method":"GET","path":"/api/v1/keys/user:123","remote_ip":"10.0.0.7","status":200,"duration_ms":0.42}
+//
+// Unlike the command log, requests rejected by middlewares are logged too.
+func WithAccessLog(w io.Writer) HandlerOption {
+	return func(h *Handler) {
+		if w != nil {
+			h.accessLog = slog.New(slog.NewJSONHandler(w, nil))
+		}
+	}
+}
+
+// requestIDs wraps next to give every request an ID, taken from its X-Request-ID
+// header or generated, and to write the access log line, if enabled.
+func (h *Handler) requestIDs(next http.Handler) http.Handler {
+	return &requestIDHandler{next: next, accessLog: h.accessLog}
+}
+
+type requestIDHandler struct {
+	next      http.Handler
+	accessLog *slog.Logger
+}
+
+func (h *requestIDHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := r.Header.Get(RequestIDHeader)
+	if !validRequestID(id) {
+		id = newRequestID()
+	}
+	w.Header().Set(RequestIDHeader, id)
+	r = r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+
+	if h.accessLog == nil {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	h.next.ServeHTTP(rec, r)
+
+	h.accessLog.Info("request",
+		slog.String("request_id", id),
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.String("remote_ip", clientIP(r)),
+		slog.Int("status", rec.status),
+		slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+	)
+}
+
+// validRequestID reports whether id, taken from a request header, can be used as is:
+// it is not empty, not too long and only made of printable ASCII characters, so it
+// cannot forge log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random version 4 UUID.
+func newRequestID() string {
+	var b [16]byte
+	// crypto/rand.Read does not fail on supported platforms.
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+func TestHandler_RequestID(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	h := NewHandler(memoryStore)
+	var seen string
+	h.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = RequestID(r.Context())
+			next.ServeHTTP(w, r)
+		})
+	})
+	mux := h.SetupRoutes()
+
+	// A valid ID is kept and echoed back
+	req := httptest.NewRequest("GET", "/api/v1/keys/missing", nil)
+	req.Header.Set(RequestIDHeader, "client-42")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if got := rec.Header().Get(RequestIDHeader); got != "client-42" || seen != "client-42" {
+		t.Errorf("Expected client-42 echoed and in the context, got %q and %q", got, seen)
+	}
+	var resp Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Error != "Key not found" {
+		t.Errorf("Expected the error body unchanged, got %s", rec.Body.String())
+	}
+
+	// Missing and invalid IDs are replaced with a UUID
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	for _, id := range []string{"", "bad id\nwith a line break", strings.Repeat("x", maxRequestIDLength+1)} {
+		req := httptest.NewRequest("GET", "/api/v1/time", nil)
+		req.Header.Set(RequestIDHeader, id)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if got := rec.Header().Get(RequestIDHeader); !uuid.MatchString(got) || seen != got {
+			t.Errorf("Expected a generated UUID for %q, got %q (context %q)", id, got, seen)
+		}
+	}
+}
+
+func TestHandler_AccessLog(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	var log bytes.Buffer
+	h := NewHandler(memoryStore, WithAccessLog(&log))
+	// Requests rejected by middlewares are logged too
+	h.Use(h.AdminAuthMiddleware("secret"))
+	mux := h.SetupRoutes()
+
+	req := httptest.NewRequest("GET", "/api/v1/admin/health", nil)
+	req.Header.Set(RequestIDHeader, "abc-123")
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	var line struct {
+		Msg        string  `json:"msg"`
+		RequestID  string  `json:"request_id"`
+		Method     string  `json:"method"`
+		Path       string  `json:"path"`
+		RemoteIP   string  `json:"remote_ip"`
+		Status     int     `json:"status"`
+		DurationMS float64 `json:"duration_ms"`
+	}
+	if err := json.Unmarshal(log.Bytes(), &line); err != nil {
+		t.Fatalf("Expected a JSON line, got %q: %v", log.String(), err)
+	}
+	if line.Msg != "request" || line.RequestID != "abc-123" || line.Method != "GET" || line.Path != "/api/v1/admin/health" ||
+		line.RemoteIP != "192.0.2.1" || line.Status != http.StatusUnauthorized {
+		t.Errorf("Unexpected access log line %q", log.String())
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// defaultVisibilityTimeout is how long a reserved item stays in flight when the
+// request does not set visibility_timeout_ms.
+const defaultVisibilityTimeout = 30 * time.Second
+
+// ReserveHandler takes the item at the head of a list and keeps it in flight until acked
+// POST /api/v1/lists/{key}/reserve?visibility_timeout_ms={ms}
+func (h *Handler) ReserveHandler(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	timeout := defaultVisibilityTimeout
+	if query := r.URL.Query(); query.Has("visibility_timeout_ms") {
+		ms, err := strconv.ParseInt(query.Get("visibility_timeout_ms"), 10, 64)
+		if err != nil || ms <= 0 {
+			h.writeError(w, http.StatusBadRequest, "visibility_timeout_ms must be a positive integer")
+			return
+		}
+		timeout = time.Duration(ms) * time.Millisecond
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	fence, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+
+	item, receipt, err := h.store.Reserve(ctx, key, timeout, fence...)
+	if err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		if errors.Is(err, store.ErrKeyNotFound) {
+			h.writeError(w, http.StatusNotFound, "Key not found")
+			return
+		}
+		if errors.Is(err, store.ErrEmptyList) {
+			h.writeError(w, http.StatusBadRequest, "List is empty")
+			return
+		}
+		if errors.Is(err, store.ErrTypeMismatch) {
+			h.writeError(w, http.StatusConflict, "Key does not hold a list")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to reserve item: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, ReserveResponse{
+		Key:                 key,
+		Item:                item,
+		Receipt:             receipt,
+		VisibilityTimeoutMs: timeout.Milliseconds(),
+	})
+}
+
+// AckHandler deletes a reserved item for good
+// POST /api/v1/lists/ack
+func (h *Handler) AckHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req AckRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.Receipt == "" {
+		h.writeError(w, http.StatusBadRequest, "Receipt is required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.store.Ack(ctx, req.Receipt); err != nil {
+		if errors.Is(err, store.ErrReceiptNotFound) {
+			h.writeError(w, http.StatusNotFound, "Receipt not found or expired")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to ack item: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, map[string]string{"message": "Item acknowledged successfully"})
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// setOperation routes per-set operations addressed by key, the operation being the
+// last path segment.
+func (h *Handler) setOperation(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path[len("/api/v1/sets/"):]
+	if !h.allowedKeys(w, path) {
+		return
+	}
+
+	i := strings.LastIndex(path, "/")
+	if i <= 0 {
+		h.writeError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	key, operation := path[:i], path[i+1:]
+	noteKey(r, key)
+
+	switch operation {
+	case "add":
+		h.SAddHandler(w, r, key)
+	case "remove":
+		h.SRemHandler(w, r, key)
+	case "members":
+		h.SMembersHandler(w, r, key)
+	case "contains":
+		h.SIsMemberHandler(w, r, key)
+	case "card":
+		h.SCardHandler(w, r, key)
+	default:
+		h.writeError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+// SAddHandler adds members to a set, creating the set if needed
+// POST /api/v1/sets/{key}/add
+func (h *Handler) SAddHandler(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req SetMembersRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if len(req.Members) == 0 {
+		h.writeError(w, http.StatusBadRequest, "Members are required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	fence, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+
+	added, err := h.store.SAdd(ctx, key, req.Members, fence...)
+	if err != nil {
+		if errors.Is(err, store.ErrKeyPendingDelete) {
+			h.writeError(w, http.StatusConflict, "Key is pending soft delete, restore it before adding members")
+			return
+		}
+		h.writeSetError(w, err, "add members")
+		return
+	}
+
+	h.writeSuccess(w, SAddResponse{Key: key, Added: added})
+}
+
+// SRemHandler removes members from a set
+// POST /api/v1/sets/{key}/remove
+func (h *Handler) SRemHandler(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req SetMembersRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if len(req.Members) == 0 {
+		h.writeError(w, http.StatusBadRequest, "Members are required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	fence, ok := h.fence(w, r)
+	if !ok {
+		return
+	}
+
+	removed, err := h.store.SRem(ctx, key, req.Members, fence...)
+	if err != nil {
+		h.writeSetError(w, err, "remove members")
+		return
+	}
+
+	h.writeSuccess(w, SRemResponse{Key: key, Removed: removed})
+}
+
+// SMembersHandler returns the members of a set, sorted
+// GET /api/v1/sets/{key}/members
+func (h *Handler) SMembersHandler(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	members, err := h.store.SMembers(ctx, key)
+	if err != nil {
+		h.writeSetError(w, err, "get members")
+		return
+	}
+
+	h.writeSuccess(w, SMembersResponse{Key: key, Members: members})
+}
+
+// SIsMemberHandler reports whether a member belongs to a set
+// GET /api/v1/sets/{key}/contains?member={member}
+func (h *Handler) SIsMemberHandler(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	if !query.Has("member") {
+		h.writeError(w, http.StatusBadRequest, "member is required")
+		return
+	}
+	member := query.Get("member")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	isMember, err := h.store.SIsMember(ctx, key, member)
+	if err != nil {
+		h.writeSetError(w, err, "check member")
+		return
+	}
+
+	h.writeSuccess(w, SIsMemberResponse{Key: key, Member: member, IsMember: isMember})
+}
+
+// SCardHandler returns the number of members of a set
+// GET /api/v1/sets/{key}/card
+func (h *Handler) SCardHandler(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodGet {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	card, err := h.store.SCard(ctx, key)
+	if err != nil {
+		h.writeSetError(w, err, "count members")
+		return
+	}
+
+	h.writeSuccess(w, SCardResponse{Key: key, Card: card})
+}
+
+// writeSetError writes the response for a failed set operation.
+func (h *Handler) writeSetError(w http.ResponseWriter, err error, action string) {
+	if h.writeRejected(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, store.ErrKeyNotFound):
+		h.writeError(w, http.StatusNotFound, "Key not found")
+	case errors.Is(err, store.ErrTypeMismatch):
+		h.writeError(w, http.StatusConflict, "Key does not hold a set")
+	default:
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to %s: %v", action, err))
+	}
+}
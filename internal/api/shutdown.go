@@ -0,0 +1,31 @@
+package api
+
+import (
+	"context"
+	"errors"
+)
+
+// Close ends the event streams and other long-lived requests in progress, and makes
+// new ones end right away. http.Server.Shutdown waits for active requests without
+// cancelling them, so register Close with http.Server.RegisterOnShutdown to let the
+// server drain. Close may be called more than once.
+func (h *Handler) Close() {
+	h.closeOnce.Do(func() { close(h.closed) })
+}
+
+// errHandlerClosed is the cause of the contexts untilClosed cancels on Close.
+var errHandlerClosed = errors.New("handler closed")
+
+// untilClosed returns a copy of ctx that is also cancelled, with errHandlerClosed as
+// its cause, when the handler is closed.
+func (h *Handler) untilClosed(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	go func() {
+		select {
+		case <-h.closed:
+			cancel(errHandlerClosed)
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() { cancel(context.Canceled) }
+}
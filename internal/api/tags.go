@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// tagOperation routes requests for a single tag.
+func (h *Handler) tagOperation(w http.ResponseWriter, r *http.Request) {
+	tag := r.URL.Path[len("/api/v1/tags/"):]
+	if tag == "" {
+		h.writeError(w, http.StatusBadRequest, "Tag is required")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.KeysByTagHandler(w, r, tag)
+	case http.MethodDelete:
+		h.DeleteByTagHandler(w, r, tag)
+	default:
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// KeysByTagHandler lists the live keys tagged with a tag
+// GET /api/v1/tags/{tag}
+func (h *Handler) KeysByTagHandler(w http.ResponseWriter, r *http.Request, tag string) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	keys, err := h.store.KeysByTag(ctx, tag)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list keys: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, TagKeysResponse{Tag: tag, Keys: keys})
+}
+
+// DeleteByTagHandler deletes every key tagged with a tag
+// DELETE /api/v1/tags/{tag}
+func (h *Handler) DeleteByTagHandler(w http.ResponseWriter, r *http.Request, tag string) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	deleted, err := h.store.DeleteByTag(ctx, tag)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete keys: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, TagDeleteResponse{Tag: tag, Deleted: deleted})
+}
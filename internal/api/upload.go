@@ -0,0 +1,380 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+const (
+	// uploadKeyPrefix is the key prefix under which in-progress uploads are kept. Keys
+	// with the prefix are rejected by the other endpoints, see allowedKeys.
+	uploadKeyPrefix = "__upload:"
+
+	// uploadKeyPattern matches the keys of every upload in progress.
+	uploadKeyPattern = uploadKeyPrefix + "*"
+
+	// defaultUploadTTLSeconds is how long an upload may stay idle before its chunks expire.
+	defaultUploadTTLSeconds = 600
+
+	// maxChunkBytes bounds the size of a single uploaded chunk.
+	maxChunkBytes = 16 << 20
+
+	// maxUploadChunks bounds the number of chunks of an upload.
+	maxUploadChunks = 1024
+
+	// defaultMaxUploadBytes bounds the total size of the chunks of an upload.
+	defaultMaxUploadBytes = 256 << 20
+)
+
+// splitUploadPath splits "{key}/upload/{rest}" into the key and the upload sub-path.
+// It reports false unless rest has the shape of an upload route, so a key that merely
+// contains "/upload/" is still addressed as a key.
+func splitUploadPath(path string) (key, sub string, ok bool) {
+	i := strings.LastIndex(path, "/upload/")
+	if i <= 0 {
+		return "", "", false
+	}
+	key, sub = path[:i], path[i+len("/upload/"):]
+
+	parts := strings.Split(sub, "/")
+	switch {
+	case sub == "init":
+	case len(parts) == 3 && parts[0] != "" && parts[1] == "chunk":
+	case len(parts) == 2 && parts[0] != "" && parts[1] == "complete":
+	default:
+		return "", "", false
+	}
+	return key, sub, true
+}
+
+// allowedKeys writes an error response and returns false if any of keys is reserved.
+// Keys are checked where requests name them: at the start of the path by the routers,
+// in JSON bodies by decodeJSON, see keyedRequest, and in the X-Key header after
+// requestKey. The store holds reserved keys like any other, so the endpoints listing,
+// counting or changing keys in bulk leave them out themselves, see visibleKeys and
+// uploadsMatching.
+func (h *Handler) allowedKeys(w http.ResponseWriter, keys ...string) bool {
+	for _, key := range keys {
+		if reservedKey(key) {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Keys starting with %q are reserved", uploadKeyPrefix))
+			return false
+		}
+	}
+	return true
+}
+
+// reservedKey reports whether key has the prefix of in-progress uploads, which are only
+// reachable through the upload endpoints.
+func reservedKey(key string) bool {
+	return strings.HasPrefix(key, uploadKeyPrefix)
+}
+
+// visibleKeys returns keys without the reserved ones.
+func visibleKeys(keys []string) []string {
+	visible := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !reservedKey(key) {
+			visible = append(visible, key)
+		}
+	}
+	return visible
+}
+
+// uploadsMatching returns the keys of the uploads in progress that match p. There are
+// few of them, so bulk endpoints only fall back to changing keys one at a time, see
+// eachVisibleKey, when an upload would be caught up in the change.
+func (h *Handler) uploadsMatching(ctx context.Context, p store.Pattern) ([]string, error) {
+	keys, err := h.store.Keys(ctx, uploadKeyPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	matching := keys[:0]
+	for _, key := range keys {
+		if p.Match(key) {
+			matching = append(matching, key)
+		}
+	}
+	return matching, nil
+}
+
+// eachVisibleKey calls fn for each of keys that is not reserved and returns the number
+// of calls that succeeded. Keys that were removed in the meantime are skipped.
+func eachVisibleKey(keys []string, fn func(key string) error) (int, error) {
+	count := 0
+	for _, key := range visibleKeys(keys) {
+		err := fn(key)
+		if errors.Is(err, store.ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// uploadOperation dispatches the chunked upload endpoints:
+//
+//	POST /api/v1/keys/{key}/upload/init
+//	PUT  /api/v1/keys/{key}/upload/{id}/chunk/{n}
+//	POST /api/v1/keys/{key}/upload/{id}/complete
+func (h *Handler) uploadOperation(w http.ResponseWriter, r *http.Request, key, sub string) {
+	if sub == "init" {
+		h.UploadInitHandler(w, r, key)
+		return
+	}
+
+	parts := strings.Split(sub, "/")
+	switch {
+	case len(parts) == 3 && parts[1] == "chunk":
+		n, err := strconv.Atoi(parts[2])
+		if err != nil || n < 0 {
+			h.writeError(w, http.StatusBadRequest, "Chunk number must be a non-negative integer")
+			return
+		}
+		if n >= maxUploadChunks {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Chunk number must be less than %d", maxUploadChunks))
+			return
+		}
+		h.UploadChunkHandler(w, r, key, parts[0], n)
+	case len(parts) == 2 && parts[1] == "complete":
+		h.UploadCompleteHandler(w, r, key, parts[0])
+	default:
+		h.writeError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+// UploadInitHandler starts a chunked upload for a key
+// POST /api/v1/keys/{key}/upload/init
+func (h *Handler) UploadInitHandler(w http.ResponseWriter, r *http.Request, key string) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	uploadID, err := newUploadID()
+	if err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create upload: %v", err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	// The upload record binds the upload ID to its target key.
+	if err := h.store.Set(ctx, uploadMetaKey(uploadID), key, h.uploadTTLSeconds); err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create upload: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, map[string]string{"key": key, "upload_id": uploadID})
+}
+
+// UploadChunkHandler stores a single part of a chunked upload
+// PUT /api/v1/keys/{key}/upload/{id}/chunk/{n}
+func (h *Handler) UploadChunkHandler(w http.ResponseWriter, r *http.Request, key, uploadID string, n int) {
+	if r.Method != http.MethodPut {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if !h.checkUpload(ctx, w, key, uploadID) {
+		return
+	}
+
+	chunk, ok := h.readBody(w, r, maxChunkBytes)
+	if !ok {
+		return
+	}
+
+	// The chunks are kept in a single hash, so that refreshing its TTL keeps every chunk
+	// sent so far. A re-sent chunk replaces the one sent before.
+	chunksKey, field := uploadChunksKey(uploadID), strconv.Itoa(n)
+	previous, err := h.store.HGet(ctx, chunksKey, field)
+	if err != nil && !errors.Is(err, store.ErrKeyNotFound) && !errors.Is(err, store.ErrFieldNotFound) {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to read upload: %v", err))
+		return
+	}
+
+	// The running total of the upload is checked and updated in one step, so concurrent
+	// chunks cannot take it past the limit together.
+	delta := int64(len(chunk) - len(previous))
+	_, applied, err := h.store.IncrWithCeiling(ctx, uploadBytesKey(uploadID), delta, h.maxUploadBytes)
+	if err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to store chunk: %v", err))
+		return
+	}
+	if !applied {
+		h.writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Upload exceeds the maximum size of %d bytes", h.maxUploadBytes))
+		return
+	}
+
+	if _, err := h.store.HSet(ctx, chunksKey, field, string(chunk)); err != nil {
+		// The chunk was not stored, so its bytes no longer count against the limit.
+		if _, decErr := h.store.Decrement(ctx, uploadBytesKey(uploadID), delta); decErr != nil {
+			h.errorLog.Printf("failed to release the size of chunk %d of upload %s: %v", n, uploadID, decErr)
+		}
+		if h.writeRejected(w, err) {
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to store chunk: %v", err))
+		return
+	}
+
+	// Every chunk refreshes the upload so only idle uploads expire.
+	for _, k := range []string{chunksKey, uploadBytesKey(uploadID)} {
+		if err := h.store.Expire(ctx, k, h.uploadTTLSeconds); err != nil {
+			h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to store chunk: %v", err))
+			return
+		}
+	}
+	if err := h.store.Set(ctx, uploadMetaKey(uploadID), key, h.uploadTTLSeconds); err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to store chunk: %v", err))
+		return
+	}
+
+	h.writeSuccess(w, map[string]any{"upload_id": uploadID, "chunk": n, "size": len(chunk)})
+}
+
+// UploadCompleteHandler assembles the uploaded chunks and stores them as the key's value
+// POST /api/v1/keys/{key}/upload/{id}/complete
+func (h *Handler) UploadCompleteHandler(w http.ResponseWriter, r *http.Request, key, uploadID string) {
+	if r.Method != http.MethodPost {
+		h.writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req UploadCompleteRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.Chunks <= 0 || req.Chunks > maxUploadChunks {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Chunks must be between 1 and %d", maxUploadChunks))
+		return
+	}
+
+	if req.TTLSeconds < 0 {
+		h.writeError(w, http.StatusBadRequest, "TTL must be >= 0 (0 = no expiration)")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if !h.checkUpload(ctx, w, key, uploadID) {
+		return
+	}
+
+	chunks, err := h.store.HGetAll(ctx, uploadChunksKey(uploadID))
+	if err != nil && !errors.Is(err, store.ErrKeyNotFound) {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to read chunks: %v", err))
+		return
+	}
+
+	var value strings.Builder
+	for n := 0; n < req.Chunks; n++ {
+		chunk, ok := chunks[strconv.Itoa(n)]
+		if !ok {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Upload is incomplete: chunk %d is missing", n))
+			return
+		}
+		value.WriteString(chunk)
+	}
+
+	if err := h.store.Set(ctx, key, value.String(), req.TTLSeconds); err != nil {
+		if h.writeRejected(w, err) {
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to set key: %v", err))
+		return
+	}
+
+	// Chunks would expire on their own, drop them now to free memory early. The value
+	// is already stored, so a failure to drop them is only logged.
+	if err := h.dropUpload(ctx, uploadID); err != nil {
+		h.errorLog.Printf("failed to drop the chunks of upload %s: %v", uploadID, err)
+	}
+
+	h.writeSuccess(w, map[string]any{"key": key, "size": value.Len()})
+}
+
+// dropUpload removes the chunks of an upload and its records. It carries on past
+// failures and returns them joined. Keys that already expired are not an error.
+func (h *Handler) dropUpload(ctx context.Context, uploadID string) error {
+	var errs []error
+	for _, key := range []string{uploadChunksKey(uploadID), uploadBytesKey(uploadID), uploadMetaKey(uploadID)} {
+		if err := h.store.Remove(ctx, key); err != nil && !errors.Is(err, store.ErrKeyNotFound) {
+			errs = append(errs, fmt.Errorf("remove %s: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkUpload verifies the upload exists and belongs to key, writing an error response if not.
+func (h *Handler) checkUpload(ctx context.Context, w http.ResponseWriter, key, uploadID string) bool {
+	owner, err := h.store.Get(ctx, uploadMetaKey(uploadID))
+	if err != nil {
+		if errors.Is(err, store.ErrKeyNotFound) {
+			h.writeError(w, http.StatusNotFound, "Upload not found or expired")
+			return false
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to read upload: %v", err))
+		return false
+	}
+
+	if owner != key {
+		h.writeError(w, http.StatusNotFound, "Upload not found or expired")
+		return false
+	}
+
+	return true
+}
+
+func uploadMetaKey(uploadID string) string {
+	return uploadKeyPrefix + uploadID
+}
+
+// uploadChunksKey is the hash of the chunks sent for an upload, by chunk number.
+func uploadChunksKey(uploadID string) string {
+	return uploadKeyPrefix + uploadID + ":chunks"
+}
+
+// uploadBytesKey is the counter of the bytes sent for an upload, see maxUploadBytes.
+func uploadBytesKey(uploadID string) string {
+	return uploadKeyPrefix + uploadID + ":bytes"
+}
+
+func newUploadID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
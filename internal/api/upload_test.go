@@ -0,0 +1,379 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+func initUpload(t *testing.T, mux http.Handler, key string) string {
+	t.Helper()
+
+	req := httptest.NewRequest("POST", "/api/v1/keys/"+key+"/upload/init", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 on init, got %d", w.Code)
+	}
+
+	var response Response
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	uploadID, _ := response.Data.(map[string]any)["upload_id"].(string)
+	if uploadID == "" {
+		t.Fatal("Expected a non-empty upload_id")
+	}
+	return uploadID
+}
+
+func putChunk(mux http.Handler, key, uploadID string, n int, data string) *httptest.ResponseRecorder {
+	path := "/api/v1/keys/" + key + "/upload/" + uploadID + "/chunk/" + strconv.Itoa(n)
+	req := httptest.NewRequest("PUT", path, strings.NewReader(data))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func completeUpload(mux http.Handler, key, uploadID string, chunks, ttl int) *httptest.ResponseRecorder {
+	payloadBytes, _ := json.Marshal(UploadCompleteRequest{Chunks: chunks, TTLSeconds: ttl})
+	req := httptest.NewRequest("POST", "/api/v1/keys/"+key+"/upload/"+uploadID+"/complete", bytes.NewReader(payloadBytes))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandler_ChunkedUpload(t *testing.T) {
+	var memoryStore store.IStore = memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	handler := NewHandler(memoryStore)
+	mux := handler.SetupRoutes()
+
+	uploadID := initUpload(t, mux, "big_value")
+
+	// Chunks may arrive out of order.
+	chunks := []string{"first-", "second-", "third"}
+	for _, n := range []int{2, 0, 1} {
+		if w := putChunk(mux, "big_value", uploadID, n, chunks[n]); w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for chunk %d, got %d", n, w.Code)
+		}
+	}
+
+	if w := completeUpload(mux, "big_value", uploadID, len(chunks), 60); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 on complete, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/keys/big_value", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	var getResponse Response
+	if err := json.NewDecoder(w.Body).Decode(&getResponse); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	data := getResponse.Data.(map[string]any)
+	if data["value"] != "first-second-third" {
+		t.Errorf("Expected value 'first-second-third', got %v", data["value"])
+	}
+
+	// The upload is consumed once completed.
+	if w := putChunk(mux, "big_value", uploadID, 3, "late"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for chunk after complete, got %d", w.Code)
+	}
+}
+
+func TestHandler_ChunkedUploadIncomplete(t *testing.T) {
+	var memoryStore store.IStore = memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	handler := NewHandler(memoryStore)
+	mux := handler.SetupRoutes()
+
+	uploadID := initUpload(t, mux, "partial")
+	putChunk(mux, "partial", uploadID, 0, "only-one")
+
+	w := completeUpload(mux, "partial", uploadID, 2, 60)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for missing chunk, got %d", w.Code)
+	}
+
+	// An upload ID cannot be used for a different key.
+	if w := putChunk(mux, "other_key", uploadID, 1, "x"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for upload of another key, got %d", w.Code)
+	}
+}
+
+func TestHandler_ChunkedUploadAbandoned(t *testing.T) {
+	var memoryStore store.IStore = memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	handler := NewHandler(memoryStore)
+	handler.uploadTTLSeconds = 1
+	mux := handler.SetupRoutes()
+
+	uploadID := initUpload(t, mux, "abandoned")
+	if w := putChunk(mux, "abandoned", uploadID, 0, "data"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for chunk, got %d", w.Code)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	if w := completeUpload(mux, "abandoned", uploadID, 1, 60); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for expired upload, got %d", w.Code)
+	}
+
+	for _, key := range []string{uploadChunksKey(uploadID), uploadBytesKey(uploadID)} {
+		if exists, _ := memoryStore.Exists(context.Background(), key); exists {
+			t.Errorf("Expected %s to be expired", key)
+		}
+	}
+}
+
+func TestHandler_ChunkedUploadActive(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	handler := NewHandler(memoryStore)
+	handler.uploadTTLSeconds = 1
+	mux := handler.SetupRoutes()
+
+	// An upload outliving its TTL keeps every chunk as long as chunks keep coming
+	uploadID := initUpload(t, mux, "active")
+	for n, data := range []string{"ab", "cd", "ef"} {
+		if w := putChunk(mux, "active", uploadID, n, data); w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for chunk %d, got %d", n, w.Code)
+		}
+		time.Sleep(600 * time.Millisecond)
+	}
+
+	if w := completeUpload(mux, "active", uploadID, 3, 60); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 on complete, got %d %s", w.Code, w.Body.String())
+	}
+	if value, _ := memoryStore.Get(context.Background(), "active"); value != "abcdef" {
+		t.Errorf("Expected every chunk assembled, got %q", value)
+	}
+}
+
+func TestHandler_ChunkedUploadLimits(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+	mux := NewHandler(memoryStore).SetupRoutes()
+	ctx := context.Background()
+
+	uploadID := initUpload(t, mux, "capped")
+	if w := putChunk(mux, "capped", uploadID, maxUploadChunks, "data"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a chunk number past the limit, got %d", w.Code)
+	}
+	if w := completeUpload(mux, "capped", uploadID, maxUploadChunks+1, 0); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a chunk count past the limit, got %d", w.Code)
+	}
+
+	// Chunks past the final count are dropped on complete too
+	for n, data := range []string{"ab", "cd", "stale"} {
+		putChunk(mux, "capped", uploadID, n, data)
+	}
+	if w := completeUpload(mux, "capped", uploadID, 2, 0); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 on complete, got %d %s", w.Code, w.Body.String())
+	}
+	if value, _ := memoryStore.Get(ctx, "capped"); value != "abcd" {
+		t.Errorf("Expected the first two chunks assembled, got %q", value)
+	}
+	for _, key := range []string{uploadChunksKey(uploadID), uploadBytesKey(uploadID), uploadMetaKey(uploadID)} {
+		if exists, _ := memoryStore.Exists(ctx, key); exists {
+			t.Errorf("Expected %s to be removed on complete", key)
+		}
+	}
+}
+
+func TestHandler_ChunkedUploadMaxBytes(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+	handler := NewHandler(memoryStore)
+	handler.maxUploadBytes = 8
+	mux := handler.SetupRoutes()
+
+	uploadID := initUpload(t, mux, "capped")
+	if w := putChunk(mux, "capped", uploadID, 0, "abcde"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for chunk 0, got %d", w.Code)
+	}
+	if w := putChunk(mux, "capped", uploadID, 1, "fghij"); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413 past the upload size limit, got %d %s", w.Code, w.Body.String())
+	}
+
+	// A re-sent chunk replaces the size of the one it replaces
+	putChunk(mux, "capped", uploadID, 0, "abc")
+	if w := putChunk(mux, "capped", uploadID, 1, "fghij"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 within the upload size limit, got %d %s", w.Code, w.Body.String())
+	}
+	if w := completeUpload(mux, "capped", uploadID, 2, 0); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 on complete, got %d %s", w.Code, w.Body.String())
+	}
+	if value, _ := memoryStore.Get(context.Background(), "capped"); value != "abcfghij" {
+		t.Errorf("Expected the chunks assembled, got %q", value)
+	}
+
+	// Concurrent chunks cannot take the upload past the limit together
+	uploadID = initUpload(t, mux, "capped")
+	var wg sync.WaitGroup
+	var stored atomic.Int32
+	for n := 0; n < 10; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			if w := putChunk(mux, "capped", uploadID, n, "abc"); w.Code == http.StatusOK {
+				stored.Add(1)
+			}
+		}(n)
+	}
+	wg.Wait()
+	if got := stored.Load(); got != 2 {
+		t.Errorf("Expected 2 chunks within the upload size limit, got %d", got)
+	}
+}
+
+func TestHandler_UploadKeysReserved(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+	mux := NewHandler(memoryStore).SetupRoutes()
+
+	uploadID := initUpload(t, mux, "report")
+	if w := putChunk(mux, "report", uploadID, 0, "data"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for chunk, got %d", w.Code)
+	}
+	chunkKey := uploadChunksKey(uploadID)
+	encodedKey := base64.StdEncoding.EncodeToString([]byte(chunkKey))
+
+	for _, tc := range []struct {
+		method, path, body, header string
+	}{
+		{"GET", "/api/v1/keys/" + chunkKey, "", ""},
+		{"PUT", "/api/v1/keys/" + chunkKey, `{"value":"forged"}`, ""},
+		{"DELETE", "/api/v1/keys/" + uploadMetaKey(uploadID), "", ""},
+		{"POST", "/api/v1/keys", `{"key":"` + chunkKey + `","value":"forged"}`, ""},
+		{"POST", "/api/v1/keys/mset", `{"pairs":{"` + chunkKey + `":"forged"}}`, ""},
+		{"POST", "/api/v1/keys/mget", `{"keys":["` + chunkKey + `"]}`, ""},
+		{"POST", "/api/v1/keys/" + uploadKeyPrefix + "x/upload/init", "", ""},
+		{"GET", "/api/v1/keys/x", "", encodedKey},
+		{"PUT", "/api/v1/keys/x", `{"value":"forged"}`, encodedKey},
+		{"DELETE", "/api/v1/keys/x", "", encodedKey},
+		{"POST", "/api/v1/keys/delete", `{"key":"` + encodedKey + `"}`, ""},
+		{"POST", "/api/v1/lists/push", `{"key":"` + chunkKey + `","item":"forged"}`, ""},
+		{"POST", "/api/v1/lists/moveall", `{"src":"queue","dst":"` + chunkKey + `"}`, ""},
+		{"POST", "/api/v1/lists/batch", `[{"key":"` + chunkKey + `","op":"lpush","item":"forged"}]`, ""},
+		{"POST", "/api/v1/lists/" + chunkKey + "/clear", "", ""},
+		{"PUT", "/api/v1/hashes/" + chunkKey + "/fields/f", `{"value":"forged"}`, ""},
+		{"POST", "/api/v1/sets/" + chunkKey + "/add", `{"members":["forged"]}`, ""},
+		{"POST", "/api/v1/keys/info", `{"keys":["` + chunkKey + `"]}`, ""},
+		{"POST", "/api/v1/read/pipeline", `[{"key":"` + chunkKey + `"}]`, ""},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		if tc.header != "" {
+			req.Header.Set(keyHeader, tc.header)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "are reserved") {
+			t.Errorf("%s %s: expected status 400 for a reserved key, got %d %s", tc.method, tc.path, w.Code, w.Body.String())
+		}
+	}
+
+	req := httptest.NewRequest("HEAD", "/api/v1/keys/x", nil)
+	req.Header.Set(keyHeader, encodedKey)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for HEAD of a reserved key, got %d", w.Code)
+	}
+
+	// Bulk operations leave uploads alone but still apply to the other keys
+	ctx := context.Background()
+	memoryStore.Set(ctx, "other", "v", 60)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/keys/expire?pattern=*", strings.NewReader(`{"ttl_seconds":0}`)))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"count":1`) {
+		t.Errorf("Expected only the other key to be persisted, got %d %s", w.Code, w.Body.String())
+	}
+	for _, path := range []string{"/api/v1/keys?pattern=*", "/api/v1/keys?expiring_within=3600"} {
+		memoryStore.Set(ctx, "other", "v", 60)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("DELETE", path, nil))
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"deleted":1`) {
+			t.Errorf("DELETE %s: expected only the other key deleted, got %d %s", path, w.Code, w.Body.String())
+		}
+	}
+	memoryStore.Set(ctx, "other", "v", 0)
+	for _, path := range []string{"/api/v1/keys?pattern=*", "/api/v1/admin/top", "/api/v1/admin/sample", "/api/v1/admin/export?pattern=*"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusOK || strings.Contains(w.Body.String(), uploadKeyPrefix) || !strings.Contains(w.Body.String(), "other") {
+			t.Errorf("GET %s: expected only the other key, got %d %s", path, w.Code, w.Body.String())
+		}
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/keys/count?pattern=*", nil))
+	if !strings.Contains(w.Body.String(), `"count":1`) {
+		t.Errorf("Expected uploads not to be counted, got %s", w.Body.String())
+	}
+
+	// The upload is untouched and still completes
+	if w := completeUpload(mux, "report", uploadID, 1, 0); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 on complete, got %d %s", w.Code, w.Body.String())
+	}
+	if value, _ := memoryStore.Get(context.Background(), "report"); value != "data" {
+		t.Errorf("Expected the uploaded value, got %q", value)
+	}
+}
+
+func TestHandler_KeyContainingUpload(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+	mux := NewHandler(memoryStore).SetupRoutes()
+
+	const key = "files/upload/report"
+	payload, _ := json.Marshal(SetRequest{Key: key, Value: "v1"})
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/keys", bytes.NewReader(payload)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 on set, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/keys/"+key, nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"value":"v1"`) {
+		t.Errorf("Expected the key containing /upload/ to be readable, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/v1/keys/"+key, nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the key containing /upload/ to be deletable, got %d %s", w.Code, w.Body.String())
+	}
+
+	// Upload routes still apply to such keys
+	uploadID := initUpload(t, mux, key)
+	putChunk(mux, key, uploadID, 0, "v2")
+	if w := completeUpload(mux, key, uploadID, 1, 0); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 on complete, got %d %s", w.Code, w.Body.String())
+	}
+	if value, _ := memoryStore.Get(context.Background(), key); value != "v2" {
+		t.Errorf("Expected the uploaded value, got %q", value)
+	}
+}
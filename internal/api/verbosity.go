@@ -0,0 +1,61 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// ErrorVerbosity controls how much detail error responses carry.
+type ErrorVerbosity string
+
+const (
+	// ErrorVerbosityDev returns the full message of every error, the default.
+	ErrorVerbosityDev ErrorVerbosity = "dev"
+	// ErrorVerbosityProduction hides the details of 500 errors from clients. They get
+	// a generic message and a correlation ID, which is logged along with the real error.
+	// Other errors are returned in full, as they describe what the client got wrong.
+	ErrorVerbosityProduction ErrorVerbosity = "production"
+)
+
+// internalErrorMessage is the message of 500 errors in production verbosity.
+const internalErrorMessage = "internal error"
+
+// WithErrorVerbosity sets how much detail error responses carry.
+// An empty verbosity keeps the default, ErrorVerbosityDev.
+func WithErrorVerbosity(verbosity ErrorVerbosity) HandlerOption {
+	return func(h *Handler) {
+		if verbosity != "" {
+			h.errorVerbosity = verbosity
+		}
+	}
+}
+
+// hidesError reports whether the message of an error with statusCode is withheld from clients.
+func (h *Handler) hidesError(statusCode int) bool {
+	return h.errorVerbosity == ErrorVerbosityProduction && statusCode == http.StatusInternalServerError
+}
+
+// writeInternalError logs message under a new correlation ID and writes a 500 response
+// carrying only the ID.
+func (h *Handler) writeInternalError(w http.ResponseWriter, message string) {
+	id, err := newCorrelationID()
+	if err != nil {
+		h.errorLog.Printf("failed to generate correlation ID: %v", err)
+	}
+	h.errorLog.Printf("internal error correlation_id=%s: %s", id, message)
+
+	h.writeJSON(w, http.StatusInternalServerError, Response{
+		Success:       false,
+		Error:         internalErrorMessage,
+		CorrelationID: id,
+	})
+}
+
+func newCorrelationID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+// brokenGetStore fails every Get with an internal error.
+type brokenGetStore struct {
+	store.IStore
+}
+
+func (brokenGetStore) Get(ctx context.Context, key string) (string, error) {
+	return "", errors.New("backend exploded at shard 7")
+}
+
+func TestHandler_ErrorVerbosity(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+
+	tests := []struct {
+		verbosity ErrorVerbosity
+		detailed  bool
+	}{
+		{"", true},
+		{ErrorVerbosityDev, true},
+		{ErrorVerbosityProduction, false},
+	}
+
+	for _, tt := range tests {
+		var logged bytes.Buffer
+		h := NewHandler(brokenGetStore{memoryStore}, WithErrorVerbosity(tt.verbosity))
+		h.errorLog = log.New(&logged, "", 0)
+		mux := h.SetupRoutes()
+
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/keys/user", nil))
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("%q: expected status 500, got %d", tt.verbosity, w.Code)
+		}
+
+		var resp Response
+		json.NewDecoder(w.Body).Decode(&resp)
+		if got := strings.Contains(resp.Error, "shard 7"); got != tt.detailed {
+			t.Errorf("%q: expected detailed error %v, got %q", tt.verbosity, tt.detailed, resp.Error)
+		}
+
+		if tt.detailed {
+			if resp.CorrelationID != "" || logged.Len() != 0 {
+				t.Errorf("%q: expected no correlation ID or log, got %q and %q", tt.verbosity, resp.CorrelationID, logged.String())
+			}
+			continue
+		}
+		if resp.Error != "internal error" || resp.CorrelationID == "" {
+			t.Errorf("%q: expected a generic error with a correlation ID, got %+v", tt.verbosity, resp)
+		}
+		if line := logged.String(); !strings.Contains(line, resp.CorrelationID) || !strings.Contains(line, "shard 7") {
+			t.Errorf("%q: expected the real error logged under the correlation ID, got %q", tt.verbosity, line)
+		}
+
+		// Client errors stay descriptive
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/keys", strings.NewReader(`{"key":""}`)))
+		json.NewDecoder(w.Body).Decode(&resp)
+		if w.Code != http.StatusBadRequest || resp.Error == "internal error" || resp.Error == "" {
+			t.Errorf("%q: expected a descriptive 400, got %d %q", tt.verbosity, w.Code, resp.Error)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIVersion is the version of the API served, sent in the X-API-Version header of
+// every response.
+const APIVersion = "1"
+
+const versionHeader = "X-API-Version"
+
+// Deprecation marks requests to an endpoint as deprecated. Path is matched like a
+// ServeMux pattern: exactly, or as a prefix if it ends in a slash. An empty Method
+// matches all methods.
+type Deprecation struct {
+	Method string
+	Path   string
+	// Since is when the endpoint was deprecated, sent in the Deprecation header.
+	Since time.Time
+	// Sunset is when the endpoint is going to be removed, sent in the Sunset header
+	// unless zero.
+	Sunset time.Time
+}
+
+// DeprecatedRoutes lists the deprecated endpoints. It is the one place to mark an
+// endpoint deprecated; handlers report these unless configured otherwise with
+// WithDeprecations.
+var DeprecatedRoutes = []Deprecation{}
+
+// WithDeprecations replaces DeprecatedRoutes as the endpoints reported deprecated.
+func WithDeprecations(deprecations []Deprecation) HandlerOption {
+	return func(h *Handler) {
+		h.deprecations = deprecations
+	}
+}
+
+// matches reports whether d applies to r.
+func (d Deprecation) matches(r *http.Request) bool {
+	if d.Method != "" && d.Method != r.Method {
+		return false
+	}
+	if strings.HasSuffix(d.Path, "/") {
+		return strings.HasPrefix(r.URL.Path, d.Path)
+	}
+	return r.URL.Path == d.Path
+}
+
+// versionHeaders wraps next to send the API version with every response, and the
+// Deprecation and Sunset headers (RFC 9745, RFC 8594) with responses of deprecated
+// endpoints.
+func (h *Handler) versionHeaders(next http.Handler) http.Handler {
+	return &versionHandler{next: next, deprecations: h.deprecations}
+}
+
+type versionHandler struct {
+	next         http.Handler
+	deprecations []Deprecation
+}
+
+func (v *versionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(versionHeader, APIVersion)
+	for _, d := range v.deprecations {
+		if !d.matches(r) {
+			continue
+		}
+		w.Header().Set("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+		if !d.Sunset.IsZero() {
+			w.Header().Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		break
+	}
+	v.next.ServeHTTP(w, r)
+}
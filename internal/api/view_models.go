@@ -1,15 +1,120 @@
 package api
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
 type Response struct {
 	Success bool   `json:"success"`
 	Data    any    `json:"data,omitempty"`
 	Error   string `json:"error,omitempty"`
+	// Code is a machine-readable error code, set for errors clients are expected to handle.
+	Code string `json:"code,omitempty"`
+	// RetryAfterMs tells clients how long to wait before retrying a rate limited request.
+	RetryAfterMs int64 `json:"retry_after_ms,omitempty"`
+	// CorrelationID identifies the server log entry of an error whose details are withheld.
+	CorrelationID string `json:"correlation_id,omitempty"`
 }
 
 type SetRequest struct {
 	Key        string `json:"key"`
 	Value      any    `json:"value"`
 	TTLSeconds int    `json:"ttl_seconds"`
+	NX         bool   `json:"nx,omitempty"`
+	// Compress set to false stores the value uncompressed even if the store compresses
+	// values of its size.
+	Compress *bool `json:"compress,omitempty"`
+	// Tags replace the tags of the key, see DeleteByTagHandler. Setting a key without
+	// tags clears them.
+	Tags []string `json:"tags,omitempty"`
+}
+
+func (r SetRequest) requestKeys() []string {
+	return []string{r.Key}
+}
+
+// RawResponse holds a value in its original JSON structure, see GetRawHandler.
+type RawResponse struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+type MultiGetRequest struct {
+	Keys []string `json:"keys"`
+}
+
+func (r MultiGetRequest) requestKeys() []string {
+	return r.Keys
+}
+
+type MultiGetResponse struct {
+	Entries []store.KeyEntry `json:"entries"`
+}
+
+// MSetRequest sets every key of Pairs to its value with the same TTL.
+type MSetRequest struct {
+	Pairs      map[string]any `json:"pairs"`
+	TTLSeconds int            `json:"ttl_seconds"`
+	// ChunkSize writes the pairs ChunkSize keys per store lock rather than all under
+	// one, trading atomicity for shorter stalls of other requests. 0 writes them at once.
+	ChunkSize int `json:"chunk_size,omitempty"`
+}
+
+func (r MSetRequest) requestKeys() []string {
+	keys := make([]string, 0, len(r.Pairs))
+	for key := range r.Pairs {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// MSetResponse reports how many keys were written. It is also the data of an error
+// response, counting the keys written before the failure.
+type MSetResponse struct {
+	Count int `json:"count"`
+}
+
+type MGetRequest struct {
+	Keys []string `json:"keys"`
+}
+
+func (r MGetRequest) requestKeys() []string {
+	return r.Keys
+}
+
+// MGetResponse maps each found key to its value. Missing keys are left out.
+type MGetResponse struct {
+	Values map[string]string `json:"values"`
+}
+
+type KeysInfoRequest struct {
+	Keys []string `json:"keys"`
+}
+
+func (r KeysInfoRequest) requestKeys() []string {
+	return r.Keys
+}
+
+// KeysInfoResponse lists the live keys of a KeysInfoRequest in request order.
+type KeysInfoResponse struct {
+	Keys []store.KeyInfo `json:"keys"`
+}
+
+// PreviousResponse is returned by writes made with ?return=previous.
+// Previous is omitted when the key did not exist.
+type PreviousResponse struct {
+	Message  string          `json:"message"`
+	Previous *store.KeyEntry `json:"previous,omitempty"`
+}
+
+// GetAnyResponse holds a string value or the items of a list, discriminated by Type.
+type GetAnyResponse struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value any    `json:"value"`
 }
 
 type UpdateRequest struct {
@@ -17,11 +122,462 @@ type UpdateRequest struct {
 	Value any    `json:"value"`
 }
 
+func (r UpdateRequest) requestKeys() []string {
+	return []string{r.Key}
+}
+
+// GetSetRequest replaces the value of a key, see GetSetResponse.
+type GetSetRequest struct {
+	Value any `json:"value"`
+}
+
+func (r GetSetRequest) requestKeys() []string {
+	return nil
+}
+
+// GetSetResponse holds the value a GetSet replaced.
+type GetSetResponse struct {
+	Key      string `json:"key"`
+	Previous string `json:"previous"`
+}
+
+type ExpireRequest struct {
+	TTLSeconds int     `json:"ttl_seconds"`
+	IfValue    *string `json:"if_value,omitempty"`
+}
+
+func (r ExpireRequest) requestKeys() []string {
+	return nil
+}
+
+type ExpirePatternRequest struct {
+	TTLSeconds int `json:"ttl_seconds"`
+}
+
+func (r ExpirePatternRequest) requestKeys() []string {
+	return nil
+}
+
+// KeysResponse lists the keys matching a pattern.
+type KeysResponse struct {
+	Pattern string   `json:"pattern,omitempty"`
+	Keys    []string `json:"keys"`
+}
+
+// DeleteMatchingResponse reports how many keys matching a pattern were deleted.
+type DeleteMatchingResponse struct {
+	Pattern string `json:"pattern"`
+	Deleted int    `json:"deleted"`
+}
+
+// DeleteExpiringResponse reports how many keys expiring within a number of seconds were deleted.
+type DeleteExpiringResponse struct {
+	ExpiringWithin int `json:"expiring_within"`
+	Deleted        int `json:"deleted"`
+}
+
+// PatternCountResponse reports how many keys matching a pattern were affected.
+type PatternCountResponse struct {
+	Pattern string `json:"pattern"`
+	Count   int    `json:"count"`
+}
+
 type PushRequest struct {
+	Key       string `json:"key"`
+	Item      any    `json:"item"`
+	Resurrect bool   `json:"resurrect"`
+	// MaxLen trims the list to its first MaxLen items after the push, 0 for no cap.
+	MaxLen int `json:"max_len,omitempty"`
+	// ReturnEvicted includes the items trimmed by MaxLen in the response.
+	ReturnEvicted bool `json:"return_evicted,omitempty"`
+}
+
+func (r PushRequest) requestKeys() []string {
+	return []string{r.Key}
+}
+
+// PushResponse is returned by pushes made with return_evicted.
+type PushResponse struct {
+	Message string   `json:"message"`
+	Seq     uint64   `json:"seq"`
+	Evicted []string `json:"evicted"`
+}
+
+type LMoveAllRequest struct {
+	Src string `json:"src"`
+	Dst string `json:"dst"`
+}
+
+func (r LMoveAllRequest) requestKeys() []string {
+	return []string{r.Src, r.Dst}
+}
+
+type LSetRequest struct {
+	Key        string `json:"key"`
+	Items      []any  `json:"items"`
+	TTLSeconds int    `json:"ttl_seconds"`
+	NX         bool   `json:"nx"`
+}
+
+func (r LSetRequest) requestKeys() []string {
+	return []string{r.Key}
+}
+
+// RPushRequest is the body of a push to the end of a list.
+type RPushRequest struct {
 	Key  string `json:"key"`
 	Item any    `json:"item"`
 }
 
+func (r RPushRequest) requestKeys() []string {
+	return []string{r.Key}
+}
+
 type PopRequest struct {
 	Key string `json:"key"`
 }
+
+func (r PopRequest) requestKeys() []string {
+	return []string{r.Key}
+}
+
+// BLPopRequest is the body of POST /api/v1/lists/blpop. TimeoutSeconds may be
+// fractional.
+type BLPopRequest struct {
+	Key            string  `json:"key"`
+	TimeoutSeconds float64 `json:"timeout_seconds"`
+}
+
+func (r BLPopRequest) requestKeys() []string {
+	return []string{r.Key}
+}
+
+type UploadCompleteRequest struct {
+	Chunks     int `json:"chunks"`
+	TTLSeconds int `json:"ttl_seconds"`
+}
+
+func (r UploadCompleteRequest) requestKeys() []string {
+	return nil
+}
+
+// ListBatchRequest is the body of POST /api/v1/lists/batch, a JSON array of operations.
+type ListBatchRequest []store.ListOp
+
+func (r ListBatchRequest) requestKeys() []string {
+	keys := make([]string, len(r))
+	for i, op := range r {
+		keys[i] = op.Key
+	}
+	return keys
+}
+
+// PipelineGetRequest is the body of POST /api/v1/read/pipeline, a JSON array of reads.
+type PipelineGetRequest []store.ReadSpec
+
+func (r PipelineGetRequest) requestKeys() []string {
+	keys := make([]string, len(r))
+	for i, spec := range r {
+		keys[i] = spec.Key
+	}
+	return keys
+}
+
+type ListHistoryResponse struct {
+	Key     string              `json:"key"`
+	Samples []store.DepthSample `json:"samples"`
+}
+
+type LMoveAllResponse struct {
+	Moved int `json:"moved"`
+}
+
+type LLenResponse struct {
+	Key    string `json:"key"`
+	Length int    `json:"length"`
+}
+
+type LRangeResponse struct {
+	Key string `json:"key"`
+	store.ListPage
+}
+
+type LRangeMetaResponse struct {
+	Key   string         `json:"key"`
+	Items []ListItemMeta `json:"items"`
+}
+
+// ListItemMeta is a list item with its sequence number and, if recorded, push time.
+type ListItemMeta struct {
+	Value    string     `json:"value"`
+	Seq      uint64     `json:"seq"`
+	PushedAt *time.Time `json:"pushed_at,omitempty"`
+}
+
+type LTrimResponse struct {
+	Key     string   `json:"key"`
+	Removed []string `json:"removed"`
+}
+
+type ListBatchResult struct {
+	Key     string  `json:"key"`
+	Op      string  `json:"op"`
+	Success bool    `json:"success"`
+	Item    *string `json:"item,omitempty"`
+	Code    string  `json:"code,omitempty"`
+	Error   string  `json:"error,omitempty"`
+}
+
+type ListBatchResponse struct {
+	Results []ListBatchResult `json:"results"`
+}
+
+// PipelineReadResult is the outcome of a pipeline read. Value is set if it succeeded,
+// Code and Error otherwise.
+type PipelineReadResult struct {
+	Key     string  `json:"key"`
+	Index   *int    `json:"index,omitempty"`
+	Success bool    `json:"success"`
+	Value   *string `json:"value,omitempty"`
+	Code    string  `json:"code,omitempty"`
+	Error   string  `json:"error,omitempty"`
+}
+
+type PipelineGetResponse struct {
+	Results []PipelineReadResult `json:"results"`
+}
+
+type ReserveResponse struct {
+	Key                 string `json:"key"`
+	Item                string `json:"item"`
+	Receipt             string `json:"receipt"`
+	VisibilityTimeoutMs int64  `json:"visibility_timeout_ms"`
+}
+
+type AckRequest struct {
+	Receipt string `json:"receipt"`
+}
+
+func (r AckRequest) requestKeys() []string {
+	return nil
+}
+
+type BinaryKeyRequest struct {
+	Key string `json:"key"`
+}
+
+func (r BinaryKeyRequest) requestKeys() []string {
+	key, err := decodeKey(r.Key)
+	if err != nil {
+		return nil
+	}
+	return []string{key}
+}
+
+type RateIncrRequest struct {
+	Key      string `json:"key"`
+	WindowMs int64  `json:"window_ms"`
+	Limit    int    `json:"limit"`
+}
+
+func (r RateIncrRequest) requestKeys() []string {
+	return []string{r.Key}
+}
+
+type RateIncrResponse struct {
+	Key     string `json:"key"`
+	Count   int    `json:"count"`
+	Limit   int    `json:"limit"`
+	Allowed bool   `json:"allowed"`
+}
+
+type TopKeysResponse struct {
+	By   string          `json:"by"`
+	Keys []store.KeySize `json:"keys"`
+}
+
+// TagKeysResponse lists the keys tagged with Tag.
+type TagKeysResponse struct {
+	Tag  string   `json:"tag"`
+	Keys []string `json:"keys"`
+}
+
+// TagDeleteResponse reports how many keys were deleted by tag.
+type TagDeleteResponse struct {
+	Tag     string `json:"tag"`
+	Deleted int    `json:"deleted"`
+}
+
+// SampleKeysResponse holds a random sample of live keys, see SampleKeysHandler.
+type SampleKeysResponse struct {
+	Keys []store.KeySize `json:"keys"`
+}
+
+// TTLWorkerResponse reports whether the TTL worker is paused, see PauseTTLWorkerHandler.
+type TTLWorkerResponse struct {
+	Paused bool `json:"paused"`
+}
+
+// VerifyResponse reports the keys whose content no longer matches its checksum, see
+// VerifyHandler. OK is set when there are none.
+type VerifyResponse struct {
+	OK       bool     `json:"ok"`
+	Problems []string `json:"problems"`
+}
+
+// ExportResponse holds the keys matching a pattern, see ExportHandler.
+type ExportResponse struct {
+	Pattern string          `json:"pattern"`
+	Keys    []store.KeyDump `json:"keys"`
+}
+
+// TTLHistogramResponse counts live keys per TTL bucket, see store.TTLBucketLonger and store.TTLBucketNever.
+type TTLHistogramResponse struct {
+	Buckets map[string]int `json:"buckets"`
+}
+
+type HealthResponse struct {
+	Ready   bool                 `json:"ready"`
+	Workers []store.WorkerHealth `json:"workers"`
+}
+
+type TimeResponse struct {
+	Time   string `json:"time"`
+	UnixMs int64  `json:"unix_ms"`
+}
+
+// PublishRequest is the body of POST /api/v1/publish. Payload may be any JSON value.
+type PublishRequest struct {
+	Channel string          `json:"channel"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func (r PublishRequest) requestKeys() []string {
+	return nil
+}
+
+type PublishResponse struct {
+	Channel   string `json:"channel"`
+	Receivers int    `json:"receivers"`
+}
+
+type CapabilitiesResponse struct {
+	APIVersion string   `json:"api_version"`
+	Operations []string `json:"operations"`
+}
+
+type SetNXResponse struct {
+	Set        bool   `json:"set"`
+	FenceToken uint64 `json:"fence_token,omitempty"`
+}
+
+// IncrRequest adds Delta, which may be negative, to a counter. If Ceiling is set, the
+// increment is only applied if the counter does not exceed it.
+type IncrRequest struct {
+	Delta   int64  `json:"delta"`
+	Ceiling *int64 `json:"ceiling,omitempty"`
+}
+
+func (r IncrRequest) requestKeys() []string {
+	return nil
+}
+
+// DecrRequest decrements a counter by Delta. If Floor is set, the decrement is only
+// applied if the counter does not drop below it.
+type DecrRequest struct {
+	Delta int64  `json:"delta"`
+	Floor *int64 `json:"floor,omitempty"`
+}
+
+func (r DecrRequest) requestKeys() []string {
+	return nil
+}
+
+// CounterResponse is the value of a counter after an operation. Applied is false if
+// the operation was refused by its bound, in which case Value is left unchanged.
+type CounterResponse struct {
+	Value   int64 `json:"value"`
+	Applied bool  `json:"applied"`
+}
+
+type TTLResponse struct {
+	// TTLSeconds is the remaining time to live rounded up, or -1 if the key does not expire.
+	TTLSeconds int `json:"ttl_seconds"`
+}
+
+type ExpireResponse struct {
+	Message    string `json:"message"`
+	FenceToken uint64 `json:"fence_token,omitempty"`
+}
+
+// HSetRequest is the value to set a hash field to. Values that are not strings are
+// stored as their JSON encoding.
+type HSetRequest struct {
+	Value any `json:"value"`
+}
+
+func (r HSetRequest) requestKeys() []string {
+	return nil
+}
+
+// HSetResponse reports whether the field was new to the hash.
+type HSetResponse struct {
+	Key     string `json:"key"`
+	Field   string `json:"field"`
+	Created bool   `json:"created"`
+}
+
+type HGetResponse struct {
+	Key   string `json:"key"`
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
+
+// HDelResponse reports whether the field was there to delete.
+type HDelResponse struct {
+	Key     string `json:"key"`
+	Field   string `json:"field"`
+	Deleted bool   `json:"deleted"`
+}
+
+type HGetAllResponse struct {
+	Key    string            `json:"key"`
+	Fields map[string]string `json:"fields"`
+}
+
+// SetMembersRequest is the members to add to or remove from a set.
+type SetMembersRequest struct {
+	Members []string `json:"members"`
+}
+
+func (r SetMembersRequest) requestKeys() []string {
+	return nil
+}
+
+// SAddResponse reports how many members were not already in the set.
+type SAddResponse struct {
+	Key   string `json:"key"`
+	Added int    `json:"added"`
+}
+
+// SRemResponse reports how many members were in the set to remove.
+type SRemResponse struct {
+	Key     string `json:"key"`
+	Removed int    `json:"removed"`
+}
+
+type SMembersResponse struct {
+	Key     string   `json:"key"`
+	Members []string `json:"members"`
+}
+
+type SIsMemberResponse struct {
+	Key      string `json:"key"`
+	Member   string `json:"member"`
+	IsMember bool   `json:"is_member"`
+}
+
+type SCardResponse struct {
+	Key  string `json:"key"`
+	Card int    `json:"card"`
+}
@@ -0,0 +1,33 @@
+package store
+
+import "errors"
+
+// Errors returned by IStore implementations. Implementations re-export these so
+// callers can match them with errors.Is regardless of the backend in use.
+var (
+	ErrKeyNotFound   = errors.New("key not found")
+	ErrTypeMismatch  = errors.New("operation not supported for this data type")
+	ErrInvalidTTL    = errors.New("invalid TTL value")
+	ErrEmptyList     = errors.New("list is empty")
+	ErrMarshalFailed = errors.New("failed to marshal value to JSON")
+
+	ErrInvalidRateLimit = errors.New("rate limit window and limit must be positive")
+	ErrInvalidPattern   = errors.New("invalid key pattern")
+	ErrKeyExists        = errors.New("key already exists")
+	ErrStaleFence       = errors.New("fence token is older than the latest one for this key")
+	ErrUnknownFence     = errors.New("fence token was never issued")
+	ErrOutOfMemory      = errors.New("store memory limit reached")
+	ErrReceiptNotFound  = errors.New("receipt not found or expired")
+	ErrKeyPendingDelete = errors.New("key is pending soft delete")
+	ErrInvalidListOp    = errors.New("unknown list operation")
+	ErrValueTooLarge    = errors.New("value exceeds the maximum size")
+	ErrInvalidBuckets   = errors.New("histogram buckets must be positive and distinct")
+	ErrTooManyKeys      = errors.New("store key limit reached")
+	ErrNotInteger       = errors.New("value is not an integer")
+	ErrIntegerOverflow  = errors.New("integer operation would overflow")
+	ErrInvalidType      = errors.New("invalid key type")
+	ErrIndexOutOfRange  = errors.New("list index out of range")
+	ErrChecksumMismatch = errors.New("value does not match its checksum")
+	ErrFieldNotFound    = errors.New("hash field not found")
+	ErrTimeout          = errors.New("timed out waiting for an item")
+)
@@ -1,15 +1,103 @@
 package store
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
 
 // Store defines the interface for in memory data structure store
 type IStore interface {
-	Set(ctx context.Context, key string, value any, ttlSeconds int) error
+	Set(ctx context.Context, key string, value any, ttlSeconds int, opts ...WriteOption) error
+	SetNX(ctx context.Context, key string, value any, ttlSeconds int, opts ...WriteOption) (bool, error)
+	SetNXFenced(ctx context.Context, key string, value any, ttlSeconds int, opts ...WriteOption) (set bool, fenceToken uint64, err error)
+	SetIfExpiringWithin(ctx context.Context, key string, value any, ttlSeconds, thresholdSeconds int, opts ...WriteOption) (bool, error)
+	SetIfType(ctx context.Context, key string, value any, ttlSeconds int, expectedType string, opts ...WriteOption) (bool, error)
+	GetSet(ctx context.Context, key string, value any, opts ...WriteOption) (string, error)
+	SetReturningPrevious(ctx context.Context, key string, value any, ttlSeconds int, opts ...WriteOption) (*KeyEntry, error)
 	Get(ctx context.Context, key string) (string, error)
-	Update(ctx context.Context, key string, value any) error
-	Remove(ctx context.Context, key string) error
-	Push(ctx context.Context, key string, item any) error
-	Pop(ctx context.Context, key string) (string, error)
+	GetRaw(ctx context.Context, key string) (json.RawMessage, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	TTL(ctx context.Context, key string) (int, error)
+	GetAny(ctx context.Context, key string) (value any, kind string, err error)
+	GetEntries(ctx context.Context, keys []string) ([]KeyEntry, error)
+	MSet(ctx context.Context, pairs map[string]any, ttlSeconds int) error
+	MSetChunked(ctx context.Context, pairs map[string]any, ttlSeconds int, chunkSize int) (int, error)
+	MGet(ctx context.Context, keys []string) (map[string]string, error)
+	PipelineGet(ctx context.Context, specs []ReadSpec) ([]ReadResult, error)
+	Update(ctx context.Context, key string, value any, opts ...WriteOption) error
+	Remove(ctx context.Context, key string, opts ...WriteOption) error
+	RemoveReturningPrevious(ctx context.Context, key string, opts ...WriteOption) (*KeyEntry, error)
+	SoftRemove(ctx context.Context, key string, opts ...WriteOption) error
+	Restore(ctx context.Context, key string, opts ...WriteOption) error
+	CompareAndDelete(ctx context.Context, key string, expected string, opts ...WriteOption) (bool, error)
+	Increment(ctx context.Context, key string, delta int64, opts ...WriteOption) (int64, error)
+	Decrement(ctx context.Context, key string, delta int64, opts ...WriteOption) (int64, error)
+	DecrWithFloor(ctx context.Context, key string, delta, floor int64, opts ...WriteOption) (int64, bool, error)
+	IncrWithCeiling(ctx context.Context, key string, delta, ceiling int64, opts ...WriteOption) (int64, bool, error)
+	Expire(ctx context.Context, key string, ttlSeconds int, opts ...WriteOption) error
+	ExpireFenced(ctx context.Context, key string, ttlSeconds int, opts ...WriteOption) (fenceToken uint64, err error)
+	ExpireReturningPrevious(ctx context.Context, key string, ttlSeconds int, opts ...WriteOption) (*KeyEntry, error)
+	ExpirePattern(ctx context.Context, pattern string, ttlSeconds int) (int, error)
+	CountPattern(ctx context.Context, pattern string) (int, error)
+	DeleteExpiringWithin(ctx context.Context, threshold time.Duration) (int, error)
+	DeleteMatching(ctx context.Context, pattern string) (int, error)
+	Keys(ctx context.Context, pattern string) ([]string, error)
+	CompareAndExpire(ctx context.Context, key string, expected string, ttlSeconds int, opts ...WriteOption) (bool, error)
+	Push(ctx context.Context, key string, item any, opts ...WriteOption) error
+	PushCappedReturn(ctx context.Context, key string, item any, maxLen int, opts ...WriteOption) (evicted []string, err error)
+	PushResurrect(ctx context.Context, key string, item any, opts ...WriteOption) error
+	Pop(ctx context.Context, key string, opts ...WriteOption) (string, error)
+	BLPop(ctx context.Context, key string, timeout time.Duration, opts ...WriteOption) (string, error)
+	RPush(ctx context.Context, key string, item any, opts ...WriteOption) error
+	RPop(ctx context.Context, key string, opts ...WriteOption) (string, error)
+	PushItem(ctx context.Context, key string, item any, opts PushOptions, writeOpts ...WriteOption) (PushResult, error)
+	PopItem(ctx context.Context, key string, opts ...WriteOption) (ListItem, error)
+	RPopItem(ctx context.Context, key string, opts ...WriteOption) (ListItem, error)
+	ListBatch(ctx context.Context, ops []ListOp, opts ...WriteOption) ([]ListOpResult, error)
+	LLen(ctx context.Context, key string) (int, error)
+	LRange(ctx context.Context, key string, start, stop int) ([]string, error)
+	LRangePage(ctx context.Context, key string, start, stop int) (ListPage, error)
+	LRangeReverse(ctx context.Context, key string, start, stop int) (ListPage, error)
+	LRangeWithMeta(ctx context.Context, key string, start, stop int) ([]ListItem, error)
+	LTrim(ctx context.Context, key string, start, stop int, opts ...WriteOption) error
+	LTrimReturn(ctx context.Context, key string, start, stop int, opts ...WriteOption) (removed []string, err error)
+	LClear(ctx context.Context, key string, opts ...WriteOption) error
+	LSet(ctx context.Context, key string, items []any, ttlSeconds int, opts ...WriteOption) error
+	LMoveAll(ctx context.Context, src, dst string, opts ...WriteOption) (int, error)
+	LInitNX(ctx context.Context, key string, items []any, ttlSeconds int, opts ...WriteOption) (bool, error)
+	HSet(ctx context.Context, key, field string, value any, opts ...WriteOption) (created bool, err error)
+	HGet(ctx context.Context, key, field string) (string, error)
+	HDel(ctx context.Context, key, field string, opts ...WriteOption) (bool, error)
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	SAdd(ctx context.Context, key string, members []string, opts ...WriteOption) (added int, err error)
+	SRem(ctx context.Context, key string, members []string, opts ...WriteOption) (removed int, err error)
+	SMembers(ctx context.Context, key string) ([]string, error)
+	SIsMember(ctx context.Context, key, member string) (bool, error)
+	SCard(ctx context.Context, key string) (int, error)
+	Reserve(ctx context.Context, key string, visibilityTimeout time.Duration, opts ...WriteOption) (item string, receipt string, err error)
+	Ack(ctx context.Context, receipt string) error
+	RateIncr(ctx context.Context, key string, window time.Duration, limit int) (count int, allowed bool, err error)
+	TopKeysBySize(ctx context.Context, n int) ([]KeySize, error)
+	TopKeysByTTL(ctx context.Context, n int) ([]KeySize, error)
+	TopKeysByAccess(ctx context.Context, n int) ([]KeySize, error)
+	KeysInfo(ctx context.Context, keys []string) ([]KeyInfo, error)
+	RandomKeys(ctx context.Context, n int) ([]KeySize, error)
+	ExportPattern(ctx context.Context, pattern string) ([]KeyDump, error)
+	VerifyIntegrity(ctx context.Context) ([]string, error)
+	KeysByTag(ctx context.Context, tag string) ([]string, error)
+	DeleteByTag(ctx context.Context, tag string) (int, error)
+	FlushAll(ctx context.Context) error
+	TTLHistogram(ctx context.Context, buckets []time.Duration) (map[string]int, error)
+	Stats(ctx context.Context) (StoreStats, error)
+	Config(ctx context.Context) (StoreConfig, error)
+	ListDepthHistory(ctx context.Context, key string) ([]DepthSample, error)
+	Health(ctx context.Context) ([]WorkerHealth, error)
+	EventsSince(ctx context.Context, since uint64) (EventPage, error)
+	Subscribe(channel string) (<-chan Message, func())
+	Publish(channel string, payload any) (int, error)
 	StartTTLWorker(ctx context.Context)
 	StopTTLWorker()
+	PauseTTLWorker()
+	ResumeTTLWorker()
 }
@@ -0,0 +1,33 @@
+package memory
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// keyAccess counts accesses to a key. Entries are only added and removed under the
+// write lock, while the counters are updated atomically so reads holding the read
+// lock can record accesses too.
+type keyAccess struct {
+	hits atomic.Uint64
+	last atomic.Int64
+}
+
+// touch records an access to key. The caller must hold the lock, for reading or writing.
+func (s *MemoryStore) touch(key string, now time.Time) {
+	if a, ok := s.access[key]; ok {
+		a.hits.Add(1)
+		a.last.Store(now.UnixNano())
+		if s.lru != nil {
+			s.lru.touch(key)
+		}
+	}
+}
+
+// hits returns the number of recorded accesses to key. The caller must hold the lock.
+func (s *MemoryStore) hits(key string) uint64 {
+	if a, ok := s.access[key]; ok {
+		return a.hits.Load()
+	}
+	return 0
+}
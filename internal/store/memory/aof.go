@@ -0,0 +1,463 @@
+package memory
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Name of the append-only file writer in health reports.
+const aofWorkerName = "aof"
+
+// FsyncPolicy selects how often an AOFWriter flushes the file to disk. Every record is
+// written to the file as soon as the mutation happens either way; the policy only
+// decides how much of it a machine crash, rather than a process crash, can lose.
+type FsyncPolicy string
+
+const (
+	// FsyncAlways syncs after every record. Nothing acknowledged is lost, at the cost
+	// of a disk flush per write.
+	FsyncAlways FsyncPolicy = "always"
+	// FsyncEverySec syncs once a second, losing at most the last second of writes.
+	FsyncEverySec FsyncPolicy = "everysec"
+	// FsyncNo never syncs and leaves flushing to the operating system.
+	FsyncNo FsyncPolicy = "no"
+)
+
+// Operations of AOF records.
+const (
+	aofSet     = "set"
+	aofDel     = "del"
+	aofFlush   = "flush"
+	aofPush    = "push"
+	aofPop     = "pop"
+	aofReserve = "reserve"
+	aofAck     = "ack"
+)
+
+// aofRecord is a line of the append-only file. Rather than the operation that caused
+// it, a record mostly holds the resulting state of the key, so every mutation, from
+// Set to a TTL expiry, replays exactly by setting or deleting the key. A push to or a
+// pop from an existing list is recorded as the operation itself, so queues do not
+// write out their whole content on every operation, and so is FlushAll, as a record
+// without a key. Reserve is recorded as a pop that keeps the item in flight under its
+// receipt, and the end of a reservation, acknowledged or requeued, as an ack.
+//
+// Keys, values, list items, tags and hash and set content are recorded as bytes,
+// base64 in the JSON line, as they need not be valid UTF-8.
+type aofRecord struct {
+	Op  string `json:"op"`
+	Key []byte `json:"key,omitempty"`
+
+	// Val is the string value, and Gzip the value instead when it is stored compressed.
+	Val     []byte     `json:"val,omitempty"`
+	Gzip    []byte     `json:"gzip,omitempty"`
+	TTL     *time.Time `json:"ttl,omitempty"`
+	IsJSON  bool       `json:"is_json,omitempty"`
+	IsList  bool       `json:"is_list,omitempty"`
+	List    [][]byte   `json:"list,omitempty"`
+	Seqs    []uint64   `json:"seqs,omitempty"`
+	LastSeq uint64     `json:"last_seq,omitempty"`
+	Tags    [][]byte   `json:"tags,omitempty"`
+	// PushedAt are the push times of List, or of Item for a push, if recorded.
+	PushedAt []time.Time `json:"pushed_at,omitempty"`
+
+	// Item is the item pushed, in its stored form, numbered Seq. The list is then
+	// trimmed to MaxLen items if it is positive. Tail selects the end of the list
+	// pushed to or popped from.
+	Item   []byte `json:"item,omitempty"`
+	Seq    uint64 `json:"seq,omitempty"`
+	MaxLen int    `json:"max_len,omitempty"`
+	Tail   bool   `json:"tail,omitempty"`
+
+	// Receipt is the receipt of a reservation, in flight until Until.
+	Receipt string     `json:"receipt,omitempty"`
+	Until   *time.Time `json:"until,omitempty"`
+
+	IsHash bool `json:"is_hash,omitempty"`
+	// Hash are the fields of a hash, sorted by name.
+	Hash  []aofField `json:"hash,omitempty"`
+	IsSet bool       `json:"is_set,omitempty"`
+	// Set are the members of a set, sorted.
+	Set [][]byte `json:"set,omitempty"`
+}
+
+// aofField is a field of a hash in an aofRecord.
+type aofField struct {
+	Name []byte `json:"name"`
+	Val  []byte `json:"val"`
+}
+
+func setRecord(key string, v Value) aofRecord {
+	r := aofRecord{
+		Op:       aofSet,
+		Key:      []byte(key),
+		IsJSON:   v.IsJSON,
+		IsList:   v.IsList,
+		List:     byteStrings(v.List),
+		Seqs:     v.Seqs,
+		LastSeq:  v.LastSeq,
+		Tags:     byteStrings(v.Tags),
+		IsHash:   v.IsHash,
+		IsSet:    v.IsSet,
+		PushedAt: v.PushedAt,
+	}
+	if v.IsHash {
+		names := make([]string, 0, len(v.Hash))
+		for name := range v.Hash {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		r.Hash = make([]aofField, len(names))
+		for i, name := range names {
+			r.Hash[i] = aofField{Name: []byte(name), Val: []byte(v.Hash[name])}
+		}
+	}
+	if v.IsSet {
+		r.Set = byteStrings(members(v))
+	}
+	if v.Compressed {
+		r.Gzip = []byte(v.Val)
+	} else {
+		r.Val = []byte(v.Val)
+	}
+	if !v.TTL.IsZero() {
+		ttl := v.TTL
+		r.TTL = &ttl
+	}
+	return r
+}
+
+func (r aofRecord) value() Value {
+	v := Value{
+		Val:      string(r.Val),
+		IsJSON:   r.IsJSON,
+		IsList:   r.IsList,
+		List:     stringsOf(r.List),
+		Seqs:     r.Seqs,
+		LastSeq:  r.LastSeq,
+		Tags:     stringsOf(r.Tags),
+		IsHash:   r.IsHash,
+		IsSet:    r.IsSet,
+		PushedAt: r.PushedAt,
+	}
+	if r.IsHash {
+		v.Hash = make(map[string]string, len(r.Hash))
+		for _, f := range r.Hash {
+			v.Hash[string(f.Name)] = string(f.Val)
+		}
+	}
+	if r.IsSet {
+		v.Set = make(map[string]struct{}, len(r.Set))
+		for _, member := range r.Set {
+			v.Set[string(member)] = struct{}{}
+		}
+	}
+	if r.Gzip != nil {
+		v.Val, v.Compressed = string(r.Gzip), true
+	}
+	if r.TTL != nil {
+		v.TTL = *r.TTL
+	}
+	return v
+}
+
+// byteStrings returns ss as byte slices, nil if ss is empty.
+func byteStrings(ss []string) [][]byte {
+	if len(ss) == 0 {
+		return nil
+	}
+	b := make([][]byte, len(ss))
+	for i, s := range ss {
+		b[i] = []byte(s)
+	}
+	return b
+}
+
+// stringsOf is the reverse of byteStrings.
+func stringsOf(b [][]byte) []string {
+	if len(b) == 0 {
+		return nil
+	}
+	ss := make([]string, len(b))
+	for i, item := range b {
+		ss[i] = string(item)
+	}
+	return ss
+}
+
+// AOFWriter appends a record of every mutation of a MemoryStore to an append-only
+// file, one JSON object per line, so the store can be rebuilt with ReplayAOF after a
+// crash. Pass it in Options.AOF. Records are written under the store write lock, in
+// the order of the mutations.
+//
+// The file only grows, as nothing is compacted. Pushes and pops on a list are recorded
+// as such, but any other change to a list, hash or set records its full content.
+// Reserved items are recorded too, so they are not lost with the process.
+type AOFWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	policy FsyncPolicy
+	buf    bytes.Buffer
+	// dirty is set when records were written since the last sync.
+	dirty  bool
+	err    error
+	cancel context.CancelFunc
+}
+
+// syncer is implemented by files.
+type syncer interface {
+	Sync() error
+}
+
+// NewAOFWriter returns an AOFWriter appending to w, typically a file opened with
+// os.O_APPEND, and syncing it according to policy if it is a file. An empty policy
+// means FsyncEverySec. Close the writer to sync and stop the background flushing.
+func NewAOFWriter(w io.Writer, policy FsyncPolicy) (*AOFWriter, error) {
+	switch policy {
+	case "":
+		policy = FsyncEverySec
+	case FsyncAlways, FsyncEverySec, FsyncNo:
+	default:
+		return nil, fmt.Errorf("unknown fsync policy %q", policy)
+	}
+
+	a := &AOFWriter{w: w, policy: policy}
+	if policy == FsyncEverySec {
+		ctx, cancel := context.WithCancel(context.Background())
+		a.cancel = cancel
+		go a.syncEverySecond(ctx)
+	}
+	return a, nil
+}
+
+// append writes r to the file. Once a write or sync has failed, the error is returned
+// for every later record, as the file no longer reflects the store.
+func (a *AOFWriter) append(r aofRecord) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.err != nil {
+		return a.err
+	}
+
+	a.buf.Reset()
+	if err := json.NewEncoder(&a.buf).Encode(r); err != nil {
+		return err
+	}
+	if _, err := a.w.Write(a.buf.Bytes()); err != nil {
+		a.err = fmt.Errorf("write append-only file: %w", err)
+		return a.err
+	}
+
+	a.dirty = true
+	if a.policy == FsyncAlways {
+		return a.syncLocked()
+	}
+	return nil
+}
+
+func (a *AOFWriter) syncLocked() error {
+	f, ok := a.w.(syncer)
+	if !ok || !a.dirty {
+		return nil
+	}
+	a.dirty = false
+	if err := f.Sync(); err != nil {
+		a.err = fmt.Errorf("sync append-only file: %w", err)
+		return a.err
+	}
+	return nil
+}
+
+func (a *AOFWriter) syncEverySecond(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			a.mu.Lock()
+			if a.err == nil {
+				a.syncLocked()
+			}
+			a.mu.Unlock()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Close stops the background syncing and syncs the file one last time, whatever the
+// policy. It does not close the underlying writer.
+func (a *AOFWriter) Close() error {
+	if a.cancel != nil {
+		a.cancel()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.err != nil {
+		return a.err
+	}
+	return a.syncLocked()
+}
+
+// logMutation appends the new state of key to the append-only file, v being nil when
+// the key was deleted. Failures are reported as the health of the aof worker rather
+// than failing the mutation, which has already been applied. The caller must hold
+// the write lock.
+func (s *MemoryStore) logMutation(key string, v *Value) {
+	if s.aof == nil {
+		return
+	}
+
+	r := aofRecord{Op: aofDel, Key: []byte(key)}
+	if v != nil {
+		r = setRecord(key, *v)
+	}
+	s.logRecord(r)
+}
+
+// logRecord appends r to the append-only file, like logMutation. The caller must hold
+// the write lock.
+func (s *MemoryStore) logRecord(r aofRecord) {
+	if s.aof == nil {
+		return
+	}
+	if err := s.aof.append(r); err != nil {
+		s.health.report(aofWorkerName, err, s.clock.Now())
+	}
+}
+
+// ReplayAOF rebuilds the store from an append-only file written through an AOFWriter,
+// applying its records in order on top of the current content. Keys that have expired
+// by the time of the replay are left out. Replayed records are not written
+// to the store's own AOFWriter again, and are applied regardless of the key and
+// memory limits, as they describe a state the store already held.
+//
+// A last record cut short by a crash, with no line ending, is ignored; any other
+// record that cannot be decoded fails the replay, leaving the records before it
+// applied.
+//
+// Reserved items that were not acknowledged are in flight again, and those whose
+// visibility timeout passed in the meantime are put back in their list, which is
+// recorded.
+func (s *MemoryStore) ReplayAOF(r io.Reader) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	aof := s.aof
+	s.aof = nil
+	err := s.replayAOF(r)
+	s.aof = aof
+
+	s.requeueExpired(s.clock.Now())
+	return err
+}
+
+// replayAOF applies the records read from r, see ReplayAOF. The caller must hold the
+// write lock.
+func (s *MemoryStore) replayAOF(r io.Reader) error {
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		b, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var rec aofRecord
+		if err := json.Unmarshal(b, &rec); err != nil {
+			return fmt.Errorf("append-only file line %d: %w", line, err)
+		}
+
+		switch rec.Op {
+		case aofSet:
+			v := rec.value()
+			if !v.TTL.IsZero() && s.clock.Now().After(v.TTL) {
+				s.del(string(rec.Key))
+				continue
+			}
+			s.put(string(rec.Key), v)
+		case aofDel:
+			s.del(string(rec.Key))
+		case aofFlush:
+			s.flush()
+		case aofPush, aofPop:
+			if err := s.replayListOp(rec); err != nil {
+				return fmt.Errorf("append-only file line %d: %w", line, err)
+			}
+		case aofReserve:
+			if err := s.replayReserve(rec); err != nil {
+				return fmt.Errorf("append-only file line %d: %w", line, err)
+			}
+		case aofAck:
+			s.dropInflight(rec.Receipt)
+		default:
+			return fmt.Errorf("append-only file line %d: unknown operation %q", line, rec.Op)
+		}
+	}
+}
+
+// replayListOp applies a push or pop record to the list at its key. A key gone by the
+// time of the replay is left alone: its list expired, and the operation with it.
+func (s *MemoryStore) replayListOp(rec aofRecord) error {
+	key := string(rec.Key)
+	v, exists := s.data[key]
+	if !exists {
+		return nil
+	}
+	if !v.TTL.IsZero() && s.clock.Now().After(v.TTL) {
+		s.del(key)
+		return nil
+	}
+	if !v.IsList {
+		return fmt.Errorf("%s on %q, which does not hold a list", rec.Op, key)
+	}
+
+	if rec.Op == aofPop {
+		if len(v.List) == 0 {
+			return fmt.Errorf("pop from %q, which holds an empty list", key)
+		}
+		s.putListOp(key, v.popItem(rec.Tail), rec)
+		return nil
+	}
+
+	var at time.Time
+	if len(rec.PushedAt) > 0 {
+		at = rec.PushedAt[0]
+	}
+	v, _ = v.pushItem(string(rec.Item), rec.Seq, rec.Tail, rec.MaxLen, at)
+	s.putListOp(key, v, rec)
+	return nil
+}
+
+// replayReserve applies a reserve record: the item is popped from its list, if the list
+// has not expired since, and put in flight under the receipt either way, as it was
+// when the record was written.
+func (s *MemoryStore) replayReserve(rec aofRecord) error {
+	pop := rec
+	pop.Op = aofPop
+	if err := s.replayListOp(pop); err != nil {
+		return err
+	}
+
+	r := reservation{key: string(rec.Key), item: string(rec.Item), seq: rec.Seq}
+	if len(rec.PushedAt) > 0 {
+		r.pushedAt = rec.PushedAt[0]
+	}
+	if rec.Until != nil {
+		r.until = *rec.Until
+	}
+	s.putInflight(rec.Receipt, r)
+	return nil
+}
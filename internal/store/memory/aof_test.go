@@ -0,0 +1,271 @@
+package memory_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+func TestAOFReplay(t *testing.T) {
+	clock := newFakeClock()
+	path := filepath.Join(t.TempDir(), "store.aof")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("Failed to open AOF: %v", err)
+	}
+	defer f.Close()
+	aof, err := memory.NewAOFWriter(f, memory.FsyncAlways)
+	if err != nil {
+		t.Fatalf("NewAOFWriter failed: %v", err)
+	}
+
+	store := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock, AOF: aof, CompressThreshold: 64})
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	long := strings.Repeat("compressible ", 20)
+	store.Set(ctx, "name", "alice", 0)
+	store.Update(ctx, "name", "bob")
+	store.Set(ctx, "count", 1, 0)
+	store.Increment(ctx, "count", 41)
+	store.Set(ctx, "big", long, 0)
+	store.Set(ctx, "gone", "x", 0)
+	store.Remove(ctx, "gone")
+	store.Set(ctx, "session", "token", 0)
+	store.Expire(ctx, "session", 60)
+	store.Set(ctx, "short", "x", 5)
+	store.RPush(ctx, "queue", "a")
+	store.RPush(ctx, "queue", "b")
+	store.RPush(ctx, "queue", "c")
+	store.Pop(ctx, "queue")
+
+	// The process is killed: the writer is never closed and the store never shut down.
+	// A new store is rebuilt from the file after some downtime.
+	clock.Advance(10 * time.Second)
+	replayed := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock})
+	defer replayed.StopTTLWorker()
+	r, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open AOF: %v", err)
+	}
+	defer r.Close()
+	if err := replayed.ReplayAOF(r); err != nil {
+		t.Fatalf("ReplayAOF failed: %v", err)
+	}
+
+	if got, _ := replayed.Get(ctx, "name"); got != "bob" {
+		t.Errorf("Expected the update to be replayed, got %q", got)
+	}
+	if got, _ := replayed.Get(ctx, "count"); got != "42" {
+		t.Errorf("Expected 42, got %q", got)
+	}
+	if got, _ := replayed.Get(ctx, "big"); got != long {
+		t.Errorf("Expected the compressed value intact, got %q", got)
+	}
+	if _, err := replayed.Get(ctx, "gone"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected the removed key to stay removed, got %v", err)
+	}
+	if _, err := replayed.Get(ctx, "short"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected the key that expired while down to be skipped, got %v", err)
+	}
+	if ttl, _ := replayed.TTL(ctx, "session"); ttl != 50 {
+		t.Errorf("Expected 50 seconds left on session, got %d", ttl)
+	}
+	page, _ := replayed.LRangePage(ctx, "queue", 0, -1)
+	if !reflect.DeepEqual(page.Items, []string{"b", "c"}) || !reflect.DeepEqual(page.Seqs, []uint64{2, 3}) {
+		t.Errorf("Expected list [b c] with seqs [2 3], got %v %v", page.Items, page.Seqs)
+	}
+}
+
+func TestAOFReplayListOps(t *testing.T) {
+	clock := newFakeClock()
+	ctx := context.Background()
+	var buf bytes.Buffer
+	aof, _ := memory.NewAOFWriter(&buf, memory.FsyncNo)
+	opts := memory.Options{Clock: clock, AOF: aof, ListPushTimes: true, CompressThreshold: 64}
+	s := memory.NewMemoryStoreWithOptions(opts)
+	defer s.StopTTLWorker()
+
+	s.RPush(ctx, "queue", "a")
+	clock.Advance(time.Second)
+	s.PushItem(ctx, "queue", "b", store.PushOptions{Tail: true})
+	clock.Advance(time.Second)
+	s.PushItem(ctx, "queue", "c", store.PushOptions{MaxLen: 2})
+	s.RPush(ctx, "queue", strings.Repeat("compressible ", 20))
+	s.RPush(ctx, "queue", "d")
+	s.RPop(ctx, "queue")
+	s.Pop(ctx, "queue")
+	s.Reserve(ctx, "queue", time.Minute)
+	s.RPush(ctx, "queue", "e")
+
+	// Only the push that created the list records it whole
+	if sets := strings.Count(buf.String(), `"op":"set"`); sets != 1 {
+		t.Errorf("Expected a single full record of the list, got %d in\n%s", sets, buf.String())
+	}
+
+	opts.AOF = nil
+	replayed := memory.NewMemoryStoreWithOptions(opts)
+	defer replayed.StopTTLWorker()
+	if err := replayed.ReplayAOF(&buf); err != nil {
+		t.Fatalf("ReplayAOF failed: %v", err)
+	}
+
+	want, _ := s.LRangeWithMeta(ctx, "queue", 0, -1)
+	got, _ := replayed.LRangeWithMeta(ctx, "queue", 0, -1)
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v replayed, got %v", want, got)
+	}
+	for i := range want {
+		if got[i].Value != want[i].Value || got[i].Seq != want[i].Seq || !got[i].PushedAt.Equal(want[i].PushedAt) {
+			t.Errorf("Expected item %d to be %+v, got %+v", i, want[i], got[i])
+		}
+	}
+	if seq, _ := replayed.PushItem(ctx, "queue", "f", store.PushOptions{}); seq.Seq != 7 {
+		t.Errorf("Expected the sequence to carry on at 7, got %d", seq.Seq)
+	}
+	if corrupt, _ := replayed.VerifyIntegrity(ctx); len(corrupt) != 0 {
+		t.Errorf("Expected the replayed list intact, got %v", corrupt)
+	}
+}
+
+func TestAOFReplayReserve(t *testing.T) {
+	clock := newFakeClock()
+	ctx := context.Background()
+	var buf bytes.Buffer
+	aof, _ := memory.NewAOFWriter(&buf, memory.FsyncNo)
+	s := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock, AOF: aof})
+	defer s.StopTTLWorker()
+
+	for _, item := range []string{"a", "b", "c", "d"} {
+		s.RPush(ctx, "jobs", item)
+	}
+	_, acked, _ := s.Reserve(ctx, "jobs", time.Minute)
+	_, pending, _ := s.Reserve(ctx, "jobs", time.Minute)
+	_, lapsed, _ := s.Reserve(ctx, "jobs", 10*time.Second)
+	s.Ack(ctx, acked)
+
+	// The process dies with two reservations in flight, one of which times out while down
+	clock.Advance(20 * time.Second)
+	var replayedAOF bytes.Buffer
+	aof, _ = memory.NewAOFWriter(&replayedAOF, memory.FsyncNo)
+	replayed := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock, AOF: aof})
+	defer replayed.StopTTLWorker()
+	if err := replayed.ReplayAOF(&buf); err != nil {
+		t.Fatalf("ReplayAOF failed: %v", err)
+	}
+
+	if items, _ := replayed.LRange(ctx, "jobs", 0, -1); !reflect.DeepEqual(items, []string{"c", "d"}) {
+		t.Errorf("Expected the lapsed item requeued ahead of d, got %v", items)
+	}
+	if err := replayed.Ack(ctx, pending); err != nil {
+		t.Errorf("Expected the pending reservation to survive, got %v", err)
+	}
+	for _, receipt := range []string{acked, lapsed} {
+		if err := replayed.Ack(ctx, receipt); err != memory.ErrReceiptNotFound {
+			t.Errorf("Expected ErrReceiptNotFound for a settled reservation, got %v", err)
+		}
+	}
+
+	// The requeue on load is recorded, so the next replay does not requeue it again
+	if !strings.Contains(replayedAOF.String(), `"op":"ack"`) {
+		t.Errorf("Expected the requeue to be recorded, got\n%s", replayedAOF.String())
+	}
+}
+
+func TestAOFReplayBinary(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	aof, _ := memory.NewAOFWriter(&buf, memory.FsyncNo)
+	store := memory.NewMemoryStoreWithOptions(memory.Options{AOF: aof})
+	defer store.StopTTLWorker()
+
+	// None of these are valid UTF-8, which a JSON string would have replaced
+	key, val := "k\xff\xfe", "v\x80\x81"
+	store.Set(ctx, key, val, 0)
+	store.RPush(ctx, "queue\xff", val)
+	store.HSet(ctx, "hash", "f\xfe", val)
+	store.SAdd(ctx, "set", []string{"m\x80"})
+
+	replayed := memory.NewMemoryStore()
+	defer replayed.StopTTLWorker()
+	if err := replayed.ReplayAOF(&buf); err != nil {
+		t.Fatalf("ReplayAOF failed: %v", err)
+	}
+
+	if got, err := replayed.Get(ctx, key); err != nil || got != val {
+		t.Errorf("Expected %q under the binary key, got %q, %v", val, got, err)
+	}
+	if items, _ := replayed.LRange(ctx, "queue\xff", 0, -1); !reflect.DeepEqual(items, []string{val}) {
+		t.Errorf("Expected the binary list item intact, got %q", items)
+	}
+	if got, _ := replayed.HGet(ctx, "hash", "f\xfe"); got != val {
+		t.Errorf("Expected the binary hash field intact, got %q", got)
+	}
+	if members, _ := replayed.SMembers(ctx, "set"); !reflect.DeepEqual(members, []string{"m\x80"}) {
+		t.Errorf("Expected the binary set member intact, got %q", members)
+	}
+}
+
+func TestAOFReplayTornRecord(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	aof, _ := memory.NewAOFWriter(&buf, memory.FsyncNo)
+	store := memory.NewMemoryStoreWithOptions(memory.Options{AOF: aof})
+	defer store.StopTTLWorker()
+
+	store.Set(ctx, "a", "1", 0)
+	store.Set(ctx, "b", "2", 0)
+	complete := buf.Len()
+
+	// A crash while writing leaves the last record cut short
+	torn := append([]byte(nil), buf.Bytes()...)
+	torn = append(torn, `{"op":"set","key":"Yw==","va`...)
+	replayed := memory.NewMemoryStore()
+	defer replayed.StopTTLWorker()
+	if err := replayed.ReplayAOF(bytes.NewReader(torn)); err != nil {
+		t.Fatalf("Expected a torn last record to be ignored, got %v", err)
+	}
+	if keys, _ := replayed.Keys(ctx, "*"); len(keys) != 2 {
+		t.Errorf("Expected the 2 complete records replayed, got %v", keys)
+	}
+
+	// Anything else that cannot be decoded fails the replay
+	corrupt := append([]byte("not json\n"), buf.Bytes()[:complete]...)
+	if err := replayed.ReplayAOF(bytes.NewReader(corrupt)); err == nil {
+		t.Error("Expected a corrupt record to fail the replay")
+	}
+
+	if _, err := memory.NewAOFWriter(&buf, "sometimes"); err == nil {
+		t.Error("Expected an unknown fsync policy to be rejected")
+	}
+}
+
+func TestAOFReplayFlush(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	aof, _ := memory.NewAOFWriter(&buf, memory.FsyncNo)
+	store := memory.NewMemoryStoreWithOptions(memory.Options{AOF: aof})
+	defer store.StopTTLWorker()
+
+	store.Set(ctx, "before", "1", 0)
+	store.RPush(ctx, "queue", "a")
+	store.FlushAll(ctx)
+	store.Set(ctx, "after", "2", 0)
+
+	replayed := memory.NewMemoryStore()
+	defer replayed.StopTTLWorker()
+	if err := replayed.ReplayAOF(&buf); err != nil {
+		t.Fatalf("ReplayAOF failed: %v", err)
+	}
+	if keys, _ := replayed.Keys(ctx, "*"); !reflect.DeepEqual(keys, []string{"after"}) {
+		t.Errorf("Expected only the key set after the flush, got %v", keys)
+	}
+}
@@ -0,0 +1,94 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// listWaiter is shared by the BLPop calls waiting on a list. Its wake channel is closed
+// once an item is pushed, waking all of them; only the first to take the write lock
+// gets the item, the others wait again.
+type listWaiter struct {
+	wake chan struct{}
+	// n counts the waiting calls, so the last one to give up removes the waiter.
+	n int
+}
+
+// BLPop takes the item at the front of a list like Pop, waiting for one to be pushed if
+// the list is empty or missing. It returns ErrTimeout once timeout elapses without an
+// item, or the context error if ctx is done first; a timeout of zero or less waits
+// until then. Waiting calls are woken by pushes rather than polling, and are not served
+// in any particular order. BLPop returns ErrTypeMismatch right away for keys not
+// holding a list.
+func (s *MemoryStore) BLPop(ctx context.Context, key string, timeout time.Duration, opts ...store.WriteOption) (string, error) {
+	o := store.NewWriteOptions(opts)
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		if err := s.lock(ctx); err != nil {
+			return "", err
+		}
+		item, err := s.popLocked(key, o)
+		if !errors.Is(err, ErrKeyNotFound) && !errors.Is(err, ErrEmptyList) {
+			s.mu.Unlock()
+			return item, err
+		}
+		waiter := s.waitForItem(key)
+		s.mu.Unlock()
+
+		select {
+		case <-waiter.wake:
+			continue
+		case <-expired:
+			s.stopWaiting(key, waiter)
+			return "", ErrTimeout
+		case <-ctx.Done():
+			s.stopWaiting(key, waiter)
+			return "", ctx.Err()
+		}
+	}
+}
+
+// waitForItem registers a BLPop call waiting for an item to be pushed to the list at
+// key. The caller must hold the write lock.
+func (s *MemoryStore) waitForItem(key string) *listWaiter {
+	waiter, ok := s.listWaiters[key]
+	if !ok {
+		waiter = &listWaiter{wake: make(chan struct{})}
+		s.listWaiters[key] = waiter
+	}
+	waiter.n++
+	return waiter
+}
+
+// stopWaiting unregisters a BLPop call that gave up waiting on waiter.
+func (s *MemoryStore) stopWaiting(key string, waiter *listWaiter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A woken waiter has already been removed.
+	if s.listWaiters[key] != waiter {
+		return
+	}
+	waiter.n--
+	if waiter.n == 0 {
+		delete(s.listWaiters, key)
+	}
+}
+
+// wakeListWaiters wakes the BLPop calls waiting on the list at key. The caller must
+// hold the write lock.
+func (s *MemoryStore) wakeListWaiters(key string) {
+	if waiter, ok := s.listWaiters[key]; ok {
+		close(waiter.wake)
+		delete(s.listWaiters, key)
+	}
+}
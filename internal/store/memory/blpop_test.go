@@ -0,0 +1,104 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+func TestBLPop(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	// An item already queued is taken right away
+	store.Push(ctx, "queue", "first")
+	if item, err := store.BLPop(ctx, "queue", time.Second); err != nil || item != "first" {
+		t.Fatalf("Expected first, got %q (err %v)", item, err)
+	}
+
+	// Otherwise BLPop waits for a push, to an empty or a missing list
+	for _, key := range []string{"queue", "missing"} {
+		result := make(chan string)
+		go func() {
+			item, _ := store.BLPop(ctx, key, 5*time.Second)
+			result <- item
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+		store.Push(ctx, key, "pushed")
+		select {
+		case item := <-result:
+			if item != "pushed" {
+				t.Errorf("%s: expected the pushed item, got %q", key, item)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s: expected BLPop to be woken by the push", key)
+		}
+	}
+	if n, _ := store.LLen(ctx, "missing"); n != 0 {
+		t.Errorf("Expected the item taken by BLPop, got %d items left", n)
+	}
+}
+
+func TestBLPopOneItemPerWaiter(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	results := make(chan string, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			item, err := store.BLPop(ctx, "jobs", 500*time.Millisecond)
+			if err != nil {
+				item = err.Error()
+			}
+			results <- item
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	store.Push(ctx, "jobs", "a")
+	store.Push(ctx, "jobs", "b")
+
+	got := map[string]int{}
+	for i := 0; i < 3; i++ {
+		got[<-results]++
+	}
+	if got["a"] != 1 || got["b"] != 1 || got[memory.ErrTimeout.Error()] != 1 {
+		t.Errorf("Expected each item taken once and one waiter timed out, got %v", got)
+	}
+}
+
+func TestBLPopTimeoutAndCancel(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+
+	start := time.Now()
+	if _, err := store.BLPop(context.Background(), "queue", 30*time.Millisecond); !errors.Is(err, memory.ErrTimeout) {
+		t.Errorf("Expected ErrTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("Expected BLPop to wait for the timeout, returned after %v", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := store.BLPop(ctx, "queue", 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context error without a timeout, got %v", err)
+	}
+
+	// Waiters that gave up do not get items pushed later
+	store.Push(context.Background(), "queue", "item")
+	if n, _ := store.LLen(context.Background(), "queue"); n != 1 {
+		t.Errorf("Expected the item left in the list, got %d items", n)
+	}
+
+	store.Set(context.Background(), "string", "value", 0)
+	if _, err := store.BLPop(context.Background(), "string", time.Minute); !errors.Is(err, memory.ErrTypeMismatch) {
+		t.Errorf("Expected ErrTypeMismatch right away, got %v", err)
+	}
+}
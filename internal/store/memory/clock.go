@@ -0,0 +1,17 @@
+package memory
+
+import "time"
+
+// Clock tells the store the current time. It decides when keys expire, rate windows
+// slide, reservations time out and soft deletes become final. Tests can substitute
+// a fake clock to advance time instantly instead of sleeping.
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock is the Clock used unless one is configured, reading the system time.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
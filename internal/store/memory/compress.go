@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Gzip writers and readers are pooled, as a writer allocates close to a megabyte.
+var (
+	gzipWriters = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
+	gzipReaders sync.Pool
+)
+
+// encode returns the stored form of a string value and whether it is compressed.
+// Values longer than the compression threshold are gzip compressed unless noCompress
+// is set, see store.NoCompress, or compressing would not make them smaller.
+func (s *MemoryStore) encode(val string, noCompress bool) (string, bool) {
+	if s.compressThreshold <= 0 || len(val) <= s.compressThreshold || noCompress {
+		return val, false
+	}
+
+	var buf bytes.Buffer
+	zw := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(zw)
+	zw.Reset(&buf)
+	if _, err := io.WriteString(zw, val); err != nil {
+		return val, false
+	}
+	if err := zw.Close(); err != nil {
+		return val, false
+	}
+	if buf.Len() >= len(val) {
+		return val, false
+	}
+	return buf.String(), true
+}
+
+// text returns the string value of v, decompressing it if needed.
+func (v Value) text() string {
+	if !v.Compressed {
+		return v.Val
+	}
+	return gunzipString(v.Val)
+}
+
+// gunzipString returns the decompressed content of a value compressed by encode.
+func gunzipString(val string) string {
+	zr, err := gzipReader(strings.NewReader(val))
+	if err != nil {
+		// Only the store compresses values, so this cannot happen
+		return ""
+	}
+	defer gzipReaders.Put(zr)
+	var sb strings.Builder
+	if _, err := io.Copy(&sb, zr); err != nil {
+		return ""
+	}
+	return sb.String()
+}
+
+// gzipReader returns a pooled gzip reader reading r. Put it back in gzipReaders once
+// done.
+func gzipReader(r io.Reader) (*gzip.Reader, error) {
+	if zr, ok := gzipReaders.Get().(*gzip.Reader); ok {
+		return zr, zr.Reset(r)
+	}
+	return gzip.NewReader(r)
+}
+
+// List items have no room for a per-value flag, so each item records whether it is
+// compressed itself. A compressed item is stored as itemMarker followed by the gzip
+// stream, which starts with gzipMagic. Items that start with itemMarker as they are
+// get a second itemMarker in front, so every other item is stored unchanged.
+const (
+	itemMarker = '\x00'
+	gzipMagic  = '\x1f'
+)
+
+// storedItem stringifies a list item, checks it against the list item size limit and
+// returns its stored form, compressed under the same rules as string values, see
+// encode. Like encode, call it before taking the lock.
+func (s *MemoryStore) storedItem(item any) (string, error) {
+	stringItem, err := s.Stringify(item)
+	if err != nil {
+		return "", ErrMarshalFailed
+	}
+	if err := s.checkListItem(stringItem); err != nil {
+		return "", err
+	}
+
+	if stored, compressed := s.encode(stringItem, false); compressed {
+		return string(itemMarker) + stored, nil
+	}
+	if stringItem != "" && stringItem[0] == itemMarker {
+		return string(itemMarker) + stringItem, nil
+	}
+	return stringItem, nil
+}
+
+// itemText returns the list item stored as stored, decompressing it if needed.
+func itemText(stored string) string {
+	if stored == "" || stored[0] != itemMarker {
+		return stored
+	}
+	if len(stored) > 1 && stored[1] == gzipMagic {
+		return gunzipString(stored[1:])
+	}
+	return stored[1:]
+}
+
+// itemTexts returns a copy of the list items stored as stored, decompressed.
+func itemTexts(stored []string) []string {
+	items := make([]string, len(stored))
+	for i, item := range stored {
+		items[i] = itemText(item)
+	}
+	return items
+}
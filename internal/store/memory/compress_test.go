@@ -0,0 +1,201 @@
+package memory_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+// storedSize returns the estimated size of key, which reflects its stored form.
+func storedSize(t *testing.T, s *memory.MemoryStore, key string) int {
+	t.Helper()
+
+	keys, err := s.TopKeysBySize(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("TopKeysBySize failed: %v", err)
+	}
+	for _, k := range keys {
+		if k.Key == key {
+			return k.SizeBytes
+		}
+	}
+	t.Fatalf("Key %q not found", key)
+	return 0
+}
+
+func TestCompression(t *testing.T) {
+	ctx := context.Background()
+	doc := strings.Repeat(`{"name":"żółw","tags":["a","b"]}`, 100)
+
+	for _, readIndex := range []bool{false, true} {
+		t.Run(fmt.Sprintf("read_index=%t", readIndex), func(t *testing.T) {
+			s := memory.NewMemoryStoreWithOptions(memory.Options{CompressThreshold: 64, ReadIndex: readIndex})
+			defer s.StopTTLWorker()
+
+			if err := s.Set(ctx, "compressed", doc, 0); err != nil {
+				t.Fatalf("Set failed: %v", err)
+			}
+			if err := s.Set(ctx, "plain", doc, 0, store.NoCompress()); err != nil {
+				t.Fatalf("Set failed: %v", err)
+			}
+			if err := s.Set(ctx, "small", "tiny", 0); err != nil {
+				t.Fatalf("Set failed: %v", err)
+			}
+
+			if size := storedSize(t, s, "compressed"); size >= len(doc) {
+				t.Errorf("Expected the value to be stored compressed, got size %d for %d bytes", size, len(doc))
+			}
+			if size := storedSize(t, s, "plain"); size < len(doc) {
+				t.Errorf("Expected a compress-false value to be stored uncompressed, got size %d for %d bytes", size, len(doc))
+			}
+
+			for _, key := range []string{"compressed", "plain"} {
+				if got, err := s.Get(ctx, key); err != nil || got != doc {
+					t.Errorf("Expected %s to round-trip, got %d bytes and %v", key, len(got), err)
+				}
+				if got, _, err := s.GetAny(ctx, key); err != nil || got != doc {
+					t.Errorf("Expected GetAny of %s to round-trip, got %v", key, err)
+				}
+			}
+			if got, _ := s.Get(ctx, "small"); got != "tiny" {
+				t.Errorf("Expected tiny, got %q", got)
+			}
+
+			s.Update(ctx, "small", doc, store.NoCompress())
+			if size := storedSize(t, s, "small"); size < len(doc) {
+				t.Errorf("Expected an update opting out to store the value uncompressed, got size %d", size)
+			}
+
+			entries, _ := s.GetEntries(ctx, []string{"compressed"})
+			if entries[0].Value != doc {
+				t.Errorf("Expected GetEntries to return the decompressed value")
+			}
+		})
+	}
+}
+
+func TestCompression_Writes(t *testing.T) {
+	ctx := context.Background()
+	doc := strings.Repeat("abc", 100)
+
+	s := memory.NewMemoryStoreWithOptions(memory.Options{CompressThreshold: 64})
+	defer s.StopTTLWorker()
+
+	s.Set(ctx, "key", "small", 0)
+	if err := s.Update(ctx, "key", doc); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if size := storedSize(t, s, "key"); size >= len(doc) {
+		t.Errorf("Expected Update to compress the value, got size %d", size)
+	}
+
+	deleted, err := s.CompareAndDelete(ctx, "key", doc)
+	if err != nil || !deleted {
+		t.Errorf("Expected CompareAndDelete to match the decompressed value, got %v, %v", deleted, err)
+	}
+
+	if err := s.Transform(ctx, "key", func(old string, exists bool) (string, bool, error) {
+		return doc, false, nil
+	}); err != nil {
+		t.Fatalf("Transform failed: %v", err)
+	}
+	if err := s.Transform(ctx, "key", func(old string, exists bool) (string, bool, error) {
+		if old != doc {
+			t.Errorf("Expected Transform to see the decompressed value")
+		}
+		return old + "!", false, nil
+	}); err != nil {
+		t.Fatalf("Transform failed: %v", err)
+	}
+	if got, _ := s.Get(ctx, "key"); got != doc+"!" {
+		t.Errorf("Expected the transformed value, got %d bytes", len(got))
+	}
+
+	previous, err := s.SetReturningPrevious(ctx, "key", "new", 0)
+	if err != nil || previous == nil || previous.Value != doc+"!" {
+		t.Errorf("Expected the previous value decompressed, got %v", err)
+	}
+}
+
+func TestCompression_BinaryRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	s := memory.NewMemoryStoreWithOptions(memory.Options{CompressThreshold: 16})
+	defer s.StopTTLWorker()
+
+	// Control characters, NUL bytes, multi-byte runes and bytes that are not valid UTF-8
+	var b strings.Builder
+	for i := 0; i < 4096; i++ {
+		b.WriteByte(byte(i * 7))
+		if i%64 == 0 {
+			b.WriteString("\x00\t\r\n€𝄞żółw\xff\xfe")
+		}
+	}
+	values := map[string]string{
+		"binary":   b.String(),
+		"repeated": strings.Repeat("\x00\x01\x02\x03", 1000),
+		"unicode":  strings.Repeat("日本語のテキスト😀", 200),
+	}
+
+	for key, value := range values {
+		if err := s.Set(ctx, key, value, 0); err != nil {
+			t.Fatalf("Set %s failed: %v", key, err)
+		}
+		if got, err := s.Get(ctx, key); err != nil || got != value {
+			t.Errorf("Expected %s to round-trip losslessly, got %d of %d bytes and %v", key, len(got), len(value), err)
+		}
+	}
+
+	// Round trips hold for values stored compressed, not just the ones left as they are
+	if size := storedSize(t, s, "repeated"); size >= len(values["repeated"]) {
+		t.Errorf("Expected the repetitive value to be stored compressed, got size %d", size)
+	}
+}
+
+func TestCompression_ListItems(t *testing.T) {
+	ctx := context.Background()
+	var log bytes.Buffer
+	aof, err := memory.NewAOFWriter(&log, memory.FsyncNo)
+	if err != nil {
+		t.Fatalf("NewAOFWriter failed: %v", err)
+	}
+	s := memory.NewMemoryStoreWithOptions(memory.Options{CompressThreshold: 64, AOF: aof})
+	defer s.StopTTLWorker()
+
+	// Items that look like the stored form of a compressed item must come back as they are
+	items := []string{
+		strings.Repeat("compressible ", 100),
+		"small",
+		"\x00leading NUL",
+		"\x00\x1flooks compressed",
+		"",
+	}
+	for _, item := range items {
+		if err := s.RPush(ctx, "queue", item); err != nil {
+			t.Fatalf("RPush failed: %v", err)
+		}
+	}
+	if size := storedSize(t, s, "queue"); size >= len(items[0]) {
+		t.Errorf("Expected the large item to be stored compressed, got size %d", size)
+	}
+
+	if got, err := s.LRange(ctx, "queue", 0, -1); err != nil || !reflect.DeepEqual(got, items) {
+		t.Errorf("Expected LRange to return the items as pushed, got %q and %v", got, err)
+	}
+
+	replayed := memory.NewMemoryStore()
+	defer replayed.StopTTLWorker()
+	if err := replayed.ReplayAOF(bytes.NewReader(log.Bytes())); err != nil {
+		t.Fatalf("ReplayAOF failed: %v", err)
+	}
+	for _, want := range items {
+		if got, err := replayed.Pop(ctx, "queue"); err != nil || got != want {
+			t.Errorf("Expected the replayed list to pop %q, got %q and %v", want, got, err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package memory
+
+import "context"
+
+// Store operations honor their context: they fail with ctx.Err() without doing any
+// work if it is done before they get the store lock, and read-only operations going
+// over many keys, such as Keys or MGet, check it again as they go. Operations that
+// change many keys, such as ExpirePattern or DeleteByTag, run to completion once they
+// hold the lock, so they are never left half applied.
+
+// ctxCheckInterval is how many keys operations iterating over the whole store go
+// through between checks of their context.
+const ctxCheckInterval = 1024
+
+// lock acquires the write lock for an operation on behalf of ctx. It fails with
+// ctx.Err() instead, without the lock held, if ctx is done before the lock is acquired
+// or by the time it is, so a cancelled or timed out request does no work.
+func (s *MemoryStore) lock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if err := ctx.Err(); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// rlock is lock for the read lock.
+func (s *MemoryStore) rlock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.RLock()
+	if err := ctx.Err(); err != nil {
+		s.mu.RUnlock()
+		return err
+	}
+	return nil
+}
+
+// checkCtx returns ctx.Err() every ctxCheckInterval calls, i being the number of
+// keys iterated over so far, and nil otherwise.
+func checkCtx(ctx context.Context, i int) error {
+	if i%ctxCheckInterval != 0 {
+		return nil
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// Increment atomically adds delta to the integer held by a string key and returns the
+// new value. A missing key is created holding delta, with the default TTL of its
+// prefix; an existing key keeps its TTL. Increment returns ErrTypeMismatch for keys
+// not holding a string, ErrNotInteger if the value is not an integer and ErrIntegerOverflow
+// if the result does not fit in an int64.
+func (s *MemoryStore) Increment(ctx context.Context, key string, delta int64, opts ...store.WriteOption) (int64, error) {
+	value, _, err := s.addInt(ctx, key, delta, func(int64) bool { return true }, store.NewWriteOptions(opts))
+	return value, err
+}
+
+// Decrement atomically subtracts delta from the integer held by a string key, like
+// Increment with -delta.
+func (s *MemoryStore) Decrement(ctx context.Context, key string, delta int64, opts ...store.WriteOption) (int64, error) {
+	if delta == math.MinInt64 {
+		return 0, ErrIntegerOverflow
+	}
+	return s.Increment(ctx, key, -delta, opts...)
+}
+
+// DecrWithFloor atomically decrements the integer held by a string key by delta,
+// unless the result would drop below floor. It returns the resulting value and whether
+// the decrement was applied; if not, the key is left unchanged and its current value
+// is returned. This suits counters that must never go negative, such as inventory.
+//
+// A missing key counts as 0 and is created with the default TTL of its prefix if the
+// decrement is applied; an existing key keeps its TTL. DecrWithFloor returns
+// ErrTypeMismatch for keys not holding a string and ErrNotInteger if the value is not
+// an integer.
+func (s *MemoryStore) DecrWithFloor(ctx context.Context, key string, delta, floor int64, opts ...store.WriteOption) (int64, bool, error) {
+	if delta == math.MinInt64 {
+		return 0, false, ErrIntegerOverflow
+	}
+	return s.addInt(ctx, key, -delta, func(next int64) bool { return next >= floor }, store.NewWriteOptions(opts))
+}
+
+// IncrWithCeiling atomically increments the integer held by a string key by delta,
+// unless the result would exceed ceiling. It returns the resulting value and whether
+// the increment was applied; if not, the key is left unchanged and its current value
+// is returned. This suits quota counters that must stop at a maximum.
+//
+// A missing key counts as 0 and is created with the default TTL of its prefix if the
+// increment is applied; an existing key keeps its TTL. IncrWithCeiling returns
+// ErrTypeMismatch for keys not holding a string and ErrNotInteger if the value is not
+// an integer.
+func (s *MemoryStore) IncrWithCeiling(ctx context.Context, key string, delta, ceiling int64, opts ...store.WriteOption) (int64, bool, error) {
+	return s.addInt(ctx, key, delta, func(next int64) bool { return next <= ceiling }, store.NewWriteOptions(opts))
+}
+
+// addInt adds delta to the integer held by a string key if allow accepts the result,
+// and returns the resulting value and whether it was stored.
+func (s *MemoryStore) addInt(ctx context.Context, key string, delta int64, allow func(next int64) bool, o store.WriteOptions) (int64, bool, error) {
+	if err := s.lock(ctx); err != nil {
+		return 0, false, err
+	}
+	defer s.mu.Unlock()
+
+	if err := s.checkFence(key, o); err != nil {
+		return 0, false, err
+	}
+
+	v, err := s.liveString(key)
+	exists := err == nil
+	if err != nil && err != ErrKeyNotFound {
+		return 0, false, err
+	}
+
+	var current int64
+	if exists {
+		if current, err = strconv.ParseInt(v.text(), 10, 64); err != nil {
+			return 0, false, ErrNotInteger
+		}
+	}
+
+	next := current + delta
+	if (delta > 0 && next < current) || (delta < 0 && next > current) {
+		return current, false, ErrIntegerOverflow
+	}
+	if !allow(next) {
+		return current, false, nil
+	}
+
+	if !exists {
+		v.TTL = s.ttlFromSeconds(s.defaultTTL(key, 0))
+	}
+	// The counter is stored as an integer, which is valid JSON whatever the key held.
+	v.Val, v.Compressed, v.IsJSON = strconv.FormatInt(next, 10), false, true
+	if err := s.reserve(key, v); err != nil {
+		return current, false, err
+	}
+
+	s.put(key, v)
+	s.recordFence(key, o)
+	s.touch(key, s.clock.Now())
+	return next, true, nil
+}
@@ -0,0 +1,252 @@
+package memory_test
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+func TestIncrement(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	if value, err := store.Increment(ctx, "hits", 5); err != nil || value != 5 {
+		t.Errorf("Expected a missing key to be created with the delta, got %d, %v", value, err)
+	}
+	if value, _ := store.Increment(ctx, "hits", 2); value != 7 {
+		t.Errorf("Expected 7, got %d", value)
+	}
+	if value, _ := store.Increment(ctx, "hits", -10); value != -3 {
+		t.Errorf("Expected a negative delta to subtract, got %d", value)
+	}
+	if value, _ := store.Decrement(ctx, "hits", 3); value != -6 {
+		t.Errorf("Expected -6 after Decrement, got %d", value)
+	}
+	if got, _ := store.Get(ctx, "hits"); got != "-6" {
+		t.Errorf("Expected -6 stored, got %q", got)
+	}
+
+	// A counter reads back as a JSON number, whether Increment created it or the key
+	// was set as a string.
+	store.Set(ctx, "visits", "41", 0)
+	store.Increment(ctx, "visits", 1)
+	for _, key := range []string{"hits", "visits"} {
+		if raw, _ := store.GetRaw(ctx, key); !json.Valid(raw) || raw[0] == '"' {
+			t.Errorf("Expected %s to read back as a JSON number, got %s", key, raw)
+		}
+	}
+
+	store.Set(ctx, "name", "alice", 0)
+	if _, err := store.Increment(ctx, "name", 1); err != memory.ErrNotInteger {
+		t.Errorf("Expected ErrNotInteger, got %v", err)
+	}
+
+	store.Set(ctx, "max", strconv.FormatInt(math.MaxInt64, 10), 0)
+	if _, err := store.Increment(ctx, "max", 1); err != memory.ErrIntegerOverflow {
+		t.Errorf("Expected ErrIntegerOverflow, got %v", err)
+	}
+	if got, _ := store.Get(ctx, "max"); got != strconv.FormatInt(math.MaxInt64, 10) {
+		t.Errorf("Expected an overflowing increment to leave the value unchanged, got %s", got)
+	}
+}
+
+func TestIncrement_Concurrent(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	const workers, increments = 20, 50
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < increments; j++ {
+				store.Increment(ctx, "counter", 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, _ := store.Get(ctx, "counter"); got != strconv.Itoa(workers*increments) {
+		t.Errorf("Expected %d, got %s", workers*increments, got)
+	}
+}
+
+func TestDecrWithFloor(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("decrements down to the floor", func(t *testing.T) {
+		store := memory.NewMemoryStore()
+		defer store.StopTTLWorker()
+
+		store.Set(ctx, "stock", "5", 60)
+
+		value, applied, err := store.DecrWithFloor(ctx, "stock", 3, 0)
+		if err != nil || !applied || value != 2 {
+			t.Errorf("Expected 2 and applied, got %d, %v, %v", value, applied, err)
+		}
+
+		value, applied, err = store.DecrWithFloor(ctx, "stock", 3, 0)
+		if err != nil || applied || value != 2 {
+			t.Errorf("Expected the decrement to be refused at 2, got %d, %v, %v", value, applied, err)
+		}
+
+		value, applied, _ = store.DecrWithFloor(ctx, "stock", 2, 0)
+		if !applied || value != 0 {
+			t.Errorf("Expected to reach the floor exactly, got %d, %v", value, applied)
+		}
+		if got, _ := store.Get(ctx, "stock"); got != "0" {
+			t.Errorf("Expected 0 stored, got %q", got)
+		}
+		if ttl, _ := store.TTL(ctx, "stock"); ttl != 60 {
+			t.Errorf("Expected the TTL to be kept, got %d", ttl)
+		}
+	})
+
+	t.Run("missing key counts as zero", func(t *testing.T) {
+		store := memory.NewMemoryStore()
+		defer store.StopTTLWorker()
+
+		if _, applied, _ := store.DecrWithFloor(ctx, "stock", 1, 0); applied {
+			t.Error("Expected a missing key not to go below a floor of 0")
+		}
+		if exists, _ := store.Exists(ctx, "stock"); exists {
+			t.Error("Expected a refused decrement not to create the key")
+		}
+
+		value, applied, err := store.DecrWithFloor(ctx, "balance", 10, -100)
+		if err != nil || !applied || value != -10 {
+			t.Errorf("Expected -10 and applied, got %d, %v, %v", value, applied, err)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		store := memory.NewMemoryStore()
+		defer store.StopTTLWorker()
+
+		store.Set(ctx, "name", "alice", 0)
+		store.Push(ctx, "queue", "job")
+
+		if _, _, err := store.DecrWithFloor(ctx, "name", 1, 0); err != memory.ErrNotInteger {
+			t.Errorf("Expected ErrNotInteger, got %v", err)
+		}
+		if _, _, err := store.DecrWithFloor(ctx, "queue", 1, 0); err != memory.ErrTypeMismatch {
+			t.Errorf("Expected ErrTypeMismatch, got %v", err)
+		}
+	})
+}
+
+func TestDecrWithFloor_Concurrent(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	const stock, buyers = 50, 200
+	store.Set(ctx, "stock", stock, 0)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sold := 0
+	for i := 0; i < buyers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, applied, err := store.DecrWithFloor(ctx, "stock", 1, 0)
+			if err != nil {
+				t.Errorf("DecrWithFloor failed: %v", err)
+				return
+			}
+			if value < 0 {
+				t.Errorf("Expected stock never to go negative, got %d", value)
+			}
+			if applied {
+				mu.Lock()
+				sold++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if sold != stock {
+		t.Errorf("Expected exactly %d decrements to be applied, got %d", stock, sold)
+	}
+	if got, _ := store.Get(ctx, "stock"); got != "0" {
+		t.Errorf("Expected 0 left, got %q", got)
+	}
+}
+
+func TestIncrWithCeiling(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	value, applied, err := store.IncrWithCeiling(ctx, "quota", 3, 5)
+	if err != nil || !applied || value != 3 {
+		t.Errorf("Expected a missing key to be created holding 3, got %d, %v, %v", value, applied, err)
+	}
+	if value, applied, _ = store.IncrWithCeiling(ctx, "quota", 3, 5); applied || value != 3 {
+		t.Errorf("Expected the increment to be refused at 3, got %d, %v", value, applied)
+	}
+	if value, applied, _ = store.IncrWithCeiling(ctx, "quota", 2, 5); !applied || value != 5 {
+		t.Errorf("Expected to reach the ceiling exactly, got %d, %v", value, applied)
+	}
+
+	if _, applied, _ := store.IncrWithCeiling(ctx, "over", 1, 0); applied {
+		t.Error("Expected a missing key not to go above a ceiling of 0")
+	}
+	if exists, _ := store.Exists(ctx, "over"); exists {
+		t.Error("Expected a refused increment not to create the key")
+	}
+
+	store.Set(ctx, "name", "alice", 0)
+	if _, _, err := store.IncrWithCeiling(ctx, "name", 1, 10); err != memory.ErrNotInteger {
+		t.Errorf("Expected ErrNotInteger, got %v", err)
+	}
+}
+
+func TestIncrWithCeiling_Concurrent(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	const ceiling, callers = 100, 300
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	applied := 0
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(delta int64) {
+			defer wg.Done()
+			value, ok, err := store.IncrWithCeiling(ctx, "quota", delta, ceiling)
+			if err != nil {
+				t.Errorf("IncrWithCeiling failed: %v", err)
+				return
+			}
+			if value > ceiling {
+				t.Errorf("Expected the quota never to exceed %d, got %d", ceiling, value)
+			}
+			if ok {
+				mu.Lock()
+				applied += int(delta)
+				mu.Unlock()
+			}
+		}(int64(i%3 + 1))
+	}
+	wg.Wait()
+
+	got, _ := store.Get(ctx, "quota")
+	if got != strconv.Itoa(applied) {
+		t.Errorf("Expected the applied increments to add up to the stored %s, got %d", got, applied)
+	}
+	if applied > ceiling || applied < ceiling-2 {
+		t.Errorf("Expected the quota filled up to the ceiling, got %d", applied)
+	}
+}
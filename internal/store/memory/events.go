@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// eventLog is a bounded ring of the most recent keyspace events. It has its own lock
+// so readers do not contend with the store, and is appended to under the store write
+// lock, which keeps events in the order of the writes.
+type eventLog struct {
+	mu     sync.Mutex
+	events []store.KeyEvent
+	// oldest is the index of the oldest event once the ring is full.
+	oldest int
+	seq    uint64
+	// wake is closed on the next append. It is only created once a reader waits for it.
+	wake chan struct{}
+}
+
+func newEventLog(capacity int) *eventLog {
+	return &eventLog{events: make([]store.KeyEvent, 0, capacity)}
+}
+
+func (l *eventLog) append(typ, key string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.seq++
+	e := store.KeyEvent{Seq: l.seq, Time: now, Type: typ, Key: key}
+	if len(l.events) < cap(l.events) {
+		l.events = append(l.events, e)
+	} else {
+		l.events[l.oldest] = e
+		l.oldest = (l.oldest + 1) % len(l.events)
+	}
+
+	if l.wake != nil {
+		close(l.wake)
+		l.wake = nil
+	}
+}
+
+func (l *eventLog) since(seq uint64) store.EventPage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.wake == nil {
+		l.wake = make(chan struct{})
+	}
+	page := store.EventPage{Events: []store.KeyEvent{}, Next: l.wake}
+
+	first := l.seq - uint64(len(l.events)) + 1
+	if seq > l.seq || seq+1 < first {
+		page.Gap = true
+		seq = first - 1
+	}
+
+	for i := int(seq + 1 - first); i < len(l.events); i++ {
+		page.Events = append(page.Events, l.events[(l.oldest+i)%len(l.events)])
+	}
+	return page
+}
+
+// EventsSince returns the buffered keyspace events recorded after the event numbered
+// since, oldest first; a since of 0 returns all of them. Only the most recent events
+// are kept, see Options.EventBufferSize, so a consumer that falls behind or reconnects
+// late is told about the gap. Consumers wait on the returned page's Next channel for
+// newer events, which makes EventsSince suitable for resumable change feeds.
+func (s *MemoryStore) EventsSince(ctx context.Context, since uint64) (store.EventPage, error) {
+	return s.events.since(since), nil
+}
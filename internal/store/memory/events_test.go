@@ -0,0 +1,83 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+// eventKeys returns the type and key of each event, e.g. "set:a".
+func eventKeys(events []store.KeyEvent) []string {
+	keys := make([]string, len(events))
+	for i, e := range events {
+		keys[i] = e.Type + ":" + e.Key
+	}
+	return keys
+}
+
+func TestEventsSince(t *testing.T) {
+	s := memory.NewMemoryStoreWithOptions(memory.Options{EventBufferSize: 4})
+	defer s.StopTTLWorker()
+	ctx := context.Background()
+
+	s.Set(ctx, "a", "1", 0)
+	s.Push(ctx, "queue", "job")
+	s.Remove(ctx, "a")
+	s.Remove(ctx, "missing")
+
+	page, _ := s.EventsSince(ctx, 0)
+	if got := eventKeys(page.Events); len(got) != 3 || got[0] != "set:a" || got[1] != "set:queue" || got[2] != "del:a" || page.Gap {
+		t.Fatalf("Expected [set:a set:queue del:a] without a gap, got %v gap=%v", got, page.Gap)
+	}
+	for i, e := range page.Events {
+		if e.Seq != uint64(i+1) {
+			t.Errorf("Expected event %d to have seq %d, got %d", i, i+1, e.Seq)
+		}
+	}
+
+	page, _ = s.EventsSince(ctx, 2)
+	if got := eventKeys(page.Events); len(got) != 1 || got[0] != "del:a" {
+		t.Errorf("Expected only the events after the cursor, got %v", got)
+	}
+
+	page, _ = s.EventsSince(ctx, 3)
+	if len(page.Events) != 0 || page.Gap {
+		t.Errorf("Expected no events at the head, got %v gap=%v", page.Events, page.Gap)
+	}
+	select {
+	case <-page.Next:
+		t.Fatal("Expected Next to stay open until an event is recorded")
+	default:
+	}
+	s.Set(ctx, "b", "2", 0)
+	select {
+	case <-page.Next:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Next to be closed by a new event")
+	}
+
+	t.Run("gap", func(t *testing.T) {
+		for _, key := range []string{"c", "d", "e"} {
+			s.Set(ctx, key, "v", 0)
+		}
+
+		// Events 1 to 3 fell off the ring of 4, which now holds 4 to 7
+		page, _ := s.EventsSince(ctx, 1)
+		if !page.Gap || len(page.Events) != 4 || page.Events[0].Seq != 4 {
+			t.Errorf("Expected a gap and events from seq 4, got gap=%v %+v", page.Gap, page.Events)
+		}
+
+		page, _ = s.EventsSince(ctx, 3)
+		if page.Gap || len(page.Events) != 4 {
+			t.Errorf("Expected no gap right before the oldest event, got gap=%v %+v", page.Gap, page.Events)
+		}
+
+		page, _ = s.EventsSince(ctx, 100)
+		if !page.Gap || len(page.Events) != 4 {
+			t.Errorf("Expected a gap for an unknown cursor, got gap=%v %+v", page.Gap, page.Events)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package memory
+
+import "github.com/mo-mohamed/acronis-memory-store/internal/store"
+
+// Fence tokens are issued from a single counter shared by all keys, so a token issued
+// later is always higher. Only SetNXFenced and ExpireFenced issue tokens. The latest
+// token issued or accepted is kept per key while the key exists, and for the last
+// maxDeletedFences keys deleted after that, so a stale writer is still rejected once
+// the key is gone without the fences of short-lived keys piling up.
+
+// maxDeletedFences caps the number of deleted keys whose fence is kept.
+const maxDeletedFences = 10000
+
+// keyFence is the fence left behind by a deleted key.
+type keyFence struct {
+	key   string
+	token uint64
+}
+
+// issueFence issues a new fence token for key. The caller must hold the write lock.
+func (s *MemoryStore) issueFence(key string) uint64 {
+	s.fenceSeq++
+	s.fences[key] = s.fenceSeq
+	return s.fenceSeq
+}
+
+// checkFence rejects a write to key with ErrStaleFence if o carries a fence token
+// lower than the latest one seen for key, and with ErrUnknownFence if the token was
+// never issued. Writes without a token are not checked. The token is only recorded
+// by recordFence once the write is applied, so a write that fails or changes nothing
+// does not fence out other writers. The caller must hold the write lock.
+func (s *MemoryStore) checkFence(key string, o store.WriteOptions) error {
+	if !o.Fenced {
+		return nil
+	}
+
+	if o.FenceToken > s.fenceSeq {
+		return ErrUnknownFence
+	}
+	if o.FenceToken < s.fences[key] {
+		return ErrStaleFence
+	}
+	return nil
+}
+
+// recordFence records the fence token of a write to key that passed checkFence and
+// was applied. The caller must hold the write lock.
+func (s *MemoryStore) recordFence(key string, o store.WriteOptions) {
+	if !o.Fenced {
+		return
+	}
+
+	s.fences[key] = o.FenceToken
+	if _, exists := s.data[key]; !exists {
+		s.retireFence(key)
+	}
+}
+
+// retireFence queues the fence of a key that no longer exists, dropping the fence of
+// the oldest deleted key once more than maxDeletedFences are queued. A fence already
+// queued is not queued again, and a queued fence is only dropped if the key was not
+// recreated or given a newer token since. The caller must hold the write lock.
+func (s *MemoryStore) retireFence(key string) {
+	token, ok := s.fences[key]
+	if !ok || s.retiredFences[key] == token {
+		return
+	}
+
+	s.retiredFences[key] = token
+	s.deletedFences = append(s.deletedFences, keyFence{key: key, token: token})
+	if len(s.deletedFences) <= maxDeletedFences {
+		return
+	}
+
+	oldest := s.deletedFences[0]
+	s.deletedFences[0] = keyFence{}
+	s.deletedFences = s.deletedFences[1:]
+	if s.retiredFences[oldest.key] == oldest.token {
+		delete(s.retiredFences, oldest.key)
+	}
+	if _, exists := s.data[oldest.key]; !exists && s.fences[oldest.key] == oldest.token {
+		delete(s.fences, oldest.key)
+	}
+}
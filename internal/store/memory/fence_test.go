@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+func TestFenceBookkeeping(t *testing.T) {
+	s := NewMemoryStore()
+	defer s.StopTTLWorker()
+	ctx := context.Background()
+
+	// Plain SetNX and Expire leave no fence behind.
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("idempotency:%d", i)
+		if set, err := s.SetNX(ctx, key, "1", 60); err != nil || !set {
+			t.Fatalf("Expected SetNX to set %s, got %v (err %v)", key, set, err)
+		}
+		if err := s.Expire(ctx, key, 120); err != nil {
+			t.Fatalf("Expected Expire to succeed, got %v", err)
+		}
+	}
+	if len(s.fences) != 0 || s.fenceSeq != 0 {
+		t.Errorf("Expected no fences from unfenced calls, got %d (seq %d)", len(s.fences), s.fenceSeq)
+	}
+
+	// A token the store never issued is rejected and not recorded.
+	_, token, _ := s.SetNXFenced(ctx, "lock", "holder", 60)
+	forged := store.Fence(token + 1)
+	if err := s.Set(ctx, "data", "forged", 0, forged); err != ErrUnknownFence {
+		t.Errorf("Expected ErrUnknownFence for a token never issued, got %v", err)
+	}
+	if _, ok := s.fences["data"]; ok {
+		t.Error("Expected the forged token not to be recorded")
+	}
+
+	// Fences of deleted keys are kept up to maxDeletedFences, oldest dropped first.
+	fenced := store.Fence(token)
+	for i := 0; i < maxDeletedFences+5; i++ {
+		key := fmt.Sprintf("job:%d", i)
+		if err := s.Set(ctx, key, "v", 0, fenced); err != nil {
+			t.Fatalf("Expected the fenced Set of %s to succeed, got %v", key, err)
+		}
+		s.Remove(ctx, key)
+	}
+	if got := len(s.fences); got != maxDeletedFences+1 {
+		t.Errorf("Expected %d fences (deleted keys plus the live lock), got %d", maxDeletedFences+1, got)
+	}
+	if _, ok := s.fences["job:0"]; ok {
+		t.Error("Expected the fence of the oldest deleted key to be dropped")
+	}
+	if _, ok := s.fences[fmt.Sprintf("job:%d", maxDeletedFences+4)]; !ok {
+		t.Error("Expected the fence of the latest deleted key to be kept")
+	}
+	if _, ok := s.fences["lock"]; !ok {
+		t.Error("Expected the fence of a live key to be kept")
+	}
+
+	// FlushAll drops the fences but keeps the counter.
+	s.FlushAll(ctx)
+	if len(s.fences) != 0 || len(s.deletedFences) != 0 {
+		t.Errorf("Expected FlushAll to drop fences, got %d and %d queued", len(s.fences), len(s.deletedFences))
+	}
+	if _, next, _ := s.SetNXFenced(ctx, "lock", "holder", 60); next <= token {
+		t.Errorf("Expected a token after the flush to order after %d, got %d", token, next)
+	}
+}
+
+func TestFenceFailedWrite(t *testing.T) {
+	s := NewMemoryStore()
+	defer s.StopTTLWorker()
+	ctx := context.Background()
+
+	_, older, _ := s.SetNXFenced(ctx, "lock:a", "holder", 60)
+	_, newer, _ := s.SetNXFenced(ctx, "lock:b", "holder", 60)
+	s.Push(ctx, "queue", "job")
+
+	// Writes that fail do not record their token.
+	if err := s.Update(ctx, "queue", "v", store.Fence(newer)); err != ErrTypeMismatch {
+		t.Fatalf("Expected ErrTypeMismatch, got %v", err)
+	}
+	if err := s.Update(ctx, "missing", "v", store.Fence(newer)); err != ErrKeyNotFound {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+	if _, ok := s.fences["queue"]; ok {
+		t.Error("Expected the token of a failed write not to be recorded")
+	}
+	if _, ok := s.fences["missing"]; ok {
+		t.Error("Expected the token of a failed write to a missing key not to be recorded")
+	}
+
+	// So a writer with an older token is not fenced out by them.
+	if err := s.Push(ctx, "queue", "job", store.Fence(older)); err != nil {
+		t.Errorf("Expected the older token to still be accepted, got %v", err)
+	}
+	if got := s.fences["queue"]; got != older {
+		t.Errorf("Expected the applied write to record token %d, got %d", older, got)
+	}
+	if err := s.Push(ctx, "queue", "job", store.Fence(newer)); err != nil {
+		t.Fatalf("Expected the newer token to be accepted, got %v", err)
+	}
+	if err := s.Push(ctx, "queue", "job", store.Fence(older)); err != ErrStaleFence {
+		t.Errorf("Expected ErrStaleFence once the newer token was applied, got %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// FlushAll deletes every key at once, e.g. to reset the store between integration
+// tests. The key space is replaced with an empty one under the write lock, so readers
+// see the store either whole or empty. Soft deleted keys, reserved list items, rate
+// limit windows, tags and the fences of the flushed keys go with it. The fence counter
+// is kept, so tokens handed out after the flush still order after those handed out
+// before it.
+func (s *MemoryStore) FlushAll(ctx context.Context) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	s.flush()
+	if s.aof != nil {
+		if err := s.aof.append(aofRecord{Op: aofFlush}); err != nil {
+			s.health.report(aofWorkerName, err, s.clock.Now())
+		}
+	}
+	return nil
+}
+
+// flush empties the store, recording a del event for every key. The caller must hold
+// the write lock.
+func (s *MemoryStore) flush() {
+	now := s.clock.Now()
+	for key := range s.data {
+		s.unpublish(key)
+		s.events.append(store.EventDel, key, now)
+	}
+
+	s.data = make(map[string]Value)
+	s.access = make(map[string]*keyAccess)
+	s.tags = make(map[string]map[string]struct{})
+	s.tombstones = make(map[string]tombstone)
+	s.inflight = make(map[string]reservation)
+	s.rates = make(map[string]*rateWindow)
+	s.fences = make(map[string]uint64)
+	s.deletedFences = nil
+	s.retiredFences = make(map[string]uint64)
+	s.usedBytes = 0
+	if s.lru != nil {
+		s.lru = newLRUList()
+	}
+}
@@ -0,0 +1,145 @@
+package memory
+
+import (
+	"context"
+	"maps"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// HSet sets a field of a hash to a value, stringified like Set values, creating the
+// hash if needed. It reports whether the field is new to the hash. A new hash does not
+// expire; an existing one keeps its TTL. HSet returns ErrTypeMismatch for keys not
+// holding a hash, and ErrKeyPendingDelete instead of creating a hash where a soft deleted
+// key can still be restored.
+func (s *MemoryStore) HSet(ctx context.Context, key, field string, value any, opts ...store.WriteOption) (bool, error) {
+	stringValue, err := s.Stringify(value)
+	if err != nil {
+		return false, ErrMarshalFailed
+	}
+
+	if err := s.lock(ctx); err != nil {
+		return false, err
+	}
+	defer s.mu.Unlock()
+
+	o := store.NewWriteOptions(opts)
+	if err := s.checkFence(key, o); err != nil {
+		return false, err
+	}
+
+	now := s.clock.Now()
+	v, exists := s.data[key]
+	if exists && !v.TTL.IsZero() && now.After(v.TTL) {
+		s.del(key)
+		exists = false
+	}
+	if !exists {
+		if _, pending := s.pendingDelete(key, now); pending {
+			return false, ErrKeyPendingDelete
+		}
+		v = Value{IsHash: true}
+	}
+	if !v.IsHash {
+		return false, ErrTypeMismatch
+	}
+
+	_, existed := v.Hash[field]
+	v.Hash = maps.Clone(v.Hash)
+	if v.Hash == nil {
+		v.Hash = map[string]string{}
+	}
+	v.Hash[field] = stringValue
+	if err := s.reserve(key, v); err != nil {
+		return false, err
+	}
+
+	s.put(key, v)
+	s.recordFence(key, o)
+	s.touch(key, now)
+	return !existed, nil
+}
+
+// HGet returns the value of a field of a hash. It returns ErrKeyNotFound for missing
+// and expired keys, ErrFieldNotFound if the hash has no such field and
+// ErrTypeMismatch for keys not holding a hash.
+func (s *MemoryStore) HGet(ctx context.Context, key, field string) (string, error) {
+	v, err := s.liveHash(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := v.Hash[field]
+	if !ok {
+		return "", ErrFieldNotFound
+	}
+	return value, nil
+}
+
+// HDel removes a field from a hash and reports whether it was there. A hash left
+// without fields is kept, like a list left without items. HDel returns ErrKeyNotFound
+// for missing and expired keys and ErrTypeMismatch for keys not holding a hash.
+func (s *MemoryStore) HDel(ctx context.Context, key, field string, opts ...store.WriteOption) (bool, error) {
+	if err := s.lock(ctx); err != nil {
+		return false, err
+	}
+	defer s.mu.Unlock()
+
+	o := store.NewWriteOptions(opts)
+	if err := s.checkFence(key, o); err != nil {
+		return false, err
+	}
+
+	now := s.clock.Now()
+	v, exists := s.data[key]
+	if !exists {
+		return false, ErrKeyNotFound
+	}
+	if !v.TTL.IsZero() && now.After(v.TTL) {
+		s.del(key)
+		return false, ErrKeyNotFound
+	}
+	if !v.IsHash {
+		return false, ErrTypeMismatch
+	}
+
+	if _, ok := v.Hash[field]; !ok {
+		return false, nil
+	}
+	v.Hash = maps.Clone(v.Hash)
+	delete(v.Hash, field)
+
+	s.put(key, v)
+	s.recordFence(key, o)
+	s.touch(key, now)
+	return true, nil
+}
+
+// HGetAll returns a copy of every field of a hash with its value. It returns
+// ErrKeyNotFound for missing and expired keys and ErrTypeMismatch for keys not
+// holding a hash.
+func (s *MemoryStore) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	v, err := s.liveHash(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]string, len(v.Hash))
+	maps.Copy(fields, v.Hash)
+	return fields, nil
+}
+
+// liveHash returns the live hash stored at key, recording the access.
+func (s *MemoryStore) liveHash(ctx context.Context, key string) (Value, error) {
+	v, exists, err := s.getAny(ctx, key)
+	if err != nil {
+		return Value{}, err
+	}
+	if !exists {
+		return Value{}, ErrKeyNotFound
+	}
+	if !v.IsHash {
+		return Value{}, ErrTypeMismatch
+	}
+	return v, nil
+}
@@ -0,0 +1,206 @@
+package memory_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	s "github.com/mo-mohamed/acronis-memory-store/internal/store"
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+func TestHash(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	if created, err := store.HSet(ctx, "user:1", "name", "alice"); err != nil || !created {
+		t.Fatalf("Expected a new field, got %v (err %v)", created, err)
+	}
+	store.HSet(ctx, "user:1", "age", 30)
+	if created, _ := store.HSet(ctx, "user:1", "name", "bob"); created {
+		t.Error("Expected overwriting a field not to report it as new")
+	}
+
+	if got, err := store.HGet(ctx, "user:1", "name"); err != nil || got != "bob" {
+		t.Errorf("Expected bob, got %q (err %v)", got, err)
+	}
+	if got, _ := store.HGet(ctx, "user:1", "age"); got != "30" {
+		t.Errorf("Expected a number stored as its JSON encoding, got %q", got)
+	}
+	if _, err := store.HGet(ctx, "user:1", "email"); !errors.Is(err, memory.ErrFieldNotFound) {
+		t.Errorf("Expected ErrFieldNotFound, got %v", err)
+	}
+	if _, err := store.HGet(ctx, "user:2", "name"); !errors.Is(err, memory.ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+
+	fields, err := store.HGetAll(ctx, "user:1")
+	if err != nil || !reflect.DeepEqual(fields, map[string]string{"name": "bob", "age": "30"}) {
+		t.Errorf("Expected both fields, got %v (err %v)", fields, err)
+	}
+	// The result is a copy
+	fields["name"] = "mallory"
+	if got, _ := store.HGet(ctx, "user:1", "name"); got != "bob" {
+		t.Errorf("Expected the hash unchanged by editing HGetAll's result, got %q", got)
+	}
+
+	if deleted, err := store.HDel(ctx, "user:1", "age"); err != nil || !deleted {
+		t.Errorf("Expected the field deleted, got %v (err %v)", deleted, err)
+	}
+	if deleted, _ := store.HDel(ctx, "user:1", "age"); deleted {
+		t.Error("Expected deleting a missing field to report false")
+	}
+	store.HDel(ctx, "user:1", "name")
+	if fields, err := store.HGetAll(ctx, "user:1"); err != nil || len(fields) != 0 {
+		t.Errorf("Expected an empty hash to be kept, got %v (err %v)", fields, err)
+	}
+	if _, err := store.HDel(ctx, "user:2", "name"); !errors.Is(err, memory.ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestHashTypeMismatch(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Set(ctx, "string", "value", 0)
+	store.Push(ctx, "list", "item")
+	store.HSet(ctx, "hash", "field", "value")
+
+	// Hash operations on strings and lists
+	for _, key := range []string{"string", "list"} {
+		if _, err := store.HSet(ctx, key, "field", "value"); !errors.Is(err, memory.ErrTypeMismatch) {
+			t.Errorf("HSet on %s: expected ErrTypeMismatch, got %v", key, err)
+		}
+		if _, err := store.HGet(ctx, key, "field"); !errors.Is(err, memory.ErrTypeMismatch) {
+			t.Errorf("HGet on %s: expected ErrTypeMismatch, got %v", key, err)
+		}
+		if _, err := store.HDel(ctx, key, "field"); !errors.Is(err, memory.ErrTypeMismatch) {
+			t.Errorf("HDel on %s: expected ErrTypeMismatch, got %v", key, err)
+		}
+		if _, err := store.HGetAll(ctx, key); !errors.Is(err, memory.ErrTypeMismatch) {
+			t.Errorf("HGetAll on %s: expected ErrTypeMismatch, got %v", key, err)
+		}
+	}
+
+	// String and list operations on hashes
+	if _, err := store.Get(ctx, "hash"); !errors.Is(err, memory.ErrTypeMismatch) {
+		t.Errorf("Get: expected ErrTypeMismatch, got %v", err)
+	}
+	if err := store.Update(ctx, "hash", "value"); !errors.Is(err, memory.ErrTypeMismatch) {
+		t.Errorf("Update: expected ErrTypeMismatch, got %v", err)
+	}
+	if _, err := store.Increment(ctx, "hash", 1); !errors.Is(err, memory.ErrTypeMismatch) {
+		t.Errorf("Increment: expected ErrTypeMismatch, got %v", err)
+	}
+	if _, err := store.GetRaw(ctx, "hash"); !errors.Is(err, memory.ErrTypeMismatch) {
+		t.Errorf("GetRaw: expected ErrTypeMismatch, got %v", err)
+	}
+	if err := store.Push(ctx, "hash", "item"); !errors.Is(err, memory.ErrTypeMismatch) {
+		t.Errorf("Push: expected ErrTypeMismatch, got %v", err)
+	}
+	if _, err := store.Pop(ctx, "hash"); !errors.Is(err, memory.ErrTypeMismatch) {
+		t.Errorf("Pop: expected ErrTypeMismatch, got %v", err)
+	}
+	if _, err := store.LLen(ctx, "hash"); !errors.Is(err, memory.ErrTypeMismatch) {
+		t.Errorf("LLen: expected ErrTypeMismatch, got %v", err)
+	}
+	if values, _ := store.MGet(ctx, []string{"hash", "string"}); len(values) != 1 {
+		t.Errorf("Expected MGet to leave the hash out, got %v", values)
+	}
+	if set, _ := store.SetIfType(ctx, "hash", "value", 0, s.TypeString); set {
+		t.Error("Expected SetIfType not to clobber a hash expecting a string")
+	}
+
+	// Type agnostic reads report the hash
+	value, kind, err := store.GetAny(ctx, "hash")
+	if err != nil || kind != s.TypeHash || !reflect.DeepEqual(value, map[string]string{"field": "value"}) {
+		t.Errorf("Expected the hash from GetAny, got %v %q (err %v)", value, kind, err)
+	}
+	if stats, _ := store.Stats(ctx); stats.Strings != 1 || stats.Lists != 1 || stats.Hashes != 1 {
+		t.Errorf("Expected one key of each type, got %+v", stats)
+	}
+
+	// Set replaces a hash like any other key
+	store.Set(ctx, "hash", "plain", 0)
+	if got, _ := store.Get(ctx, "hash"); got != "plain" {
+		t.Errorf("Expected Set to replace the hash, got %q", got)
+	}
+}
+
+func TestHashExpiration(t *testing.T) {
+	clock := newFakeClock()
+	store := memory.NewMemoryStoreWithClock(clock)
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.HSet(ctx, "session", "user", "alice")
+	store.Expire(ctx, "session", 10)
+	store.HSet(ctx, "session", "role", "admin")
+	if ttl, _ := store.TTL(ctx, "session"); ttl != 10 {
+		t.Errorf("Expected the hash to keep its TTL across HSet, got %d", ttl)
+	}
+
+	clock.Advance(11 * time.Second)
+	if _, err := store.HGet(ctx, "session", "user"); !errors.Is(err, memory.ErrKeyNotFound) {
+		t.Errorf("Expected the expired hash to be gone, got %v", err)
+	}
+	if _, err := store.HGetAll(ctx, "session"); !errors.Is(err, memory.ErrKeyNotFound) {
+		t.Errorf("Expected the expired hash to be gone, got %v", err)
+	}
+
+	// An expired hash is replaced by a fresh one without a TTL
+	if created, err := store.HSet(ctx, "session", "user", "bob"); err != nil || !created {
+		t.Errorf("Expected a new field in a new hash, got %v (err %v)", created, err)
+	}
+	if fields, _ := store.HGetAll(ctx, "session"); !reflect.DeepEqual(fields, map[string]string{"user": "bob"}) {
+		t.Errorf("Expected only the new field, got %v", fields)
+	}
+	if ttl, _ := store.TTL(ctx, "session"); ttl != -1 {
+		t.Errorf("Expected the new hash not to expire, got %d", ttl)
+	}
+}
+
+func TestHashPersistence(t *testing.T) {
+	ctx := context.Background()
+	var aofBuf bytes.Buffer
+	aof, _ := memory.NewAOFWriter(&aofBuf, memory.FsyncNo)
+	store := memory.NewMemoryStoreWithOptions(memory.Options{AOF: aof})
+	defer store.StopTTLWorker()
+
+	store.HSet(ctx, "user:1", "name", "alice")
+	store.HSet(ctx, "user:1", "email", "alice@example.com")
+	store.HDel(ctx, "user:1", "email")
+	expected := map[string]string{"name": "alice"}
+
+	if problems, _ := store.VerifyIntegrity(ctx); len(problems) != 0 {
+		t.Errorf("Expected the hash to match its checksum, got %v", problems)
+	}
+
+	var snap bytes.Buffer
+	if err := store.Snapshot(&snap); err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	restored := memory.NewMemoryStore()
+	defer restored.StopTTLWorker()
+	if err := restored.RestoreSnapshot(&snap); err != nil {
+		t.Fatalf("RestoreSnapshot failed: %v", err)
+	}
+	if fields, _ := restored.HGetAll(ctx, "user:1"); !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Expected the hash restored from the snapshot, got %v", fields)
+	}
+
+	replayed := memory.NewMemoryStore()
+	defer replayed.StopTTLWorker()
+	if err := replayed.ReplayAOF(&aofBuf); err != nil {
+		t.Fatalf("ReplayAOF failed: %v", err)
+	}
+	if fields, _ := replayed.HGetAll(ctx, "user:1"); !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Expected the hash replayed from the AOF, got %v", fields)
+	}
+}
@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// Name of the TTL worker in health reports.
+const ttlWorkerName = "ttl"
+
+// workerHealth records the outcome of background worker runs. It has its own lock
+// so workers can report while holding, or without taking, the store lock.
+type workerHealth struct {
+	mu      sync.Mutex
+	workers map[string]*store.WorkerHealth
+}
+
+func (h *workerHealth) report(name string, err error, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.workers == nil {
+		h.workers = make(map[string]*store.WorkerHealth)
+	}
+	w, ok := h.workers[name]
+	if !ok {
+		w = &store.WorkerHealth{Name: name}
+		h.workers[name] = w
+	}
+
+	w.LastRun = now
+	w.Healthy = err == nil
+	if err == nil {
+		w.ConsecutiveFailures = 0
+		return
+	}
+	w.LastError = err.Error()
+	at := now
+	w.LastErrorAt = &at
+	w.ConsecutiveFailures++
+}
+
+func (h *workerHealth) snapshot() []store.WorkerHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	workers := make([]store.WorkerHealth, 0, len(h.workers))
+	for _, w := range h.workers {
+		workers = append(workers, *w)
+	}
+	sort.Slice(workers, func(i, j int) bool { return workers[i].Name < workers[j].Name })
+	return workers
+}
+
+// ReportWorker records the outcome of a run of the named background worker, err
+// being nil on success. Workers outside the store, such as persistence, report
+// through it so their failures show up in Health.
+func (s *MemoryStore) ReportWorker(name string, err error) {
+	s.health.report(name, err, s.clock.Now())
+}
+
+// Health returns the status of every background worker that has reported, by name.
+func (s *MemoryStore) Health(ctx context.Context) ([]store.WorkerHealth, error) {
+	return s.health.snapshot(), nil
+}
@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// DefaultTTLBuckets are the TTL histogram buckets used when none are given: keys
+// expiring within a minute, an hour and a day.
+var DefaultTTLBuckets = []time.Duration{time.Minute, time.Hour, 24 * time.Hour}
+
+// TTLHistogram counts live keys by remaining TTL. A key falls into the smallest bucket
+// its remaining TTL does not exceed, labeled by the bucket's duration string, e.g.
+// "1h0m0s". Keys expiring later than the largest bucket are counted under
+// store.TTLBucketLonger and keys without a TTL under store.TTLBucketNever. Every
+// label is present, even if no key falls into it. Buckets may be given in any order
+// and default to DefaultTTLBuckets; ErrInvalidBuckets is returned if a bucket is not
+// positive or is given twice.
+func (s *MemoryStore) TTLHistogram(ctx context.Context, buckets []time.Duration) (map[string]int, error) {
+	if len(buckets) == 0 {
+		buckets = DefaultTTLBuckets
+	}
+	bounds := append([]time.Duration{}, buckets...)
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+
+	histogram := map[string]int{store.TTLBucketLonger: 0, store.TTLBucketNever: 0}
+	for i, bound := range bounds {
+		if bound <= 0 || (i > 0 && bound == bounds[i-1]) {
+			return nil, ErrInvalidBuckets
+		}
+		histogram[bound.String()] = 0
+	}
+
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	now := s.clock.Now()
+	scanned := 0
+	for _, v := range s.data {
+		if err := checkCtx(ctx, scanned); err != nil {
+			return nil, err
+		}
+		scanned++
+		if v.TTL.IsZero() {
+			histogram[store.TTLBucketNever]++
+			continue
+		}
+		if now.After(v.TTL) {
+			continue
+		}
+
+		remaining := v.TTL.Sub(now)
+		i := sort.Search(len(bounds), func(i int) bool { return remaining <= bounds[i] })
+		if i == len(bounds) {
+			histogram[store.TTLBucketLonger]++
+		} else {
+			histogram[bounds[i].String()]++
+		}
+	}
+
+	return histogram, nil
+}
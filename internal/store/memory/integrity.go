@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"sort"
+)
+
+// checksumTable is the CRC-32C table, which most CPUs compute in hardware.
+var checksumTable = crc32.MakeTable(crc32.Castagnoli)
+
+// checksum returns the CRC-32C of the content of v: the string value, the fields of a
+// hash sorted by name, each followed by its value, or the sorted members of a set.
+// Fields, values and members are each prefixed with their length so that moving bytes
+// from one to the next changes the checksum. The checksum of a list is instead the sum
+// of the checksums of its items as stored, so compressed if they are, each with its
+// position, see itemSum, so that a push or a pop updates it without going over the
+// whole list, while items that swap places still change it.
+func checksum(v Value) uint32 {
+	switch {
+	case v.IsList:
+		var sum uint32
+		for i, item := range v.List {
+			sum += itemSum(v.Head+int64(i), item)
+		}
+		return sum
+	case v.IsHash:
+		fields := make([]string, 0, len(v.Hash))
+		for field := range v.Hash {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		var sum uint32
+		for _, field := range fields {
+			sum = checksumStrings(sum, []string{field, v.Hash[field]})
+		}
+		return sum
+	case v.IsSet:
+		return checksumStrings(0, members(v))
+	}
+	return crc32.Checksum([]byte(v.Val), checksumTable)
+}
+
+// itemSum returns the CRC-32C of a list item, in its stored form, prefixed with its
+// position in the list, see Value.Head, and its length so that an empty item counts
+// too.
+func itemSum(pos int64, item string) uint32 {
+	var prefix [12]byte
+	binary.BigEndian.PutUint64(prefix[:8], uint64(pos))
+	binary.BigEndian.PutUint32(prefix[8:], uint32(len(item)))
+	sum := crc32.Update(0, checksumTable, prefix[:])
+	return crc32.Update(sum, checksumTable, []byte(item))
+}
+
+// checksumStrings adds each of strs, prefixed with its length, to the checksum sum.
+func checksumStrings(sum uint32, strs []string) uint32 {
+	var length [4]byte
+	for _, str := range strs {
+		binary.BigEndian.PutUint32(length[:], uint32(len(str)))
+		sum = crc32.Update(sum, checksumTable, length[:])
+		sum = crc32.Update(sum, checksumTable, []byte(str))
+	}
+	return sum
+}
+
+// corruption describes how the content of key no longer matches its checksum, sum
+// being the checksum computed from it.
+func corruption(key string, v Value, sum uint32) string {
+	return fmt.Sprintf("%q: checksum %08x, computed %08x", key, v.Checksum, sum)
+}
+
+// VerifyIntegrity recomputes the checksum of every live key, taken when the key was
+// last written, and returns a description of each key whose content no longer
+// matches it, sorted by key. Memory corruption, or a bug writing a value without going
+// through the store, are the only ways for a key to fail; an empty result means every
+// key is intact. The store is read under a single read lock.
+func (s *MemoryStore) VerifyIntegrity(ctx context.Context) ([]string, error) {
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	now := s.clock.Now()
+	var corrupt []string
+	scanned := 0
+	for key, v := range s.data {
+		if err := checkCtx(ctx, scanned); err != nil {
+			return nil, err
+		}
+		scanned++
+		if !v.TTL.IsZero() && now.After(v.TTL) {
+			continue
+		}
+		if checksum(v) != v.Checksum {
+			corrupt = append(corrupt, key)
+		}
+	}
+	sort.Strings(corrupt)
+
+	problems := make([]string, len(corrupt))
+	for i, key := range corrupt {
+		v := s.data[key]
+		problems[i] = corruption(key, v, checksum(v))
+	}
+	return problems, nil
+}
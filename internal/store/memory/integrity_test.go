@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// tamper flips a bit of the stored bytes of key behind the store's back, like memory
+// corruption would, leaving its checksum as it was.
+func tamper(s *MemoryStore, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v := s.data[key]
+	if v.IsList {
+		item := []byte(v.List[0])
+		item[0] ^= 1
+		v.List = append([]string{string(item)}, v.List[1:]...)
+	} else {
+		val := []byte(v.Val)
+		val[len(val)-1] ^= 1
+		v.Val = string(val)
+	}
+	s.data[key] = v
+}
+
+func TestVerifyIntegrity(t *testing.T) {
+	s := NewMemoryStoreWithOptions(Options{CompressThreshold: 64})
+	defer s.StopTTLWorker()
+	ctx := context.Background()
+
+	s.Set(ctx, "name", "alice", 0)
+	s.Set(ctx, "big", strings.Repeat("compressible ", 20), 0)
+	s.RPush(ctx, "queue", "a")
+	s.RPush(ctx, "queue", "b")
+	s.Increment(ctx, "count", 1)
+
+	if problems, err := s.VerifyIntegrity(ctx); err != nil || len(problems) != 0 {
+		t.Fatalf("Expected every key intact, got %v, %v", problems, err)
+	}
+
+	tamper(s, "big")
+	tamper(s, "queue")
+	problems, err := s.VerifyIntegrity(ctx)
+	if err != nil {
+		t.Fatalf("VerifyIntegrity failed: %v", err)
+	}
+	if len(problems) != 2 || !strings.HasPrefix(problems[0], `"big"`) || !strings.HasPrefix(problems[1], `"queue"`) {
+		t.Errorf("Expected big and queue to be flagged, got %v", problems)
+	}
+
+	// Writing a key again checksums its new content
+	s.Set(ctx, "big", "fresh", 0)
+	if problems, _ := s.VerifyIntegrity(ctx); len(problems) != 1 || !strings.HasPrefix(problems[0], `"queue"`) {
+		t.Errorf("Expected only queue to be flagged, got %v", problems)
+	}
+
+	// A corrupted value saved in a snapshot is skipped and reported on restore
+	var buf bytes.Buffer
+	if err := s.Snapshot(&buf); err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	restored := NewMemoryStore()
+	defer restored.StopTTLWorker()
+	err = restored.RestoreSnapshot(&buf)
+	if !errors.Is(err, ErrChecksumMismatch) || !strings.Contains(err.Error(), `"queue"`) {
+		t.Errorf("Expected ErrChecksumMismatch naming queue, got %v", err)
+	}
+	if keys, _ := restored.Keys(ctx, "*"); len(keys) != 3 {
+		t.Errorf("Expected the 3 intact keys restored, got %v", keys)
+	}
+	if problems, _ := restored.VerifyIntegrity(ctx); len(problems) != 0 {
+		t.Errorf("Expected the restored keys intact, got %v", problems)
+	}
+}
+
+func TestListChecksumFollowsPushAndPop(t *testing.T) {
+	s := NewMemoryStoreWithOptions(Options{CompressThreshold: 64})
+	defer s.StopTTLWorker()
+	ctx := context.Background()
+
+	s.RPush(ctx, "queue", "a")
+	s.PushItem(ctx, "queue", "", store.PushOptions{Tail: true})
+	s.PushItem(ctx, "queue", strings.Repeat("compressible ", 20), store.PushOptions{MaxLen: 2})
+	s.Reserve(ctx, "queue", time.Minute)
+	s.RPush(ctx, "queue", "b")
+	s.RPop(ctx, "queue")
+	s.Push(ctx, "queue", "c")
+	s.PushItem(ctx, "queue", "e", store.PushOptions{Tail: true, MaxLen: 2})
+
+	if problems, _ := s.VerifyIntegrity(ctx); len(problems) != 0 {
+		t.Fatalf("Expected the checksum to follow pushes and pops, got %v", problems)
+	}
+	s.mu.RLock()
+	v := s.data["queue"]
+	s.mu.RUnlock()
+	if v.Checksum != checksum(v) {
+		t.Errorf("Expected checksum %08x, got %08x", checksum(v), v.Checksum)
+	}
+
+	// Updating the checksum rather than computing it again keeps a corrupted list flagged
+	tamper(s, "queue")
+	s.RPush(ctx, "queue", "d")
+	s.Pop(ctx, "queue")
+	if problems, _ := s.VerifyIntegrity(ctx); len(problems) != 1 {
+		t.Errorf("Expected queue to stay flagged, got %v", problems)
+	}
+}
+
+func TestListChecksumDetectsReordering(t *testing.T) {
+	s := NewMemoryStore()
+	defer s.StopTTLWorker()
+	ctx := context.Background()
+
+	s.RPush(ctx, "queue", "a")
+	s.RPush(ctx, "queue", "b")
+	s.Push(ctx, "queue", "c")
+
+	// Swap the first two items, keeping each one's content and sequence number
+	s.mu.Lock()
+	v := s.data["queue"]
+	v.List = []string{v.List[1], v.List[0], v.List[2]}
+	v.Seqs = []uint64{v.Seqs[1], v.Seqs[0], v.Seqs[2]}
+	s.data["queue"] = v
+	s.mu.Unlock()
+
+	if problems, _ := s.VerifyIntegrity(ctx); len(problems) != 1 {
+		t.Errorf("Expected the reordered list to be flagged, got %v", problems)
+	}
+
+	var buf bytes.Buffer
+	if err := s.Snapshot(&buf); err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	restored := NewMemoryStore()
+	defer restored.StopTTLWorker()
+	if err := restored.RestoreSnapshot(&buf); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch for the reordered list, got %v", err)
+	}
+}
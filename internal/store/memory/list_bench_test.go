@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"strconv"
+	"testing"
+)
+
+// listBenchSizes are the list lengths list operations are benchmarked at.
+var listBenchSizes = []struct {
+	name string
+	n    int
+}{
+	{"1K", 1_000},
+	{"100K", 100_000},
+	{"1M", 1_000_000},
+}
+
+// benchList returns a store holding a list of n items at key "list". The list is
+// written directly, as building it through Push would take quadratic time.
+func benchList(b *testing.B, n int) *MemoryStore {
+	b.Helper()
+
+	s := NewMemoryStore()
+	b.Cleanup(s.StopTTLWorker)
+
+	items := make([]string, n)
+	for i := range items {
+		items[i] = "item-" + strconv.Itoa(i)
+	}
+	s.put("list", Value{IsList: true, List: items})
+	return s
+}
+
+func BenchmarkListPush(b *testing.B) {
+	ctx := context.Background()
+	for _, size := range listBenchSizes {
+		b.Run(size.name, func(b *testing.B) {
+			s := benchList(b, size.n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				s.Push(ctx, "list", "item")
+				// Keep the list at its benchmarked length.
+				s.Pop(ctx, "list")
+			}
+		})
+	}
+}
+
+func BenchmarkListPop(b *testing.B) {
+	ctx := context.Background()
+	for _, size := range listBenchSizes {
+		b.Run(size.name, func(b *testing.B) {
+			s := benchList(b, size.n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				item, _ := s.Pop(ctx, "list")
+				s.Push(ctx, "list", item)
+			}
+		})
+	}
+}
+
+func BenchmarkListRangeHead(b *testing.B) {
+	ctx := context.Background()
+	for _, size := range listBenchSizes {
+		b.Run(size.name, func(b *testing.B) {
+			s := benchList(b, size.n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				s.LRange(ctx, "list", 0, 99)
+			}
+		})
+	}
+}
+
+func BenchmarkListRangeTail(b *testing.B) {
+	ctx := context.Background()
+	for _, size := range listBenchSizes {
+		b.Run(size.name, func(b *testing.B) {
+			s := benchList(b, size.n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				s.LRange(ctx, "list", -100, -1)
+			}
+		})
+	}
+}
+
+func BenchmarkListTrim(b *testing.B) {
+	ctx := context.Background()
+	for _, size := range listBenchSizes {
+		b.Run(size.name, func(b *testing.B) {
+			s := benchList(b, size.n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// Drop the front item and put it back.
+				removed, _ := s.LTrimReturn(ctx, "list", 1, -1)
+				s.Push(ctx, "list", removed[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+// listModel is a plain slice reference implementation of the list operations.
+// The store must produce the same observable results whatever its list representation.
+type listModel struct {
+	items  []string
+	exists bool
+}
+
+func (m *listModel) push(item string) {
+	m.items = append([]string{item}, m.items...)
+	m.exists = true
+}
+
+func (m *listModel) pop() (string, error) {
+	if !m.exists {
+		return "", memory.ErrKeyNotFound
+	}
+	if len(m.items) == 0 {
+		return "", memory.ErrEmptyList
+	}
+	item := m.items[0]
+	m.items = m.items[1:]
+	return item, nil
+}
+
+// bounds resolves start and stop like LRange: negative indexes count from the end
+// and out of range indexes are clamped.
+func (m *listModel) bounds(start, stop int) (int, int) {
+	n := len(m.items)
+	if start < 0 {
+		start += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+	return start, stop
+}
+
+func (m *listModel) lrange(start, stop int) ([]string, error) {
+	if !m.exists {
+		return nil, memory.ErrKeyNotFound
+	}
+	start, stop = m.bounds(start, stop)
+	if start > stop {
+		return []string{}, nil
+	}
+	return slices.Clone(m.items[start : stop+1]), nil
+}
+
+func (m *listModel) ltrim(start, stop int) ([]string, error) {
+	if !m.exists {
+		return nil, memory.ErrKeyNotFound
+	}
+	start, stop = m.bounds(start, stop)
+	if start > stop {
+		removed := m.items
+		m.items, m.exists = nil, false
+		return removed, nil
+	}
+	removed := append(slices.Clone(m.items[:start]), m.items[stop+1:]...)
+	m.items = slices.Clone(m.items[start : stop+1])
+	return removed, nil
+}
+
+// FuzzListOperations applies a sequence of list operations decoded from the input to
+// both the store and listModel and fails on the first difference in results.
+func FuzzListOperations(f *testing.F) {
+	f.Add([]byte{0, 1, 0, 2, 0, 3, 1, 2, 0, 255})
+	f.Add([]byte{0, 0, 0, 0, 3, 1, 254, 2, 0, 255, 1, 1, 1})
+	f.Add([]byte{2, 0, 255, 3, 0, 0, 0, 7, 3, 5, 2, 1})
+	f.Add([]byte{1, 0, 9, 0, 8, 0, 7, 3, 250, 255, 2, 253, 3, 1})
+
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		s := memory.NewMemoryStore()
+		defer s.StopTTLWorker()
+		ctx := context.Background()
+
+		var model listModel
+		for i := 0; i+2 < len(ops); i += 3 {
+			op := ops[i] % 4
+			// Signed arguments exercise negative and out of range indexes.
+			a, b := int(int8(ops[i+1])), int(int8(ops[i+2]))
+
+			switch op {
+			case 0:
+				item := fmt.Sprintf("item-%d", i)
+				if err := s.Push(ctx, "list", item); err != nil {
+					t.Fatalf("op %d: Push failed: %v", i, err)
+				}
+				model.push(item)
+			case 1:
+				got, err := s.Pop(ctx, "list")
+				want, wantErr := model.pop()
+				if !errors.Is(err, wantErr) || got != want {
+					t.Fatalf("op %d: Pop returned %q, %v; want %q, %v", i, got, err, want, wantErr)
+				}
+			case 2:
+				got, err := s.LRange(ctx, "list", a, b)
+				want, wantErr := model.lrange(a, b)
+				if !errors.Is(err, wantErr) || !slices.Equal(got, want) {
+					t.Fatalf("op %d: LRange(%d, %d) returned %v, %v; want %v, %v", i, a, b, got, err, want, wantErr)
+				}
+			case 3:
+				got, err := s.LTrimReturn(ctx, "list", a, b)
+				want, wantErr := model.ltrim(a, b)
+				if !errors.Is(err, wantErr) || !slices.Equal(got, want) {
+					t.Fatalf("op %d: LTrimReturn(%d, %d) returned %v, %v; want %v, %v", i, a, b, got, err, want, wantErr)
+				}
+			}
+		}
+
+		got, err := s.LRange(ctx, "list", 0, -1)
+		want, wantErr := model.lrange(0, -1)
+		if !errors.Is(err, wantErr) || !slices.Equal(got, want) {
+			t.Fatalf("final list is %v, %v; want %v, %v", got, err, want, wantErr)
+		}
+	})
+}
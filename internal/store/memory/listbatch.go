@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// ListBatch runs push and pop operations on any number of lists under a single
+// write lock and returns one result per operation, in order. A failing operation
+// does not stop the batch or undo earlier operations; its error is reported in its
+// result instead. Each operation behaves like the matching single call.
+func (s *MemoryStore) ListBatch(ctx context.Context, ops []store.ListOp, opts ...store.WriteOption) ([]store.ListOpResult, error) {
+	results := make([]store.ListOpResult, len(ops))
+
+	// Encode pushed items before taking the lock.
+	items := make([]string, len(ops))
+	for i, op := range ops {
+		if op.Op != store.ListOpPush {
+			continue
+		}
+		item, err := s.storedItem(op.Item)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		items[i] = item
+	}
+
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	o := store.NewWriteOptions(opts)
+	for i, op := range ops {
+		if results[i].Err != nil {
+			continue
+		}
+
+		switch op.Op {
+		case store.ListOpPush:
+			results[i].Err = s.pushLocked(op.Key, items[i], false, o)
+		case store.ListOpPop:
+			results[i].Item, results[i].Err = s.popLocked(op.Key, o)
+		default:
+			results[i].Err = ErrInvalidListOp
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// LMoveAll atomically moves every item of the list at src onto the front of the list
+// at dst and deletes src, returning the number of items moved. The moved items keep
+// their order and come before dst's existing items, so src's head becomes dst's head
+// and popping dst yields all of src's items before any of dst's. A missing dst is
+// created without a TTL; an existing dst keeps its TTL. The moved items are given new
+// sequence numbers from dst, as if they were pushed onto it, but keep their push times.
+//
+// It returns ErrKeyNotFound if src does not exist, ErrTypeMismatch if src or dst is
+// not a list and ErrKeyPendingDelete if dst is missing but pending soft delete, as
+// Push does. Moving a list onto itself leaves it unchanged and returns 0.
+func (s *MemoryStore) LMoveAll(ctx context.Context, src, dst string, opts ...store.WriteOption) (int, error) {
+	if err := s.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer s.mu.Unlock()
+
+	o := store.NewWriteOptions(opts)
+	if err := s.checkFence(src, o); err != nil {
+		return 0, err
+	}
+	if err := s.checkFence(dst, o); err != nil {
+		return 0, err
+	}
+
+	now := s.clock.Now()
+	from, exists := s.data[src]
+	if !exists || (!from.TTL.IsZero() && now.After(from.TTL)) {
+		return 0, ErrKeyNotFound
+	}
+	if !from.IsList {
+		return 0, ErrTypeMismatch
+	}
+
+	to, exists := s.data[dst]
+	if !exists || (!to.TTL.IsZero() && now.After(to.TTL)) {
+		if _, pending := s.pendingDelete(dst, now); pending {
+			return 0, ErrKeyPendingDelete
+		}
+		to = Value{IsList: true}
+	}
+	if !to.IsList {
+		return 0, ErrTypeMismatch
+	}
+
+	if src == dst {
+		return 0, nil
+	}
+
+	moved := len(from.List)
+	if s.listPushTimes {
+		to.PushedAt = append(append([]time.Time(nil), alignedPushTimes(from)...), alignedPushTimes(to)...)
+	} else {
+		to.PushedAt = nil
+	}
+	to.List = append(append(make([]string, 0, moved+len(to.List)), from.List...), to.List...)
+	to.Seqs = prependSeqs(&to, moved, to.Seqs)
+
+	s.del(src)
+	if err := s.reserve(dst, to); err != nil {
+		s.put(src, from)
+		return 0, err
+	}
+
+	s.put(dst, to)
+	s.recordFence(src, o)
+	s.recordFence(dst, o)
+	s.touch(dst, now)
+	return moved, nil
+}
@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// meteredRWMutex is a sync.RWMutex that optionally counts how often and how long
+// callers wait to acquire it. An uncontended acquisition succeeds on the first
+// TryLock and is not timed, so metering only costs time on the contended path, plus
+// timing how long the write lock is held. When disabled it adds a single branch per
+// acquisition.
+type meteredRWMutex struct {
+	sync.RWMutex
+
+	// enabled is set once at construction and never changed.
+	enabled bool
+
+	writeContentions atomic.Uint64
+	writeWaitNanos   atomic.Int64
+	readContentions  atomic.Uint64
+	readWaitNanos    atomic.Int64
+	maxWriteHold     atomic.Int64
+
+	// writeLocked is when the write lock was acquired, only accessed by its holder.
+	writeLocked time.Time
+
+	// watchdog reports write locks held too long, nil when disabled.
+	watchdog *lockWatchdog
+}
+
+func (m *meteredRWMutex) Lock() {
+	m.lock()
+	if m.enabled {
+		m.writeLocked = time.Now()
+	}
+	if m.watchdog != nil {
+		m.watchdog.acquired()
+	}
+}
+
+func (m *meteredRWMutex) Unlock() {
+	if m.enabled {
+		m.recordHold(time.Since(m.writeLocked))
+	}
+	if m.watchdog != nil {
+		m.watchdog.released()
+	}
+	m.RWMutex.Unlock()
+}
+
+// recordHold raises the longest write lock hold to held if it is longer.
+func (m *meteredRWMutex) recordHold(held time.Duration) {
+	for {
+		longest := m.maxWriteHold.Load()
+		if int64(held) <= longest || m.maxWriteHold.CompareAndSwap(longest, int64(held)) {
+			return
+		}
+	}
+}
+
+func (m *meteredRWMutex) lock() {
+	if !m.enabled {
+		m.RWMutex.Lock()
+		return
+	}
+	if m.RWMutex.TryLock() {
+		return
+	}
+
+	start := time.Now()
+	m.RWMutex.Lock()
+	m.writeContentions.Add(1)
+	m.writeWaitNanos.Add(int64(time.Since(start)))
+}
+
+func (m *meteredRWMutex) RLock() {
+	if !m.enabled {
+		m.RWMutex.RLock()
+		return
+	}
+	if m.RWMutex.TryRLock() {
+		return
+	}
+
+	start := time.Now()
+	m.RWMutex.RLock()
+	m.readContentions.Add(1)
+	m.readWaitNanos.Add(int64(time.Since(start)))
+}
+
+func (m *meteredRWMutex) stats() store.LockStats {
+	return store.LockStats{
+		Enabled:          m.enabled,
+		WriteContentions: m.writeContentions.Load(),
+		WriteWait:        time.Duration(m.writeWaitNanos.Load()),
+		ReadContentions:  m.readContentions.Load(),
+		ReadWait:         time.Duration(m.readWaitNanos.Load()),
+		MaxWriteHold:     time.Duration(m.maxWriteHold.Load()),
+	}
+}
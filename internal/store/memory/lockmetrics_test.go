@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// holdLockWhile holds the store write lock for d while the given operations run
+// concurrently, so each of them has to wait for it.
+func holdLockWhile(s *MemoryStore, d time.Duration, ops ...func()) {
+	s.mu.Lock()
+
+	var wg sync.WaitGroup
+	for _, op := range ops {
+		wg.Add(1)
+		go func(op func()) {
+			defer wg.Done()
+			op()
+		}(op)
+	}
+
+	time.Sleep(d)
+	s.mu.Unlock()
+	wg.Wait()
+}
+
+func TestLockMetrics(t *testing.T) {
+	s := NewMemoryStoreWithOptions(Options{LockMetrics: true})
+	// The TTL worker would add contention of its own.
+	s.StopTTLWorker()
+	ctx := context.Background()
+
+	holdLockWhile(s, 50*time.Millisecond,
+		func() { s.Set(ctx, "a", "1", 0) },
+		func() { s.Set(ctx, "b", "2", 0) },
+		func() { s.Get(ctx, "a") },
+	)
+
+	stats, err := s.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+
+	if !stats.Locks.Enabled {
+		t.Error("Expected lock metrics to be enabled")
+	}
+	if stats.Locks.WriteContentions != 2 {
+		t.Errorf("Expected 2 write contentions, got %d", stats.Locks.WriteContentions)
+	}
+	if stats.Locks.WriteWait < 50*time.Millisecond {
+		t.Errorf("Expected write wait of at least 50ms, got %v", stats.Locks.WriteWait)
+	}
+	if stats.Locks.ReadContentions != 1 {
+		t.Errorf("Expected 1 read contention, got %d", stats.Locks.ReadContentions)
+	}
+	if stats.Locks.MaxWriteHold < 50*time.Millisecond {
+		t.Errorf("Expected the longest write lock hold to be at least 50ms, got %v", stats.Locks.MaxWriteHold)
+	}
+
+	// Uncontended operations do not count.
+	s.Set(ctx, "c", "3", 0)
+	if after, _ := s.Stats(ctx); after.Locks.WriteContentions != stats.Locks.WriteContentions {
+		t.Errorf("Expected uncontended Set not to count, got %d contentions", after.Locks.WriteContentions)
+	}
+}
+
+func TestLockMetricsDisabled(t *testing.T) {
+	s := NewMemoryStore()
+	s.StopTTLWorker()
+	ctx := context.Background()
+
+	holdLockWhile(s, 20*time.Millisecond, func() { s.Set(ctx, "a", "1", 0) })
+
+	stats, _ := s.Stats(ctx)
+	if stats.Locks.Enabled || stats.Locks.WriteContentions != 0 || stats.Locks.WriteWait != 0 {
+		t.Errorf("Expected no lock metrics when disabled, got %+v", stats.Locks)
+	}
+}
@@ -0,0 +1,103 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// lockHolder records when and where the write lock was acquired.
+type lockHolder struct {
+	since  time.Time
+	pcs    []uintptr
+	warned atomic.Bool
+}
+
+// lockWatchdog logs a warning when the store write lock is held longer than threshold,
+// along with the stack that acquired it. Holding the lock only records the holder; the
+// watchdog goroutine checks it every half threshold, so a generous threshold costs
+// little more than the bookkeeping.
+//
+// Only the write lock is watched. Read locks may be held by many goroutines at once
+// and released by none in particular, so they cannot be attributed to a holder.
+type lockWatchdog struct {
+	threshold time.Duration
+	logger    *log.Logger
+	holder    atomic.Pointer[lockHolder]
+	cancel    context.CancelFunc
+}
+
+func newLockWatchdog(threshold time.Duration, logger *log.Logger) *lockWatchdog {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &lockWatchdog{threshold: threshold, logger: logger}
+}
+
+// acquired records the caller of Lock as the holder of the write lock.
+func (w *lockWatchdog) acquired() {
+	pcs := make([]uintptr, 16)
+	// Skip runtime.Callers, acquired and meteredRWMutex.Lock.
+	n := runtime.Callers(3, pcs)
+	w.holder.Store(&lockHolder{since: time.Now(), pcs: pcs[:n]})
+}
+
+func (w *lockWatchdog) released() {
+	w.holder.Store(nil)
+}
+
+// check warns about the current holder once it has held the lock past the threshold.
+// Each acquisition is warned about at most once.
+func (w *lockWatchdog) check(now time.Time) {
+	h := w.holder.Load()
+	if h == nil {
+		return
+	}
+	held := now.Sub(h.since)
+	if held < w.threshold || !h.warned.CompareAndSwap(false, true) {
+		return
+	}
+	w.logger.Printf("store write lock held for over %v (threshold %v), acquired at:\n%s", held.Round(time.Millisecond), w.threshold, formatStack(h.pcs))
+}
+
+func (w *lockWatchdog) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	ticker := time.NewTicker(max(w.threshold/2, time.Millisecond))
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				w.check(now)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func formatStack(pcs []uintptr) string {
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "\t%s\n\t\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			return b.String()
+		}
+	}
+}
+
+// StopLockWatchdog stops watching how long the store lock is held. It does nothing if
+// the watchdog is disabled.
+func (s *MemoryStore) StopLockWatchdog() {
+	if s.mu.watchdog != nil && s.mu.watchdog.cancel != nil {
+		s.mu.watchdog.cancel()
+	}
+}
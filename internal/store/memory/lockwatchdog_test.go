@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for the watchdog goroutine to write to while the
+// test reads it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// slowLockedOperation holds the store write lock for d, like a pathological operation.
+func slowLockedOperation(s *MemoryStore, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	time.Sleep(d)
+}
+
+func TestLockWatchdog(t *testing.T) {
+	var logged syncBuffer
+	s := NewMemoryStoreWithOptions(Options{LockHoldThreshold: 20 * time.Millisecond, LockHoldLog: log.New(&logged, "", 0)})
+	defer s.StopLockWatchdog()
+	defer s.StopTTLWorker()
+	ctx := context.Background()
+
+	// Fast operations stay quiet
+	for i := 0; i < 100; i++ {
+		s.Set(ctx, "key", i, 0)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := logged.String(); got != "" {
+		t.Fatalf("Expected no warning for fast operations, got %q", got)
+	}
+
+	slowLockedOperation(s, 100*time.Millisecond)
+
+	got := logged.String()
+	if !strings.Contains(got, "store write lock held for over") {
+		t.Fatalf("Expected a lock hold warning, got %q", got)
+	}
+	if !strings.Contains(got, "slowLockedOperation") {
+		t.Errorf("Expected the warning to name the lock holder, got %q", got)
+	}
+	if n := strings.Count(got, "store write lock held"); n != 1 {
+		t.Errorf("Expected a single warning per acquisition, got %d", n)
+	}
+}
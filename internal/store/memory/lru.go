@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lruList orders keys from the most to the least recently accessed, so FullEvict finds
+// its victim without scanning the keyspace. Keys are only added and removed under the
+// store's write lock, while accesses move them to the front under the list's own lock,
+// as reads may hold just the read lock or, with Options.ReadIndex, no store lock at all.
+type lruList struct {
+	mu    sync.Mutex
+	order *list.List
+	elems map[string]*list.Element
+}
+
+func newLRUList() *lruList {
+	return &lruList{order: list.New(), elems: make(map[string]*list.Element)}
+}
+
+// add tracks a new key as the least recently accessed one, until it is first accessed.
+func (l *lruList) add(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.elems[key]; !ok {
+		l.elems[key] = l.order.PushBack(key)
+	}
+}
+
+// touch marks key as the most recently accessed. Untracked keys are ignored, so a
+// lock-free read racing with a delete cannot bring the key back.
+func (l *lruList) touch(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, ok := l.elems[key]; ok {
+		l.order.MoveToFront(e)
+	}
+}
+
+func (l *lruList) remove(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, ok := l.elems[key]; ok {
+		l.order.Remove(e)
+		delete(l.elems, key)
+	}
+}
+
+// oldest returns the least recently accessed key other than keep.
+func (l *lruList) oldest(keep string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for e := l.order.Back(); e != nil; e = e.Prev() {
+		if key := e.Value.(string); key != keep {
+			return key, true
+		}
+	}
+	return "", false
+}
@@ -4,38 +4,181 @@ package memory
 import (
 	"context"
 	"encoding/json"
-	"errors"
+	"maps"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
 )
 
 var (
-	ErrKeyNotFound   = errors.New("key not found")
-	ErrTypeMismatch  = errors.New("operation not supported for this data type")
-	ErrInvalidTTL    = errors.New("invalid TTL value")
-	ErrEmptyList     = errors.New("list is empty")
-	ErrMarshalFailed = errors.New("failed to marshal value to JSON")
+	ErrKeyNotFound   = store.ErrKeyNotFound
+	ErrTypeMismatch  = store.ErrTypeMismatch
+	ErrInvalidTTL    = store.ErrInvalidTTL
+	ErrEmptyList     = store.ErrEmptyList
+	ErrMarshalFailed = store.ErrMarshalFailed
+
+	ErrInvalidRateLimit = store.ErrInvalidRateLimit
+	ErrInvalidPattern   = store.ErrInvalidPattern
+	ErrKeyExists        = store.ErrKeyExists
+	ErrStaleFence       = store.ErrStaleFence
+	ErrUnknownFence     = store.ErrUnknownFence
+	ErrOutOfMemory      = store.ErrOutOfMemory
+	ErrReceiptNotFound  = store.ErrReceiptNotFound
+	ErrKeyPendingDelete = store.ErrKeyPendingDelete
+	ErrInvalidListOp    = store.ErrInvalidListOp
+	ErrValueTooLarge    = store.ErrValueTooLarge
+	ErrInvalidBuckets   = store.ErrInvalidBuckets
+	ErrTooManyKeys      = store.ErrTooManyKeys
+	ErrNotInteger       = store.ErrNotInteger
+	ErrIntegerOverflow  = store.ErrIntegerOverflow
+	ErrInvalidType      = store.ErrInvalidType
+	ErrIndexOutOfRange  = store.ErrIndexOutOfRange
+	ErrChecksumMismatch = store.ErrChecksumMismatch
+	ErrFieldNotFound    = store.ErrFieldNotFound
+	ErrTimeout          = store.ErrTimeout
 )
 
 type MemoryStore struct {
-	mu        sync.RWMutex
+	clock     Clock
+	mu        meteredRWMutex
 	data      map[string]Value
 	ttlCtx    context.Context
 	ttlCancel context.CancelFunc
+	// ttlPaused makes the TTL worker skip its sweeps, see PauseTTLWorker.
+	ttlPaused atomic.Bool
+	sampler   *listSampler
+	rates     map[string]*rateWindow
+	access    map[string]*keyAccess
+	health    workerHealth
+
+	tombstones       map[string]tombstone
+	softDeleteWindow time.Duration
+
+	fences   map[string]uint64
+	fenceSeq uint64
+	// deletedFences holds the fences of deleted keys in the order they were left
+	// behind, so the oldest can be dropped, and retiredFences the token queued per
+	// key, see retireFence.
+	deletedFences []keyFence
+	retiredFences map[string]uint64
+
+	// usedBytes is the estimated size of all keys, soft deleted values and reserved
+	// items, see estimateSize and reservationSize.
+	usedBytes      int64
+	maxMemoryBytes int64
+	maxKeys        int
+	onFull         FullPolicy
+	// lru orders keys by access for FullEvict, nil under other policies.
+	lru *lruList
+
+	maxListItemBytes int
+
+	compressThreshold int
+
+	// listPushTimes records a push time per list item, see Value.PushedAt.
+	listPushTimes bool
+
+	ttlDefaults store.TTLDefaults
+
+	// index mirrors data for reads without the store lock if Options.ReadIndex is set, nil otherwise.
+	index *sync.Map
+
+	inflight map[string]reservation
+
+	events *eventLog
+
+	pubsub *PubSub
+	// keyspaceNotifications publishes key changes on pubsub, see Options.KeyspaceNotifications.
+	keyspaceNotifications bool
+
+	// tags maps each tag to the keys tagged with it, see Value.Tags.
+	tags map[string]map[string]struct{}
+
+	// listWaiters holds the BLPop calls waiting for an item, by list key.
+	listWaiters map[string]*listWaiter
+
+	// aof records every mutation for ReplayAOF, nil when disabled.
+	aof *AOFWriter
 }
 
-// NewMemoryStore initializes a new in memory store.
+// NewMemoryStore initializes a new in memory store with default options.
 func NewMemoryStore() *MemoryStore {
+	return NewMemoryStoreWithOptions(Options{})
+}
+
+// NewMemoryStoreWithClock initializes a new in memory store with default options that
+// reads the current time from clock, e.g. a fake clock in tests.
+func NewMemoryStoreWithClock(clock Clock) *MemoryStore {
+	return NewMemoryStoreWithOptions(Options{Clock: clock})
+}
+
+// NewMemoryStoreWithOptions initializes a new in memory store configured by opts.
+func NewMemoryStoreWithOptions(opts Options) *MemoryStore {
+	opts = opts.withDefaults()
+
 	s := &MemoryStore{
+		clock:     opts.Clock,
+		mu:        meteredRWMutex{enabled: opts.LockMetrics},
 		data:      make(map[string]Value),
+		rates:     make(map[string]*rateWindow),
+		access:    make(map[string]*keyAccess),
 		ttlCtx:    nil,
 		ttlCancel: nil,
+
+		tombstones:       make(map[string]tombstone),
+		softDeleteWindow: opts.SoftDeleteWindow,
+
+		fences:        make(map[string]uint64),
+		retiredFences: make(map[string]uint64),
+
+		tags: make(map[string]map[string]struct{}),
+
+		listWaiters: make(map[string]*listWaiter),
+
+		maxMemoryBytes: opts.MaxMemoryBytes,
+		maxKeys:        opts.MaxKeys,
+		onFull:         opts.OnFull,
+
+		maxListItemBytes: opts.MaxListItemBytes,
+
+		compressThreshold: opts.CompressThreshold,
+
+		listPushTimes: opts.ListPushTimes,
+
+		ttlDefaults: opts.TTLDefaults,
+
+		inflight: make(map[string]reservation),
+
+		events: newEventLog(opts.EventBufferSize),
+
+		pubsub:                NewPubSub(opts.Clock),
+		keyspaceNotifications: opts.KeyspaceNotifications,
+
+		aof: opts.AOF,
+	}
+	if opts.ReadIndex {
+		s.index = &sync.Map{}
+	}
+	if opts.OnFull == FullEvict {
+		s.lru = newLRUList()
+	}
+	if opts.LockHoldThreshold > 0 {
+		s.mu.watchdog = newLockWatchdog(opts.LockHoldThreshold, opts.LockHoldLog)
+		s.mu.watchdog.start()
 	}
 
 	// Start the bakground worker to clean expired keys
 	s.ttlCtx, s.ttlCancel = context.WithCancel(context.Background())
 	s.doStartTTLWorker()
 
+	if opts.ListSampleInterval > 0 {
+		s.sampler = newListSampler(opts.ListSampleCapacity, opts.ListSampleMaxLists)
+		s.startListSampler(opts.ListSampleInterval)
+	}
+
 	return s
 }
 
@@ -65,33 +208,209 @@ func (s *MemoryStore) StopTTLWorker() {
 	}
 }
 
-// Set sets a key with a value and optional ttl (0 = no TTL)
-func (s *MemoryStore) Set(ctx context.Context, key string, value any, ttlSeconds int) error {
+// PauseTTLWorker makes the TTL worker skip its sweeps until ResumeTTLWorker is called,
+// e.g. so keys loaded with short TTLs during a bulk load are not deleted mid-load. The
+// worker keeps running, so pausing and resuming is cheap. Reads still treat expired
+// keys as missing; only their deletion, along with the other sweep duties such as
+// redelivering unacknowledged reservations, is put off.
+func (s *MemoryStore) PauseTTLWorker() {
+	s.ttlPaused.Store(true)
+}
+
+// ResumeTTLWorker resumes the sweeps paused by PauseTTLWorker. Keys that expired while
+// paused are deleted by the next sweep.
+func (s *MemoryStore) ResumeTTLWorker() {
+	s.ttlPaused.Store(false)
+}
+
+// Set sets a key with a value and optional ttl. A ttl of 0 applies the key's default
+// TTL, see Options.TTLDefaults, and without defaults means no expiration.
+func (s *MemoryStore) Set(ctx context.Context, key string, value any, ttlSeconds int, opts ...store.WriteOption) error {
 	if ttlSeconds < 0 {
 		return ErrInvalidTTL
 	}
 
+	o := store.NewWriteOptions(opts)
 	stringValue, err := s.Stringify(value)
 	if err != nil {
 		return ErrMarshalFailed
 	}
+	stringValue, compressed := s.encode(stringValue, o.NoCompress)
 
-	s.mu.Lock()
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
 	defer s.mu.Unlock()
 
-	var ttl time.Time
-	if ttlSeconds > 0 {
-		ttl = time.Now().Add(time.Duration(ttlSeconds) * time.Second)
+	if err := s.checkFence(key, o); err != nil {
+		return err
+	}
+
+	v := Value{Val: stringValue, TTL: s.ttlFromSeconds(s.defaultTTL(key, ttlSeconds)), IsList: false, IsJSON: isJSON(value), Compressed: compressed, Tags: uniqueTags(o.Tags)}
+	if err := s.reserve(key, v); err != nil {
+		return err
 	}
-	// If ttlSeconds == 0, ttl remains zero (no expiration)
 
-	s.data[key] = Value{Val: stringValue, TTL: ttl, IsList: false}
+	s.put(key, v)
+	s.recordFence(key, o)
+	s.touch(key, s.clock.Now())
 	return nil
 }
 
+// SetNX sets a key only if it does not exist or has expired. It reports whether the key was set.
+func (s *MemoryStore) SetNX(ctx context.Context, key string, value any, ttlSeconds int, opts ...store.WriteOption) (bool, error) {
+	set, _, err := s.setNX(ctx, key, value, ttlSeconds, false, store.NewWriteOptions(opts))
+	return set, err
+}
+
+// SetNXFenced sets a key like SetNX and, if it was set, also returns a new fence token
+// for the key. Writes carrying a lower token are rejected from then on.
+func (s *MemoryStore) SetNXFenced(ctx context.Context, key string, value any, ttlSeconds int, opts ...store.WriteOption) (bool, uint64, error) {
+	return s.setNX(ctx, key, value, ttlSeconds, true, store.NewWriteOptions(opts))
+}
+
+// setNX implements SetNX and SetNXFenced, issuing a fence token only if fenced is set.
+func (s *MemoryStore) setNX(ctx context.Context, key string, value any, ttlSeconds int, fenced bool, o store.WriteOptions) (bool, uint64, error) {
+	if ttlSeconds < 0 {
+		return false, 0, ErrInvalidTTL
+	}
+
+	stringValue, err := s.Stringify(value)
+	if err != nil {
+		return false, 0, ErrMarshalFailed
+	}
+	stringValue, compressed := s.encode(stringValue, o.NoCompress)
+
+	if err := s.lock(ctx); err != nil {
+		return false, 0, err
+	}
+	defer s.mu.Unlock()
+
+	if err := s.checkFence(key, o); err != nil {
+		return false, 0, err
+	}
+
+	if v, exists := s.data[key]; exists && (v.TTL.IsZero() || s.clock.Now().Before(v.TTL)) {
+		return false, 0, nil
+	}
+
+	v := Value{Val: stringValue, TTL: s.ttlFromSeconds(s.defaultTTL(key, ttlSeconds)), IsList: false, IsJSON: isJSON(value), Compressed: compressed, Tags: uniqueTags(o.Tags)}
+	if err := s.reserve(key, v); err != nil {
+		return false, 0, err
+	}
+
+	s.put(key, v)
+	s.recordFence(key, o)
+	s.touch(key, s.clock.Now())
+	if !fenced {
+		return true, 0, nil
+	}
+	return true, s.issueFence(key), nil
+}
+
+// SetIfExpiringWithin sets a key only if it is missing, expired, or expires in less than
+// thresholdSeconds. Keys without a TTL never qualify. It reports whether the key was set.
+func (s *MemoryStore) SetIfExpiringWithin(ctx context.Context, key string, value any, ttlSeconds, thresholdSeconds int, opts ...store.WriteOption) (bool, error) {
+	if ttlSeconds < 0 || thresholdSeconds < 0 {
+		return false, ErrInvalidTTL
+	}
+
+	o := store.NewWriteOptions(opts)
+	stringValue, err := s.Stringify(value)
+	if err != nil {
+		return false, ErrMarshalFailed
+	}
+	stringValue, compressed := s.encode(stringValue, o.NoCompress)
+
+	if err := s.lock(ctx); err != nil {
+		return false, err
+	}
+	defer s.mu.Unlock()
+
+	if err := s.checkFence(key, o); err != nil {
+		return false, err
+	}
+
+	now := s.clock.Now()
+	if v, exists := s.data[key]; exists && (v.TTL.IsZero() || v.TTL.Sub(now) >= time.Duration(thresholdSeconds)*time.Second) {
+		return false, nil
+	}
+
+	v := Value{Val: stringValue, TTL: s.ttlFromSeconds(s.defaultTTL(key, ttlSeconds)), IsList: false, IsJSON: isJSON(value), Compressed: compressed, Tags: uniqueTags(o.Tags)}
+	if err := s.reserve(key, v); err != nil {
+		return false, err
+	}
+
+	s.put(key, v)
+	s.recordFence(key, o)
+	s.touch(key, now)
+	return true, nil
+}
+
+// SetIfType sets a key only if it is missing, expired, or holds a value of expectedType,
+// so a client expecting a string does not clobber a key that has become a list, a hash
+// or a set. It reports whether the key was set. The value is always stored as a string,
+// so expectedType must be store.TypeString; any other type returns ErrInvalidType
+// rather than replacing a value of that type with a string.
+func (s *MemoryStore) SetIfType(ctx context.Context, key string, value any, ttlSeconds int, expectedType string, opts ...store.WriteOption) (bool, error) {
+	if ttlSeconds < 0 {
+		return false, ErrInvalidTTL
+	}
+	if expectedType != store.TypeString {
+		return false, ErrInvalidType
+	}
+
+	o := store.NewWriteOptions(opts)
+	stringValue, err := s.Stringify(value)
+	if err != nil {
+		return false, ErrMarshalFailed
+	}
+	stringValue, compressed := s.encode(stringValue, o.NoCompress)
+
+	if err := s.lock(ctx); err != nil {
+		return false, err
+	}
+	defer s.mu.Unlock()
+
+	if err := s.checkFence(key, o); err != nil {
+		return false, err
+	}
+
+	now := s.clock.Now()
+	if v, exists := s.data[key]; exists && (v.TTL.IsZero() || now.Before(v.TTL)) && v.kind() != expectedType {
+		return false, nil
+	}
+
+	v := Value{Val: stringValue, TTL: s.ttlFromSeconds(s.defaultTTL(key, ttlSeconds)), IsList: false, IsJSON: isJSON(value), Compressed: compressed, Tags: uniqueTags(o.Tags)}
+	if err := s.reserve(key, v); err != nil {
+		return false, err
+	}
+
+	s.put(key, v)
+	s.recordFence(key, o)
+	s.touch(key, now)
+	return true, nil
+}
+
 // Get gets a value from the store
 func (s *MemoryStore) Get(ctx context.Context, key string) (string, error) {
-	s.mu.RLock()
+	if s.index != nil {
+		v, ok, err := s.lookup(ctx, key, s.clock.Now())
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", ErrKeyNotFound
+		}
+		if !v.isString() {
+			return "", ErrTypeMismatch
+		}
+		return v.text(), nil
+	}
+
+	if err := s.rlock(ctx); err != nil {
+		return "", err
+	}
 
 	v, ok := s.data[key]
 	if !ok {
@@ -100,19 +419,21 @@ func (s *MemoryStore) Get(ctx context.Context, key string) (string, error) {
 	}
 
 	// key exists and is not expired or doesn't have a TTL, return the value
-	if v.TTL.IsZero() || time.Now().Before(v.TTL) {
-		if v.IsList {
+	if now := s.clock.Now(); v.TTL.IsZero() || now.Before(v.TTL) {
+		if !v.isString() {
 			s.mu.RUnlock()
 			return "", ErrTypeMismatch
 		}
-		result := v.Val
+		s.touch(key, now)
 		s.mu.RUnlock()
-		return result, nil
+		return v.text(), nil
 	}
 
 	// key is expired, lazy delete it
 	s.mu.RUnlock()
-	s.mu.Lock()
+	if err := s.lock(ctx); err != nil {
+		return "", err
+	}
 	defer s.mu.Unlock()
 
 	v, ok = s.data[key]
@@ -120,151 +441,1229 @@ func (s *MemoryStore) Get(ctx context.Context, key string) (string, error) {
 		return "", ErrKeyNotFound
 	}
 
-	if !v.TTL.IsZero() && time.Now().After(v.TTL) {
-		delete(s.data, key)
+	if !v.TTL.IsZero() && s.clock.Now().After(v.TTL) {
+		s.del(key)
 		return "", ErrKeyNotFound
 	}
 
-	if v.IsList {
+	if !v.isString() {
 		return "", ErrTypeMismatch
 	}
 
-	return v.Val, nil
+	return v.text(), nil
+}
+
+// Exists reports whether a key of any type exists, without reading its value or
+// counting as an access. An expired key is reported missing and deleted, or left for
+// the TTL worker when read through Options.ReadIndex.
+func (s *MemoryStore) Exists(ctx context.Context, key string) (bool, error) {
+	now := s.clock.Now()
+	if s.index != nil {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		e, ok := s.index.Load(key)
+		if !ok {
+			return false, nil
+		}
+		v := e.(*indexEntry).value
+		return v.TTL.IsZero() || now.Before(v.TTL), nil
+	}
+
+	if err := s.rlock(ctx); err != nil {
+		return false, err
+	}
+	v, ok := s.data[key]
+	s.mu.RUnlock()
+	if !ok {
+		return false, nil
+	}
+	if v.TTL.IsZero() || now.Before(v.TTL) {
+		return true, nil
+	}
+
+	// key is expired, lazy delete it unless it was set again in the meantime
+	if err := s.lock(ctx); err != nil {
+		return false, err
+	}
+	defer s.mu.Unlock()
+
+	v, ok = s.data[key]
+	if !ok {
+		return false, nil
+	}
+	if !v.TTL.IsZero() && s.clock.Now().After(v.TTL) {
+		s.del(key)
+		return false, nil
+	}
+	return true, nil
+}
+
+// TTL returns the remaining time to live of a string or list key in seconds, rounded
+// up, or -1 if the key does not expire. It returns ErrKeyNotFound for missing and
+// expired keys, and does not count as an access.
+func (s *MemoryStore) TTL(ctx context.Context, key string) (int, error) {
+	if err := s.rlock(ctx); err != nil {
+		return 0, err
+	}
+	defer s.mu.RUnlock()
+
+	now := s.clock.Now()
+	v, ok := s.data[key]
+	if !ok || (!v.TTL.IsZero() && now.After(v.TTL)) {
+		return 0, ErrKeyNotFound
+	}
+	return remainingTTLSeconds(v, now), nil
+}
+
+// GetAny returns the value of a key of any type along with its kind, store.TypeString,
+// store.TypeList, store.TypeHash or store.TypeSet. String values are returned as a
+// string, lists as a copy of their items as a []string, hashes as a copy of their
+// fields as a map[string]string and sets as their sorted members as a []string, so it
+// never returns ErrTypeMismatch.
+func (s *MemoryStore) GetAny(ctx context.Context, key string) (any, string, error) {
+	v, exists, err := s.getAny(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+	if !exists {
+		return nil, "", ErrKeyNotFound
+	}
+
+	switch {
+	case v.IsList:
+		return itemTexts(v.List), store.TypeList, nil
+	case v.IsHash:
+		return maps.Clone(v.Hash), store.TypeHash, nil
+	case v.IsSet:
+		return members(v), store.TypeSet, nil
+	}
+	return v.text(), store.TypeString, nil
+}
+
+// GetEntries returns a typed snapshot of each key, in order, under a single read lock.
+// Missing and expired keys are reported with type store.TypeNone.
+func (s *MemoryStore) GetEntries(ctx context.Context, keys []string) ([]store.KeyEntry, error) {
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	now := s.clock.Now()
+	entries := make([]store.KeyEntry, len(keys))
+	for i, key := range keys {
+		if err := checkCtx(ctx, i); err != nil {
+			return nil, err
+		}
+		v, ok := s.data[key]
+		if !ok || (!v.TTL.IsZero() && now.After(v.TTL)) {
+			entries[i] = store.KeyEntry{Key: key, Type: store.TypeNone}
+			continue
+		}
+		entries[i] = entryOf(key, v, now)
+		s.touch(key, now)
+	}
+
+	return entries, nil
 }
 
 // Update updates a value in the store
-func (s *MemoryStore) Update(ctx context.Context, key string, value any) error {
+func (s *MemoryStore) Update(ctx context.Context, key string, value any, opts ...store.WriteOption) error {
+	o := store.NewWriteOptions(opts)
 	stringValue, err := s.Stringify(value)
 	if err != nil {
 		return ErrMarshalFailed
 	}
+	stringValue, compressed := s.encode(stringValue, o.NoCompress)
 
-	s.mu.Lock()
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
 	defer s.mu.Unlock()
 
+	if err := s.checkFence(key, o); err != nil {
+		return err
+	}
+
 	v, exists := s.data[key]
 	if !exists {
 		return ErrKeyNotFound
 	}
 
 	// If expired, delete it and return key not found
-	if !v.TTL.IsZero() && time.Now().After(v.TTL) {
-		delete(s.data, key)
+	if !v.TTL.IsZero() && s.clock.Now().After(v.TTL) {
+		s.del(key)
 		return ErrKeyNotFound
 	}
 
-	if v.IsList {
+	if !v.isString() {
 		return ErrTypeMismatch
 	}
 
-	v.Val = stringValue
-	s.data[key] = v
+	v.Val, v.Compressed = stringValue, compressed
+	v.IsJSON = isJSON(value)
+	if err := s.reserve(key, v); err != nil {
+		return err
+	}
+
+	s.put(key, v)
+	s.recordFence(key, o)
+	s.touch(key, s.clock.Now())
 	return nil
 }
 
 // Remove deletes a key from the store
-func (s *MemoryStore) Remove(ctx context.Context, key string) error {
-	s.mu.Lock()
+func (s *MemoryStore) Remove(ctx context.Context, key string, opts ...store.WriteOption) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
 	defer s.mu.Unlock()
 
+	o := store.NewWriteOptions(opts)
+	if err := s.checkFence(key, o); err != nil {
+		return err
+	}
+
 	if _, exists := s.data[key]; !exists {
 		return ErrKeyNotFound
 	}
 
-	delete(s.data, key)
+	s.del(key)
+	s.recordFence(key, o)
 	return nil
 }
 
-// List operations
-func (s *MemoryStore) Push(ctx context.Context, key string, item any) error {
-	stringItem, err := s.Stringify(item)
-	if err != nil {
-		return ErrMarshalFailed
+// CompareAndDelete deletes a string key only if its current value equals expected.
+// It reports whether the key was deleted.
+func (s *MemoryStore) CompareAndDelete(ctx context.Context, key string, expected string, opts ...store.WriteOption) (bool, error) {
+	if err := s.lock(ctx); err != nil {
+		return false, err
 	}
-
-	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	v := s.data[key]
+	o := store.NewWriteOptions(opts)
+	if err := s.checkFence(key, o); err != nil {
+		return false, err
+	}
 
-	// If the key doesn't exist, or exists but expired, then create a new list
-	if _, exists := s.data[key]; !exists || (!v.TTL.IsZero() && time.Now().After(v.TTL)) {
-		// If key exists but is expired, lazy delete it first
-		if _, exists := s.data[key]; exists && (!v.TTL.IsZero() && time.Now().After(v.TTL)) {
-			delete(s.data, key)
-		}
-		v = Value{IsList: true, List: []string{}}
+	v, err := s.liveString(key)
+	if err != nil {
+		return false, err
 	}
 
-	if !v.IsList {
-		return ErrTypeMismatch
+	if v.text() != expected {
+		return false, nil
 	}
 
-	v.List = append([]string{stringItem}, v.List...)
-	s.data[key] = v
-	return nil
+	s.del(key)
+	s.recordFence(key, o)
+	return true, nil
 }
 
-// Pop takes a value from the list
-func (s *MemoryStore) Pop(ctx context.Context, key string) (string, error) {
-	s.mu.Lock()
+// Expire changes the TTL of an existing key. A ttlSeconds of 0 removes the expiration.
+func (s *MemoryStore) Expire(ctx context.Context, key string, ttlSeconds int, opts ...store.WriteOption) error {
+	_, err := s.expire(ctx, key, ttlSeconds, false, store.NewWriteOptions(opts))
+	return err
+}
+
+// Persist removes the expiration of an existing key, like Expire with a ttlSeconds of 0.
+func (s *MemoryStore) Persist(ctx context.Context, key string) error {
+	return s.Expire(ctx, key, 0)
+}
+
+// ExpireFenced changes the TTL of a key like Expire and returns a new fence token for
+// the key. Writes carrying a lower token are rejected from then on.
+func (s *MemoryStore) ExpireFenced(ctx context.Context, key string, ttlSeconds int, opts ...store.WriteOption) (uint64, error) {
+	return s.expire(ctx, key, ttlSeconds, true, store.NewWriteOptions(opts))
+}
+
+// expire implements Expire and ExpireFenced, issuing a fence token only if fenced is set.
+func (s *MemoryStore) expire(ctx context.Context, key string, ttlSeconds int, fenced bool, o store.WriteOptions) (uint64, error) {
+	if ttlSeconds < 0 {
+		return 0, ErrInvalidTTL
+	}
+
+	if err := s.lock(ctx); err != nil {
+		return 0, err
+	}
 	defer s.mu.Unlock()
 
+	if err := s.checkFence(key, o); err != nil {
+		return 0, err
+	}
+
 	v, exists := s.data[key]
 	if !exists {
-		return "", ErrKeyNotFound
+		return 0, ErrKeyNotFound
 	}
 
-	// If expired, lazy delete it
-	if !v.TTL.IsZero() && time.Now().After(v.TTL) {
-		delete(s.data, key)
-		return "", ErrKeyNotFound
+	if !v.TTL.IsZero() && s.clock.Now().After(v.TTL) {
+		s.del(key)
+		return 0, ErrKeyNotFound
 	}
 
-	if !v.IsList {
-		return "", ErrTypeMismatch
+	v.TTL = s.ttlFromSeconds(ttlSeconds)
+	s.put(key, v)
+	s.recordFence(key, o)
+	if !fenced {
+		return 0, nil
 	}
+	return s.issueFence(key), nil
+}
 
-	if len(v.List) == 0 {
-		return "", ErrEmptyList
+// ExpirePattern sets the TTL of every live key matching a glob pattern and returns
+// the number of keys changed. A ttlSeconds of 0 removes the expiration.
+// All keys are changed under a single write lock. See store.Pattern for the pattern syntax.
+func (s *MemoryStore) ExpirePattern(ctx context.Context, pattern string, ttlSeconds int) (int, error) {
+	if ttlSeconds < 0 {
+		return 0, ErrInvalidTTL
 	}
 
-	item := v.List[0]
-	v.List = v.List[1:]
-	s.data[key] = v
-	return item, nil
+	p, err := store.CompilePattern(pattern)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	ttl := s.ttlFromSeconds(ttlSeconds)
+	count := 0
+	for k, v := range s.data {
+		if !v.TTL.IsZero() && now.After(v.TTL) {
+			continue
+		}
+		if !p.Match(k) {
+			continue
+		}
+		v.TTL = ttl
+		s.put(k, v)
+		count++
+	}
+
+	return count, nil
 }
 
-// Stringify converts any value to string
-func (s *MemoryStore) Stringify(v any) (string, error) {
-	switch val := v.(type) {
-	case string:
-		return val, nil
-	default:
-		b, err := json.Marshal(val)
-		if err != nil {
-			return "", err
+// DeleteExpiringWithin deletes all keys whose remaining TTL is below threshold, freeing
+// keys that are about to expire anyway, and returns how many live keys it deleted.
+// Keys without a TTL are kept. Already expired keys are cleaned up but not counted.
+func (s *MemoryStore) DeleteExpiringWithin(ctx context.Context, threshold time.Duration) (int, error) {
+	if threshold < 0 {
+		return 0, ErrInvalidTTL
+	}
+
+	if err := s.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	count := 0
+	for k, v := range s.data {
+		if v.TTL.IsZero() || v.TTL.Sub(now) >= threshold {
+			continue
 		}
-		return string(b), nil
+		if !now.After(v.TTL) {
+			count++
+		}
+		s.del(k)
 	}
+
+	return count, nil
 }
 
-// doStartTTLWorker starts the actual TTL cleanup worker
-func (s *MemoryStore) doStartTTLWorker() {
-	s.mu.RLock()
-	ctx := s.ttlCtx
-	s.mu.RUnlock()
+// DeleteMatching deletes every key matching a glob pattern under a single write lock,
+// e.g. all the keys of a tenant, and returns how many live keys it deleted. Expired
+// keys matching the pattern are cleaned up too but not counted, like
+// DeleteExpiringWithin. It returns ErrInvalidPattern if the pattern is malformed.
+func (s *MemoryStore) DeleteMatching(ctx context.Context, pattern string) (int, error) {
+	p, err := store.CompilePattern(pattern)
+	if err != nil {
+		return 0, err
+	}
 
-	if ctx == nil {
-		return
+	if err := s.lock(ctx); err != nil {
+		return 0, err
 	}
+	defer s.mu.Unlock()
 
-	ticker := time.NewTicker(1 * time.Second)
-	go func() {
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
+	now := s.clock.Now()
+	count := 0
+	for k, v := range s.data {
+		if !p.Match(k) {
+			continue
+		}
+		if v.TTL.IsZero() || !now.After(v.TTL) {
+			count++
+		}
+		s.del(k)
+	}
+
+	return count, nil
+}
+
+// CountPattern returns the number of live keys matching a glob pattern, counted under
+// a single read lock. See store.Pattern for the pattern syntax.
+func (s *MemoryStore) CountPattern(ctx context.Context, pattern string) (int, error) {
+	p, err := store.CompilePattern(pattern)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.rlock(ctx); err != nil {
+		return 0, err
+	}
+	defer s.mu.RUnlock()
+
+	now := s.clock.Now()
+	count := 0
+	scanned := 0
+	for k, v := range s.data {
+		if err := checkCtx(ctx, scanned); err != nil {
+			return 0, err
+		}
+		scanned++
+		if !v.TTL.IsZero() && now.After(v.TTL) {
+			continue
+		}
+		if p.Match(k) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// Keys returns the live keys matching a glob pattern in lexical order. An empty
+// pattern matches every key.
+func (s *MemoryStore) Keys(ctx context.Context, pattern string) ([]string, error) {
+	if pattern == "" {
+		pattern = "*"
+	}
+	p, err := store.CompilePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	now := s.clock.Now()
+	keys := []string{}
+	scanned := 0
+	for k, v := range s.data {
+		if err := checkCtx(ctx, scanned); err != nil {
+			return nil, err
+		}
+		scanned++
+		if !v.TTL.IsZero() && now.After(v.TTL) {
+			continue
+		}
+		if p.Match(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// CompareAndExpire changes the TTL of a string key only if its current value equals expected.
+// It reports whether the TTL was changed.
+func (s *MemoryStore) CompareAndExpire(ctx context.Context, key string, expected string, ttlSeconds int, opts ...store.WriteOption) (bool, error) {
+	if ttlSeconds < 0 {
+		return false, ErrInvalidTTL
+	}
+
+	if err := s.lock(ctx); err != nil {
+		return false, err
+	}
+	defer s.mu.Unlock()
+
+	o := store.NewWriteOptions(opts)
+	if err := s.checkFence(key, o); err != nil {
+		return false, err
+	}
+
+	v, err := s.liveString(key)
+	if err != nil {
+		return false, err
+	}
+
+	if v.text() != expected {
+		return false, nil
+	}
+
+	v.TTL = s.ttlFromSeconds(ttlSeconds)
+	s.put(key, v)
+	s.recordFence(key, o)
+	return true, nil
+}
+
+// List operations
+
+// Push adds an item to the front of a list, creating the list if needed. It returns
+// ErrKeyPendingDelete instead of creating a list where a soft deleted key can still
+// be restored; use PushResurrect to push onto the deleted list instead.
+func (s *MemoryStore) Push(ctx context.Context, key string, item any, opts ...store.WriteOption) error {
+	return s.push(ctx, key, item, false, store.NewWriteOptions(opts))
+}
+
+// PushResurrect pushes like Push, but if the key is pending soft delete it first
+// restores the deleted list, with its original expiration, and pushes onto it.
+func (s *MemoryStore) PushResurrect(ctx context.Context, key string, item any, opts ...store.WriteOption) error {
+	return s.push(ctx, key, item, true, store.NewWriteOptions(opts))
+}
+
+// PushCappedReturn pushes an item to the front of a list like Push, then trims the list
+// to its first maxLen items and returns the items dropped from the far end, in list
+// order, so a bounded buffer can act on what overflowed. Nothing is returned while the
+// list stays within maxLen. A maxLen of 0 or less leaves the list uncapped.
+func (s *MemoryStore) PushCappedReturn(ctx context.Context, key string, item any, maxLen int, opts ...store.WriteOption) ([]string, error) {
+	stringItem, err := s.storedItem(item)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	res, err := s.addItemLocked(key, stringItem, store.PushOptions{MaxLen: max(maxLen, 0)}, store.NewWriteOptions(opts))
+	return res.Evicted, err
+}
+
+// RPush adds an item to the end of a list, creating the list if the key doesn't exist.
+// Combined with Pop it makes a FIFO queue.
+func (s *MemoryStore) RPush(ctx context.Context, key string, item any, opts ...store.WriteOption) error {
+	stringItem, err := s.storedItem(item)
+	if err != nil {
+		return err
+	}
+
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	_, err = s.addItemLocked(key, stringItem, store.PushOptions{Tail: true}, store.NewWriteOptions(opts))
+	return err
+}
+
+// PushItem adds an item to a list as configured by opts, combining Push, RPush,
+// PushResurrect and PushCappedReturn, and returns the sequence number assigned to
+// the item along with any items trimmed by opts.MaxLen.
+func (s *MemoryStore) PushItem(ctx context.Context, key string, item any, opts store.PushOptions, writeOpts ...store.WriteOption) (store.PushResult, error) {
+	stringItem, err := s.storedItem(item)
+	if err != nil {
+		return store.PushResult{}, err
+	}
+
+	if err := s.lock(ctx); err != nil {
+		return store.PushResult{}, err
+	}
+	defer s.mu.Unlock()
+
+	opts.MaxLen = max(opts.MaxLen, 0)
+	return s.addItemLocked(key, stringItem, opts, store.NewWriteOptions(writeOpts))
+}
+
+func (s *MemoryStore) push(ctx context.Context, key string, item any, resurrect bool, o store.WriteOptions) error {
+	stringItem, err := s.storedItem(item)
+	if err != nil {
+		return err
+	}
+
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	return s.pushLocked(key, stringItem, resurrect, o)
+}
+
+// pushLocked adds an item, in its stored form, to the front of a list. The caller must hold the write lock.
+func (s *MemoryStore) pushLocked(key string, stringItem string, resurrect bool, o store.WriteOptions) error {
+	_, err := s.addItemLocked(key, stringItem, store.PushOptions{Resurrect: resurrect}, o)
+	return err
+}
+
+// addItemLocked adds an item, in its stored form, to the front of a list, or to its
+// end if opts.Tail is set, and then trims the list to opts.MaxLen items from the other
+// end. The caller must hold the write lock.
+func (s *MemoryStore) addItemLocked(key string, stringItem string, opts store.PushOptions, o store.WriteOptions) (store.PushResult, error) {
+	if err := s.checkFence(key, o); err != nil {
+		return store.PushResult{}, err
+	}
+
+	v := s.data[key]
+	created, resurrected := false, false
+
+	// If the key doesn't exist, or exists but expired, then create a new list
+	if _, exists := s.data[key]; !exists || (!v.TTL.IsZero() && s.clock.Now().After(v.TTL)) {
+		// If key exists but is expired, lazy delete it first
+		if _, exists := s.data[key]; exists && (!v.TTL.IsZero() && s.clock.Now().After(v.TTL)) {
+			s.del(key)
+		}
+		v, created = Value{IsList: true, List: []string{}}, true
+
+		// A soft deleted key may still be restored, so do not silently shadow it with a fresh list.
+		if t, pending := s.pendingDelete(key, s.clock.Now()); pending {
+			if !opts.Resurrect {
+				return store.PushResult{}, ErrKeyPendingDelete
+			}
+			v, resurrected = t.value, true
+		}
+	}
+
+	if !v.IsList {
+		return store.PushResult{}, ErrTypeMismatch
+	}
+
+	var at time.Time
+	if s.listPushTimes {
+		at = s.clock.Now()
+	}
+	res := store.PushResult{Seq: v.LastSeq + 1}
+	v, evicted := v.pushItem(stringItem, res.Seq, opts.Tail, opts.MaxLen, at)
+	if len(evicted) > 0 {
+		res.Evicted = itemTexts(evicted)
+	}
+	if resurrected {
+		// The kept value becomes the list again, so it must not count twice.
+		t := s.tombstones[key]
+		s.dropTombstone(key)
+		if err := s.reserve(key, v); err != nil {
+			s.putTombstone(key, t)
+			return store.PushResult{}, err
+		}
+	} else if err := s.reserve(key, v); err != nil {
+		return store.PushResult{}, err
+	}
+
+	if resurrected {
+		s.put(key, v)
+	} else if created {
+		s.put(key, v)
+	} else {
+		op := aofRecord{Op: aofPush, Key: []byte(key), Item: []byte(stringItem), Seq: res.Seq, Tail: opts.Tail, MaxLen: opts.MaxLen}
+		if !at.IsZero() {
+			op.PushedAt = []time.Time{at}
+		}
+		s.putListOp(key, v, op)
+	}
+	s.recordFence(key, o)
+	s.touch(key, s.clock.Now())
+	return res, nil
+}
+
+// pushItem returns v with item, in its stored form and numbered seq, added to the head
+// of its list, or to its tail if tail is set, the list then being trimmed to maxLen
+// items from the other end if maxLen is positive. It also returns the items trimmed,
+// in their stored form. at is the push time of the item, the zero time if push times
+// are not recorded. Pushes replayed from the append-only file go through it too.
+//
+// The checksum of v is updated with the item added and those trimmed, so a list that
+// no longer matched its checksum still does not.
+func (v Value) pushItem(item string, seq uint64, tail bool, maxLen int, at time.Time) (Value, []string) {
+	before := v
+	v.LastSeq = seq
+	var evicted []string
+	n := len(v.List)
+	if tail {
+		v.List = append(v.List[:n:n], item)
+		v.Seqs = append(v.Seqs[:n:n], seq)
+		v.Checksum += itemSum(v.Head+int64(n), item)
+		if maxLen > 0 && len(v.List) > maxLen {
+			evicted = v.List[:len(v.List)-maxLen]
+			v.List = v.List[len(v.List)-maxLen:]
+			v.Seqs = v.Seqs[len(v.Seqs)-maxLen:]
+			for i, e := range evicted {
+				v.Checksum -= itemSum(v.Head+int64(i), e)
+			}
+			v.Head += int64(len(evicted))
+		}
+	} else {
+		v.Head--
+		v.List = append([]string{item}, v.List...)
+		v.Seqs = append([]uint64{seq}, v.Seqs...)
+		v.Checksum += itemSum(v.Head, item)
+		if maxLen > 0 && len(v.List) > maxLen {
+			evicted = v.List[maxLen:]
+			v.List = v.List[:maxLen]
+			v.Seqs = v.Seqs[:maxLen]
+			for i, e := range evicted {
+				v.Checksum -= itemSum(v.Head+int64(maxLen+i), e)
+			}
+		}
+	}
+	v.PushedAt = pushedTimes(before, tail, len(evicted), at)
+	return v, evicted
+}
+
+// popItem returns v without the item at the head of its list, or at its tail if tail
+// is set, its checksum updated like pushItem does. The list must not be empty.
+func (v Value) popItem(tail bool) Value {
+	if last := len(v.List) - 1; tail {
+		v.Checksum -= itemSum(v.Head+int64(last), v.List[last])
+		v.keepPushTimes(0, last)
+		v.List, v.Seqs = v.List[:last], v.Seqs[:last]
+	} else {
+		v.Checksum -= itemSum(v.Head, v.List[0])
+		v.Head++
+		v.keepPushTimes(1, len(v.List))
+		v.List, v.Seqs = v.List[1:], v.Seqs[1:]
+	}
+	return v
+}
+
+// Pop takes a value from the list
+func (s *MemoryStore) Pop(ctx context.Context, key string, opts ...store.WriteOption) (string, error) {
+	if err := s.lock(ctx); err != nil {
+		return "", err
+	}
+	defer s.mu.Unlock()
+
+	return s.popLocked(key, store.NewWriteOptions(opts))
+}
+
+// RPop takes the item at the end of a list.
+func (s *MemoryStore) RPop(ctx context.Context, key string, opts ...store.WriteOption) (string, error) {
+	item, err := s.RPopItem(ctx, key, opts...)
+	return item.Value, err
+}
+
+// PopItem takes the item at the front of a list like Pop, along with its sequence number.
+func (s *MemoryStore) PopItem(ctx context.Context, key string, opts ...store.WriteOption) (store.ListItem, error) {
+	if err := s.lock(ctx); err != nil {
+		return store.ListItem{}, err
+	}
+	defer s.mu.Unlock()
+
+	return s.takeItemLocked(key, false, store.NewWriteOptions(opts))
+}
+
+// RPopItem takes the item at the end of a list like RPop, along with its sequence number.
+func (s *MemoryStore) RPopItem(ctx context.Context, key string, opts ...store.WriteOption) (store.ListItem, error) {
+	if err := s.lock(ctx); err != nil {
+		return store.ListItem{}, err
+	}
+	defer s.mu.Unlock()
+
+	return s.takeItemLocked(key, true, store.NewWriteOptions(opts))
+}
+
+// popLocked takes the item at the front of a list. The caller must hold the write lock.
+func (s *MemoryStore) popLocked(key string, o store.WriteOptions) (string, error) {
+	item, err := s.takeItemLocked(key, false, o)
+	return item.Value, err
+}
+
+// takeItemLocked takes the item at the front of a list, or at its end if tail is set.
+// The caller must hold the write lock.
+func (s *MemoryStore) takeItemLocked(key string, tail bool, o store.WriteOptions) (store.ListItem, error) {
+	if err := s.checkFence(key, o); err != nil {
+		return store.ListItem{}, err
+	}
+
+	v, exists := s.data[key]
+	if !exists {
+		return store.ListItem{}, ErrKeyNotFound
+	}
+
+	// If expired, lazy delete it
+	if !v.TTL.IsZero() && s.clock.Now().After(v.TTL) {
+		s.del(key)
+		return store.ListItem{}, ErrKeyNotFound
+	}
+
+	if !v.IsList {
+		return store.ListItem{}, ErrTypeMismatch
+	}
+
+	if len(v.List) == 0 {
+		return store.ListItem{}, ErrEmptyList
+	}
+
+	i := 0
+	if tail {
+		i = len(v.List) - 1
+	}
+	item := store.ListItem{Value: itemText(v.List[i]), Seq: v.Seqs[i], PushedAt: v.pushedAt(i)}
+	s.putListOp(key, v.popItem(tail), aofRecord{Op: aofPop, Key: []byte(key), Tail: tail})
+	s.recordFence(key, o)
+	s.touch(key, s.clock.Now())
+	return item, nil
+}
+
+// LRange returns the items of a list between start and stop, both inclusive.
+// Negative indexes count from the end of the list, -1 being the last item.
+// Out of range indexes are clamped, so an empty slice is returned when the range is empty.
+func (s *MemoryStore) LRange(ctx context.Context, key string, start, stop int) ([]string, error) {
+	page, err := s.LRangePage(ctx, key, start, stop)
+	if err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
+
+// LRangePage returns a range of list items like LRange along with the length of the
+// list and the resolved bounds of the range, all read under a single read lock.
+func (s *MemoryStore) LRangePage(ctx context.Context, key string, start, stop int) (store.ListPage, error) {
+	return s.rangePage(ctx, key, start, stop, false, false)
+}
+
+// LRangeWithMeta returns a range of list items like LRange, each with its sequence
+// number and the time it was pushed, e.g. to find out how long the items of a stuck
+// queue have been waiting. Push times are only recorded if the store was created
+// with Options.ListPushTimes; otherwise, and for items pushed before, they are zero.
+func (s *MemoryStore) LRangeWithMeta(ctx context.Context, key string, start, stop int) ([]store.ListItem, error) {
+	page, err := s.rangePage(ctx, key, start, stop, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]store.ListItem, len(page.Items))
+	for i, item := range page.Items {
+		items[i] = store.ListItem{Value: item, Seq: page.Seqs[i], PushedAt: page.PushedAt[i]}
+	}
+	return items, nil
+}
+
+// LRangeReverse returns a range of list items like LRangePage, but indexes and returns
+// the list tail first: index 0 is the last item and -1 the first. The stored order is
+// left unchanged, so LRangeReverse(ctx, key, 0, -1) is the whole list reversed.
+func (s *MemoryStore) LRangeReverse(ctx context.Context, key string, start, stop int) (store.ListPage, error) {
+	return s.rangePage(ctx, key, start, stop, true, false)
+}
+
+// LLen returns the number of items in a list.
+func (s *MemoryStore) LLen(ctx context.Context, key string) (int, error) {
+	if err := s.rlock(ctx); err != nil {
+		return 0, err
+	}
+	defer s.mu.RUnlock()
+
+	now := s.clock.Now()
+	v, exists := s.data[key]
+	if !exists || (!v.TTL.IsZero() && now.After(v.TTL)) {
+		return 0, ErrKeyNotFound
+	}
+
+	if !v.IsList {
+		return 0, ErrTypeMismatch
+	}
+	s.touch(key, now)
+
+	return len(v.List), nil
+}
+
+// rangePage reads a range of list items. With meta, it also reads their push times.
+func (s *MemoryStore) rangePage(ctx context.Context, key string, start, stop int, reverse, meta bool) (store.ListPage, error) {
+	if err := s.rlock(ctx); err != nil {
+		return store.ListPage{}, err
+	}
+	defer s.mu.RUnlock()
+
+	now := s.clock.Now()
+	v, exists := s.data[key]
+	if !exists || (!v.TTL.IsZero() && now.After(v.TTL)) {
+		return store.ListPage{}, ErrKeyNotFound
+	}
+
+	if !v.IsList {
+		return store.ListPage{}, ErrTypeMismatch
+	}
+	s.touch(key, now)
+
+	n := len(v.List)
+	start, stop = listBounds(n, start, stop)
+	if start > stop {
+		return store.ListPage{Items: []string{}, Seqs: []uint64{}, Total: n, Start: start, Stop: start - 1}, nil
+	}
+
+	var items []string
+	var seqs []uint64
+	var times, pushed []time.Time
+	if meta {
+		pushed = alignedPushTimes(v)
+	}
+	if reverse {
+		// Reversed index i is forward index n-1-i, so the range maps to [n-1-stop, n-1-start].
+		items = make([]string, 0, stop-start+1)
+		seqs = make([]uint64, 0, stop-start+1)
+		for i := n - 1 - start; i >= n-1-stop; i-- {
+			items = append(items, itemText(v.List[i]))
+			seqs = append(seqs, v.Seqs[i])
+			if meta {
+				times = append(times, pushed[i])
+			}
+		}
+	} else {
+		items = itemTexts(v.List[start : stop+1])
+		seqs = append([]uint64(nil), v.Seqs[start:stop+1]...)
+		if meta {
+			times = append([]time.Time(nil), pushed[start:stop+1]...)
+		}
+	}
+
+	return store.ListPage{
+		Items:    items,
+		Seqs:     seqs,
+		PushedAt: times,
+		Total:    n,
+		Start:    start,
+		Stop:     stop,
+	}, nil
+}
+
+// LTrim trims a list so that it only keeps the items between start and stop, both
+// inclusive. Indexes follow LRange. The key is removed if no items are kept.
+func (s *MemoryStore) LTrim(ctx context.Context, key string, start, stop int, opts ...store.WriteOption) error {
+	_, err := s.LTrimReturn(ctx, key, start, stop, opts...)
+	return err
+}
+
+// LTrimReturn trims a list like LTrim and returns the removed items in list order:
+// those cut from the front followed by those cut from the back.
+func (s *MemoryStore) LTrimReturn(ctx context.Context, key string, start, stop int, opts ...store.WriteOption) ([]string, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	o := store.NewWriteOptions(opts)
+	if err := s.checkFence(key, o); err != nil {
+		return nil, err
+	}
+
+	v, exists := s.data[key]
+	if !exists {
+		return nil, ErrKeyNotFound
+	}
+
+	if !v.TTL.IsZero() && s.clock.Now().After(v.TTL) {
+		s.del(key)
+		return nil, ErrKeyNotFound
+	}
+
+	if !v.IsList {
+		return nil, ErrTypeMismatch
+	}
+
+	start, stop = listBounds(len(v.List), start, stop)
+	if start > stop {
+		s.del(key)
+		s.recordFence(key, o)
+		return itemTexts(v.List), nil
+	}
+
+	removed := make([]string, 0, len(v.List)-(stop-start+1))
+	removed = append(removed, itemTexts(v.List[:start])...)
+	removed = append(removed, itemTexts(v.List[stop+1:])...)
+
+	v.keepPushTimes(start, stop+1)
+	v.PushedAt = append([]time.Time(nil), v.PushedAt...)
+	v.List = append([]string(nil), v.List[start:stop+1]...)
+	v.Seqs = append([]uint64(nil), v.Seqs[start:stop+1]...)
+	s.put(key, v)
+	s.recordFence(key, o)
+	s.touch(key, s.clock.Now())
+	return removed, nil
+}
+
+// LClear removes every item of a list but keeps the key, so unlike deleting it, the
+// list keeps its TTL and tags, and its sequence numbers carry on. It returns
+// ErrKeyNotFound if the key does not exist and ErrTypeMismatch if it is not a list.
+func (s *MemoryStore) LClear(ctx context.Context, key string, opts ...store.WriteOption) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	o := store.NewWriteOptions(opts)
+	if err := s.checkFence(key, o); err != nil {
+		return err
+	}
+
+	v, exists := s.data[key]
+	if !exists {
+		return ErrKeyNotFound
+	}
+
+	now := s.clock.Now()
+	if !v.TTL.IsZero() && now.After(v.TTL) {
+		s.del(key)
+		return ErrKeyNotFound
+	}
+
+	if !v.IsList {
+		return ErrTypeMismatch
+	}
+
+	v.List, v.Seqs = []string{}, []uint64{}
+	if v.PushedAt != nil {
+		v.PushedAt = []time.Time{}
+	}
+	s.put(key, v)
+	s.recordFence(key, o)
+	s.touch(key, now)
+	return nil
+}
+
+// LSet replaces key with a list holding items, the first item at the head. Any
+// existing value is overwritten, like Set, but a list's sequence numbers carry on
+// from the one it replaces. A ttl of 0 applies the key's default TTL, like Set.
+func (s *MemoryStore) LSet(ctx context.Context, key string, items []any, ttlSeconds int, opts ...store.WriteOption) error {
+	_, err := s.setList(ctx, key, items, ttlSeconds, false, store.NewWriteOptions(opts))
+	return err
+}
+
+// LInitNX creates a list holding items like LSet, but only if the key does not exist
+// or has expired. It reports whether the list was created, so racing initializers
+// can tell which one of them set up the list.
+func (s *MemoryStore) LInitNX(ctx context.Context, key string, items []any, ttlSeconds int, opts ...store.WriteOption) (bool, error) {
+	return s.setList(ctx, key, items, ttlSeconds, true, store.NewWriteOptions(opts))
+}
+
+func (s *MemoryStore) setList(ctx context.Context, key string, items []any, ttlSeconds int, nx bool, o store.WriteOptions) (bool, error) {
+	if ttlSeconds < 0 {
+		return false, ErrInvalidTTL
+	}
+	if len(items) == 0 {
+		return false, ErrEmptyList
+	}
+
+	list := make([]string, len(items))
+	for i, item := range items {
+		stringItem, err := s.storedItem(item)
+		if err != nil {
+			return false, err
+		}
+		list[i] = stringItem
+	}
+
+	if err := s.lock(ctx); err != nil {
+		return false, err
+	}
+	defer s.mu.Unlock()
+
+	if err := s.checkFence(key, o); err != nil {
+		return false, err
+	}
+
+	now := s.clock.Now()
+	old, exists := s.data[key]
+	live := exists && (old.TTL.IsZero() || now.Before(old.TTL))
+	if nx && live {
+		return false, nil
+	}
+
+	v := Value{IsList: true, List: list, TTL: s.ttlFromSeconds(s.defaultTTL(key, ttlSeconds))}
+	if live && old.IsList {
+		// Replacing a list carries its sequence numbers on, as LClear does, so items
+		// are never numbered below ones already handed out.
+		v.LastSeq = old.LastSeq
+	}
+	v.Seqs = prependSeqs(&v, len(list), nil)
+	if s.listPushTimes {
+		v.PushedAt = timesAt(now, len(list))
+	}
+	if err := s.reserve(key, v); err != nil {
+		return false, err
+	}
+
+	s.put(key, v)
+	s.recordFence(key, o)
+	s.touch(key, now)
+	return true, nil
+}
+
+// prependSeqs numbers n items added to the front of v's list at once, as if they
+// were pushed one by one from the last to the first, and returns them followed by
+// seqs. The first item gets the highest number.
+func prependSeqs(v *Value, n int, seqs []uint64) []uint64 {
+	added := make([]uint64, n, n+len(seqs))
+	for i := n - 1; i >= 0; i-- {
+		v.LastSeq++
+		added[i] = v.LastSeq
+	}
+	return append(added, seqs...)
+}
+
+// listBounds resolves LRange style start and stop indexes against a list of length n.
+// Negative indexes count from the end and out of range indexes are clamped, so the
+// range is empty when the returned start is greater than stop.
+func listBounds(n, start, stop int) (int, int) {
+	if start < 0 {
+		start = max(n+start, 0)
+	}
+	if stop < 0 {
+		stop = n + stop
+	}
+	return start, min(stop, n-1)
+}
+
+// Stringify converts any value to string
+func (s *MemoryStore) Stringify(v any) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	default:
+		b, err := json.Marshal(val)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+}
+
+// put stores v at key. Every write to data goes through put so per-key bookkeeping
+// stays in sync. The caller must hold the write lock.
+func (s *MemoryStore) put(key string, v Value) {
+	v.Checksum = checksum(v)
+	s.write(key, v, nil)
+}
+
+// putListOp stores v, the list at key after the push or pop op, like put, but records
+// op in the append-only file rather than the whole list, and keeps the checksum
+// pushItem or popItem updated rather than computing it over the whole list. The
+// caller must hold the write lock.
+func (s *MemoryStore) putListOp(key string, v Value, op aofRecord) {
+	s.write(key, v, &op)
+}
+
+// write stores v at key for put and putListOp.
+func (s *MemoryStore) write(key string, v Value, op *aofRecord) {
+	old, exists := s.data[key]
+	if exists {
+		s.usedBytes -= int64(estimateSize(key, old))
+	}
+	s.usedBytes += int64(estimateSize(key, v))
+	s.retag(key, old.Tags, v.Tags)
+
+	s.data[key] = v
+	if _, tracked := s.access[key]; !tracked {
+		s.access[key] = &keyAccess{}
+		if s.lru != nil {
+			s.lru.add(key)
+		}
+	}
+	s.publish(key, v)
+	s.events.append(store.EventSet, key, s.clock.Now())
+	s.notifyKeyspace(store.EventSet, key)
+	if op != nil {
+		s.logRecord(*op)
+	} else {
+		s.logMutation(key, &v)
+	}
+	if v.IsList && len(v.List) > 0 {
+		s.wakeListWaiters(key)
+	}
+}
+
+// del removes key and its bookkeeping. The caller must hold the write lock.
+func (s *MemoryStore) del(key string) {
+	old, exists := s.data[key]
+	if exists {
+		s.usedBytes -= int64(estimateSize(key, old))
+	}
+
+	delete(s.data, key)
+	delete(s.access, key)
+	if s.lru != nil {
+		s.lru.remove(key)
+	}
+	s.retag(key, old.Tags, nil)
+	s.unpublish(key)
+	s.retireFence(key)
+	if exists {
+		s.events.append(store.EventDel, key, s.clock.Now())
+		s.notifyKeyspace(store.EventDel, key)
+		s.logMutation(key, nil)
+	}
+}
+
+// liveString returns the string value stored at key, lazily deleting it if expired.
+// The caller must hold the write lock.
+func (s *MemoryStore) liveString(key string) (Value, error) {
+	v, exists := s.data[key]
+	if !exists {
+		return Value{}, ErrKeyNotFound
+	}
+
+	if !v.TTL.IsZero() && s.clock.Now().After(v.TTL) {
+		s.del(key)
+		return Value{}, ErrKeyNotFound
+	}
+
+	if !v.isString() {
+		return Value{}, ErrTypeMismatch
+	}
+
+	return v, nil
+}
+
+// entryOf builds the typed snapshot of a live value. List items, hash fields and set
+// members are copied.
+func entryOf(key string, v Value, now time.Time) store.KeyEntry {
+	entry := store.KeyEntry{Key: key, Type: v.kind(), TTLSeconds: remainingTTLSeconds(v, now)}
+	switch {
+	case v.IsList:
+		entry.Value = itemTexts(v.List)
+	case v.IsHash:
+		entry.Value = maps.Clone(v.Hash)
+	case v.IsSet:
+		entry.Value = members(v)
+	default:
+		entry.Value = v.text()
+	}
+	return entry
+}
+
+// remainingTTLSeconds returns the time left before v expires rounded up to whole seconds,
+// or -1 if v does not expire.
+func remainingTTLSeconds(v Value, now time.Time) int {
+	if v.TTL.IsZero() {
+		return -1
+	}
+	remaining := v.TTL.Sub(now)
+	return int((remaining + time.Second - 1) / time.Second)
+}
+
+// ttlFromSeconds converts a TTL in seconds to an expiration time, zero meaning no expiration.
+func (s *MemoryStore) ttlFromSeconds(ttlSeconds int) time.Time {
+	if ttlSeconds == 0 {
+		return time.Time{}
+	}
+	return s.clock.Now().Add(time.Duration(ttlSeconds) * time.Second)
+}
+
+// doStartTTLWorker starts the actual TTL cleanup worker
+func (s *MemoryStore) doStartTTLWorker() {
+	s.mu.RLock()
+	ctx := s.ttlCtx
+	s.mu.RUnlock()
+
+	if ctx == nil {
+		return
+	}
+
+	ticker := time.NewTicker(1 * time.Second)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if s.ttlPaused.Load() {
+					continue
+				}
 				s.mu.Lock()
 				for k, v := range s.data {
 					select {
@@ -272,12 +1671,16 @@ func (s *MemoryStore) doStartTTLWorker() {
 						s.mu.Unlock()
 						return
 					default:
-						if !v.TTL.IsZero() && time.Now().After(v.TTL) {
-							delete(s.data, k)
+						if !v.TTL.IsZero() && s.clock.Now().After(v.TTL) {
+							s.del(k)
 						}
 					}
 				}
+				s.purgeRates(s.clock.Now())
+				s.purgeTombstones(s.clock.Now())
+				s.requeueExpired(s.clock.Now())
 				s.mu.Unlock()
+				s.ReportWorker(ttlWorkerName, nil)
 			case <-ctx.Done():
 				return
 			}
@@ -3,6 +3,7 @@ package memory_test
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
@@ -133,6 +134,43 @@ func BenchmarkConcurrentGet(b *testing.B) {
 	})
 }
 
+// BenchmarkReadMostly compares reads with and without Options.ReadIndex on a workload of 95% reads and 5% writes
+// over a fixed keyset, with and without expiring keys.
+func BenchmarkReadMostly(b *testing.B) {
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key_%d", i)
+	}
+
+	for _, readIndex := range []bool{false, true} {
+		for _, ttl := range []int{0, 60} {
+			b.Run(fmt.Sprintf("read_index=%t/ttl=%d", readIndex, ttl), func(b *testing.B) {
+				store := memory.NewMemoryStoreWithOptions(memory.Options{ReadIndex: readIndex})
+				defer store.StopTTLWorker()
+				ctx := context.Background()
+
+				for _, key := range keys {
+					store.Set(ctx, key, "value", ttl)
+				}
+
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					i := 0
+					for pb.Next() {
+						key := keys[i%len(keys)]
+						if i%20 == 0 {
+							store.Set(ctx, key, "value", ttl)
+						} else {
+							store.Get(ctx, key)
+						}
+						i++
+					}
+				})
+			})
+		}
+	}
+}
+
 func BenchmarkConcurrentSet(b *testing.B) {
 	store := memory.NewMemoryStore()
 	defer store.StopTTLWorker()
@@ -189,3 +227,50 @@ func BenchmarkConcurrentPop(b *testing.B) {
 		}
 	})
 }
+
+// BenchmarkCompression compares writing and reading 10 KB JSON documents with and
+// without compression. The stored-B/key metric is the estimated memory held per key.
+func BenchmarkCompression(b *testing.B) {
+	doc := strings.Repeat(`{"id":12345,"name":"żółw","tags":["alpha","beta"],"active":true},`, 150)
+	const keys = 1000
+
+	for _, bc := range []struct {
+		name      string
+		threshold int
+	}{
+		{"Uncompressed", 0},
+		{"Compressed", 1024},
+	} {
+		b.Run("Set/"+bc.name, func(b *testing.B) {
+			store := memory.NewMemoryStoreWithOptions(memory.Options{CompressThreshold: bc.threshold})
+			defer store.StopTTLWorker()
+			ctx := context.Background()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				store.Set(ctx, fmt.Sprintf("doc_%d", i%keys), doc, 0)
+			}
+			b.StopTimer()
+
+			stats, _ := store.Stats(ctx)
+			b.ReportMetric(float64(stats.MemoryBytes)/float64(stats.Keys), "stored-B/key")
+		})
+
+		b.Run("Get/"+bc.name, func(b *testing.B) {
+			store := memory.NewMemoryStoreWithOptions(memory.Options{CompressThreshold: bc.threshold})
+			defer store.StopTTLWorker()
+			ctx := context.Background()
+
+			for i := 0; i < keys; i++ {
+				store.Set(ctx, fmt.Sprintf("doc_%d", i), doc, 0)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				store.Get(ctx, fmt.Sprintf("doc_%d", i%keys))
+			}
+		})
+	}
+}
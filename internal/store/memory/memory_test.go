@@ -2,9 +2,14 @@ package memory_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
 	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
 )
 
@@ -159,6 +164,44 @@ func TestUpdate(t *testing.T) {
 	})
 }
 
+func TestGetSet(t *testing.T) {
+	clock := newFakeClock()
+	store := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock})
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Set(ctx, "token", "old", 60)
+	clock.Advance(10 * time.Second)
+
+	previous, err := store.GetSet(ctx, "token", "new")
+	if err != nil || previous != "old" {
+		t.Errorf("Expected previous value old, got %q (err %v)", previous, err)
+	}
+	if value, _ := store.Get(ctx, "token"); value != "new" {
+		t.Errorf("Expected new, got %q", value)
+	}
+	if ttl, _ := store.TTL(ctx, "token"); ttl != 50 {
+		t.Errorf("Expected the TTL to be kept at 50, got %d", ttl)
+	}
+
+	if _, err := store.GetSet(ctx, "missing", "v"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+	if _, err := store.Get(ctx, "missing"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected a missing key not to be created, got %v", err)
+	}
+
+	store.Push(ctx, "queue", "job")
+	if _, err := store.GetSet(ctx, "queue", "v"); err != memory.ErrTypeMismatch {
+		t.Errorf("Expected ErrTypeMismatch for a list, got %v", err)
+	}
+
+	clock.Advance(time.Minute)
+	if _, err := store.GetSet(ctx, "token", "newer"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound for an expired key, got %v", err)
+	}
+}
+
 func TestRemove(t *testing.T) {
 	store := memory.NewMemoryStore()
 	defer store.StopTTLWorker()
@@ -353,6 +396,214 @@ func TestTTLExpiration(t *testing.T) {
 	})
 }
 
+// fakeClock is a Clock that only moves when advanced.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestFakeClockExpiration(t *testing.T) {
+	ctx := context.Background()
+
+	for _, readIndex := range []bool{false, true} {
+		t.Run(fmt.Sprintf("read_index=%t", readIndex), func(t *testing.T) {
+			clock := newFakeClock()
+			store := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock, ReadIndex: readIndex})
+			defer store.StopTTLWorker()
+
+			if err := store.Set(ctx, "session", "abc", 10); err != nil {
+				t.Fatalf("Set failed: %v", err)
+			}
+			store.Push(ctx, "queue", "job")
+			if err := store.Expire(ctx, "queue", 10); err != nil {
+				t.Fatalf("Expire failed: %v", err)
+			}
+
+			clock.Advance(4 * time.Second)
+
+			entries, err := store.GetEntries(ctx, []string{"session"})
+			if err != nil {
+				t.Fatalf("GetEntries failed: %v", err)
+			}
+			if entries[0].TTLSeconds != 6 {
+				t.Errorf("Expected 6 seconds left, got %d", entries[0].TTLSeconds)
+			}
+
+			clock.Advance(5 * time.Second)
+
+			if value, err := store.Get(ctx, "session"); err != nil || value != "abc" {
+				t.Errorf("Expected 'abc' before expiration, got %q, %v", value, err)
+			}
+
+			clock.Advance(2 * time.Second)
+
+			if _, err := store.Get(ctx, "session"); !errors.Is(err, memory.ErrKeyNotFound) {
+				t.Errorf("Expected ErrKeyNotFound for expired string, got %v", err)
+			}
+			if _, _, err := store.GetAny(ctx, "session"); !errors.Is(err, memory.ErrKeyNotFound) {
+				t.Errorf("Expected ErrKeyNotFound from GetAny for expired string, got %v", err)
+			}
+			if _, err := store.Pop(ctx, "queue"); !errors.Is(err, memory.ErrKeyNotFound) {
+				t.Errorf("Expected ErrKeyNotFound for expired list, got %v", err)
+			}
+
+			ok, err := store.SetNX(ctx, "session", "def", 0)
+			if err != nil || !ok {
+				t.Errorf("Expected SetNX to claim expired key, got %v, %v", ok, err)
+			}
+		})
+	}
+}
+
+func TestExists(t *testing.T) {
+	for _, readIndex := range []bool{false, true} {
+		t.Run(fmt.Sprintf("read_index=%t", readIndex), func(t *testing.T) {
+			clock := newFakeClock()
+			store := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock, ReadIndex: readIndex})
+			defer store.StopTTLWorker()
+			ctx := context.Background()
+
+			store.Set(ctx, "string", "v", 10)
+			store.Push(ctx, "list", "item")
+
+			for _, key := range []string{"string", "list"} {
+				if exists, err := store.Exists(ctx, key); err != nil || !exists {
+					t.Errorf("Expected %s to exist, got %v (err %v)", key, exists, err)
+				}
+			}
+			if exists, err := store.Exists(ctx, "missing"); err != nil || exists {
+				t.Errorf("Expected missing key not to exist, got %v (err %v)", exists, err)
+			}
+
+			clock.Advance(11 * time.Second)
+
+			if exists, err := store.Exists(ctx, "string"); err != nil || exists {
+				t.Errorf("Expected expired key not to exist, got %v (err %v)", exists, err)
+			}
+			if n, _ := store.CountPattern(ctx, "*"); n != 1 {
+				t.Errorf("Expected only the list to be live, got %d keys", n)
+			}
+		})
+	}
+}
+
+func TestExists_DeletesExpired(t *testing.T) {
+	clock := newFakeClock()
+	// Room for one key holding a 100 byte value, but not two.
+	store := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock, MaxMemoryBytes: 300})
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+	value := strings.Repeat("v", 100)
+
+	store.Set(ctx, "old", value, 10)
+	clock.Advance(11 * time.Second)
+
+	if exists, _ := store.Exists(ctx, "old"); exists {
+		t.Fatal("Expected expired key not to exist")
+	}
+	// The expired key was deleted, freeing its memory for a new key.
+	if err := store.Set(ctx, "new", value, 0); err != nil {
+		t.Errorf("Expected Exists to delete the expired key, got %v", err)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	clock := newFakeClock()
+	store := memory.NewMemoryStoreWithClock(clock)
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Set(ctx, "session", "abc", 10)
+	store.Set(ctx, "config", "on", 0)
+	store.Push(ctx, "queue", "job")
+	store.Expire(ctx, "queue", 60)
+
+	clock.Advance(1500 * time.Millisecond)
+
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"session", 9},
+		{"config", -1},
+		{"queue", 59},
+	}
+	for _, tt := range tests {
+		if ttl, err := store.TTL(ctx, tt.key); err != nil || ttl != tt.want {
+			t.Errorf("%s: expected TTL %d, got %d, %v", tt.key, tt.want, ttl, err)
+		}
+	}
+
+	if _, err := store.TTL(ctx, "missing"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound for a missing key, got %v", err)
+	}
+
+	clock.Advance(10 * time.Second)
+	if _, err := store.TTL(ctx, "session"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound for an expired key, got %v", err)
+	}
+}
+
+func TestGetRaw(t *testing.T) {
+	for _, readIndex := range []bool{false, true} {
+		t.Run(fmt.Sprintf("read_index=%t", readIndex), func(t *testing.T) {
+			store := memory.NewMemoryStoreWithOptions(memory.Options{ReadIndex: readIndex})
+			defer store.StopTTLWorker()
+			ctx := context.Background()
+
+			store.Set(ctx, "name", "alice", 0)
+			store.Set(ctx, "numeric string", "42", 0)
+			store.Set(ctx, "age", 30, 0)
+			store.Set(ctx, "user", map[string]any{"name": "alice", "tags": []string{"a"}}, 0)
+			store.Push(ctx, "queue", "job")
+
+			tests := []struct {
+				key  string
+				want string
+			}{
+				{"name", `"alice"`},
+				{"numeric string", `"42"`},
+				{"age", `30`},
+				{"user", `{"name":"alice","tags":["a"]}`},
+			}
+			for _, tt := range tests {
+				raw, err := store.GetRaw(ctx, tt.key)
+				if err != nil || string(raw) != tt.want {
+					t.Errorf("%s: expected %s, got %s, %v", tt.key, tt.want, raw, err)
+				}
+			}
+
+			store.Update(ctx, "age", "thirty")
+			if raw, _ := store.GetRaw(ctx, "age"); string(raw) != `"thirty"` {
+				t.Errorf("Expected an update to a string to return a JSON string, got %s", raw)
+			}
+
+			if _, err := store.GetRaw(ctx, "queue"); err != memory.ErrTypeMismatch {
+				t.Errorf("Expected ErrTypeMismatch for a list, got %v", err)
+			}
+			if _, err := store.GetRaw(ctx, "missing"); err != memory.ErrKeyNotFound {
+				t.Errorf("Expected ErrKeyNotFound, got %v", err)
+			}
+		})
+	}
+}
+
 func TestConcurrentOperations(t *testing.T) {
 	store := memory.NewMemoryStore()
 	defer store.StopTTLWorker()
@@ -501,3 +752,2258 @@ func TestLazyExpiration(t *testing.T) {
 		}
 	})
 }
+
+func TestSetNX(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	set, err := store.SetNX(ctx, "nx_key", "first", 60)
+	if err != nil || !set {
+		t.Fatalf("Expected first SetNX to set the key, got set=%v err=%v", set, err)
+	}
+
+	set, err = store.SetNX(ctx, "nx_key", "second", 60)
+	if err != nil || set {
+		t.Errorf("Expected second SetNX not to set the key, got set=%v err=%v", set, err)
+	}
+
+	value, _ := store.Get(ctx, "nx_key")
+	if value != "first" {
+		t.Errorf("Expected 'first', got %q", value)
+	}
+
+	store.Set(ctx, "nx_expired", "old", 1)
+	time.Sleep(1100 * time.Millisecond)
+
+	set, err = store.SetNX(ctx, "nx_expired", "new", 60)
+	if err != nil || !set {
+		t.Errorf("Expected SetNX over an expired key to set it, got set=%v err=%v", set, err)
+	}
+}
+
+func TestExpire(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	t.Run("persist removes expiration", func(t *testing.T) {
+		store.Set(ctx, "persisted", "value", 1)
+		if err := store.Expire(ctx, "persisted", 0); err != nil {
+			t.Fatalf("Expire failed: %v", err)
+		}
+
+		time.Sleep(1100 * time.Millisecond)
+		if _, err := store.Get(ctx, "persisted"); err != nil {
+			t.Errorf("Expected persisted key to survive, got %v", err)
+		}
+	})
+
+	t.Run("persist", func(t *testing.T) {
+		store.Set(ctx, "kept", "value", 60)
+		if err := store.Persist(ctx, "kept"); err != nil {
+			t.Fatalf("Persist failed: %v", err)
+		}
+		if ttl, _ := store.TTL(ctx, "kept"); ttl != -1 {
+			t.Errorf("Expected Persist to remove the expiration, got TTL %d", ttl)
+		}
+		if err := store.Persist(ctx, "missing"); err != memory.ErrKeyNotFound {
+			t.Errorf("Expected ErrKeyNotFound, got %v", err)
+		}
+	})
+
+	t.Run("expire missing key", func(t *testing.T) {
+		err := store.Expire(ctx, "missing", 10)
+		if err == nil || err.Error() != "key not found" {
+			t.Errorf("Expected 'key not found', got %v", err)
+		}
+	})
+
+	t.Run("compare and expire", func(t *testing.T) {
+		store.Set(ctx, "owned", "token-a", 60)
+
+		updated, err := store.CompareAndExpire(ctx, "owned", "token-b", 10)
+		if err != nil || updated {
+			t.Errorf("Expected mismatched CompareAndExpire not to update, got updated=%v err=%v", updated, err)
+		}
+
+		updated, err = store.CompareAndExpire(ctx, "owned", "token-a", 10)
+		if err != nil || !updated {
+			t.Errorf("Expected matching CompareAndExpire to update, got updated=%v err=%v", updated, err)
+		}
+	})
+}
+
+func TestCompareAndDelete(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Set(ctx, "owned", "token-a", 60)
+
+	deleted, err := store.CompareAndDelete(ctx, "owned", "token-b")
+	if err != nil || deleted {
+		t.Errorf("Expected mismatched CompareAndDelete not to delete, got deleted=%v err=%v", deleted, err)
+	}
+
+	deleted, err = store.CompareAndDelete(ctx, "owned", "token-a")
+	if err != nil || !deleted {
+		t.Errorf("Expected matching CompareAndDelete to delete, got deleted=%v err=%v", deleted, err)
+	}
+
+	if _, err := store.CompareAndDelete(ctx, "owned", "token-a"); err == nil || err.Error() != "key not found" {
+		t.Errorf("Expected 'key not found', got %v", err)
+	}
+
+	store.Push(ctx, "list", "item")
+	if _, err := store.CompareAndDelete(ctx, "list", "item"); err == nil {
+		t.Error("Expected type mismatch for list key")
+	}
+}
+
+func TestGetEntries(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Set(ctx, "str", "value", 60)
+	store.Set(ctx, "forever", "value", 0)
+	store.Push(ctx, "list", "b")
+	store.Push(ctx, "list", "a")
+
+	entries, err := store.GetEntries(ctx, []string{"str", "list", "missing", "forever"})
+	if err != nil {
+		t.Fatalf("GetEntries failed: %v", err)
+	}
+
+	if len(entries) != 4 {
+		t.Fatalf("Expected 4 entries, got %d", len(entries))
+	}
+
+	if entries[0].Type != "string" || entries[0].Value != "value" || entries[0].TTLSeconds != 60 {
+		t.Errorf("Unexpected string entry: %+v", entries[0])
+	}
+
+	items, _ := entries[1].Value.([]string)
+	if entries[1].Type != "list" || len(items) != 2 || items[0] != "a" || items[1] != "b" {
+		t.Errorf("Unexpected list entry: %+v", entries[1])
+	}
+
+	if entries[2].Type != "none" || entries[2].Value != nil {
+		t.Errorf("Unexpected missing entry: %+v", entries[2])
+	}
+
+	if entries[3].TTLSeconds != -1 {
+		t.Errorf("Expected TTL -1 for key without expiration, got %d", entries[3].TTLSeconds)
+	}
+}
+
+func TestMSetMGet(t *testing.T) {
+	clock := newFakeClock()
+	store := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock})
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	err := store.MSet(ctx, map[string]any{
+		"a": "1",
+		"b": map[string]int{"n": 2},
+		"c": 3,
+	}, 0)
+	if err != nil {
+		t.Fatalf("MSet failed: %v", err)
+	}
+	store.MSet(ctx, map[string]any{"short": "lived"}, 10)
+	store.Push(ctx, "list", "item")
+	clock.Advance(11 * time.Second)
+
+	values, err := store.MGet(ctx, []string{"a", "b", "c", "missing", "short", "list"})
+	if err != nil {
+		t.Fatalf("MGet failed: %v", err)
+	}
+	want := map[string]string{"a": "1", "b": `{"n":2}`, "c": "3"}
+	if len(values) != len(want) {
+		t.Errorf("Expected %v, got %v", want, values)
+	}
+	for k, v := range want {
+		if values[k] != v {
+			t.Errorf("Expected %s=%q, got %q", k, v, values[k])
+		}
+	}
+
+	if raw, _ := store.GetRaw(ctx, "c"); string(raw) != "3" {
+		t.Errorf("Expected MSet to keep the JSON type of values, got %s", raw)
+	}
+
+	if err := store.MSet(ctx, map[string]any{"x": "1"}, -1); err != memory.ErrInvalidTTL {
+		t.Errorf("Expected ErrInvalidTTL, got %v", err)
+	}
+	if err := store.MSet(ctx, map[string]any{"x": "1", "y": func() {}}, 0); err != memory.ErrMarshalFailed {
+		t.Errorf("Expected ErrMarshalFailed, got %v", err)
+	}
+	if _, err := store.Get(ctx, "x"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected no key written when a value is invalid, got %v", err)
+	}
+}
+
+// cancelAfter is a context that reports itself cancelled once Err has been called
+// checks times, to cancel an operation part way deterministically.
+type cancelAfter struct {
+	context.Context
+	checks int
+}
+
+func (c *cancelAfter) Err() error {
+	if c.checks <= 0 {
+		return context.Canceled
+	}
+	c.checks--
+	return nil
+}
+
+func TestMSetChunked(t *testing.T) {
+	store := memory.NewMemoryStoreWithOptions(memory.Options{MaxKeys: 5})
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	pairs := map[string]any{"a": "1", "b": "2", "c": "3"}
+	if n, err := store.MSetChunked(ctx, pairs, 0, 2); err != nil || n != 3 {
+		t.Fatalf("Expected 3 keys written, got %d, %v", n, err)
+	}
+	if values, _ := store.MGet(ctx, []string{"a", "b", "c"}); len(values) != 3 {
+		t.Errorf("Expected every chunk written, got %v", values)
+	}
+
+	// The key limit is reached in the second chunk: the first chunk and the key
+	// written before the limit are kept and counted
+	pairs = map[string]any{"d": "4", "e": "5", "f": "6", "g": "7"}
+	n, err := store.MSetChunked(ctx, pairs, 0, 1)
+	if !errors.Is(err, memory.ErrTooManyKeys) || n != 2 {
+		t.Errorf("Expected ErrTooManyKeys after 2 keys, got %d, %v", n, err)
+	}
+	if keys, _ := store.Keys(ctx, "*"); len(keys) != 5 {
+		t.Errorf("Expected 5 keys, got %v", keys)
+	}
+
+	// A context done between chunks stops the batch before the next chunk
+	n, err = store.MSetChunked(&cancelAfter{Context: ctx, checks: 2}, map[string]any{"a": "x", "b": "y"}, 0, 1)
+	if !errors.Is(err, context.Canceled) || n != 1 {
+		t.Errorf("Expected context.Canceled after 1 key, got %d, %v", n, err)
+	}
+	if got, _ := store.Get(ctx, "b"); got != "2" {
+		t.Errorf("Expected b untouched, got %q", got)
+	}
+
+	if _, err := store.MSetChunked(ctx, map[string]any{"x": "1"}, -1, 1); err != memory.ErrInvalidTTL {
+		t.Errorf("Expected ErrInvalidTTL, got %v", err)
+	}
+}
+
+// maxWriteHold writes a large batch with MSetChunked to a new store, and returns the
+// longest the batch held the store write lock.
+func maxWriteHold(t *testing.T, chunkSize int) time.Duration {
+	store := memory.NewMemoryStoreWithOptions(memory.Options{LockMetrics: true})
+	// The TTL worker would take the lock of its own.
+	store.StopTTLWorker()
+	ctx := context.Background()
+
+	pairs := make(map[string]any, 100000)
+	for i := 0; i < 100000; i++ {
+		pairs[fmt.Sprintf("import:%d", i)] = "value"
+	}
+	if n, err := store.MSetChunked(ctx, pairs, 0, chunkSize); err != nil || n != len(pairs) {
+		t.Fatalf("Expected %d keys written, got %d, %v", len(pairs), n, err)
+	}
+
+	stats, _ := store.Stats(ctx)
+	return stats.Locks.MaxWriteHold
+}
+
+func TestMSetChunkedLockHold(t *testing.T) {
+	if testing.Short() {
+		t.Skip("writes large batches")
+	}
+
+	atOnce := maxWriteHold(t, 0)
+	chunked := maxWriteHold(t, 100)
+	if chunked == 0 || chunked > atOnce/4 {
+		t.Errorf("Expected chunks of 100 keys to hold the lock far less than the whole batch, got %v against %v", chunked, atOnce)
+	}
+}
+
+func TestReturningPrevious(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	previous, err := store.SetReturningPrevious(ctx, "key", "first", 0)
+	if err != nil {
+		t.Fatalf("SetReturningPrevious failed: %v", err)
+	}
+	if previous != nil {
+		t.Errorf("Expected no previous entry for new key, got %+v", previous)
+	}
+
+	previous, err = store.SetReturningPrevious(ctx, "key", "second", 60)
+	if err != nil {
+		t.Fatalf("SetReturningPrevious failed: %v", err)
+	}
+	if previous == nil || previous.Value != "first" || previous.TTLSeconds != -1 {
+		t.Errorf("Expected previous value 'first' without TTL, got %+v", previous)
+	}
+
+	previous, err = store.ExpireReturningPrevious(ctx, "key", 0)
+	if err != nil {
+		t.Fatalf("ExpireReturningPrevious failed: %v", err)
+	}
+	if previous == nil || previous.Value != "second" || previous.TTLSeconds != 60 {
+		t.Errorf("Expected previous value 'second' with TTL 60, got %+v", previous)
+	}
+
+	previous, err = store.RemoveReturningPrevious(ctx, "key")
+	if err != nil {
+		t.Fatalf("RemoveReturningPrevious failed: %v", err)
+	}
+	if previous == nil || previous.Value != "second" {
+		t.Errorf("Expected removed value 'second', got %+v", previous)
+	}
+
+	if _, err := store.RemoveReturningPrevious(ctx, "key"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound for missing key, got %v", err)
+	}
+
+	if _, err := store.ExpireReturningPrevious(ctx, "key", 10); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound for missing key, got %v", err)
+	}
+}
+
+func TestSetIfExpiringWithin(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	set, err := store.SetIfExpiringWithin(ctx, "missing", "v1", 60, 10)
+	if err != nil || !set {
+		t.Errorf("Expected missing key to be set, got %v (err %v)", set, err)
+	}
+
+	// 60 seconds left is above the 10 second threshold.
+	set, _ = store.SetIfExpiringWithin(ctx, "missing", "v2", 60, 10)
+	if set {
+		t.Error("Expected key with TTL above the threshold not to be set")
+	}
+	if value, _ := store.Get(ctx, "missing"); value != "v1" {
+		t.Errorf("Expected value to stay v1, got %s", value)
+	}
+
+	store.Set(ctx, "expiring", "old", 5)
+	set, _ = store.SetIfExpiringWithin(ctx, "expiring", "new", 60, 10)
+	if !set {
+		t.Error("Expected key with TTL below the threshold to be set")
+	}
+	if value, _ := store.Get(ctx, "expiring"); value != "new" {
+		t.Errorf("Expected value new, got %s", value)
+	}
+	entries, _ := store.GetEntries(ctx, []string{"expiring"})
+	if entries[0].TTLSeconds != 60 {
+		t.Errorf("Expected TTL to be reset to 60, got %d", entries[0].TTLSeconds)
+	}
+
+	store.Set(ctx, "permanent", "old", 0)
+	if set, _ := store.SetIfExpiringWithin(ctx, "permanent", "new", 60, 10); set {
+		t.Error("Expected key without TTL not to be set")
+	}
+
+	if _, err := store.SetIfExpiringWithin(ctx, "key", "v", 60, -1); err != memory.ErrInvalidTTL {
+		t.Errorf("Expected ErrInvalidTTL for negative threshold, got %v", err)
+	}
+}
+
+func TestSetIfType(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	set, err := store.SetIfType(ctx, "missing", "v1", 0, "string")
+	if err != nil || !set {
+		t.Errorf("Expected missing key to be set, got %v (err %v)", set, err)
+	}
+
+	set, err = store.SetIfType(ctx, "missing", "v2", 0, "string")
+	if err != nil || !set {
+		t.Errorf("Expected string key to be replaced, got %v (err %v)", set, err)
+	}
+	if value, _ := store.Get(ctx, "missing"); value != "v2" {
+		t.Errorf("Expected v2, got %s", value)
+	}
+
+	store.Push(ctx, "queue", "job")
+	if set, _ := store.SetIfType(ctx, "queue", "v", 0, "string"); set {
+		t.Error("Expected list not to be overwritten when expecting a string")
+	}
+	if items, _ := store.LRange(ctx, "queue", 0, -1); len(items) != 1 || items[0] != "job" {
+		t.Errorf("Expected list to stay intact, got %v", items)
+	}
+
+	// A string write never replaces a list, even when the caller expects one
+	for _, expectedType := range []string{"list", "hash"} {
+		if _, err := store.SetIfType(ctx, "queue", "v", 0, expectedType); err != memory.ErrInvalidType {
+			t.Errorf("Expected ErrInvalidType for %s, got %v", expectedType, err)
+		}
+	}
+	if items, _ := store.LRange(ctx, "queue", 0, -1); len(items) != 1 || items[0] != "job" {
+		t.Errorf("Expected list to stay intact, got %v", items)
+	}
+}
+
+func TestLRange(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	for _, item := range []string{"e", "d", "c", "b", "a"} {
+		store.Push(ctx, "list", item)
+	}
+
+	tests := []struct {
+		start, stop int
+		want        string
+	}{
+		{0, -1, "abcde"},
+		{1, 2, "bc"},
+		{-2, -1, "de"},
+		{-100, 1, "ab"},
+		{3, 100, "de"},
+		{3, 1, ""},
+		{10, 20, ""},
+	}
+
+	for _, tt := range tests {
+		items, err := store.LRange(ctx, "list", tt.start, tt.stop)
+		if err != nil {
+			t.Fatalf("LRange(%d, %d) failed: %v", tt.start, tt.stop, err)
+		}
+		got := ""
+		for _, item := range items {
+			got += item
+		}
+		if got != tt.want {
+			t.Errorf("LRange(%d, %d): expected %q, got %q", tt.start, tt.stop, tt.want, got)
+		}
+	}
+
+	if _, err := store.LRange(ctx, "missing", 0, -1); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound for missing list, got %v", err)
+	}
+
+	store.Set(ctx, "str", "value", 0)
+	if _, err := store.LRange(ctx, "str", 0, -1); err != memory.ErrTypeMismatch {
+		t.Errorf("Expected ErrTypeMismatch for string key, got %v", err)
+	}
+}
+
+func TestLRangeExpired(t *testing.T) {
+	clock := newFakeClock()
+	store := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock})
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Push(ctx, "list", "a")
+	store.Expire(ctx, "list", 10)
+
+	if items, err := store.LRange(ctx, "list", 0, -1); err != nil || len(items) != 1 {
+		t.Fatalf("Expected the list before it expires, got %v, %v", items, err)
+	}
+	clock.Advance(11 * time.Second)
+	if _, err := store.LRange(ctx, "list", 0, -1); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound for an expired list, got %v", err)
+	}
+}
+
+func TestLRangePage(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	for _, item := range []string{"e", "d", "c", "b", "a"} {
+		store.Push(ctx, "list", item)
+	}
+
+	tests := []struct {
+		start, stop         int
+		wantStart, wantStop int
+		wantItems           int
+	}{
+		{0, -1, 0, 4, 5},
+		{0, 1, 0, 1, 2},
+		{2, 3, 2, 3, 2},
+		{-2, -1, 3, 4, 2},
+		{3, 100, 3, 4, 2},
+		{10, 20, 10, 9, 0},
+	}
+
+	for _, tt := range tests {
+		page, err := store.LRangePage(ctx, "list", tt.start, tt.stop)
+		if err != nil {
+			t.Fatalf("LRangePage(%d, %d) failed: %v", tt.start, tt.stop, err)
+		}
+		if page.Total != 5 {
+			t.Errorf("LRangePage(%d, %d): expected total 5, got %d", tt.start, tt.stop, page.Total)
+		}
+		if page.Start != tt.wantStart || page.Stop != tt.wantStop || len(page.Items) != tt.wantItems {
+			t.Errorf("LRangePage(%d, %d): expected [%d, %d] with %d items, got %+v",
+				tt.start, tt.stop, tt.wantStart, tt.wantStop, tt.wantItems, page)
+		}
+	}
+}
+
+func TestLTrimReturn(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	tests := []struct {
+		start, stop   int
+		kept, removed string
+	}{
+		{1, 3, "bcd", "ae"},
+		{0, 1, "ab", "cde"},
+		{-2, -1, "de", "abc"},
+		{-100, 100, "abcde", ""},
+		{3, 1, "", "abcde"},
+	}
+
+	for _, tt := range tests {
+		store.Remove(ctx, "list")
+		for _, item := range []string{"e", "d", "c", "b", "a"} {
+			store.Push(ctx, "list", item)
+		}
+
+		removed, err := store.LTrimReturn(ctx, "list", tt.start, tt.stop)
+		if err != nil {
+			t.Fatalf("LTrimReturn(%d, %d) failed: %v", tt.start, tt.stop, err)
+		}
+		if got := strings.Join(removed, ""); got != tt.removed {
+			t.Errorf("LTrimReturn(%d, %d): expected removed %q, got %q", tt.start, tt.stop, tt.removed, got)
+		}
+
+		items, err := store.LRange(ctx, "list", 0, -1)
+		if tt.kept == "" {
+			if err != memory.ErrKeyNotFound {
+				t.Errorf("LTrimReturn(%d, %d): expected the emptied list to be removed, got %v", tt.start, tt.stop, err)
+			}
+			continue
+		}
+		if got := strings.Join(items, ""); got != tt.kept {
+			t.Errorf("LTrimReturn(%d, %d): expected kept %q, got %q", tt.start, tt.stop, tt.kept, got)
+		}
+		if got := strings.Join(removed, "") + strings.Join(items, ""); len(got) != 5 {
+			t.Errorf("LTrimReturn(%d, %d): expected kept and removed to cover the list, got %q", tt.start, tt.stop, got)
+		}
+	}
+
+	if _, err := store.LTrimReturn(ctx, "missing", 0, 1); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound for missing list, got %v", err)
+	}
+
+	store.Set(ctx, "str", "value", 0)
+	if err := store.LTrim(ctx, "str", 0, 1); err != memory.ErrTypeMismatch {
+		t.Errorf("Expected ErrTypeMismatch for string key, got %v", err)
+	}
+}
+
+func TestLClear(t *testing.T) {
+	clock := newFakeClock()
+	s := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock})
+	defer s.StopTTLWorker()
+	ctx := context.Background()
+
+	s.LSet(ctx, "queue", []any{"a", "b", "c"}, 60)
+	s.Set(ctx, "name", "alice", 0)
+	clock.Advance(10 * time.Second)
+
+	if err := s.LClear(ctx, "queue"); err != nil {
+		t.Fatalf("LClear failed: %v", err)
+	}
+	if n, err := s.LLen(ctx, "queue"); err != nil || n != 0 {
+		t.Errorf("Expected the list to exist with length 0, got %d, %v", n, err)
+	}
+	if ttl, _ := s.TTL(ctx, "queue"); ttl != 50 {
+		t.Errorf("Expected the TTL to be kept at 50 seconds, got %d", ttl)
+	}
+	if res, _ := s.PushItem(ctx, "queue", "d", store.PushOptions{}); res.Seq != 4 {
+		t.Errorf("Expected the sequence to carry on at 4, got %d", res.Seq)
+	}
+
+	if err := s.LClear(ctx, "name"); err != memory.ErrTypeMismatch {
+		t.Errorf("Expected ErrTypeMismatch, got %v", err)
+	}
+	if err := s.LClear(ctx, "missing"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestPushCappedReturn(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	for _, item := range []string{"a", "b", "c"} {
+		evicted, err := store.PushCappedReturn(ctx, "list", item, 3)
+		if err != nil {
+			t.Fatalf("PushCappedReturn(%q) failed: %v", item, err)
+		}
+		if len(evicted) != 0 {
+			t.Errorf("Expected no evictions under the cap, got %v", evicted)
+		}
+	}
+
+	evicted, err := store.PushCappedReturn(ctx, "list", "d", 3)
+	if err != nil {
+		t.Fatalf("PushCappedReturn failed: %v", err)
+	}
+	if got := strings.Join(evicted, ""); got != "a" {
+		t.Errorf("Expected the oldest item to be evicted, got %q", got)
+	}
+	items, _ := store.LRange(ctx, "list", 0, -1)
+	if got := strings.Join(items, ""); got != "dcb" {
+		t.Errorf("Expected list dcb, got %q", got)
+	}
+
+	evicted, _ = store.PushCappedReturn(ctx, "list", "e", 1)
+	if got := strings.Join(evicted, ""); got != "dcb" {
+		t.Errorf("Expected a lower cap to evict dcb, got %q", got)
+	}
+	items, _ = store.LRange(ctx, "list", 0, -1)
+	if got := strings.Join(items, ""); got != "e" {
+		t.Errorf("Expected list e, got %q", got)
+	}
+
+	evicted, _ = store.PushCappedReturn(ctx, "list", "f", 0)
+	if len(evicted) != 0 {
+		t.Errorf("Expected a zero cap to leave the list uncapped, got %v", evicted)
+	}
+
+	store.Set(ctx, "str", "value", 0)
+	if _, err := store.PushCappedReturn(ctx, "str", "x", 3); err != memory.ErrTypeMismatch {
+		t.Errorf("Expected ErrTypeMismatch, got %v", err)
+	}
+}
+
+func TestRPushRPop(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	for _, item := range []string{"a", "b", "c"} {
+		if err := store.RPush(ctx, "queue", item); err != nil {
+			t.Fatalf("RPush(%q) failed: %v", item, err)
+		}
+	}
+	items, _ := store.LRange(ctx, "queue", 0, -1)
+	if got := strings.Join(items, ""); got != "abc" {
+		t.Errorf("Expected list abc, got %q", got)
+	}
+
+	// RPush and Pop make a FIFO queue
+	if item, _ := store.Pop(ctx, "queue"); item != "a" {
+		t.Errorf("Expected Pop to return the first pushed item, got %q", item)
+	}
+	if item, _ := store.RPop(ctx, "queue"); item != "c" {
+		t.Errorf("Expected RPop to return the last item, got %q", item)
+	}
+	store.RPop(ctx, "queue")
+	if _, err := store.RPop(ctx, "queue"); err != memory.ErrEmptyList {
+		t.Errorf("Expected ErrEmptyList, got %v", err)
+	}
+
+	if _, err := store.RPop(ctx, "missing"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+
+	store.Set(ctx, "str", "value", 0)
+	if err := store.RPush(ctx, "str", "x"); err != memory.ErrTypeMismatch {
+		t.Errorf("Expected ErrTypeMismatch from RPush, got %v", err)
+	}
+	if _, err := store.RPop(ctx, "str"); err != memory.ErrTypeMismatch {
+		t.Errorf("Expected ErrTypeMismatch from RPop, got %v", err)
+	}
+}
+
+func TestRPushExpired(t *testing.T) {
+	clock := newFakeClock()
+	store := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock})
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.RPush(ctx, "queue", "old")
+	store.Expire(ctx, "queue", 1)
+	clock.Advance(2 * time.Second)
+
+	if _, err := store.RPop(ctx, "queue"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound for an expired list, got %v", err)
+	}
+	store.RPush(ctx, "queue", "new")
+	items, _ := store.LRange(ctx, "queue", 0, -1)
+	if got := strings.Join(items, ","); got != "new" {
+		t.Errorf("Expected a fresh list after expiry, got %q", got)
+	}
+}
+
+func TestExpirePattern(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Set(ctx, "feature:beta:search", "on", 0)
+	store.Set(ctx, "feature:beta:export", "on", 0)
+	store.Push(ctx, "feature:beta:queue", "job")
+	store.Set(ctx, "feature:stable:search", "on", 0)
+	store.Set(ctx, "feature:beta", "on", 0)
+
+	count, err := store.ExpirePattern(ctx, "feature:beta:*", 1)
+	if err != nil {
+		t.Fatalf("ExpirePattern failed: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 keys to be expired, got %d", count)
+	}
+
+	entries, _ := store.GetEntries(ctx, []string{"feature:beta:search", "feature:stable:search", "feature:beta"})
+	if entries[0].TTLSeconds != 1 {
+		t.Errorf("Expected matching key to have TTL 1, got %d", entries[0].TTLSeconds)
+	}
+	if entries[1].TTLSeconds != -1 || entries[2].TTLSeconds != -1 {
+		t.Errorf("Expected non-matching keys to keep no TTL, got %+v", entries[1:])
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	for _, key := range []string{"feature:beta:search", "feature:beta:export"} {
+		if _, err := store.Get(ctx, key); err != memory.ErrKeyNotFound {
+			t.Errorf("Expected %s to have expired, got %v", key, err)
+		}
+	}
+	if _, err := store.Get(ctx, "feature:stable:search"); err != nil {
+		t.Errorf("Expected non-matching key to remain, got %v", err)
+	}
+
+	if _, err := store.ExpirePattern(ctx, "feature:[beta", 1); err != memory.ErrInvalidPattern {
+		t.Errorf("Expected ErrInvalidPattern for unclosed class, got %v", err)
+	}
+}
+
+func TestExpirePatternSyntax(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		match   bool
+	}{
+		{"*", "a/b/c", true},
+		{"user:*:name", "user:1/2:name", true},
+		{"user:?", "user:1", true},
+		{"user:?", "user:12", false},
+		{"user:[0-9]", "user:7", true},
+		{"user:[^0-9]", "user:7", false},
+		{"user:[!a]", "user:b", true},
+		{"a\\*b", "a*b", true},
+		{"a\\*b", "axb", false},
+		{"*:end", "start:middle:end", true},
+		{"*:end", "start:middle:en", false},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		store := memory.NewMemoryStore()
+		ctx := context.Background()
+		store.Set(ctx, tt.key, "v", 0)
+
+		count, err := store.ExpirePattern(ctx, tt.pattern, 60)
+		store.StopTTLWorker()
+		if err != nil {
+			t.Fatalf("ExpirePattern(%q) failed: %v", tt.pattern, err)
+		}
+		if (count == 1) != tt.match {
+			t.Errorf("Expected pattern %q matching %q to be %v", tt.pattern, tt.key, tt.match)
+		}
+	}
+}
+
+func TestKeysAndLLen(t *testing.T) {
+	clock := newFakeClock()
+	store := memory.NewMemoryStoreWithClock(clock)
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Set(ctx, "user:2", "b", 0)
+	store.Set(ctx, "user:1", "a", 0)
+	store.Set(ctx, "user:3", "c", 1)
+	store.Push(ctx, "jobs", "x")
+	store.Push(ctx, "jobs", "y")
+
+	clock.Advance(2 * time.Second)
+
+	keys, err := store.Keys(ctx, "")
+	if err != nil {
+		t.Fatalf("Keys failed: %v", err)
+	}
+	if strings.Join(keys, ",") != "jobs,user:1,user:2" {
+		t.Errorf("Expected sorted live keys, got %v", keys)
+	}
+
+	keys, err = store.Keys(ctx, "user:*")
+	if err != nil {
+		t.Fatalf("Keys failed: %v", err)
+	}
+	if strings.Join(keys, ",") != "user:1,user:2" {
+		t.Errorf("Expected user keys, got %v", keys)
+	}
+
+	if _, err := store.Keys(ctx, "user:["); !errors.Is(err, memory.ErrInvalidPattern) {
+		t.Errorf("Expected ErrInvalidPattern, got %v", err)
+	}
+
+	n, err := store.LLen(ctx, "jobs")
+	if err != nil || n != 2 {
+		t.Errorf("Expected length 2, got %d, %v", n, err)
+	}
+	if _, err := store.LLen(ctx, "user:1"); !errors.Is(err, memory.ErrTypeMismatch) {
+		t.Errorf("Expected ErrTypeMismatch, got %v", err)
+	}
+	if _, err := store.LLen(ctx, "missing"); !errors.Is(err, memory.ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestDeleteExpiringWithin(t *testing.T) {
+	clock := newFakeClock()
+	store := memory.NewMemoryStoreWithClock(clock)
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Set(ctx, "in-10s", "v", 10)
+	store.Set(ctx, "in-30s", "v", 30)
+	store.Set(ctx, "in-60s", "v", 60)
+	store.Set(ctx, "in-1h", "v", 3600)
+	store.Set(ctx, "forever", "v", 0)
+	store.Push(ctx, "queue", "job")
+	store.Expire(ctx, "queue", 20)
+	store.Set(ctx, "expired", "v", 1)
+
+	clock.Advance(2 * time.Second)
+
+	deleted, err := store.DeleteExpiringWithin(ctx, 50*time.Second)
+	if err != nil {
+		t.Fatalf("DeleteExpiringWithin failed: %v", err)
+	}
+	if deleted != 3 {
+		t.Errorf("Expected 3 keys deleted, got %d", deleted)
+	}
+
+	keys, _ := store.Keys(ctx, "")
+	if strings.Join(keys, ",") != "forever,in-1h,in-60s" {
+		t.Errorf("Expected only keys expiring later or never to remain, got %v", keys)
+	}
+
+	if deleted, err := store.DeleteExpiringWithin(ctx, 0); err != nil || deleted != 0 {
+		t.Errorf("Expected a zero threshold to delete nothing, got %d (err %v)", deleted, err)
+	}
+	if _, err := store.DeleteExpiringWithin(ctx, -time.Second); !errors.Is(err, memory.ErrInvalidTTL) {
+		t.Errorf("Expected ErrInvalidTTL for a negative threshold, got %v", err)
+	}
+}
+
+func TestDeleteMatching(t *testing.T) {
+	clock := newFakeClock()
+	store := memory.NewMemoryStoreWithClock(clock)
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Set(ctx, "tenant:42:name", "acme", 0)
+	store.Set(ctx, "tenant:42:session", "token", 60)
+	store.RPush(ctx, "tenant:42:queue", "job")
+	store.Set(ctx, "tenant:42:short", "gone", 1)
+	store.Set(ctx, "tenant:7:name", "other", 0)
+	store.Set(ctx, "global", "config", 0)
+
+	clock.Advance(2 * time.Second)
+
+	deleted, err := store.DeleteMatching(ctx, "tenant:42:*")
+	if err != nil {
+		t.Fatalf("DeleteMatching failed: %v", err)
+	}
+	if deleted != 3 {
+		t.Errorf("Expected the 3 live keys counted, got %d", deleted)
+	}
+
+	// The expired key is gone too, rather than left for the TTL worker
+	page, _ := store.EventsSince(ctx, 0)
+	if got := eventKeys(page.Events); !strings.Contains(strings.Join(got, ","), "del:tenant:42:short") {
+		t.Errorf("Expected the expired key to be deleted, got events %v", got)
+	}
+	keys, _ := store.Keys(ctx, "")
+	if strings.Join(keys, ",") != "global,tenant:7:name" {
+		t.Errorf("Expected the other keys to remain, got %v", keys)
+	}
+
+	if deleted, err := store.DeleteMatching(ctx, "tenant:42:*"); err != nil || deleted != 0 {
+		t.Errorf("Expected nothing left to delete, got %d (err %v)", deleted, err)
+	}
+	if _, err := store.DeleteMatching(ctx, "tenant:[42"); !errors.Is(err, memory.ErrInvalidPattern) {
+		t.Errorf("Expected ErrInvalidPattern, got %v", err)
+	}
+}
+
+func TestCountPattern(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Set(ctx, "session:1", "a", 0)
+	store.Set(ctx, "session:2", "b", 60)
+	store.Set(ctx, "session:3", "c", 1)
+	store.Push(ctx, "session:queue", "d")
+	store.Set(ctx, "user:1", "e", 0)
+	store.Set(ctx, "__upload:1", "f", 0)
+
+	time.Sleep(1100 * time.Millisecond)
+
+	tests := []struct {
+		pattern string
+		want    int
+	}{
+		{"session:*", 3},
+		{"*", 5},
+		{"order:*", 0},
+		{"session:?", 2},
+		{"__upload:*", 1},
+	}
+
+	for _, tt := range tests {
+		count, err := store.CountPattern(ctx, tt.pattern)
+		if err != nil {
+			t.Fatalf("CountPattern(%q) failed: %v", tt.pattern, err)
+		}
+		if count != tt.want {
+			t.Errorf("CountPattern(%q): expected %d, got %d", tt.pattern, tt.want, count)
+		}
+	}
+
+	if _, err := store.CountPattern(ctx, "session:[1"); err != memory.ErrInvalidPattern {
+		t.Errorf("Expected ErrInvalidPattern, got %v", err)
+	}
+}
+
+func TestGetAny(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Set(ctx, "str", "value", 0)
+	store.Push(ctx, "list", "b")
+	store.Push(ctx, "list", "a")
+
+	value, kind, err := store.GetAny(ctx, "str")
+	if err != nil || kind != "string" || value != "value" {
+		t.Errorf("Expected string 'value', got %v (%s, err %v)", value, kind, err)
+	}
+
+	value, kind, err = store.GetAny(ctx, "list")
+	items, _ := value.([]string)
+	if err != nil || kind != "list" || len(items) != 2 || items[0] != "a" {
+		t.Errorf("Expected list [a b], got %v (%s, err %v)", value, kind, err)
+	}
+
+	if _, _, err := store.GetAny(ctx, "missing"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound for missing key, got %v", err)
+	}
+}
+
+func TestTopKeys(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Set(ctx, "small", "x", 0)
+	store.Set(ctx, "medium", strings.Repeat("x", 100), 60)
+	store.Set(ctx, "large", strings.Repeat("x", 1000), 10)
+	store.Push(ctx, "list", strings.Repeat("x", 300))
+	store.Push(ctx, "list", strings.Repeat("x", 300))
+
+	keys, err := store.TopKeysBySize(ctx, 3)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	got := make([]string, len(keys))
+	for i, k := range keys {
+		got[i] = k.Key
+	}
+	if strings.Join(got, ",") != "large,list,medium" {
+		t.Errorf("Expected keys by size [large list medium], got %v", got)
+	}
+	if keys[0].SizeBytes <= 1000 || keys[1].Type != "list" {
+		t.Errorf("Expected size over 1000 bytes and a list second, got %+v", keys)
+	}
+
+	keys, _ = store.TopKeysByTTL(ctx, 10)
+	if len(keys) != 2 || keys[0].Key != "medium" || keys[1].Key != "large" {
+		t.Errorf("Expected keys by TTL [medium large], got %+v", keys)
+	}
+
+	for i := 0; i < 3; i++ {
+		store.Get(ctx, "small")
+	}
+	store.LRange(ctx, "list", 0, -1)
+
+	keys, _ = store.TopKeysByAccess(ctx, 2)
+	if len(keys) != 2 || keys[0].Key != "small" || keys[0].Hits != 4 || keys[1].Key != "list" || keys[1].Hits != 3 {
+		t.Errorf("Expected small (4 hits) then list (3 hits), got %+v", keys)
+	}
+
+	store.Remove(ctx, "small")
+	store.Set(ctx, "small", "x", 0)
+	keys, _ = store.TopKeysByAccess(ctx, 1)
+	if keys[0].Key != "list" {
+		t.Errorf("Expected hits to reset when a key is removed, got %+v", keys)
+	}
+}
+
+func TestKeysInfo(t *testing.T) {
+	clock := newFakeClock()
+	store := memory.NewMemoryStoreWithClock(clock)
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Set(ctx, "expired", "x", 1)
+	clock.Advance(2 * time.Second)
+	store.Set(ctx, "user:1", strings.Repeat("x", 100), 60)
+	store.Get(ctx, "user:1")
+	store.Push(ctx, "queue", "job")
+
+	infos, err := store.KeysInfo(ctx, []string{"queue", "missing", "expired", "user:1"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("Expected missing and expired keys to be omitted, got %+v", infos)
+	}
+	if infos[0].Key != "queue" || infos[0].Type != "list" || infos[0].TTLSeconds != -1 || infos[0].Hits != 1 {
+		t.Errorf("Unexpected list info: %+v", infos[0])
+	}
+	if infos[1].Key != "user:1" || infos[1].Type != "string" || infos[1].TTLSeconds != 60 || infos[1].Hits != 2 || infos[1].SizeBytes <= 100 {
+		t.Errorf("Unexpected string info: %+v", infos[1])
+	}
+
+	store.KeysInfo(ctx, []string{"user:1"})
+	if infos, _ := store.KeysInfo(ctx, []string{"user:1"}); infos[0].Hits != 2 {
+		t.Errorf("Expected KeysInfo not to count as an access, got %d hits", infos[0].Hits)
+	}
+}
+
+func TestRandomKeys(t *testing.T) {
+	clock := newFakeClock()
+	store := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock})
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	for i := 0; i < 20; i++ {
+		store.Set(ctx, fmt.Sprintf("key%d", i), "value", 0)
+	}
+	store.Set(ctx, "expired", "value", 1)
+	clock.Advance(2 * time.Second)
+
+	seen := map[string]int{}
+	for call := 0; call < 200; call++ {
+		keys, err := store.RandomKeys(ctx, 5)
+		if err != nil {
+			t.Fatalf("RandomKeys failed: %v", err)
+		}
+		if len(keys) != 5 {
+			t.Fatalf("Expected 5 keys, got %d", len(keys))
+		}
+
+		inSample := map[string]bool{}
+		for _, k := range keys {
+			if inSample[k.Key] {
+				t.Fatalf("Expected distinct keys within a sample, got %q twice", k.Key)
+			}
+			inSample[k.Key] = true
+			if k.Key == "expired" {
+				t.Fatal("Expected expired keys to be left out of samples")
+			}
+			if k.SizeBytes <= 0 || k.Type != "string" {
+				t.Errorf("Unexpected key details: %+v", k)
+			}
+			seen[k.Key]++
+		}
+	}
+
+	// Each key is expected in a quarter of the samples, about 50 times.
+	if len(seen) != 20 {
+		t.Errorf("Expected every key to be sampled across calls, saw %d of 20", len(seen))
+	}
+	for k, count := range seen {
+		if count < 15 || count > 100 {
+			t.Errorf("Expected %s to be sampled about 50 times, got %d", k, count)
+		}
+	}
+
+	keys, _ := store.RandomKeys(ctx, 100)
+	if len(keys) != 20 {
+		t.Errorf("Expected all 20 live keys when n exceeds the keyspace, got %d", len(keys))
+	}
+	if keys, _ := store.RandomKeys(ctx, 0); len(keys) != 0 {
+		t.Errorf("Expected no keys for n 0, got %d", len(keys))
+	}
+}
+
+func TestWorkerHealth(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	time.Sleep(1100 * time.Millisecond)
+
+	workers, _ := store.Health(ctx)
+	if len(workers) != 1 || workers[0].Name != "ttl" || !workers[0].Healthy || workers[0].LastRun.IsZero() {
+		t.Fatalf("Expected a healthy ttl worker after the first sweep, got %+v", workers)
+	}
+
+	store.ReportWorker("snapshot", errors.New("disk full"))
+	store.ReportWorker("snapshot", errors.New("disk full"))
+
+	workers, _ = store.Health(ctx)
+	snapshot := workers[0]
+	if snapshot.Name != "snapshot" || snapshot.Healthy || snapshot.LastError != "disk full" ||
+		snapshot.LastErrorAt == nil || snapshot.ConsecutiveFailures != 2 {
+		t.Errorf("Expected a failing snapshot worker, got %+v", snapshot)
+	}
+
+	store.ReportWorker("snapshot", nil)
+
+	workers, _ = store.Health(ctx)
+	snapshot = workers[0]
+	if !snapshot.Healthy || snapshot.ConsecutiveFailures != 0 || snapshot.LastError != "disk full" {
+		t.Errorf("Expected a recovered snapshot worker keeping its last error, got %+v", snapshot)
+	}
+}
+
+func TestSoftRemoveAndRestore(t *testing.T) {
+	store := memory.NewMemoryStoreWithOptions(memory.Options{SoftDeleteWindow: 500 * time.Millisecond})
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Set(ctx, "key", "value", 60)
+	if err := store.SoftRemove(ctx, "key"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := store.Get(ctx, "key"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected soft deleted key to be gone, got %v", err)
+	}
+
+	if err := store.Restore(ctx, "key"); err != nil {
+		t.Fatalf("Expected restore within the window to succeed, got %v", err)
+	}
+	if value, _ := store.Get(ctx, "key"); value != "value" {
+		t.Errorf("Expected restored value, got %s", value)
+	}
+	entries, _ := store.GetEntries(ctx, []string{"key"})
+	if entries[0].TTLSeconds <= 0 || entries[0].TTLSeconds > 60 {
+		t.Errorf("Expected the original TTL to be kept, got %d", entries[0].TTLSeconds)
+	}
+	if err := store.Restore(ctx, "key"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected a second restore to fail with ErrKeyNotFound, got %v", err)
+	}
+
+	// A key set again after the delete is not overwritten.
+	store.SoftRemove(ctx, "key")
+	store.Set(ctx, "key", "new", 0)
+	if err := store.Restore(ctx, "key"); err != memory.ErrKeyExists {
+		t.Errorf("Expected ErrKeyExists, got %v", err)
+	}
+
+	store.Push(ctx, "list", "item")
+	store.SoftRemove(ctx, "list")
+	time.Sleep(600 * time.Millisecond)
+	if err := store.Restore(ctx, "list"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected restore after the window to fail with ErrKeyNotFound, got %v", err)
+	}
+
+	if err := store.SoftRemove(ctx, "missing"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound for missing key, got %v", err)
+	}
+}
+
+func TestFenceTokens(t *testing.T) {
+	s := memory.NewMemoryStore()
+	defer s.StopTTLWorker()
+	ctx := context.Background()
+
+	set, oldToken, err := s.SetNXFenced(ctx, "lock", "writer-a", 60)
+	if err != nil || !set || oldToken == 0 {
+		t.Fatalf("Expected writer A to acquire the lock with a token, got %v, %d (err %v)", set, oldToken, err)
+	}
+	if set, token, _ := s.SetNXFenced(ctx, "lock", "writer-b", 60); set || token != 0 {
+		t.Errorf("Expected writer B not to acquire a held lock, got %v, %d", set, token)
+	}
+
+	// Writer A stalls, its lock is released and writer B acquires it.
+	s.Remove(ctx, "lock")
+	_, newToken, _ := s.SetNXFenced(ctx, "lock", "writer-b", 60)
+	if newToken <= oldToken {
+		t.Fatalf("Expected a higher token for writer B, got %d after %d", newToken, oldToken)
+	}
+
+	stale := store.Fence(oldToken)
+	current := store.Fence(newToken)
+
+	if err := s.Set(ctx, "lock", "writer-a", 60, stale); err != memory.ErrStaleFence {
+		t.Errorf("Expected the stale writer to be rejected, got %v", err)
+	}
+	if err := s.Push(ctx, "lock", "item", stale); err != memory.ErrStaleFence {
+		t.Errorf("Expected the stale writer's Push to be rejected, got %v", err)
+	}
+	if err := s.Set(ctx, "lock", "writer-b:done", 60, current); err != nil {
+		t.Errorf("Expected the current holder to write, got %v", err)
+	}
+	if value, _ := s.Get(ctx, "lock"); value != "writer-b:done" {
+		t.Errorf("Expected writer B's value, got %s", value)
+	}
+
+	renewed, err := s.ExpireFenced(ctx, "lock", 60)
+	if err != nil || renewed <= newToken {
+		t.Errorf("Expected ExpireFenced to issue a higher token, got %d (err %v)", renewed, err)
+	}
+	if err := s.Remove(ctx, "lock", current); err != memory.ErrStaleFence {
+		t.Errorf("Expected a token older than the renewal to be rejected, got %v", err)
+	}
+
+	// Writes without a token are not checked.
+	if err := s.Set(ctx, "lock", "unfenced", 0); err != nil {
+		t.Errorf("Expected an unfenced write to succeed, got %v", err)
+	}
+}
+
+func TestMaxMemoryReject(t *testing.T) {
+	// Each key below is estimated at 167 bytes, so five of them fit and a sixth does not.
+	store := memory.NewMemoryStoreWithOptions(memory.Options{MaxMemoryBytes: 900})
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+	value := strings.Repeat("v", 100)
+
+	for i := 0; i < 5; i++ {
+		if err := store.Set(ctx, fmt.Sprintf("k%02d", i), value, 0); err != nil {
+			t.Fatalf("Expected key %d to fit, got %v", i, err)
+		}
+	}
+
+	if err := store.Set(ctx, "k05", value, 0); err != memory.ErrOutOfMemory {
+		t.Errorf("Expected ErrOutOfMemory once full, got %v", err)
+	}
+	if err := store.Push(ctx, "k00", "item"); err != memory.ErrTypeMismatch {
+		t.Errorf("Expected the type check to run first, got %v", err)
+	}
+	if err := store.Push(ctx, "list", "item"); err != memory.ErrOutOfMemory {
+		t.Errorf("Expected Push to be rejected once full, got %v", err)
+	}
+	if err := store.Update(ctx, "k00", value+value); err != memory.ErrOutOfMemory {
+		t.Errorf("Expected a growing Update to be rejected, got %v", err)
+	}
+
+	// Writes that do not grow the store, reads and deletes keep working.
+	if err := store.Set(ctx, "k00", "short", 0); err != nil {
+		t.Errorf("Expected a shrinking Set to succeed, got %v", err)
+	}
+	if got, err := store.Get(ctx, "k01"); err != nil || got != value {
+		t.Errorf("Expected reads to work once full, got %q (err %v)", got, err)
+	}
+	if err := store.Remove(ctx, "k01"); err != nil {
+		t.Errorf("Expected deletes to work once full, got %v", err)
+	}
+
+	// The freed space can be used again.
+	if err := store.Set(ctx, "k05", value, 0); err != nil {
+		t.Errorf("Expected a write to succeed after a delete, got %v", err)
+	}
+}
+
+func TestMaxMemoryPendingItems(t *testing.T) {
+	clock := newFakeClock()
+	store := memory.NewMemoryStoreWithOptions(memory.Options{MaxMemoryBytes: 900, SoftDeleteWindow: time.Minute, Clock: clock})
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+	value := strings.Repeat("v", 100)
+
+	// Five keys of 167 bytes fit, as in TestMaxMemoryReject.
+	for i := 0; i < 5; i++ {
+		store.Set(ctx, fmt.Sprintf("k%02d", i), value, 0)
+	}
+
+	// A soft deleted value still takes up its space until its window closes.
+	if err := store.SoftRemove(ctx, "k00"); err != nil {
+		t.Fatalf("SoftRemove failed: %v", err)
+	}
+	if err := store.Set(ctx, "k05", value, 0); err != memory.ErrOutOfMemory {
+		t.Errorf("Expected the soft deleted value to count against the limit, got %v", err)
+	}
+	if err := store.Restore(ctx, "k00"); err != nil {
+		t.Errorf("Expected Restore not to count the value twice, got %v", err)
+	}
+	store.SoftRemove(ctx, "k00")
+	clock.Advance(2 * time.Minute)
+	if err := store.Restore(ctx, "k00"); !errors.Is(err, memory.ErrKeyNotFound) {
+		t.Fatalf("Expected the window to be closed, got %v", err)
+	}
+	if err := store.Set(ctx, "k05", value, 0); err != nil {
+		t.Errorf("Expected the space to be freed once the window closed, got %v", err)
+	}
+
+	// A reserved item takes up its space until it is acknowledged.
+	store.FlushAll(ctx)
+	store.Set(ctx, "k00", value, 0)
+	store.Set(ctx, "k01", value, 0)
+	store.Set(ctx, "k02", value, 0)
+	store.Push(ctx, "queue", value)
+	_, receipt, err := store.Reserve(ctx, "queue", time.Minute)
+	if err != nil {
+		t.Fatalf("Reserve failed: %v", err)
+	}
+	if err := store.Set(ctx, "k03", value, 0); err != memory.ErrOutOfMemory {
+		t.Errorf("Expected the reserved item to count against the limit, got %v", err)
+	}
+	if err := store.Ack(ctx, receipt); err != nil {
+		t.Fatalf("Ack failed: %v", err)
+	}
+	if err := store.Set(ctx, "k03", value, 0); err != nil {
+		t.Errorf("Expected the space to be freed once the item was acknowledged, got %v", err)
+	}
+}
+
+func TestMaxKeysReject(t *testing.T) {
+	store := memory.NewMemoryStoreWithOptions(memory.Options{MaxKeys: 3})
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Set(ctx, "a", "1", 0)
+	store.Set(ctx, "b", "1", 0)
+	store.Push(ctx, "c", "1")
+
+	if err := store.Set(ctx, "d", "1", 0); err != memory.ErrTooManyKeys {
+		t.Errorf("Expected ErrTooManyKeys for a new key at the cap, got %v", err)
+	}
+	if err := store.Push(ctx, "e", "1"); err != memory.ErrTooManyKeys {
+		t.Errorf("Expected ErrTooManyKeys for a new list at the cap, got %v", err)
+	}
+
+	// Existing keys stay writable.
+	if err := store.Set(ctx, "a", "2", 0); err != nil {
+		t.Errorf("Expected Set of an existing key to succeed at the cap, got %v", err)
+	}
+	if err := store.Update(ctx, "b", "2"); err != nil {
+		t.Errorf("Expected Update to succeed at the cap, got %v", err)
+	}
+	if err := store.Push(ctx, "c", "2"); err != nil {
+		t.Errorf("Expected Push onto an existing list to succeed at the cap, got %v", err)
+	}
+
+	store.Remove(ctx, "a")
+	if err := store.Set(ctx, "d", "1", 0); err != nil {
+		t.Errorf("Expected a new key to fit after a delete, got %v", err)
+	}
+	if _, err := store.Get(ctx, "b"); err != nil {
+		t.Errorf("Expected reject mode to keep existing keys, got %v", err)
+	}
+}
+
+func TestMaxKeysEvict(t *testing.T) {
+	clock := newFakeClock()
+	store := memory.NewMemoryStoreWithOptions(memory.Options{MaxKeys: 3, OnFull: memory.FullEvict, Clock: clock})
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	for _, key := range []string{"a", "b", "c"} {
+		store.Set(ctx, key, "1", 0)
+		clock.Advance(time.Second)
+	}
+	// Reading a makes b the least recently accessed key.
+	store.Get(ctx, "a")
+	clock.Advance(time.Second)
+
+	if err := store.Set(ctx, "d", "1", 0); err != nil {
+		t.Fatalf("Expected evict mode to make room for a new key, got %v", err)
+	}
+	if _, err := store.Get(ctx, "b"); !errors.Is(err, memory.ErrKeyNotFound) {
+		t.Errorf("Expected the least recently accessed key to be evicted, got %v", err)
+	}
+	keys, _ := store.Keys(ctx, "")
+	if strings.Join(keys, ",") != "a,c,d" {
+		t.Errorf("Expected [a c d], got %v", keys)
+	}
+
+	// Updating an existing key evicts nothing.
+	if err := store.Set(ctx, "c", "2", 0); err != nil {
+		t.Errorf("Expected Set of an existing key to succeed, got %v", err)
+	}
+	if keys, _ := store.Keys(ctx, ""); len(keys) != 3 {
+		t.Errorf("Expected 3 keys after an update, got %v", keys)
+	}
+}
+
+func TestMaxMemoryEvict(t *testing.T) {
+	clock := newFakeClock()
+	// Each key below is estimated at 167 bytes, so five of them fit and a sixth does not.
+	store := memory.NewMemoryStoreWithOptions(memory.Options{MaxMemoryBytes: 900, OnFull: memory.FullEvict, Clock: clock})
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+	value := strings.Repeat("v", 100)
+
+	for i := 0; i < 6; i++ {
+		if err := store.Set(ctx, fmt.Sprintf("k%02d", i), value, 0); err != nil {
+			t.Fatalf("Expected key %d to be stored by evicting, got %v", i, err)
+		}
+		clock.Advance(time.Second)
+	}
+
+	if _, err := store.Get(ctx, "k00"); !errors.Is(err, memory.ErrKeyNotFound) {
+		t.Errorf("Expected the oldest key to be evicted, got %v", err)
+	}
+	if n, _ := store.CountPattern(ctx, "*"); n != 5 {
+		t.Errorf("Expected 5 keys to remain, got %d", n)
+	}
+
+	// A value larger than the whole store cannot be made to fit.
+	if err := store.Set(ctx, "huge", strings.Repeat("v", 1000), 0); err != memory.ErrOutOfMemory {
+		t.Errorf("Expected ErrOutOfMemory for a value over the limit, got %v", err)
+	}
+	if n, _ := store.CountPattern(ctx, "*"); n != 5 {
+		t.Errorf("Expected an oversized value not to evict anything, got %d keys", n)
+	}
+}
+
+func TestEvictLRUOrder(t *testing.T) {
+	for _, readIndex := range []bool{false, true} {
+		t.Run(fmt.Sprintf("read_index=%t", readIndex), func(t *testing.T) {
+			// The clock never advances, so only the order of accesses tells keys apart.
+			store := memory.NewMemoryStoreWithOptions(memory.Options{MaxKeys: 3, OnFull: memory.FullEvict, Clock: newFakeClock(), ReadIndex: readIndex})
+			defer store.StopTTLWorker()
+			ctx := context.Background()
+
+			for _, key := range []string{"a", "b", "c"} {
+				store.Set(ctx, key, "1", 0)
+			}
+			store.Get(ctx, "a")
+
+			// Access order is now a, c, b from the most recent.
+			store.Set(ctx, "d", "1", 0)
+			store.Set(ctx, "e", "1", 0)
+
+			keys, _ := store.Keys(ctx, "")
+			if strings.Join(keys, ",") != "a,d,e" {
+				t.Errorf("Expected b then c to be evicted, leaving [a d e], got %v", keys)
+			}
+
+			// Removed keys leave the access order too.
+			store.Remove(ctx, "a")
+			store.Set(ctx, "f", "1", 0)
+			store.Set(ctx, "g", "1", 0)
+			keys, _ = store.Keys(ctx, "")
+			if strings.Join(keys, ",") != "e,f,g" {
+				t.Errorf("Expected [e f g], got %v", keys)
+			}
+		})
+	}
+}
+
+func TestMaxListItemBytes(t *testing.T) {
+	s := memory.NewMemoryStoreWithOptions(memory.Options{MaxListItemBytes: 10})
+	defer s.StopTTLWorker()
+	ctx := context.Background()
+
+	if err := s.Push(ctx, "queue", strings.Repeat("a", 10)); err != nil {
+		t.Errorf("Expected an item at the limit to fit, got %v", err)
+	}
+	if err := s.Push(ctx, "queue", strings.Repeat("a", 11)); err != memory.ErrValueTooLarge {
+		t.Errorf("Expected ErrValueTooLarge for an item over the limit, got %v", err)
+	}
+	if err := s.PushResurrect(ctx, "queue", strings.Repeat("a", 11)); err != memory.ErrValueTooLarge {
+		t.Errorf("Expected ErrValueTooLarge from PushResurrect, got %v", err)
+	}
+	if err := s.LSet(ctx, "other", []any{"ok", strings.Repeat("a", 11)}, 0); err != memory.ErrValueTooLarge {
+		t.Errorf("Expected ErrValueTooLarge from LSet, got %v", err)
+	}
+
+	results, err := s.ListBatch(ctx, []store.ListOp{
+		{Key: "queue", Op: store.ListOpPush, Item: strings.Repeat("a", 11)},
+		{Key: "queue", Op: store.ListOpPush, Item: "small"},
+	})
+	if err != nil {
+		t.Fatalf("ListBatch failed: %v", err)
+	}
+	if results[0].Err != memory.ErrValueTooLarge || results[1].Err != nil {
+		t.Errorf("Expected only the oversized batch push to fail, got %v and %v", results[0].Err, results[1].Err)
+	}
+
+	if n, _ := s.LLen(ctx, "queue"); n != 2 {
+		t.Errorf("Expected oversized items not to be pushed, got length %d", n)
+	}
+
+	// String values are not bound by the list item limit.
+	if err := s.Set(ctx, "blob", strings.Repeat("b", 1000), 0); err != nil {
+		t.Errorf("Expected a large string value to be stored, got %v", err)
+	}
+}
+
+func TestTTLHistogram(t *testing.T) {
+	clock := newFakeClock()
+	s := memory.NewMemoryStoreWithClock(clock)
+	defer s.StopTTLWorker()
+	ctx := context.Background()
+
+	s.Set(ctx, "soon:1", "v", 30)
+	s.Set(ctx, "soon:2", "v", 60)
+	s.Set(ctx, "hour:1", "v", 75)
+	s.Set(ctx, "hour:2", "v", 3600)
+	s.Set(ctx, "day", "v", 7200)
+	s.Set(ctx, "week", "v", 7*24*3600)
+	s.Set(ctx, "forever", "v", 0)
+	s.Push(ctx, "queue", "job")
+	s.Set(ctx, "expired", "v", 5)
+
+	clock.Advance(10 * time.Second)
+
+	histogram, err := s.TTLHistogram(ctx, nil)
+	if err != nil {
+		t.Fatalf("TTLHistogram failed: %v", err)
+	}
+	want := map[string]int{"1m0s": 2, "1h0m0s": 2, "24h0m0s": 1, store.TTLBucketLonger: 1, store.TTLBucketNever: 2}
+	if fmt.Sprint(histogram) != fmt.Sprint(want) {
+		t.Errorf("Expected %v, got %v", want, histogram)
+	}
+
+	// Buckets are sorted and every label is reported.
+	histogram, err = s.TTLHistogram(ctx, []time.Duration{time.Hour, time.Second})
+	if err != nil {
+		t.Fatalf("TTLHistogram failed: %v", err)
+	}
+	want = map[string]int{"1s": 0, "1h0m0s": 4, store.TTLBucketLonger: 2, store.TTLBucketNever: 2}
+	if fmt.Sprint(histogram) != fmt.Sprint(want) {
+		t.Errorf("Expected %v, got %v", want, histogram)
+	}
+
+	for _, buckets := range [][]time.Duration{{0}, {-time.Minute}, {time.Minute, time.Minute}} {
+		if _, err := s.TTLHistogram(ctx, buckets); !errors.Is(err, memory.ErrInvalidBuckets) {
+			t.Errorf("Expected ErrInvalidBuckets for %v, got %v", buckets, err)
+		}
+	}
+}
+
+func TestLMoveAll(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	// Push prepends, so src is [s1 s2 s3] and dst is [d1 d2].
+	for _, item := range []string{"s3", "s2", "s1"} {
+		store.Push(ctx, "src", item)
+	}
+	for _, item := range []string{"d2", "d1"} {
+		store.Push(ctx, "dst", item)
+	}
+	store.Expire(ctx, "dst", 60)
+
+	moved, err := store.LMoveAll(ctx, "src", "dst")
+	if err != nil {
+		t.Fatalf("LMoveAll failed: %v", err)
+	}
+	if moved != 3 {
+		t.Errorf("Expected 3 items moved, got %d", moved)
+	}
+
+	items, _ := store.LRange(ctx, "dst", 0, -1)
+	if strings.Join(items, ",") != "s1,s2,s3,d1,d2" {
+		t.Errorf("Expected src items ahead of dst items in order, got %v", items)
+	}
+	if _, err := store.LRange(ctx, "src", 0, -1); !errors.Is(err, memory.ErrKeyNotFound) {
+		t.Errorf("Expected src to be deleted, got %v", err)
+	}
+	entries, _ := store.GetEntries(ctx, []string{"dst"})
+	if entries[0].TTLSeconds <= 0 {
+		t.Errorf("Expected dst to keep its TTL, got %d", entries[0].TTLSeconds)
+	}
+
+	// A missing dst is created.
+	moved, err = store.LMoveAll(ctx, "dst", "fresh")
+	if err != nil || moved != 5 {
+		t.Errorf("Expected 5 items moved to a new list, got %d (err %v)", moved, err)
+	}
+	if items, _ := store.LRange(ctx, "fresh", 0, -1); strings.Join(items, ",") != "s1,s2,s3,d1,d2" {
+		t.Errorf("Expected the order to be kept, got %v", items)
+	}
+
+	if _, err := store.LMoveAll(ctx, "missing", "fresh"); !errors.Is(err, memory.ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound for a missing src, got %v", err)
+	}
+	store.Set(ctx, "string", "v", 0)
+	if _, err := store.LMoveAll(ctx, "fresh", "string"); !errors.Is(err, memory.ErrTypeMismatch) {
+		t.Errorf("Expected ErrTypeMismatch for a string dst, got %v", err)
+	}
+	if n, _ := store.LLen(ctx, "fresh"); n != 5 {
+		t.Errorf("Expected a failed move to leave src intact, got length %d", n)
+	}
+	if moved, err := store.LMoveAll(ctx, "fresh", "fresh"); err != nil || moved != 0 {
+		t.Errorf("Expected moving a list onto itself to be a no-op, got %d (err %v)", moved, err)
+	}
+}
+
+func TestTTLDefaults(t *testing.T) {
+	s := memory.NewMemoryStoreWithOptions(memory.Options{TTLDefaults: store.TTLDefaults{
+		Rules: []store.TTLRule{
+			{Prefix: "session:admin:", TTLSeconds: 60},
+			{Prefix: "session:", TTLSeconds: 1800},
+			{Prefix: "config:", TTLSeconds: 0},
+		},
+		FallbackSeconds: 3600,
+	}})
+	defer s.StopTTLWorker()
+	ctx := context.Background()
+
+	s.Set(ctx, "session:admin:1", "v", 0)
+	s.Set(ctx, "session:user:1", "v", 0)
+	s.Set(ctx, "config:limits", "v", 0)
+	s.Set(ctx, "other", "v", 0)
+	s.Set(ctx, "session:explicit", "v", 10)
+	s.SetNX(ctx, "session:nx", "v", 0)
+	s.LSet(ctx, "session:list", []any{"v"}, 0)
+	s.LInitNX(ctx, "session:init", []any{"v"}, 0)
+
+	tests := map[string]int{
+		"session:admin:1":  60,
+		"session:user:1":   1800,
+		"config:limits":    -1,
+		"other":            3600,
+		"session:explicit": 10,
+		"session:nx":       1800,
+		"session:list":     1800,
+		"session:init":     1800,
+	}
+
+	keys := make([]string, 0, len(tests))
+	for key := range tests {
+		keys = append(keys, key)
+	}
+	entries, err := s.GetEntries(ctx, keys)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, entry := range entries {
+		if want := tests[entry.Key]; entry.TTLSeconds != want {
+			t.Errorf("%s: expected TTL %d, got %d", entry.Key, want, entry.TTLSeconds)
+		}
+	}
+
+	config, _ := s.Config(ctx)
+	if len(config.TTLDefaults.Rules) != 3 || config.TTLDefaults.Rules[1].Prefix != "session:" || config.TTLDefaults.FallbackSeconds != 3600 {
+		t.Errorf("Expected the configured TTL defaults, got %+v", config.TTLDefaults)
+	}
+}
+
+func TestLInitNX_Race(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	const initializers = 50
+	var wg sync.WaitGroup
+	created := make(chan int, initializers)
+	for i := 0; i < initializers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ok, err := store.LInitNX(ctx, "queue", []any{fmt.Sprintf("init-%d", i), "second"}, 0)
+			if err != nil {
+				t.Errorf("Initializer %d: expected no error, got %v", i, err)
+			}
+			if ok {
+				created <- i
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(created)
+
+	var winners []int
+	for i := range created {
+		winners = append(winners, i)
+	}
+	if len(winners) != 1 {
+		t.Fatalf("Expected exactly one initializer to create the list, got %v", winners)
+	}
+
+	items, _ := store.LRange(ctx, "queue", 0, -1)
+	if want := fmt.Sprintf("init-%d,second", winners[0]); strings.Join(items, ",") != want {
+		t.Errorf("Expected the winner's items %s, got %v", want, items)
+	}
+}
+
+func TestLSet(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Set(ctx, "key", "value", 0)
+	if err := store.LSet(ctx, "key", []any{"a", 1}, 60); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	items, _ := store.LRange(ctx, "key", 0, -1)
+	if strings.Join(items, ",") != "a,1" {
+		t.Errorf("Expected the string to be replaced by [a 1], got %v", items)
+	}
+
+	if created, _ := store.LInitNX(ctx, "key", []any{"b"}, 0); created {
+		t.Error("Expected LInitNX not to replace an existing list")
+	}
+	if err := store.LSet(ctx, "key", nil, 0); err != memory.ErrEmptyList {
+		t.Errorf("Expected ErrEmptyList for no items, got %v", err)
+	}
+}
+
+func TestReserveAndAck(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Push(ctx, "jobs", "job-2")
+	store.Push(ctx, "jobs", "job-1")
+
+	item, receipt, err := store.Reserve(ctx, "jobs", time.Minute)
+	if err != nil || item != "job-1" || receipt == "" {
+		t.Fatalf("Expected job-1 with a receipt, got %q, %q (err %v)", item, receipt, err)
+	}
+	if items, _ := store.LRange(ctx, "jobs", 0, -1); strings.Join(items, ",") != "job-2" {
+		t.Errorf("Expected the reserved item to leave the list, got %v", items)
+	}
+
+	if err := store.Ack(ctx, receipt); err != nil {
+		t.Errorf("Expected ack to succeed, got %v", err)
+	}
+	if err := store.Ack(ctx, receipt); err != memory.ErrReceiptNotFound {
+		t.Errorf("Expected a second ack to fail with ErrReceiptNotFound, got %v", err)
+	}
+
+	if _, _, err := store.Reserve(ctx, "missing", time.Minute); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound for a missing list, got %v", err)
+	}
+	if _, _, err := store.Reserve(ctx, "jobs", 0); err != memory.ErrInvalidTTL {
+		t.Errorf("Expected ErrInvalidTTL for a zero visibility timeout, got %v", err)
+	}
+}
+
+func TestReserve_RedeliversAfterTimeout(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Push(ctx, "jobs", "job-2")
+	store.Push(ctx, "jobs", "job-1")
+
+	_, receipt, _ := store.Reserve(ctx, "jobs", 50*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+
+	// The consumer crashed without acking, so the item is delivered again first.
+	item, _, err := store.Reserve(ctx, "jobs", time.Minute)
+	if err != nil || item != "job-1" {
+		t.Errorf("Expected job-1 to be redelivered, got %q (err %v)", item, err)
+	}
+	if err := store.Ack(ctx, receipt); err != memory.ErrReceiptNotFound {
+		t.Errorf("Expected an ack after the timeout to fail with ErrReceiptNotFound, got %v", err)
+	}
+
+	// The TTL worker requeues items even when nobody reserves from the list.
+	_, _, _ = store.Reserve(ctx, "jobs", 50*time.Millisecond)
+	time.Sleep(1200 * time.Millisecond)
+	if items, _ := store.LRange(ctx, "jobs", 0, -1); strings.Join(items, ",") != "job-2" {
+		t.Errorf("Expected job-2 back in the list, got %v", items)
+	}
+}
+
+func TestReserve_RequeueSoftDeleted(t *testing.T) {
+	clock := newFakeClock()
+	store := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock})
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.RPush(ctx, "jobs", "job-1")
+	store.RPush(ctx, "jobs", "job-2")
+	store.Reserve(ctx, "jobs", 10*time.Second)
+	store.SoftRemove(ctx, "jobs")
+
+	// The item goes back to the soft deleted list rather than a new list beside it
+	clock.Advance(11 * time.Second)
+	store.Reserve(ctx, "other", time.Minute)
+	if exists, _ := store.Exists(ctx, "jobs"); exists {
+		t.Error("Expected the requeue not to recreate the soft deleted list")
+	}
+	if err := store.Restore(ctx, "jobs"); err != nil {
+		t.Fatalf("Expected Restore to succeed, got %v", err)
+	}
+	if items, _ := store.LRange(ctx, "jobs", 0, -1); strings.Join(items, ",") != "job-1,job-2" {
+		t.Errorf("Expected the requeued item restored with the list, got %v", items)
+	}
+}
+
+func TestReadIndex(t *testing.T) {
+	s := memory.NewMemoryStoreWithOptions(memory.Options{ReadIndex: true})
+	defer s.StopTTLWorker()
+	ctx := context.Background()
+
+	s.Set(ctx, "key", "value", 0)
+	if value, err := s.Get(ctx, "key"); err != nil || value != "value" {
+		t.Errorf("Expected value, got %q (err %v)", value, err)
+	}
+
+	s.Update(ctx, "key", "updated")
+	if value, _ := s.Get(ctx, "key"); value != "updated" {
+		t.Errorf("Expected reads to see the update, got %q", value)
+	}
+
+	s.Push(ctx, "list", "a")
+	if _, err := s.Get(ctx, "list"); err != memory.ErrTypeMismatch {
+		t.Errorf("Expected ErrTypeMismatch for a list, got %v", err)
+	}
+	if value, kind, _ := s.GetAny(ctx, "list"); kind != store.TypeList || len(value.([]string)) != 1 {
+		t.Errorf("Expected a list of one item, got %v (%s)", value, kind)
+	}
+
+	s.Remove(ctx, "key")
+	if _, err := s.Get(ctx, "key"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound after remove, got %v", err)
+	}
+
+	s.Set(ctx, "short", "value", 1)
+	time.Sleep(1100 * time.Millisecond)
+	if _, err := s.Get(ctx, "short"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected an expired key to read as missing, got %v", err)
+	}
+
+	// Lock-free reads are still counted as accesses.
+	s.Set(ctx, "hot", "value", 0)
+	for i := 0; i < 3; i++ {
+		s.Get(ctx, "hot")
+	}
+	top, _ := s.TopKeysByAccess(ctx, 1)
+	if len(top) != 1 || top[0].Key != "hot" || top[0].Hits != 4 {
+		t.Errorf("Expected hot with 4 hits, got %+v", top)
+	}
+}
+
+func TestLRangeReverse(t *testing.T) {
+	s := memory.NewMemoryStore()
+	defer s.StopTTLWorker()
+	ctx := context.Background()
+
+	for _, item := range []string{"e", "d", "c", "b", "a"} {
+		s.Push(ctx, "list", item)
+	}
+
+	reversed := func(items []string) []string {
+		out := make([]string, len(items))
+		for i, item := range items {
+			out[len(items)-1-i] = item
+		}
+		return out
+	}
+
+	// Reversed index i is forward index n-1-i, so each reversed range is the mirrored
+	// forward range read backwards.
+	n := 5
+	for start := -7; start <= 7; start++ {
+		for stop := -7; stop <= 7; stop++ {
+			page, err := s.LRangeReverse(ctx, "list", start, stop)
+			if err != nil {
+				t.Fatalf("LRangeReverse(%d, %d) failed: %v", start, stop, err)
+			}
+
+			var want []string
+			if page.Start <= page.Stop {
+				forward, _ := s.LRange(ctx, "list", n-1-page.Stop, n-1-page.Start)
+				want = reversed(forward)
+			}
+			if forward, _ := s.LRange(ctx, "list", start, stop); len(forward) != len(page.Items) {
+				t.Errorf("LRangeReverse(%d, %d): expected %d items like LRange, got %d", start, stop, len(forward), len(page.Items))
+			}
+			if strings.Join(page.Items, ",") != strings.Join(want, ",") {
+				t.Errorf("LRangeReverse(%d, %d): expected %v, got %v", start, stop, want, page.Items)
+			}
+		}
+	}
+
+	all, _ := s.LRangeReverse(ctx, "list", 0, -1)
+	if strings.Join(all.Items, ",") != "e,d,c,b,a" || all.Total != 5 {
+		t.Errorf("Expected the whole list reversed, got %+v", all)
+	}
+	tail, _ := s.LRangeReverse(ctx, "list", 0, 1)
+	if strings.Join(tail.Items, ",") != "e,d" {
+		t.Errorf("Expected the two tail items last first, got %v", tail.Items)
+	}
+
+	if items, _ := s.LRange(ctx, "list", 0, -1); strings.Join(items, ",") != "a,b,c,d,e" {
+		t.Errorf("Expected the stored order to be unchanged, got %v", items)
+	}
+}
+
+func TestLRangeWithMeta(t *testing.T) {
+	clock := newFakeClock()
+	s := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock, ListPushTimes: true})
+	defer s.StopTTLWorker()
+	ctx := context.Background()
+
+	start := clock.Now()
+	for _, item := range []string{"a", "b", "c", "d"} {
+		s.RPush(ctx, "queue", item)
+		clock.Advance(time.Second)
+	}
+
+	items, err := s.LRangeWithMeta(ctx, "queue", 0, -1)
+	if err != nil {
+		t.Fatalf("LRangeWithMeta failed: %v", err)
+	}
+	if len(items) != 4 {
+		t.Fatalf("Expected 4 items, got %v", items)
+	}
+	for i, item := range items {
+		if want := start.Add(time.Duration(i) * time.Second); !item.PushedAt.Equal(want) {
+			t.Errorf("Expected %s pushed at %v, got %v", item.Value, want, item.PushedAt)
+		}
+		if i > 0 && !item.PushedAt.After(items[i-1].PushedAt) {
+			t.Errorf("Expected push times to increase, got %v after %v", item.PushedAt, items[i-1].PushedAt)
+		}
+	}
+
+	// Items keep their push times through pops, trims and redeliveries
+	popped, _ := s.PopItem(ctx, "queue")
+	if popped.Value != "a" || !popped.PushedAt.Equal(start) {
+		t.Errorf("Expected a pushed at %v, got %+v", start, popped)
+	}
+	s.RPop(ctx, "queue")
+	_, receipt, _ := s.Reserve(ctx, "queue", time.Second)
+	clock.Advance(2 * time.Second)
+	s.Ack(ctx, receipt)
+	s.Push(ctx, "queue", "z")
+	s.LTrim(ctx, "queue", 1, -1)
+
+	items, _ = s.LRangeWithMeta(ctx, "queue", 0, -1)
+	if len(items) != 2 || items[0].Value != "b" || items[1].Value != "c" {
+		t.Fatalf("Expected [b c], got %+v", items)
+	}
+	for i, want := range []time.Time{start.Add(time.Second), start.Add(2 * time.Second)} {
+		if !items[i].PushedAt.Equal(want) {
+			t.Errorf("Expected %s to keep its push time %v, got %v", items[i].Value, want, items[i].PushedAt)
+		}
+	}
+
+	// Without ListPushTimes, items come with their sequence numbers only
+	plain := memory.NewMemoryStore()
+	defer plain.StopTTLWorker()
+	plain.RPush(ctx, "queue", "a")
+	if items, _ := plain.LRangeWithMeta(ctx, "queue", 0, -1); len(items) != 1 || items[0].Seq != 1 || !items[0].PushedAt.IsZero() {
+		t.Errorf("Expected a single item without a push time, got %+v", items)
+	}
+}
+
+func TestPushToSoftDeletedKey(t *testing.T) {
+	s := memory.NewMemoryStoreWithOptions(memory.Options{SoftDeleteWindow: 500 * time.Millisecond})
+	defer s.StopTTLWorker()
+	ctx := context.Background()
+
+	s.Push(ctx, "queue", "old")
+	s.SoftRemove(ctx, "queue")
+
+	if err := s.Push(ctx, "queue", "new"); err != memory.ErrKeyPendingDelete {
+		t.Errorf("Expected ErrKeyPendingDelete, got %v", err)
+	}
+	if _, err := s.LRange(ctx, "queue", 0, -1); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected no list to be created by the rejected push, got %v", err)
+	}
+
+	if err := s.PushResurrect(ctx, "queue", "new"); err != nil {
+		t.Fatalf("Expected resurrecting push to succeed, got %v", err)
+	}
+	if items, _ := s.LRange(ctx, "queue", 0, -1); strings.Join(items, ",") != "new,old" {
+		t.Errorf("Expected the new item on top of the restored list, got %v", items)
+	}
+	if err := s.Restore(ctx, "queue"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected the tombstone to be consumed, got %v", err)
+	}
+
+	// A soft deleted string cannot be resurrected as a list and stays restorable.
+	s.Set(ctx, "str", "value", 0)
+	s.SoftRemove(ctx, "str")
+	if err := s.PushResurrect(ctx, "str", "item"); err != memory.ErrTypeMismatch {
+		t.Errorf("Expected ErrTypeMismatch, got %v", err)
+	}
+	if err := s.Restore(ctx, "str"); err != nil {
+		t.Errorf("Expected the string to remain restorable, got %v", err)
+	}
+
+	// Once the window closes, Push creates a fresh list again.
+	s.SoftRemove(ctx, "queue")
+	time.Sleep(600 * time.Millisecond)
+	if err := s.Push(ctx, "queue", "fresh"); err != nil {
+		t.Errorf("Expected push after the window to succeed, got %v", err)
+	}
+	if items, _ := s.LRange(ctx, "queue", 0, -1); strings.Join(items, ",") != "fresh" {
+		t.Errorf("Expected a fresh list, got %v", items)
+	}
+}
+
+func TestListBatch(t *testing.T) {
+	s := memory.NewMemoryStore()
+	defer s.StopTTLWorker()
+	ctx := context.Background()
+
+	s.Set(ctx, "str", "value", 0)
+	s.Push(ctx, "b", "existing")
+
+	results, err := s.ListBatch(ctx, []store.ListOp{
+		{Key: "a", Op: store.ListOpPush, Item: "a1"},
+		{Key: "b", Op: store.ListOpPush, Item: "b1"},
+		{Key: "str", Op: store.ListOpPush, Item: "x"},
+		{Key: "a", Op: store.ListOpPop},
+		{Key: "missing", Op: store.ListOpPop},
+		{Key: "a", Op: "shift"},
+		{Key: "a", Op: store.ListOpPush, Item: func() {}},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	wantErrs := []error{nil, nil, memory.ErrTypeMismatch, nil, memory.ErrKeyNotFound, memory.ErrInvalidListOp, memory.ErrMarshalFailed}
+	for i, want := range wantErrs {
+		if results[i].Err != want {
+			t.Errorf("Operation %d: expected error %v, got %v", i, want, results[i].Err)
+		}
+	}
+	if results[3].Item != "a1" {
+		t.Errorf("Expected the pop to see the earlier push in the batch, got %q", results[3].Item)
+	}
+
+	if items, _ := s.LRange(ctx, "b", 0, -1); strings.Join(items, ",") != "b1,existing" {
+		t.Errorf("Expected failures not to undo other operations, got %v", items)
+	}
+}
+
+func TestPipelineGet(t *testing.T) {
+	clock := newFakeClock()
+	s := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock})
+	defer s.StopTTLWorker()
+	ctx := context.Background()
+
+	s.Set(ctx, "title", "Home", 0)
+	s.Set(ctx, "banner", "sale", 5)
+	s.RPush(ctx, "feed", "first")
+	s.RPush(ctx, "feed", "second")
+	s.RPush(ctx, "feed", "third")
+	clock.Advance(10 * time.Second)
+
+	index := func(i int) *int { return &i }
+	results, err := s.PipelineGet(ctx, []store.ReadSpec{
+		{Key: "title"},
+		{Key: "feed", Index: index(0)},
+		{Key: "feed", Index: index(-1)},
+		{Key: "missing"},
+		{Key: "banner"},
+		{Key: "feed"},
+		{Key: "title", Index: index(0)},
+		{Key: "feed", Index: index(3)},
+		{Key: "missing", Index: index(0)},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	wantValues := []string{"Home", "first", "third"}
+	for i, want := range wantValues {
+		if results[i].Err != nil || results[i].Value != want {
+			t.Errorf("Read %d: expected %q, got %q (err %v)", i, want, results[i].Value, results[i].Err)
+		}
+	}
+	wantErrs := []error{memory.ErrKeyNotFound, memory.ErrKeyNotFound, memory.ErrTypeMismatch, memory.ErrTypeMismatch, memory.ErrIndexOutOfRange, memory.ErrKeyNotFound}
+	for i, want := range wantErrs {
+		if got := results[len(wantValues)+i].Err; got != want {
+			t.Errorf("Read %d: expected error %v, got %v", len(wantValues)+i, want, got)
+		}
+	}
+}
+
+func TestPauseTTLWorker(t *testing.T) {
+	clock := newFakeClock()
+	s := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock})
+	defer s.StopTTLWorker()
+	ctx := context.Background()
+
+	// swept reports whether the TTL worker deleted key since the first event.
+	swept := func(key string) bool {
+		page, _ := s.EventsSince(ctx, 0)
+		for _, e := range page.Events {
+			if e.Type == store.EventDel && e.Key == key {
+				return true
+			}
+		}
+		return false
+	}
+
+	s.PauseTTLWorker()
+	s.Set(ctx, "loading", "row", 1)
+	clock.Advance(2 * time.Second)
+	time.Sleep(1100 * time.Millisecond)
+
+	if swept("loading") {
+		t.Fatal("Expected no keys to be swept while paused")
+	}
+	// Keys does not delete what it skips, unlike Get, so it leaves sweeping to the worker
+	if keys, _ := s.Keys(ctx, "*"); len(keys) != 0 {
+		t.Errorf("Expected reads to still treat the expired key as missing, got %v", keys)
+	}
+
+	s.ResumeTTLWorker()
+	time.Sleep(1100 * time.Millisecond)
+
+	if !swept("loading") {
+		t.Error("Expected the expired key to be swept once resumed")
+	}
+}
+
+func TestStats(t *testing.T) {
+	clock := newFakeClock()
+	s := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock})
+	// Expired keys must be left out even before the worker sweeps them
+	s.StopTTLWorker()
+	ctx := context.Background()
+
+	s.Set(ctx, "name", "alice", 0)
+	s.Set(ctx, "session", "token", 60)
+	s.Set(ctx, "short", "gone", 5)
+	s.RPush(ctx, "queue", "a")
+	s.RPush(ctx, "queue", "b")
+	s.LSet(ctx, "expiring", []any{"x"}, 5)
+	clock.Advance(10 * time.Second)
+
+	stats, err := s.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if stats.Keys != 3 || stats.Strings != 2 || stats.Lists != 1 || stats.KeysWithTTL != 1 {
+		t.Errorf("Expected 3 live keys, 2 strings, 1 list and 1 with a TTL, got %+v", stats)
+	}
+
+	top, _ := s.TopKeysBySize(ctx, 10)
+	var total int64
+	for _, k := range top {
+		total += int64(k.SizeBytes)
+	}
+	if stats.MemoryBytes != total {
+		t.Errorf("Expected the memory estimate to add up the live key sizes %d, got %d", total, stats.MemoryBytes)
+	}
+}
+
+func TestFlushAll(t *testing.T) {
+	s := memory.NewMemoryStoreWithOptions(memory.Options{MaxKeys: 2})
+	defer s.StopTTLWorker()
+	ctx := context.Background()
+
+	s.Set(ctx, "session", "token", 60, store.Tags("user:1"))
+	s.RPush(ctx, "queue", "job")
+	if err := s.Set(ctx, "third", "x", 0); !errors.Is(err, memory.ErrTooManyKeys) {
+		t.Fatalf("Expected the store to be full, got %v", err)
+	}
+
+	if err := s.FlushAll(ctx); err != nil {
+		t.Fatalf("FlushAll failed: %v", err)
+	}
+
+	if keys, _ := s.Keys(ctx, "*"); len(keys) != 0 {
+		t.Errorf("Expected no keys, got %v", keys)
+	}
+	if keys, _ := s.KeysByTag(ctx, "user:1"); len(keys) != 0 {
+		t.Errorf("Expected the tag index to be emptied, got %v", keys)
+	}
+	if stats, _ := s.Stats(ctx); stats.MemoryBytes != 0 {
+		t.Errorf("Expected no memory in use, got %d", stats.MemoryBytes)
+	}
+
+	// The key limit counts from zero again
+	if err := s.Set(ctx, "third", "x", 0); err != nil {
+		t.Errorf("Expected a set after the flush to succeed, got %v", err)
+	}
+}
+
+func TestCancelledContext(t *testing.T) {
+	s := memory.NewMemoryStore()
+	defer s.StopTTLWorker()
+	ctx := context.Background()
+
+	s.Set(ctx, "name", "alice", 0)
+	s.RPush(ctx, "queue", "a")
+	s.Set(ctx, "count", 1, 0)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	ops := map[string]func() error{
+		"Set":       func() error { return s.Set(cancelled, "name", "bob", 0) },
+		"Update":    func() error { return s.Update(cancelled, "name", "bob") },
+		"Remove":    func() error { return s.Remove(cancelled, "name") },
+		"Expire":    func() error { return s.Expire(cancelled, "name", 10) },
+		"Push":      func() error { return s.Push(cancelled, "queue", "b") },
+		"MSet":      func() error { return s.MSet(cancelled, map[string]any{"name": "bob", "new": "x"}, 0) },
+		"LSet":      func() error { return s.LSet(cancelled, "queue", []any{"z"}, 0) },
+		"FlushAll":  func() error { return s.FlushAll(cancelled) },
+		"Increment": func() error { _, err := s.Increment(cancelled, "count", 1); return err },
+		"Pop":       func() error { _, err := s.Pop(cancelled, "queue"); return err },
+		"Get":       func() error { _, err := s.Get(cancelled, "name"); return err },
+		"Exists":    func() error { _, err := s.Exists(cancelled, "name"); return err },
+		"LRange":    func() error { _, err := s.LRange(cancelled, "queue", 0, -1); return err },
+		"MGet":      func() error { _, err := s.MGet(cancelled, []string{"name"}); return err },
+		"Keys":      func() error { _, err := s.Keys(cancelled, "*"); return err },
+		"Export":    func() error { _, err := s.ExportPattern(cancelled, "*"); return err },
+		"Stats":     func() error { _, err := s.Stats(cancelled); return err },
+	}
+	for name, op := range ops {
+		if err := op(); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled, got %v", name, err)
+		}
+	}
+
+	// Reads through the read index check their context like locked reads
+	indexed := memory.NewMemoryStoreWithOptions(memory.Options{ReadIndex: true})
+	defer indexed.StopTTLWorker()
+	indexed.Set(ctx, "name", "alice", 0)
+	indexed.RPush(ctx, "queue", "a")
+	for name, op := range map[string]func() error{
+		"Get":    func() error { _, err := indexed.Get(cancelled, "name"); return err },
+		"Exists": func() error { _, err := indexed.Exists(cancelled, "name"); return err },
+		"LRange": func() error { _, err := indexed.LRange(cancelled, "queue", 0, -1); return err },
+	} {
+		if err := op(); !errors.Is(err, context.Canceled) {
+			t.Errorf("read index %s: expected context.Canceled, got %v", name, err)
+		}
+	}
+
+	dump, _ := s.ExportPattern(ctx, "*")
+	if len(dump) != 3 || dump[1].Key != "name" || dump[1].Value != "alice" || dump[1].TTLSeconds != -1 || dump[0].Value != "1" {
+		t.Errorf("Expected the store untouched, got %+v", dump)
+	}
+	if items, _ := s.LRange(ctx, "queue", 0, -1); len(items) != 1 || items[0] != "a" {
+		t.Errorf("Expected the list untouched, got %v", items)
+	}
+
+	// Scans check their context as they go, so a deadline passing part way stops them
+	for i := 0; i < 5000; i++ {
+		s.Set(ctx, fmt.Sprintf("key:%d", i), "x", 0)
+	}
+	if _, err := s.Keys(&cancelAfter{Context: ctx, checks: 3}, "*"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected Keys to stop part way, got %v", err)
+	}
+	if n, err := s.CountPattern(ctx, "key:*"); err != nil || n != 5000 {
+		t.Errorf("Expected 5000 keys counted, got %d, %v", n, err)
+	}
+}
@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"context"
+	"sort"
+)
+
+// MSet sets several string keys with the same ttl under a single write lock. A ttl of
+// 0 applies each key's default TTL, like Set. Values are validated before any key is
+// written, but if a key or memory limit is reached part way, the keys written before
+// it, in key order, are kept.
+func (s *MemoryStore) MSet(ctx context.Context, pairs map[string]any, ttlSeconds int) error {
+	_, err := s.MSetChunked(ctx, pairs, ttlSeconds, 0)
+	return err
+}
+
+// MSetChunked is MSet taking the write lock once per chunkSize keys rather than once
+// for the whole batch, and returns how many keys were written. A chunkSize of 0 or
+// less writes every key under a single lock, like MSet.
+//
+// Chunking bounds how long a large batch holds up other operations, at the cost of
+// atomicity: readers may see some chunks written and not others, and a write between
+// chunks may be overwritten by a later chunk. Keys are written in key order, and when a
+// chunk fails, on a key or memory limit, or because ctx is done before the next chunk,
+// the keys written before it are kept and counted.
+func (s *MemoryStore) MSetChunked(ctx context.Context, pairs map[string]any, ttlSeconds int, chunkSize int) (int, error) {
+	if ttlSeconds < 0 {
+		return 0, ErrInvalidTTL
+	}
+
+	keys := make([]string, 0, len(pairs))
+	for key := range pairs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	values := make([]Value, len(keys))
+	for i, key := range keys {
+		stringValue, err := s.Stringify(pairs[key])
+		if err != nil {
+			return 0, ErrMarshalFailed
+		}
+		stringValue, compressed := s.encode(stringValue, false)
+		values[i] = Value{Val: stringValue, IsJSON: isJSON(pairs[key]), Compressed: compressed}
+	}
+
+	if chunkSize <= 0 {
+		chunkSize = len(keys)
+	}
+
+	written := 0
+	for written < len(keys) {
+		end := min(written+chunkSize, len(keys))
+		n, err := s.msetLocked(ctx, keys[written:end], values[written:end], ttlSeconds)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// msetLocked writes keys to values under a single write lock, and returns how many
+// were written before a key or memory limit stopped it.
+func (s *MemoryStore) msetLocked(ctx context.Context, keys []string, values []Value, ttlSeconds int) (int, error) {
+	if err := s.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	for i, key := range keys {
+		v := values[i]
+		v.TTL = s.ttlFromSeconds(s.defaultTTL(key, ttlSeconds))
+		if err := s.reserve(key, v); err != nil {
+			return i, err
+		}
+
+		s.put(key, v)
+		s.touch(key, now)
+	}
+	return len(keys), nil
+}
+
+// MGet returns the values of several string keys under a single read lock. Missing
+// and expired keys, and keys holding lists, hashes or sets, are left out of the
+// result, so a partial fetch still succeeds.
+func (s *MemoryStore) MGet(ctx context.Context, keys []string) (map[string]string, error) {
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	now := s.clock.Now()
+	values := make(map[string]string, len(keys))
+	for i, key := range keys {
+		if err := checkCtx(ctx, i); err != nil {
+			return nil, err
+		}
+		v, ok := s.data[key]
+		if !ok || !v.isString() || (!v.TTL.IsZero() && now.After(v.TTL)) {
+			continue
+		}
+		values[key] = v.text()
+		s.touch(key, now)
+	}
+	return values, nil
+}
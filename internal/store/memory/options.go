@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"log"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// Options configures a MemoryStore. The zero value is a valid configuration.
+type Options struct {
+	// ListSampleInterval is how often list lengths are recorded for ListDepthHistory.
+	// Zero disables sampling.
+	ListSampleInterval time.Duration
+
+	// ListSampleCapacity is the number of samples kept per list. Defaults to 120.
+	ListSampleCapacity int
+
+	// ListSampleMaxLists is the maximum number of lists tracked at once. Defaults to 1000.
+	ListSampleMaxLists int
+
+	// LockMetrics enables counting contention on the store lock and timing how long
+	// the write lock is held, reported by Stats.
+	LockMetrics bool
+
+	// LockHoldThreshold enables a watchdog that logs a warning, with the stack that
+	// acquired it, when the store write lock is held longer than this. Zero disables it.
+	LockHoldThreshold time.Duration
+
+	// LockHoldLog is where lock hold warnings are written. Defaults to log.Default().
+	LockHoldLog *log.Logger
+
+	// SoftDeleteWindow is how long a key removed with SoftRemove can be restored.
+	// Defaults to 5 minutes.
+	SoftDeleteWindow time.Duration
+
+	// MaxMemoryBytes caps the estimated size of all keys, including soft deleted values
+	// and reserved items not acknowledged yet. Writes that would grow the store past it
+	// are handled according to OnFull. Zero means no limit.
+	MaxMemoryBytes int64
+
+	// MaxListItemBytes caps the size of a single list item, independently of string
+	// values. Pushes and list sets with a larger item fail with ErrValueTooLarge.
+	// Zero means no limit.
+	MaxListItemBytes int
+
+	// MaxKeys caps the number of keys. Writes adding a key beyond it are handled
+	// according to OnFull; writes to existing keys are not limited by it. Zero means
+	// no limit.
+	MaxKeys int
+
+	// OnFull selects what happens to writes once the store is full, either by
+	// MaxKeys or MaxMemoryBytes. Defaults to FullReject.
+	OnFull FullPolicy
+
+	// CompressThreshold is the length in bytes above which string values and list
+	// items are stored gzip compressed. Compression is transparent to reads, and Set and
+	// Update can opt out of it with store.NoCompress; list items are compressed one by
+	// one, so they are decompressed one at a time as they are read. Zero disables
+	// compression. See BenchmarkCompression for the memory and CPU tradeoff.
+	CompressThreshold int
+
+	// ListPushTimes records when each list item was pushed, reported by
+	// LRangeWithMeta, at the cost of a timestamp per item.
+	ListPushTimes bool
+
+	// EventBufferSize is the number of recent keyspace events kept for EventsSince.
+	// Defaults to 1024.
+	EventBufferSize int
+
+	// KeyspaceNotifications publishes a KeyspaceNotification on the channel
+	// store.KeyspaceChannelPrefix+key whenever a key is written or removed, so
+	// subscribers can react to changes of the keys they care about.
+	KeyspaceNotifications bool
+
+	// TTLDefaults sets the TTL of keys written by Set or LSet without one, by key prefix.
+	TTLDefaults store.TTLDefaults
+
+	// ReadIndex mirrors every key into a sync.Map, from which Get, GetAny and Exists
+	// read without taking the store's read lock. It is an index over the store rather
+	// than a separate backend: writes still take the write lock and then update both
+	// maps, and each key is held twice. Reads through the index do not delete the
+	// expired keys they find, which wait for the TTL worker, and with FullEvict they
+	// still take the LRU list's lock to record the access. Scans and multi-key reads
+	// keep using the plain map under the read lock. Whether it helps depends on the
+	// core count and workload; compare with BenchmarkReadMostly.
+	ReadIndex bool
+
+	// AOF, if set, is written a record of every mutation, so the store can be rebuilt
+	// with ReplayAOF after a crash.
+	AOF *AOFWriter
+
+	// Clock is the source of the current time. Defaults to the system clock.
+	Clock Clock
+}
+
+// FullPolicy selects how a full store handles writes that would grow it.
+type FullPolicy string
+
+const (
+	// FullReject rejects such writes with ErrTooManyKeys or ErrOutOfMemory. Reads,
+	// deletes and writes that do not grow the store keep working, so clients can free
+	// up space.
+	FullReject FullPolicy = "reject"
+
+	// FullEvict makes room for such writes by deleting the least recently accessed keys.
+	FullEvict FullPolicy = "evict"
+)
+
+func (o Options) withDefaults() Options {
+	if o.ListSampleCapacity <= 0 {
+		o.ListSampleCapacity = 120
+	}
+	if o.ListSampleMaxLists <= 0 {
+		o.ListSampleMaxLists = 1000
+	}
+	if o.SoftDeleteWindow <= 0 {
+		o.SoftDeleteWindow = 5 * time.Minute
+	}
+	if o.EventBufferSize <= 0 {
+		o.EventBufferSize = 1024
+	}
+	if o.OnFull == "" {
+		o.OnFull = FullReject
+	}
+	if o.Clock == nil {
+		o.Clock = realClock{}
+	}
+	return o
+}
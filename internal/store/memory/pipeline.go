@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// PipelineGet runs string reads and list index reads on any number of keys under a
+// single read lock, so the results are a consistent view of the store, and returns
+// one result per read, in order. A failing read does not stop the pipeline; its error
+// is reported in its result instead: ErrKeyNotFound for missing or expired keys,
+// ErrTypeMismatch when a string read finds a list or an index read a string, and
+// ErrIndexOutOfRange when the list has no item at the index.
+func (s *MemoryStore) PipelineGet(ctx context.Context, specs []store.ReadSpec) ([]store.ReadResult, error) {
+	results := make([]store.ReadResult, len(specs))
+
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	now := s.clock.Now()
+	for i, spec := range specs {
+		if err := checkCtx(ctx, i); err != nil {
+			return nil, err
+		}
+		v, exists := s.data[spec.Key]
+		if !exists || (!v.TTL.IsZero() && now.After(v.TTL)) {
+			results[i].Err = ErrKeyNotFound
+			continue
+		}
+
+		if spec.Index == nil {
+			if !v.isString() {
+				results[i].Err = ErrTypeMismatch
+				continue
+			}
+			results[i].Value = v.text()
+		} else {
+			if !v.IsList {
+				results[i].Err = ErrTypeMismatch
+				continue
+			}
+			index := *spec.Index
+			if index < 0 {
+				index += len(v.List)
+			}
+			if index < 0 || index >= len(v.List) {
+				results[i].Err = ErrIndexOutOfRange
+				continue
+			}
+			results[i].Value = itemText(v.List[index])
+		}
+		s.touch(spec.Key, now)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// SetReturningPrevious sets a key like Set and returns the entry it replaced,
+// or nil if the key did not exist.
+func (s *MemoryStore) SetReturningPrevious(ctx context.Context, key string, value any, ttlSeconds int, opts ...store.WriteOption) (*store.KeyEntry, error) {
+	if ttlSeconds < 0 {
+		return nil, ErrInvalidTTL
+	}
+
+	o := store.NewWriteOptions(opts)
+	stringValue, err := s.Stringify(value)
+	if err != nil {
+		return nil, ErrMarshalFailed
+	}
+	stringValue, compressed := s.encode(stringValue, o.NoCompress)
+
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	if err := s.checkFence(key, o); err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	previous := s.liveEntry(key, now)
+	v := Value{Val: stringValue, TTL: s.ttlFromSeconds(s.defaultTTL(key, ttlSeconds)), IsList: false, IsJSON: isJSON(value), Compressed: compressed, Tags: uniqueTags(o.Tags)}
+	if err := s.reserve(key, v); err != nil {
+		return nil, err
+	}
+
+	s.put(key, v)
+	s.recordFence(key, o)
+	s.touch(key, now)
+	return previous, nil
+}
+
+// RemoveReturningPrevious deletes a key like Remove and returns the removed entry.
+func (s *MemoryStore) RemoveReturningPrevious(ctx context.Context, key string, opts ...store.WriteOption) (*store.KeyEntry, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	o := store.NewWriteOptions(opts)
+	if err := s.checkFence(key, o); err != nil {
+		return nil, err
+	}
+
+	previous := s.liveEntry(key, s.clock.Now())
+	if _, exists := s.data[key]; !exists {
+		return nil, ErrKeyNotFound
+	}
+
+	s.del(key)
+	if previous == nil {
+		// The key had already expired
+		return nil, ErrKeyNotFound
+	}
+	s.recordFence(key, o)
+	return previous, nil
+}
+
+// ExpireReturningPrevious changes the TTL of a key like Expire and returns the entry
+// as it was before the change.
+func (s *MemoryStore) ExpireReturningPrevious(ctx context.Context, key string, ttlSeconds int, opts ...store.WriteOption) (*store.KeyEntry, error) {
+	if ttlSeconds < 0 {
+		return nil, ErrInvalidTTL
+	}
+
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	o := store.NewWriteOptions(opts)
+	if err := s.checkFence(key, o); err != nil {
+		return nil, err
+	}
+
+	previous := s.liveEntry(key, s.clock.Now())
+	if previous == nil {
+		s.del(key)
+		return nil, ErrKeyNotFound
+	}
+
+	v := s.data[key]
+	v.TTL = s.ttlFromSeconds(ttlSeconds)
+	s.put(key, v)
+	s.recordFence(key, o)
+	return previous, nil
+}
+
+// liveEntry returns the snapshot of key, or nil if it is missing or expired.
+// The caller must hold the lock.
+func (s *MemoryStore) liveEntry(key string, now time.Time) *store.KeyEntry {
+	v, exists := s.data[key]
+	if !exists || (!v.TTL.IsZero() && now.After(v.TTL)) {
+		return nil
+	}
+
+	entry := entryOf(key, v, now)
+	return &entry
+}
+
+// GetSet atomically replaces the value of an existing string key and returns the value
+// it replaced. The key keeps its TTL and tags. It returns ErrKeyNotFound without
+// storing anything if the key does not exist, and ErrTypeMismatch if it is a list.
+func (s *MemoryStore) GetSet(ctx context.Context, key string, value any, opts ...store.WriteOption) (string, error) {
+	stringValue, err := s.Stringify(value)
+	if err != nil {
+		return "", ErrMarshalFailed
+	}
+	stringValue, compressed := s.encode(stringValue, false)
+
+	if err := s.lock(ctx); err != nil {
+		return "", err
+	}
+	defer s.mu.Unlock()
+
+	o := store.NewWriteOptions(opts)
+	if err := s.checkFence(key, o); err != nil {
+		return "", err
+	}
+
+	v, err := s.liveString(key)
+	if err != nil {
+		return "", err
+	}
+	previous := v.text()
+
+	v.Val, v.Compressed = stringValue, compressed
+	v.IsJSON = isJSON(value)
+	if err := s.reserve(key, v); err != nil {
+		return "", err
+	}
+
+	s.put(key, v)
+	s.recordFence(key, o)
+	s.touch(key, s.clock.Now())
+	return previous, nil
+}
@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// subscriberBuffer is the number of messages a subscriber can fall behind by before
+// newer messages are dropped for it.
+const subscriberBuffer = 64
+
+// PubSub delivers messages published on a channel to the subscribers of that channel.
+// Messages are not stored: only the subscribers at the time of publishing get them.
+// Publishing never blocks, so a subscriber that does not keep up loses the messages
+// published while its buffer is full.
+type PubSub struct {
+	mu    sync.Mutex
+	clock Clock
+	subs  map[string]map[*subscriber]struct{}
+}
+
+type subscriber struct {
+	messages chan store.Message
+}
+
+// NewPubSub returns a PubSub without subscribers, stamping messages with the time
+// read from clock.
+func NewPubSub(clock Clock) *PubSub {
+	return &PubSub{clock: clock, subs: make(map[string]map[*subscriber]struct{})}
+}
+
+// Subscribe returns the messages published on channel from now on, and a function
+// ending the subscription, which closes the messages channel. It must be called once
+// the subscriber is done, and may be called more than once.
+func (ps *PubSub) Subscribe(channel string) (<-chan store.Message, func()) {
+	sub := &subscriber{messages: make(chan store.Message, subscriberBuffer)}
+
+	ps.mu.Lock()
+	if ps.subs[channel] == nil {
+		ps.subs[channel] = make(map[*subscriber]struct{})
+	}
+	ps.subs[channel][sub] = struct{}{}
+	ps.mu.Unlock()
+
+	var once sync.Once
+	return sub.messages, func() {
+		once.Do(func() { ps.unsubscribe(channel, sub) })
+	}
+}
+
+func (ps *PubSub) unsubscribe(channel string, sub *subscriber) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	delete(ps.subs[channel], sub)
+	if len(ps.subs[channel]) == 0 {
+		delete(ps.subs, channel)
+	}
+	close(sub.messages)
+}
+
+// Publish sends payload, encoded as JSON, to the subscribers of channel and returns
+// how many of them got it. It returns ErrMarshalFailed if payload cannot be encoded.
+func (ps *PubSub) Publish(channel string, payload any) (int, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, ErrMarshalFailed
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	msg := store.Message{Channel: channel, Payload: data, Time: ps.clock.Now()}
+	delivered := 0
+	for sub := range ps.subs[channel] {
+		select {
+		case sub.messages <- msg:
+			delivered++
+		default:
+		}
+	}
+	return delivered, nil
+}
+
+// subscribed reports whether channel has any subscribers.
+func (ps *PubSub) subscribed(channel string) bool {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	return len(ps.subs[channel]) > 0
+}
+
+// Subscribe returns the messages published on channel from now on, and a function
+// ending the subscription; see PubSub.Subscribe.
+func (s *MemoryStore) Subscribe(channel string) (<-chan store.Message, func()) {
+	return s.pubsub.Subscribe(channel)
+}
+
+// Publish sends payload to the subscribers of channel and returns how many of them got
+// it; see PubSub.Publish.
+func (s *MemoryStore) Publish(channel string, payload any) (int, error) {
+	return s.pubsub.Publish(channel, payload)
+}
+
+// notifyKeyspace publishes a keyspace notification of type typ for key, if enabled,
+// see Options.KeyspaceNotifications.
+func (s *MemoryStore) notifyKeyspace(typ, key string) {
+	if !s.keyspaceNotifications {
+		return
+	}
+	channel := store.KeyspaceChannelPrefix + key
+	if !s.pubsub.subscribed(channel) {
+		return
+	}
+	s.pubsub.Publish(channel, store.KeyspaceNotification{Event: typ, Key: key})
+}
@@ -0,0 +1,99 @@
+package memory_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	s "github.com/mo-mohamed/acronis-memory-store/internal/store"
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+func TestPubSub(t *testing.T) {
+	ps := memory.NewPubSub(newFakeClock())
+
+	first, unsubscribeFirst := ps.Subscribe("news")
+	second, unsubscribeSecond := ps.Subscribe("news")
+	defer unsubscribeSecond()
+	other, unsubscribeOther := ps.Subscribe("sports")
+	defer unsubscribeOther()
+
+	if n, err := ps.Publish("news", map[string]int{"id": 1}); err != nil || n != 2 {
+		t.Fatalf("Expected 2 receivers, got %d (err %v)", n, err)
+	}
+	for _, messages := range []<-chan s.Message{first, second} {
+		if msg := <-messages; msg.Channel != "news" || string(msg.Payload) != `{"id":1}` {
+			t.Errorf("Expected the published message, got %+v", msg)
+		}
+	}
+	if len(other) != 0 {
+		t.Error("Expected no message on another channel")
+	}
+
+	// Unsubscribing closes the channel and can be done twice
+	unsubscribeFirst()
+	unsubscribeFirst()
+	if _, open := <-first; open {
+		t.Error("Expected the messages channel to be closed")
+	}
+	if n, _ := ps.Publish("news", "again"); n != 1 {
+		t.Errorf("Expected 1 receiver left, got %d", n)
+	}
+
+	if _, err := ps.Publish("news", func() {}); err != memory.ErrMarshalFailed {
+		t.Errorf("Expected ErrMarshalFailed, got %v", err)
+	}
+}
+
+func TestPubSubSlowSubscriber(t *testing.T) {
+	ps := memory.NewPubSub(newFakeClock())
+	messages, unsubscribe := ps.Subscribe("firehose")
+	defer unsubscribe()
+
+	// Publishing never blocks; messages beyond the buffer are dropped
+	delivered := 0
+	for i := 0; i < 100; i++ {
+		n, _ := ps.Publish("firehose", i)
+		delivered += n
+	}
+	if delivered != len(messages) || delivered == 100 {
+		t.Errorf("Expected messages past the buffer dropped, %d delivered and %d buffered", delivered, len(messages))
+	}
+	if msg := <-messages; string(msg.Payload) != "0" {
+		t.Errorf("Expected the oldest message kept, got %s", msg.Payload)
+	}
+}
+
+func TestKeyspaceNotifications(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryStoreWithOptions(memory.Options{KeyspaceNotifications: true})
+	defer store.StopTTLWorker()
+
+	messages, unsubscribe := store.Subscribe(s.KeyspaceChannelPrefix + "jobs")
+	defer unsubscribe()
+
+	store.Push(ctx, "jobs", "a")
+	store.Set(ctx, "other", "value", 0)
+	store.Remove(ctx, "jobs")
+
+	for _, want := range []s.KeyspaceNotification{{Event: s.EventSet, Key: "jobs"}, {Event: s.EventDel, Key: "jobs"}} {
+		var got s.KeyspaceNotification
+		msg := <-messages
+		if err := json.Unmarshal(msg.Payload, &got); err != nil || got != want {
+			t.Errorf("Expected %+v, got %s (err %v)", want, msg.Payload, err)
+		}
+	}
+	if len(messages) != 0 {
+		t.Errorf("Expected no notification for other keys, got %d", len(messages))
+	}
+
+	// Notifications are off by default
+	quiet := memory.NewMemoryStore()
+	defer quiet.StopTTLWorker()
+	messages, unsubscribe = quiet.Subscribe(s.KeyspaceChannelPrefix + "jobs")
+	defer unsubscribe()
+	quiet.Push(ctx, "jobs", "a")
+	if len(messages) != 0 {
+		t.Error("Expected no notifications unless enabled")
+	}
+}
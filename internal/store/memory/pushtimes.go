@@ -0,0 +1,63 @@
+package memory
+
+import "time"
+
+// hasPushTimes reports whether v holds the push time of every item of its list. Lists
+// pushed to while push times were not recorded hold none, or too few to tell which
+// item they belong to.
+func (v Value) hasPushTimes() bool {
+	return v.PushedAt != nil && len(v.PushedAt) == len(v.List)
+}
+
+// pushedAt returns the push time of the item at index i, or the zero time if it is
+// unknown.
+func (v Value) pushedAt(i int) time.Time {
+	if !v.hasPushTimes() {
+		return time.Time{}
+	}
+	return v.PushedAt[i]
+}
+
+// keepPushTimes keeps the push times of the items between start, inclusive, and stop,
+// exclusive, as the list is cut down to them. It must be called before the list is.
+func (v *Value) keepPushTimes(start, stop int) {
+	if !v.hasPushTimes() {
+		v.PushedAt = nil
+		return
+	}
+	v.PushedAt = v.PushedAt[start:stop]
+}
+
+// alignedPushTimes returns the push times of v's items, one per item, the unknown ones
+// being zero.
+func alignedPushTimes(v Value) []time.Time {
+	if v.hasPushTimes() {
+		return v.PushedAt
+	}
+	return make([]time.Time, len(v.List))
+}
+
+// pushedTimes returns the push times of the list before, after an item pushed at now
+// was added to its head, or its tail if tail is set, and evicted items were dropped
+// from the other end. It returns nil if now is zero, push times not being recorded.
+func pushedTimes(before Value, tail bool, evicted int, now time.Time) []time.Time {
+	if now.IsZero() {
+		return nil
+	}
+
+	times := alignedPushTimes(before)
+	n := len(times)
+	if tail {
+		return append(times[:n:n], now)[evicted:]
+	}
+	return append([]time.Time{now}, times...)[:n+1-evicted]
+}
+
+// timesAt returns n copies of t, the push times of n items pushed at once.
+func timesAt(t time.Time, n int) []time.Time {
+	times := make([]time.Time, n)
+	for i := range times {
+		times[i] = t
+	}
+	return times
+}
@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+// rateBuckets is the number of buckets a rate window is split into.
+// More buckets track the window edge more precisely at the cost of memory per key.
+const rateBuckets = 10
+
+type rateBucket struct {
+	index int64
+	count int
+}
+
+// rateWindow is a sliding window counter made of rateBuckets fixed width buckets.
+// The count of a window is the sum of the buckets that overlap it, so hits leave
+// the window one bucket at a time instead of all at once at a fixed boundary.
+type rateWindow struct {
+	window  time.Duration
+	width   time.Duration
+	buckets [rateBuckets]rateBucket
+	last    time.Time
+}
+
+func newRateWindow(window time.Duration) *rateWindow {
+	width := window / rateBuckets
+	if width <= 0 {
+		width = 1
+	}
+	return &rateWindow{window: window, width: width}
+}
+
+// count returns the number of hits in the window ending at the bucket of now.
+func (w *rateWindow) count(now time.Time) int {
+	current := now.UnixNano() / int64(w.width)
+	total := 0
+	for _, b := range w.buckets {
+		if b.index > current-rateBuckets && b.index <= current {
+			total += b.count
+		}
+	}
+	return total
+}
+
+func (w *rateWindow) add(now time.Time) {
+	current := now.UnixNano() / int64(w.width)
+	b := &w.buckets[current%rateBuckets]
+	if b.index != current {
+		b.index = current
+		b.count = 0
+	}
+	b.count++
+	w.last = now
+}
+
+// expired reports whether every hit of the window has aged out.
+func (w *rateWindow) expired(now time.Time) bool {
+	return now.Sub(w.last) > w.window
+}
+
+// RateIncr counts a hit against the sliding window rate limit of key. The hit is
+// only counted if fewer than limit hits happened within the last window, so denied
+// requests do not extend the time a caller is limited. It returns the number of
+// counted hits in the window and whether this hit was allowed.
+// Rate limit keys live in their own namespace and do not clash with string or list keys.
+func (s *MemoryStore) RateIncr(ctx context.Context, key string, window time.Duration, limit int) (int, bool, error) {
+	if window <= 0 || limit <= 0 {
+		return 0, false, ErrInvalidRateLimit
+	}
+
+	if err := s.lock(ctx); err != nil {
+		return 0, false, err
+	}
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	w, exists := s.rates[key]
+	if !exists || w.window != window || w.expired(now) {
+		w = newRateWindow(window)
+		s.rates[key] = w
+	}
+
+	count := w.count(now)
+	if count >= limit {
+		return count, false, nil
+	}
+
+	w.add(now)
+	return count + 1, true, nil
+}
+
+// purgeRates drops rate windows whose hits have all aged out. The caller must hold the lock.
+func (s *MemoryStore) purgeRates(now time.Time) {
+	for k, w := range s.rates {
+		if w.expired(now) {
+			delete(s.rates, k)
+		}
+	}
+}
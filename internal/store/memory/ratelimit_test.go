@@ -0,0 +1,76 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+func TestRateIncr(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	for i := 1; i <= 5; i++ {
+		count, allowed, err := store.RateIncr(ctx, "rate", time.Second, 5)
+		if err != nil {
+			t.Fatalf("RateIncr failed: %v", err)
+		}
+		if !allowed || count != i {
+			t.Errorf("Expected request %d to be allowed with count %d, got allowed=%v count=%d", i, i, allowed, count)
+		}
+	}
+
+	count, allowed, _ := store.RateIncr(ctx, "rate", time.Second, 5)
+	if allowed || count != 5 {
+		t.Errorf("Expected burst over the limit to be denied with count 5, got allowed=%v count=%d", allowed, count)
+	}
+
+	// Other keys have their own window.
+	if _, allowed, _ := store.RateIncr(ctx, "other", time.Second, 5); !allowed {
+		t.Error("Expected request on another key to be allowed")
+	}
+
+	if _, _, err := store.RateIncr(ctx, "rate", 0, 5); err != memory.ErrInvalidRateLimit {
+		t.Errorf("Expected ErrInvalidRateLimit for zero window, got %v", err)
+	}
+}
+
+func TestRateIncrSlidingWindow(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		store.RateIncr(ctx, "rate", time.Second, 5)
+	}
+
+	time.Sleep(600 * time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		if _, allowed, _ := store.RateIncr(ctx, "rate", time.Second, 5); !allowed {
+			t.Fatalf("Expected request %d in the second half of the window to be allowed", i)
+		}
+	}
+
+	if _, allowed, _ := store.RateIncr(ctx, "rate", time.Second, 5); allowed {
+		t.Error("Expected request over the limit to be denied")
+	}
+
+	// Past the window boundary the first burst has aged out, but the later hits still count.
+	// A fixed window would have reset and allowed 5 more requests here.
+	time.Sleep(500 * time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		if _, allowed, _ := store.RateIncr(ctx, "rate", time.Second, 5); !allowed {
+			t.Fatalf("Expected request %d after the first burst aged out to be allowed", i)
+		}
+	}
+
+	count, allowed, _ := store.RateIncr(ctx, "rate", time.Second, 5)
+	if allowed || count != 5 {
+		t.Errorf("Expected hits from the previous window to still count, got allowed=%v count=%d", allowed, count)
+	}
+}
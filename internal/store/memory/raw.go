@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// isJSON reports whether Stringify stores value as its JSON encoding.
+func isJSON(value any) bool {
+	_, isString := value.(string)
+	return !isString
+}
+
+// GetRaw returns the value of a string key as JSON: values that were set as numbers,
+// objects and other non-string types are returned in their original JSON structure,
+// and values set as strings are returned as JSON strings. It returns ErrTypeMismatch
+// for keys not holding a string.
+func (s *MemoryStore) GetRaw(ctx context.Context, key string) (json.RawMessage, error) {
+	v, exists, err := s.getAny(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrKeyNotFound
+	}
+	if !v.isString() {
+		return nil, ErrTypeMismatch
+	}
+
+	if v.IsJSON {
+		return json.RawMessage(v.text()), nil
+	}
+	raw, err := json.Marshal(v.text())
+	if err != nil {
+		return nil, ErrMarshalFailed
+	}
+	return raw, nil
+}
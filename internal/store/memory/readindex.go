@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+// indexEntry is a value published to the sync.Map index along with the access
+// counters of its key, so lock-free reads can record hits. Published values are
+// never modified; writes publish a new entry instead.
+type indexEntry struct {
+	value  Value
+	access *keyAccess
+}
+
+// publish makes v visible to lock-free reads. The caller must hold the write lock.
+func (s *MemoryStore) publish(key string, v Value) {
+	if s.index == nil {
+		return
+	}
+	s.index.Store(key, &indexEntry{value: v, access: s.access[key]})
+}
+
+// unpublish hides key from lock-free reads. The caller must hold the write lock.
+func (s *MemoryStore) unpublish(key string) {
+	if s.index == nil {
+		return
+	}
+	s.index.Delete(key)
+}
+
+// lookup returns the live value at key from the index without taking the store lock,
+// recording the access, which takes the LRU list's lock under FullEvict. Expired keys
+// are reported missing and left for the TTL worker. Like the locked reads, it fails
+// with ctx.Err() if ctx is already done.
+func (s *MemoryStore) lookup(ctx context.Context, key string, now time.Time) (Value, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return Value{}, false, err
+	}
+
+	e, ok := s.index.Load(key)
+	if !ok {
+		return Value{}, false, nil
+	}
+
+	entry := e.(*indexEntry)
+	if !entry.value.TTL.IsZero() && now.After(entry.value.TTL) {
+		return Value{}, false, nil
+	}
+
+	if entry.access != nil {
+		entry.access.hits.Add(1)
+		entry.access.last.Store(now.UnixNano())
+		if s.lru != nil {
+			s.lru.touch(key)
+		}
+	}
+	return entry.value, true, nil
+}
+
+// getAny returns the live value at key of any type, recording the access. It fails
+// with ctx.Err() if ctx is done before the store lock is acquired.
+func (s *MemoryStore) getAny(ctx context.Context, key string) (Value, bool, error) {
+	now := s.clock.Now()
+	if s.index != nil {
+		return s.lookup(ctx, key, now)
+	}
+
+	if err := s.rlock(ctx); err != nil {
+		return Value{}, false, err
+	}
+	defer s.mu.RUnlock()
+
+	v, exists := s.data[key]
+	if !exists || (!v.TTL.IsZero() && now.After(v.TTL)) {
+		return Value{}, false, nil
+	}
+	s.touch(key, now)
+	return v, true, nil
+}
@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// reservation is a list item handed out by Reserve that has not been acknowledged yet.
+type reservation struct {
+	key  string
+	item string
+	seq  uint64
+	// pushedAt is the push time of the item, kept for when it is requeued.
+	pushedAt time.Time
+	until    time.Time
+}
+
+// Reserve takes the item at the head of a list like Pop, but keeps it in flight under
+// the returned receipt instead of discarding it. Ack with the receipt deletes the item
+// for good; if it is not acknowledged within visibilityTimeout, it is put back at the
+// head of the list to be reserved again. This gives at-least-once delivery to
+// consumers that may crash while processing an item. Reservations are recorded in the
+// append-only file and snapshots, so a restart does not lose reserved items either.
+func (s *MemoryStore) Reserve(ctx context.Context, key string, visibilityTimeout time.Duration, opts ...store.WriteOption) (string, string, error) {
+	if visibilityTimeout <= 0 {
+		return "", "", ErrInvalidTTL
+	}
+
+	receipt, err := newReceipt()
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := s.lock(ctx); err != nil {
+		return "", "", err
+	}
+	defer s.mu.Unlock()
+
+	o := store.NewWriteOptions(opts)
+	if err := s.checkFence(key, o); err != nil {
+		return "", "", err
+	}
+
+	now := s.clock.Now()
+	s.requeueExpired(now)
+
+	v, exists := s.data[key]
+	if !exists {
+		return "", "", ErrKeyNotFound
+	}
+
+	if !v.TTL.IsZero() && now.After(v.TTL) {
+		s.del(key)
+		return "", "", ErrKeyNotFound
+	}
+
+	if !v.IsList {
+		return "", "", ErrTypeMismatch
+	}
+
+	if len(v.List) == 0 {
+		return "", "", ErrEmptyList
+	}
+
+	r := reservation{key: key, item: v.List[0], seq: v.Seqs[0], pushedAt: v.pushedAt(0), until: now.Add(visibilityTimeout)}
+	s.putListOp(key, v.popItem(false), r.record(receipt))
+	s.recordFence(key, o)
+	s.touch(key, now)
+
+	s.putInflight(receipt, r)
+	return itemText(r.item), receipt, nil
+}
+
+// Ack deletes the item reserved under receipt. It returns ErrReceiptNotFound if the
+// receipt is unknown, was already acknowledged or its visibility timeout has passed,
+// in which case the item has been or will be delivered again.
+func (s *MemoryStore) Ack(ctx context.Context, receipt string) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	r, exists := s.inflight[receipt]
+	if !exists {
+		return ErrReceiptNotFound
+	}
+
+	if now := s.clock.Now(); !now.Before(r.until) {
+		s.requeueExpired(now)
+		return ErrReceiptNotFound
+	}
+
+	s.dropInflight(receipt)
+	s.logRecord(aofRecord{Op: aofAck, Receipt: receipt})
+	return nil
+}
+
+// record returns the reserve record of r for the append-only file.
+func (r reservation) record(receipt string) aofRecord {
+	until := r.until
+	rec := aofRecord{Op: aofReserve, Key: []byte(r.key), Item: []byte(r.item), Seq: r.seq, Receipt: receipt, Until: &until}
+	if !r.pushedAt.IsZero() {
+		rec.PushedAt = []time.Time{r.pushedAt}
+	}
+	return rec
+}
+
+// requeueExpired puts reserved items whose visibility timeout has passed back at the
+// head of their list. Items whose key now holds a string are dropped, and those whose
+// list is soft deleted go back in the list kept for Restore, rather than in a new one
+// beside it. The caller must hold the write lock.
+func (s *MemoryStore) requeueExpired(now time.Time) {
+	for receipt, r := range s.inflight {
+		if now.Before(r.until) {
+			continue
+		}
+		s.dropInflight(receipt)
+		s.logRecord(aofRecord{Op: aofAck, Receipt: receipt})
+
+		v, exists := s.data[r.key]
+		if !exists || (!v.TTL.IsZero() && now.After(v.TTL)) {
+			if t, pending := s.pendingDelete(r.key, now); pending {
+				if t.value.IsList {
+					t.value = s.requeued(t.value, r)
+					s.putTombstone(r.key, t)
+				}
+				continue
+			}
+			v = Value{IsList: true}
+		}
+		if !v.IsList {
+			continue
+		}
+		s.put(r.key, s.requeued(v, r))
+	}
+}
+
+// requeued returns the list v with the item of r back at its head.
+func (s *MemoryStore) requeued(v Value, r reservation) Value {
+	// The item keeps its sequence number, unless it starts a new list, and its push time.
+	seq := r.seq
+	if len(v.List) == 0 && v.LastSeq == 0 {
+		v.LastSeq++
+		seq = v.LastSeq
+	}
+	if s.listPushTimes {
+		v.PushedAt = append([]time.Time{r.pushedAt}, alignedPushTimes(v)...)
+	} else {
+		v.PushedAt = nil
+	}
+	v.List = append([]string{r.item}, v.List...)
+	v.Seqs = append([]uint64{seq}, v.Seqs...)
+	return v
+}
+
+// putInflight puts r in flight under receipt, replacing any reservation under it, and
+// counts it in usedBytes. The caller must hold the write lock.
+func (s *MemoryStore) putInflight(receipt string, r reservation) {
+	s.dropInflight(receipt)
+	s.inflight[receipt] = r
+	s.usedBytes += int64(reservationSize(receipt, r))
+}
+
+// dropInflight forgets the reservation under receipt, if any. The caller must hold
+// the write lock.
+func (s *MemoryStore) dropInflight(receipt string) {
+	if r, ok := s.inflight[receipt]; ok {
+		s.usedBytes -= int64(reservationSize(receipt, r))
+		delete(s.inflight, receipt)
+	}
+}
+
+func newReceipt() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// depthRing is a fixed size ring buffer of depth samples for a single list.
+type depthRing struct {
+	samples []store.DepthSample
+	next    int
+	full    bool
+}
+
+func (r *depthRing) add(sample store.DepthSample) {
+	r.samples[r.next] = sample
+	r.next = (r.next + 1) % len(r.samples)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// ordered returns the samples from oldest to newest.
+func (r *depthRing) ordered() []store.DepthSample {
+	if !r.full {
+		return append([]store.DepthSample(nil), r.samples[:r.next]...)
+	}
+	out := make([]store.DepthSample, 0, len(r.samples))
+	out = append(out, r.samples[r.next:]...)
+	return append(out, r.samples[:r.next]...)
+}
+
+// idle reports whether the ring is full and every sample in it is zero.
+func (r *depthRing) idle() bool {
+	if !r.full {
+		return false
+	}
+	for _, sample := range r.samples {
+		if sample.Depth != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// listSampler periodically records list lengths of a store.
+type listSampler struct {
+	mu       sync.Mutex
+	rings    map[string]*depthRing
+	capacity int
+	maxLists int
+	cancel   context.CancelFunc
+}
+
+func newListSampler(capacity, maxLists int) *listSampler {
+	return &listSampler{
+		rings:    make(map[string]*depthRing),
+		capacity: capacity,
+		maxLists: maxLists,
+	}
+}
+
+// record adds a sample for every tracked list and starts tracking new ones while under maxLists.
+// Tracked lists that are gone are recorded with a depth of 0 and dropped once their whole window is 0.
+func (ls *listSampler) record(now time.Time, depths map[string]int) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	for key, ring := range ls.rings {
+		ring.add(store.DepthSample{Time: now, Depth: depths[key]})
+		if ring.idle() {
+			delete(ls.rings, key)
+		}
+	}
+
+	for key, depth := range depths {
+		if _, tracked := ls.rings[key]; tracked || len(ls.rings) >= ls.maxLists {
+			continue
+		}
+		ring := &depthRing{samples: make([]store.DepthSample, ls.capacity)}
+		ring.add(store.DepthSample{Time: now, Depth: depth})
+		ls.rings[key] = ring
+	}
+}
+
+func (ls *listSampler) history(key string) ([]store.DepthSample, bool) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	ring, ok := ls.rings[key]
+	if !ok {
+		return nil, false
+	}
+	return ring.ordered(), true
+}
+
+// startListSampler starts recording list depths every interval until StopListSampler is called.
+func (s *MemoryStore) startListSampler(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.sampler.cancel = cancel
+
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				s.sampler.record(now, s.listDepths())
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// StopListSampler stops recording list depths. Recorded history remains readable.
+func (s *MemoryStore) StopListSampler() {
+	if s.sampler != nil && s.sampler.cancel != nil {
+		s.sampler.cancel()
+	}
+}
+
+// listDepths returns the length of every live list in the store.
+func (s *MemoryStore) listDepths() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.clock.Now()
+	depths := make(map[string]int)
+	for k, v := range s.data {
+		if v.IsList && (v.TTL.IsZero() || now.Before(v.TTL)) {
+			depths[k] = len(v.List)
+		}
+	}
+	return depths
+}
+
+// ListDepthHistory returns the recorded length samples of a list, oldest first.
+// It returns ErrKeyNotFound if the list is not tracked or sampling is disabled.
+func (s *MemoryStore) ListDepthHistory(ctx context.Context, key string) ([]store.DepthSample, error) {
+	if s.sampler == nil {
+		return nil, ErrKeyNotFound
+	}
+
+	samples, ok := s.sampler.history(key)
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return samples, nil
+}
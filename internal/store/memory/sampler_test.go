@@ -0,0 +1,104 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+func TestListDepthHistory(t *testing.T) {
+	store := memory.NewMemoryStoreWithOptions(memory.Options{
+		ListSampleInterval: 20 * time.Millisecond,
+		ListSampleCapacity: 50,
+	})
+	defer store.StopTTLWorker()
+	defer store.StopListSampler()
+	ctx := context.Background()
+
+	t.Run("history reflects changing depth", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			store.Push(ctx, "queue", "item")
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		store.Pop(ctx, "queue")
+		store.Pop(ctx, "queue")
+		time.Sleep(100 * time.Millisecond)
+
+		samples, err := store.ListDepthHistory(ctx, "queue")
+		if err != nil {
+			t.Fatalf("ListDepthHistory failed: %v", err)
+		}
+		if len(samples) < 2 {
+			t.Fatalf("Expected several samples, got %d", len(samples))
+		}
+
+		if samples[0].Depth != 3 {
+			t.Errorf("Expected first sample depth 3, got %d", samples[0].Depth)
+		}
+		if last := samples[len(samples)-1]; last.Depth != 1 {
+			t.Errorf("Expected last sample depth 1, got %d", last.Depth)
+		}
+
+		for i := 1; i < len(samples); i++ {
+			if samples[i].Time.Before(samples[i-1].Time) {
+				t.Fatalf("Expected samples ordered by time, sample %d is older than %d", i, i-1)
+			}
+		}
+	})
+
+	t.Run("samples are capped per list", func(t *testing.T) {
+		store.Push(ctx, "busy", "item")
+		time.Sleep(1200 * time.Millisecond)
+
+		samples, err := store.ListDepthHistory(ctx, "busy")
+		if err != nil {
+			t.Fatalf("ListDepthHistory failed: %v", err)
+		}
+		if len(samples) != 50 {
+			t.Errorf("Expected 50 samples, got %d", len(samples))
+		}
+	})
+
+	t.Run("untracked list", func(t *testing.T) {
+		_, err := store.ListDepthHistory(ctx, "never_pushed")
+		if err == nil || err.Error() != "key not found" {
+			t.Errorf("Expected 'key not found', got %v", err)
+		}
+	})
+}
+
+func TestListDepthHistoryMaxLists(t *testing.T) {
+	store := memory.NewMemoryStoreWithOptions(memory.Options{
+		ListSampleInterval: 20 * time.Millisecond,
+		ListSampleMaxLists: 1,
+	})
+	defer store.StopTTLWorker()
+	defer store.StopListSampler()
+	ctx := context.Background()
+
+	store.Push(ctx, "first", "item")
+	time.Sleep(60 * time.Millisecond)
+	store.Push(ctx, "second", "item")
+	time.Sleep(60 * time.Millisecond)
+
+	if _, err := store.ListDepthHistory(ctx, "first"); err != nil {
+		t.Errorf("Expected first list to be tracked, got %v", err)
+	}
+	if _, err := store.ListDepthHistory(ctx, "second"); err == nil {
+		t.Error("Expected second list not to be tracked beyond the limit")
+	}
+}
+
+func TestListDepthHistoryDisabled(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Push(ctx, "queue", "item")
+	if _, err := store.ListDepthHistory(ctx, "queue"); err == nil {
+		t.Error("Expected error when sampling is disabled")
+	}
+}
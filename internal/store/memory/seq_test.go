@@ -0,0 +1,109 @@
+package memory_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	s "github.com/mo-mohamed/acronis-memory-store/internal/store"
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+func TestPushItemSeq(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	// Sequences increase with every push, whichever end it goes to
+	var last uint64
+	for i, opts := range []s.PushOptions{{Tail: true}, {Tail: true}, {}, {Tail: true}} {
+		res, err := store.PushItem(ctx, "queue", i, opts)
+		if err != nil {
+			t.Fatalf("PushItem failed: %v", err)
+		}
+		if res.Seq != last+1 {
+			t.Errorf("Expected seq %d, got %d", last+1, res.Seq)
+		}
+		last = res.Seq
+	}
+
+	page, _ := store.LRangePage(ctx, "queue", 0, -1)
+	if want := []uint64{3, 1, 2, 4}; !reflect.DeepEqual(page.Seqs, want) {
+		t.Errorf("Expected seqs %v, got %v", want, page.Seqs)
+	}
+	page, _ = store.LRangeReverse(ctx, "queue", 0, 1)
+	if want := []uint64{4, 2}; !reflect.DeepEqual(page.Seqs, want) {
+		t.Errorf("Expected reversed seqs %v, got %v", want, page.Seqs)
+	}
+
+	// Popping the head of a queue filled at the tail yields increasing sequences
+	store.Pop(ctx, "queue")
+	for _, want := range []uint64{1, 2, 4} {
+		item, err := store.PopItem(ctx, "queue")
+		if err != nil || item.Seq != want {
+			t.Errorf("Expected seq %d, got %+v, %v", want, item, err)
+		}
+	}
+
+	// Popped sequences are not reused
+	res, _ := store.PushItem(ctx, "queue", "x", s.PushOptions{})
+	if res.Seq != 5 {
+		t.Errorf("Expected seq 5 after popping everything, got %d", res.Seq)
+	}
+	if item, _ := store.RPopItem(ctx, "queue"); item.Value != "x" || item.Seq != 5 {
+		t.Errorf("Expected x with seq 5, got %+v", item)
+	}
+
+	// A capped push reports its sequence along with the trimmed items
+	store.LSet(ctx, "capped", []any{"a", "b"}, 0)
+	res, err := store.PushItem(ctx, "capped", "c", s.PushOptions{MaxLen: 2})
+	if err != nil || res.Seq != 3 || !reflect.DeepEqual(res.Evicted, []string{"b"}) {
+		t.Errorf("Expected seq 3 evicting [b], got %+v, %v", res, err)
+	}
+	if page, _ := store.LRangePage(ctx, "capped", 0, -1); !reflect.DeepEqual(page.Seqs, []uint64{3, 2}) {
+		t.Errorf("Expected seqs [3 2], got %v", page.Seqs)
+	}
+
+	// A new list numbers its items from 1 again
+	store.Remove(ctx, "queue")
+	if res, _ := store.PushItem(ctx, "queue", "y", s.PushOptions{}); res.Seq != 1 {
+		t.Errorf("Expected seq 1 for a new list, got %d", res.Seq)
+	}
+}
+
+func TestSeqAcrossListOperations(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	// LSet numbers items as if they were pushed from the last to the first
+	store.LSet(ctx, "src", []any{"a", "b", "c"}, 0)
+	if page, _ := store.LRangePage(ctx, "src", 0, -1); !reflect.DeepEqual(page.Seqs, []uint64{3, 2, 1}) {
+		t.Errorf("Expected LSet seqs [3 2 1], got %v", page.Seqs)
+	}
+
+	// Replacing a list carries its sequences on rather than handing them out again
+	store.LSet(ctx, "src", []any{"a", "b", "c"}, 0)
+	if page, _ := store.LRangePage(ctx, "src", 0, -1); !reflect.DeepEqual(page.Seqs, []uint64{6, 5, 4}) {
+		t.Errorf("Expected replacing LSet seqs [6 5 4], got %v", page.Seqs)
+	}
+	store.LSet(ctx, "src", []any{"a", "b", "c"}, 0)
+	store.LTrim(ctx, "src", 3, 3)
+	store.LSet(ctx, "src", []any{"a", "b", "c"}, 0)
+	if page, _ := store.LRangePage(ctx, "src", 0, -1); !reflect.DeepEqual(page.Seqs, []uint64{3, 2, 1}) {
+		t.Errorf("Expected LSet of a removed list to start over, got %v", page.Seqs)
+	}
+
+	// Moved items are numbered by their new list
+	store.RPush(ctx, "dst", "d")
+	store.LMoveAll(ctx, "src", "dst")
+	if page, _ := store.LRangePage(ctx, "dst", 0, -1); !reflect.DeepEqual(page.Seqs, []uint64{4, 3, 2, 1}) {
+		t.Errorf("Expected moved seqs [4 3 2 1], got %v", page.Seqs)
+	}
+
+	// Trimming keeps the sequences of the remaining items
+	store.LTrim(ctx, "dst", 1, 2)
+	if page, _ := store.LRangePage(ctx, "dst", 0, -1); !reflect.DeepEqual(page.Seqs, []uint64{3, 2}) {
+		t.Errorf("Expected trimmed seqs [3 2], got %v", page.Seqs)
+	}
+}
@@ -0,0 +1,167 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"sort"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// SAdd adds members to a set, creating the set if needed, and returns how many of
+// them were not already members. Adding existing members changes nothing, so SAdd can
+// be retried safely. A new set does not expire; an existing one keeps its TTL. SAdd
+// returns ErrTypeMismatch for keys not holding a set, and ErrKeyPendingDelete instead
+// of creating a set where a soft deleted key can still be restored.
+func (s *MemoryStore) SAdd(ctx context.Context, key string, members []string, opts ...store.WriteOption) (int, error) {
+	if err := s.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer s.mu.Unlock()
+
+	o := store.NewWriteOptions(opts)
+	if err := s.checkFence(key, o); err != nil {
+		return 0, err
+	}
+
+	now := s.clock.Now()
+	v, exists := s.data[key]
+	if exists && !v.TTL.IsZero() && now.After(v.TTL) {
+		s.del(key)
+		exists = false
+	}
+	if !exists {
+		if _, pending := s.pendingDelete(key, now); pending {
+			return 0, ErrKeyPendingDelete
+		}
+		v = Value{IsSet: true}
+	}
+	if !v.IsSet {
+		return 0, ErrTypeMismatch
+	}
+
+	set := maps.Clone(v.Set)
+	if set == nil {
+		set = map[string]struct{}{}
+	}
+	added := 0
+	for _, member := range members {
+		if _, ok := set[member]; !ok {
+			set[member] = struct{}{}
+			added++
+		}
+	}
+	if added == 0 {
+		return 0, nil
+	}
+	v.Set = set
+	if err := s.reserve(key, v); err != nil {
+		return 0, err
+	}
+
+	s.put(key, v)
+	s.recordFence(key, o)
+	s.touch(key, now)
+	return added, nil
+}
+
+// SRem removes members from a set and returns how many of them were members. A set
+// left without members is kept, like a list left without items. SRem returns
+// ErrKeyNotFound for missing and expired keys and ErrTypeMismatch for keys not holding
+// a set.
+func (s *MemoryStore) SRem(ctx context.Context, key string, members []string, opts ...store.WriteOption) (int, error) {
+	if err := s.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer s.mu.Unlock()
+
+	o := store.NewWriteOptions(opts)
+	if err := s.checkFence(key, o); err != nil {
+		return 0, err
+	}
+
+	now := s.clock.Now()
+	v, exists := s.data[key]
+	if !exists {
+		return 0, ErrKeyNotFound
+	}
+	if !v.TTL.IsZero() && now.After(v.TTL) {
+		s.del(key)
+		return 0, ErrKeyNotFound
+	}
+	if !v.IsSet {
+		return 0, ErrTypeMismatch
+	}
+
+	set := maps.Clone(v.Set)
+	for _, member := range members {
+		delete(set, member)
+	}
+	removed := len(v.Set) - len(set)
+	if removed == 0 {
+		return 0, nil
+	}
+	v.Set = set
+
+	s.put(key, v)
+	s.recordFence(key, o)
+	s.touch(key, now)
+	return removed, nil
+}
+
+// SMembers returns the members of a set, sorted. It returns ErrKeyNotFound for
+// missing and expired keys and ErrTypeMismatch for keys not holding a set.
+func (s *MemoryStore) SMembers(ctx context.Context, key string) ([]string, error) {
+	v, err := s.liveSet(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return members(v), nil
+}
+
+// SIsMember reports whether member belongs to a set, without reading the other
+// members. It returns ErrKeyNotFound for missing and expired keys and
+// ErrTypeMismatch for keys not holding a set.
+func (s *MemoryStore) SIsMember(ctx context.Context, key, member string) (bool, error) {
+	v, err := s.liveSet(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	_, ok := v.Set[member]
+	return ok, nil
+}
+
+// SCard returns the number of members of a set. It returns ErrKeyNotFound for missing
+// and expired keys and ErrTypeMismatch for keys not holding a set.
+func (s *MemoryStore) SCard(ctx context.Context, key string) (int, error) {
+	v, err := s.liveSet(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return len(v.Set), nil
+}
+
+// liveSet returns the live set stored at key, recording the access.
+func (s *MemoryStore) liveSet(ctx context.Context, key string) (Value, error) {
+	v, exists, err := s.getAny(ctx, key)
+	if err != nil {
+		return Value{}, err
+	}
+	if !exists {
+		return Value{}, ErrKeyNotFound
+	}
+	if !v.IsSet {
+		return Value{}, ErrTypeMismatch
+	}
+	return v, nil
+}
+
+// members returns the members of the set v, sorted.
+func members(v Value) []string {
+	sorted := make([]string, 0, len(v.Set))
+	for member := range v.Set {
+		sorted = append(sorted, member)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
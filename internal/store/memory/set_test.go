@@ -0,0 +1,177 @@
+package memory_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	s "github.com/mo-mohamed/acronis-memory-store/internal/store"
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+func TestSetMembers(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	if added, err := store.SAdd(ctx, "visitors", []string{"bob", "alice", "bob"}); err != nil || added != 2 {
+		t.Fatalf("Expected 2 members added, got %d (err %v)", added, err)
+	}
+	// Adding again is idempotent
+	if added, _ := store.SAdd(ctx, "visitors", []string{"alice", "carol"}); added != 1 {
+		t.Errorf("Expected only the new member counted, got %d", added)
+	}
+	if added, _ := store.SAdd(ctx, "visitors", []string{"alice"}); added != 0 {
+		t.Errorf("Expected nothing added, got %d", added)
+	}
+
+	if members, err := store.SMembers(ctx, "visitors"); err != nil || !reflect.DeepEqual(members, []string{"alice", "bob", "carol"}) {
+		t.Errorf("Expected the sorted members, got %v (err %v)", members, err)
+	}
+	if n, _ := store.SCard(ctx, "visitors"); n != 3 {
+		t.Errorf("Expected 3 members, got %d", n)
+	}
+	if ok, _ := store.SIsMember(ctx, "visitors", "bob"); !ok {
+		t.Error("Expected bob to be a member")
+	}
+	if ok, _ := store.SIsMember(ctx, "visitors", "dave"); ok {
+		t.Error("Expected dave not to be a member")
+	}
+
+	if removed, err := store.SRem(ctx, "visitors", []string{"bob", "dave"}); err != nil || removed != 1 {
+		t.Errorf("Expected 1 member removed, got %d (err %v)", removed, err)
+	}
+	store.SRem(ctx, "visitors", []string{"alice", "carol"})
+	if n, err := store.SCard(ctx, "visitors"); err != nil || n != 0 {
+		t.Errorf("Expected an empty set to be kept, got %d (err %v)", n, err)
+	}
+
+	for name, err := range map[string]error{
+		"SMembers":  func() error { _, err := store.SMembers(ctx, "missing"); return err }(),
+		"SIsMember": func() error { _, err := store.SIsMember(ctx, "missing", "a"); return err }(),
+		"SCard":     func() error { _, err := store.SCard(ctx, "missing"); return err }(),
+		"SRem":      func() error { _, err := store.SRem(ctx, "missing", []string{"a"}); return err }(),
+	} {
+		if !errors.Is(err, memory.ErrKeyNotFound) {
+			t.Errorf("%s: expected ErrKeyNotFound, got %v", name, err)
+		}
+	}
+}
+
+func TestSetTypeMismatch(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Set(ctx, "string", "value", 0)
+	store.Push(ctx, "list", "item")
+	store.HSet(ctx, "hash", "field", "value")
+	store.SAdd(ctx, "set", []string{"member"})
+
+	// Set operations on the other types
+	for _, key := range []string{"string", "list", "hash"} {
+		if _, err := store.SAdd(ctx, key, []string{"member"}); !errors.Is(err, memory.ErrTypeMismatch) {
+			t.Errorf("SAdd on %s: expected ErrTypeMismatch, got %v", key, err)
+		}
+		if _, err := store.SRem(ctx, key, []string{"member"}); !errors.Is(err, memory.ErrTypeMismatch) {
+			t.Errorf("SRem on %s: expected ErrTypeMismatch, got %v", key, err)
+		}
+		if _, err := store.SMembers(ctx, key); !errors.Is(err, memory.ErrTypeMismatch) {
+			t.Errorf("SMembers on %s: expected ErrTypeMismatch, got %v", key, err)
+		}
+		if _, err := store.SIsMember(ctx, key, "member"); !errors.Is(err, memory.ErrTypeMismatch) {
+			t.Errorf("SIsMember on %s: expected ErrTypeMismatch, got %v", key, err)
+		}
+		if _, err := store.SCard(ctx, key); !errors.Is(err, memory.ErrTypeMismatch) {
+			t.Errorf("SCard on %s: expected ErrTypeMismatch, got %v", key, err)
+		}
+	}
+
+	// Operations of the other types on sets
+	if _, err := store.Get(ctx, "set"); !errors.Is(err, memory.ErrTypeMismatch) {
+		t.Errorf("Get: expected ErrTypeMismatch, got %v", err)
+	}
+	if _, err := store.Increment(ctx, "set", 1); !errors.Is(err, memory.ErrTypeMismatch) {
+		t.Errorf("Increment: expected ErrTypeMismatch, got %v", err)
+	}
+	if err := store.Push(ctx, "set", "item"); !errors.Is(err, memory.ErrTypeMismatch) {
+		t.Errorf("Push: expected ErrTypeMismatch, got %v", err)
+	}
+	if _, err := store.HSet(ctx, "set", "field", "value"); !errors.Is(err, memory.ErrTypeMismatch) {
+		t.Errorf("HSet: expected ErrTypeMismatch, got %v", err)
+	}
+
+	value, kind, err := store.GetAny(ctx, "set")
+	if err != nil || kind != s.TypeSet || !reflect.DeepEqual(value, []string{"member"}) {
+		t.Errorf("Expected the set from GetAny, got %v %q (err %v)", value, kind, err)
+	}
+	if stats, _ := store.Stats(ctx); stats.Strings != 1 || stats.Lists != 1 || stats.Hashes != 1 || stats.Sets != 1 {
+		t.Errorf("Expected one key of each type, got %+v", stats)
+	}
+}
+
+func TestSetMembersExpiration(t *testing.T) {
+	clock := newFakeClock()
+	store := memory.NewMemoryStoreWithClock(clock)
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.SAdd(ctx, "online", []string{"alice"})
+	store.Expire(ctx, "online", 10)
+	store.SAdd(ctx, "online", []string{"bob"})
+	if ttl, _ := store.TTL(ctx, "online"); ttl != 10 {
+		t.Errorf("Expected the set to keep its TTL across SAdd, got %d", ttl)
+	}
+
+	clock.Advance(11 * time.Second)
+	if _, err := store.SIsMember(ctx, "online", "alice"); !errors.Is(err, memory.ErrKeyNotFound) {
+		t.Errorf("Expected the expired set to be gone, got %v", err)
+	}
+	if added, _ := store.SAdd(ctx, "online", []string{"alice"}); added != 1 {
+		t.Errorf("Expected the expired set to be replaced by a new one, got %d added", added)
+	}
+	if members, _ := store.SMembers(ctx, "online"); !reflect.DeepEqual(members, []string{"alice"}) {
+		t.Errorf("Expected only the new member, got %v", members)
+	}
+}
+
+func TestSetMembersPersistence(t *testing.T) {
+	ctx := context.Background()
+	var aofBuf bytes.Buffer
+	aof, _ := memory.NewAOFWriter(&aofBuf, memory.FsyncNo)
+	store := memory.NewMemoryStoreWithOptions(memory.Options{AOF: aof})
+	defer store.StopTTLWorker()
+
+	store.SAdd(ctx, "visitors", []string{"alice", "bob", "carol"})
+	store.SRem(ctx, "visitors", []string{"bob"})
+	expected := []string{"alice", "carol"}
+
+	if problems, _ := store.VerifyIntegrity(ctx); len(problems) != 0 {
+		t.Errorf("Expected the set to match its checksum, got %v", problems)
+	}
+
+	var snap bytes.Buffer
+	if err := store.Snapshot(&snap); err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	restored := memory.NewMemoryStore()
+	defer restored.StopTTLWorker()
+	if err := restored.RestoreSnapshot(&snap); err != nil {
+		t.Fatalf("RestoreSnapshot failed: %v", err)
+	}
+	if members, _ := restored.SMembers(ctx, "visitors"); !reflect.DeepEqual(members, expected) {
+		t.Errorf("Expected the set restored from the snapshot, got %v", members)
+	}
+
+	replayed := memory.NewMemoryStore()
+	defer replayed.StopTTLWorker()
+	if err := replayed.ReplayAOF(&aofBuf); err != nil {
+		t.Fatalf("ReplayAOF failed: %v", err)
+	}
+	if members, _ := replayed.SMembers(ctx, "visitors"); !reflect.DeepEqual(members, expected) {
+		t.Errorf("Expected the set replayed from the AOF, got %v", members)
+	}
+}
@@ -0,0 +1,165 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// Approximate per-value overheads in bytes, used by estimateSize.
+const (
+	// entryOverhead covers the map entry, the Value struct and its TTL.
+	entryOverhead = 64
+	// itemOverhead covers the string header of a list item, hash field, set member or
+	// tag.
+	itemOverhead = 16
+)
+
+// estimateSize returns the approximate number of bytes a key and its value take up.
+// It is meant for comparing keys and reporting rough memory use, not exact accounting.
+// Soft deleted values are estimated the same way.
+func estimateSize(key string, v Value) int {
+	size := entryOverhead + len(key) + len(v.Val)
+	for _, item := range v.List {
+		size += itemOverhead + len(item)
+	}
+	for field, val := range v.Hash {
+		size += 2*itemOverhead + len(field) + len(val)
+	}
+	for member := range v.Set {
+		size += itemOverhead + len(member)
+	}
+	for _, tag := range v.Tags {
+		size += itemOverhead + len(tag)
+	}
+	return size
+}
+
+// reservationSize returns the approximate number of bytes an item reserved under
+// receipt takes up while in flight.
+func reservationSize(receipt string, r reservation) int {
+	return entryOverhead + len(receipt) + len(r.key) + itemOverhead + len(r.item)
+}
+
+// reserve checks that storing v at key fits within the key and memory limits. Writes
+// that do not add a key or grow the store always fit. Otherwise, once a limit is
+// reached, the FullReject policy fails the write with ErrTooManyKeys or
+// ErrOutOfMemory, while FullEvict deletes the least recently accessed keys other
+// than key until it fits. Soft deleted values and reserved items count against the
+// memory limit but are never evicted; they are released when their window or
+// visibility timeout ends. The caller must hold the write lock.
+func (s *MemoryStore) reserve(key string, v Value) error {
+	if err := s.reserveKey(key); err != nil {
+		return err
+	}
+
+	if s.maxMemoryBytes <= 0 {
+		return nil
+	}
+
+	growth := int64(estimateSize(key, v))
+	if old, exists := s.data[key]; exists {
+		growth -= int64(estimateSize(key, old))
+	}
+
+	if growth <= 0 || s.usedBytes+growth <= s.maxMemoryBytes {
+		return nil
+	}
+	// A value that cannot fit even in an empty store must not evict everything first.
+	if s.onFull != FullEvict || int64(estimateSize(key, v)) > s.maxMemoryBytes {
+		return ErrOutOfMemory
+	}
+	for s.usedBytes+growth > s.maxMemoryBytes {
+		if !s.evictLRU(key) {
+			return ErrOutOfMemory
+		}
+	}
+	return nil
+}
+
+// reserveKey makes room for key under the key limit, see reserve.
+func (s *MemoryStore) reserveKey(key string) error {
+	if s.maxKeys <= 0 {
+		return nil
+	}
+	if _, exists := s.data[key]; exists || len(s.data) < s.maxKeys {
+		return nil
+	}
+
+	// Expired keys still waiting for the TTL worker do not count against the limit.
+	now := s.clock.Now()
+	for k, v := range s.data {
+		if !v.TTL.IsZero() && now.After(v.TTL) {
+			s.del(k)
+		}
+	}
+
+	for len(s.data) >= s.maxKeys {
+		if s.onFull != FullEvict || !s.evictLRU(key) {
+			return ErrTooManyKeys
+		}
+	}
+	return nil
+}
+
+// evictLRU deletes the least recently accessed key other than keep, and reports
+// whether there was one to delete. The caller must hold the write lock.
+func (s *MemoryStore) evictLRU(keep string) bool {
+	victim, found := s.lru.oldest(keep)
+	if found {
+		s.del(victim)
+	}
+	return found
+}
+
+// checkListItem returns ErrValueTooLarge if item exceeds the list item size limit.
+func (s *MemoryStore) checkListItem(item string) error {
+	if s.maxListItemBytes > 0 && len(item) > s.maxListItemBytes {
+		return ErrValueTooLarge
+	}
+	return nil
+}
+
+// Stats returns runtime statistics of the store: the number of live keys by type and
+// with a TTL, their estimated memory use and the lock contention counters. Keys are
+// counted in a single pass under the read lock. Expired keys the TTL worker has not
+// swept yet are left out, so the counts match what reads see; the memory estimate
+// differs from the one the memory limit is enforced against in that respect.
+func (s *MemoryStore) Stats(ctx context.Context) (store.StoreStats, error) {
+	stats := store.StoreStats{Locks: s.mu.stats()}
+
+	if err := s.rlock(ctx); err != nil {
+		return store.StoreStats{}, err
+	}
+	defer s.mu.RUnlock()
+
+	now := s.clock.Now()
+	scanned := 0
+	for k, v := range s.data {
+		if err := checkCtx(ctx, scanned); err != nil {
+			return store.StoreStats{}, err
+		}
+		scanned++
+		if !v.TTL.IsZero() && now.After(v.TTL) {
+			continue
+		}
+
+		stats.Keys++
+		switch {
+		case v.IsList:
+			stats.Lists++
+		case v.IsHash:
+			stats.Hashes++
+		case v.IsSet:
+			stats.Sets++
+		default:
+			stats.Strings++
+		}
+		if !v.TTL.IsZero() {
+			stats.KeysWithTTL++
+		}
+		stats.MemoryBytes += int64(estimateSize(k, v))
+	}
+
+	return stats, nil
+}
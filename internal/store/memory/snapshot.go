@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// snapshotVersion is the version of the snapshot format written by Snapshot.
+const snapshotVersion = 1
+
+// snapshot is the gob encoded content of a snapshot. Gob keeps compressed values,
+// which are not valid UTF-8, and TTL timestamps intact.
+type snapshot struct {
+	Version      int
+	Entries      []snapshotEntry
+	Reservations []snapshotReservation
+}
+
+type snapshotEntry struct {
+	Key   string
+	Value Value
+}
+
+// snapshotReservation is a reserved item not acknowledged yet, see Reserve.
+type snapshotReservation struct {
+	Receipt  string
+	Key      string
+	Item     string
+	Seq      uint64
+	PushedAt time.Time
+	Until    time.Time
+}
+
+// Snapshot writes every live key of the store, with its value, expiration, list items
+// and flags, to w, along with the reserved items not acknowledged yet. Expired keys
+// are left out. The store is read under a single read
+// lock, so the snapshot is consistent, but it is encoded after the lock is released.
+func (s *MemoryStore) Snapshot(w io.Writer) error {
+	snap := snapshot{Version: snapshotVersion}
+
+	s.mu.RLock()
+	now := s.clock.Now()
+	for key, v := range s.data {
+		if !v.TTL.IsZero() && now.After(v.TTL) {
+			continue
+		}
+		// List, hash and set mutations always build new backing arrays and maps, so
+		// the content can be encoded after the lock is released without copying it.
+		snap.Entries = append(snap.Entries, snapshotEntry{Key: key, Value: v})
+	}
+	for receipt, r := range s.inflight {
+		snap.Reservations = append(snap.Reservations, snapshotReservation{
+			Receipt: receipt, Key: r.key, Item: r.item, Seq: r.seq, PushedAt: r.pushedAt, Until: r.until,
+		})
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(snap.Entries, func(i, j int) bool { return snap.Entries[i].Key < snap.Entries[j].Key })
+	sort.Slice(snap.Reservations, func(i, j int) bool { return snap.Reservations[i].Receipt < snap.Reservations[j].Receipt })
+	return gob.NewEncoder(w).Encode(snap)
+}
+
+// RestoreSnapshot reads a snapshot written by Snapshot from r and sets each of its keys,
+// overwriting keys that already exist and keeping the others. Keys that expired since
+// the snapshot was taken are skipped, the others keep their original expiration. It is
+// named apart from Restore, which brings back a single soft deleted key.
+//
+// The snapshot is decoded in full before the store is changed, so a snapshot that
+// cannot be decoded leaves the store untouched. Each value is checked against the
+// checksum it was saved with; values that do not match are skipped, the other keys
+// are restored, and an error wrapping ErrChecksumMismatch names the skipped keys.
+// Keys are still subject to the key and memory limits; if one does not fit, the keys
+// restored so far are kept and the error is returned.
+//
+// Reserved items are in flight again under their receipts once the keys are restored,
+// and those whose visibility timeout has passed are put back in their list.
+func (s *MemoryStore) RestoreSnapshot(r io.Reader) error {
+	var snap snapshot
+	if err := gob.NewDecoder(r).Decode(&snap); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", snap.Version)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	var corrupt []string
+	for _, e := range snap.Entries {
+		v := e.Value
+		if !v.TTL.IsZero() && now.After(v.TTL) {
+			continue
+		}
+		if sum := checksum(v); sum != v.Checksum {
+			corrupt = append(corrupt, corruption(e.Key, v, sum))
+			continue
+		}
+		if v.IsList && len(v.Seqs) != len(v.List) {
+			v.LastSeq = 0
+			v.Seqs = prependSeqs(&v, len(v.List), nil)
+		}
+		if err := s.reserve(e.Key, v); err != nil {
+			return fmt.Errorf("restore %q: %w", e.Key, err)
+		}
+
+		s.put(e.Key, v)
+	}
+
+	for _, r := range snap.Reservations {
+		s.putInflight(r.Receipt, reservation{key: r.Key, item: r.Item, seq: r.Seq, pushedAt: r.PushedAt, until: r.Until})
+	}
+	s.requeueExpired(now)
+
+	if len(corrupt) > 0 {
+		return fmt.Errorf("%w, skipped %d keys: %s", ErrChecksumMismatch, len(corrupt), strings.Join(corrupt, ", "))
+	}
+	return nil
+}
+
+// ExportPattern returns every live key matching a glob pattern, sorted by key, with its
+// type, value and remaining TTL, e.g. to back up or migrate the keys of one tenant.
+// Unlike Snapshot, the dump is plain JSON friendly data rather than the internal
+// representation: compressed values are decompressed and expirations are relative.
+// It returns ErrInvalidPattern if the pattern is malformed.
+func (s *MemoryStore) ExportPattern(ctx context.Context, pattern string) ([]store.KeyDump, error) {
+	p, err := store.CompilePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	now := s.clock.Now()
+	dumps := []store.KeyDump{}
+	scanned := 0
+	for k, v := range s.data {
+		if err := checkCtx(ctx, scanned); err != nil {
+			return nil, err
+		}
+		scanned++
+		if !v.TTL.IsZero() && now.After(v.TTL) {
+			continue
+		}
+		if p.Match(k) {
+			dumps = append(dumps, entryOf(k, v, now))
+		}
+	}
+	sort.Slice(dumps, func(i, j int) bool { return dumps[i].Key < dumps[j].Key })
+
+	return dumps, nil
+}
@@ -0,0 +1,157 @@
+package memory_test
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	s "github.com/mo-mohamed/acronis-memory-store/internal/store"
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	clock := newFakeClock()
+	store := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock, CompressThreshold: 64})
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	long := strings.Repeat("compressible ", 20)
+	store.Set(ctx, "name", "alice", 0)
+	store.Set(ctx, "count", 42, 0)
+	store.Set(ctx, "big", long, 0)
+	store.Set(ctx, "session", "token", 60, s.Tags("user:1"))
+	store.Set(ctx, "short", "gone", 5)
+	store.LSet(ctx, "queue", []any{"a", "b"}, 0)
+	store.RPush(ctx, "queue", "c")
+
+	var buf bytes.Buffer
+	if err := store.Snapshot(&buf); err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+
+	// The snapshot is restored into a store whose clock has moved on
+	clock.Advance(10 * time.Second)
+	restored := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock})
+	defer restored.StopTTLWorker()
+	if err := restored.RestoreSnapshot(&buf); err != nil {
+		t.Fatalf("RestoreSnapshot failed: %v", err)
+	}
+
+	if got, _ := restored.Get(ctx, "name"); got != "alice" {
+		t.Errorf("Expected alice, got %q", got)
+	}
+	if got, _ := restored.Increment(ctx, "count", 1); got != 43 {
+		t.Errorf("Expected the number to survive as JSON, got %d", got)
+	}
+	if got, _ := restored.Get(ctx, "big"); got != long {
+		t.Errorf("Expected the compressed value intact, got %q", got)
+	}
+	if _, err := restored.Get(ctx, "short"); err != memory.ErrKeyNotFound {
+		t.Errorf("Expected the expired key to be skipped, got %v", err)
+	}
+
+	// Expirations are absolute, so time spent down counts against the TTL
+	if ttl, _ := restored.TTL(ctx, "session"); ttl != 50 {
+		t.Errorf("Expected 50 seconds left, got %d", ttl)
+	}
+	if keys, _ := restored.KeysByTag(ctx, "user:1"); !reflect.DeepEqual(keys, []string{"session"}) {
+		t.Errorf("Expected tags to be restored, got %v", keys)
+	}
+
+	page, _ := restored.LRangePage(ctx, "queue", 0, -1)
+	if !reflect.DeepEqual(page.Items, []string{"a", "b", "c"}) || !reflect.DeepEqual(page.Seqs, []uint64{2, 1, 3}) {
+		t.Errorf("Expected list [a b c] with seqs [2 1 3], got %v %v", page.Items, page.Seqs)
+	}
+	if res, _ := restored.PushItem(ctx, "queue", "d", s.PushOptions{}); res.Seq != 4 {
+		t.Errorf("Expected the sequence to continue at 4, got %d", res.Seq)
+	}
+}
+
+func TestSnapshotReservations(t *testing.T) {
+	clock := newFakeClock()
+	store := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock})
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.RPush(ctx, "jobs", "a")
+	store.RPush(ctx, "jobs", "b")
+	store.RPush(ctx, "jobs", "c")
+	_, pending, _ := store.Reserve(ctx, "jobs", time.Minute)
+	store.Reserve(ctx, "jobs", 10*time.Second)
+
+	var buf bytes.Buffer
+	if err := store.Snapshot(&buf); err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+
+	clock.Advance(20 * time.Second)
+	restored := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock})
+	defer restored.StopTTLWorker()
+	if err := restored.RestoreSnapshot(&buf); err != nil {
+		t.Fatalf("RestoreSnapshot failed: %v", err)
+	}
+
+	if items, _ := restored.LRange(ctx, "jobs", 0, -1); !reflect.DeepEqual(items, []string{"b", "c"}) {
+		t.Errorf("Expected the lapsed item requeued, got %v", items)
+	}
+	if err := restored.Ack(ctx, pending); err != nil {
+		t.Errorf("Expected the pending reservation restored, got %v", err)
+	}
+}
+
+func TestRestoreSnapshotCorrupt(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Set(ctx, "name", "alice", 0)
+	if err := store.RestoreSnapshot(strings.NewReader("not a snapshot")); err == nil {
+		t.Error("Expected an error for a corrupt snapshot")
+	}
+	if got, _ := store.Get(ctx, "name"); got != "alice" {
+		t.Errorf("Expected the store untouched, got %q", got)
+	}
+}
+
+func TestExportPattern(t *testing.T) {
+	clock := newFakeClock()
+	store := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock, CompressThreshold: 64})
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	long := strings.Repeat("compressible ", 20)
+	store.Set(ctx, "tenant:42:name", "acme", 0)
+	store.Set(ctx, "tenant:42:session", "token", 60)
+	store.Set(ctx, "tenant:42:big", long, 0)
+	store.Set(ctx, "tenant:42:short", "gone", 5)
+	store.RPush(ctx, "tenant:42:queue", "a")
+	store.RPush(ctx, "tenant:42:queue", "b")
+	store.Set(ctx, "tenant:7:name", "other", 0)
+	store.Set(ctx, "global", "config", 0)
+
+	clock.Advance(10 * time.Second)
+
+	dump, err := store.ExportPattern(ctx, "tenant:42:*")
+	if err != nil {
+		t.Fatalf("ExportPattern failed: %v", err)
+	}
+	expected := []s.KeyDump{
+		{Key: "tenant:42:big", Type: s.TypeString, Value: long, TTLSeconds: -1},
+		{Key: "tenant:42:name", Type: s.TypeString, Value: "acme", TTLSeconds: -1},
+		{Key: "tenant:42:queue", Type: s.TypeList, Value: []string{"a", "b"}, TTLSeconds: -1},
+		{Key: "tenant:42:session", Type: s.TypeString, Value: "token", TTLSeconds: 50},
+	}
+	if !reflect.DeepEqual(dump, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, dump)
+	}
+
+	if dump, _ := store.ExportPattern(ctx, "tenant:99:*"); dump == nil || len(dump) != 0 {
+		t.Errorf("Expected an empty dump, got %v", dump)
+	}
+	if _, err := store.ExportPattern(ctx, "tenant:[42"); err != memory.ErrInvalidPattern {
+		t.Errorf("Expected ErrInvalidPattern, got %v", err)
+	}
+}
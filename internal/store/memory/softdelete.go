@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// tombstone is a soft deleted value that can be restored until the window closes.
+type tombstone struct {
+	value Value
+	until time.Time
+}
+
+// SoftRemove deletes a key like Remove but keeps its value for the soft delete window,
+// during which Restore brings it back. Soft deleting a key again replaces the kept value.
+func (s *MemoryStore) SoftRemove(ctx context.Context, key string, opts ...store.WriteOption) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	o := store.NewWriteOptions(opts)
+	if err := s.checkFence(key, o); err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+	v, exists := s.data[key]
+	if !exists {
+		return ErrKeyNotFound
+	}
+
+	s.del(key)
+	if !v.TTL.IsZero() && now.After(v.TTL) {
+		return ErrKeyNotFound
+	}
+
+	s.putTombstone(key, tombstone{value: v, until: now.Add(s.softDeleteWindow)})
+	s.recordFence(key, o)
+	return nil
+}
+
+// Restore brings back a soft deleted key with its value and original expiration.
+// It returns ErrKeyNotFound if the key was not soft deleted, the window has closed or
+// the key expired in the meantime, and ErrKeyExists if the key was set again since.
+func (s *MemoryStore) Restore(ctx context.Context, key string, opts ...store.WriteOption) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	o := store.NewWriteOptions(opts)
+	if err := s.checkFence(key, o); err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+	t, ok := s.pendingDelete(key, now)
+	if !ok {
+		return ErrKeyNotFound
+	}
+
+	if v, exists := s.data[key]; exists && (v.TTL.IsZero() || now.Before(v.TTL)) {
+		return ErrKeyExists
+	}
+
+	// The kept value becomes the key again, so it must not count twice.
+	s.dropTombstone(key)
+	if err := s.reserve(key, t.value); err != nil {
+		s.putTombstone(key, t)
+		return err
+	}
+
+	s.put(key, t.value)
+	s.recordFence(key, o)
+	return nil
+}
+
+// pendingDelete returns the soft deleted value of key if it can still be restored,
+// dropping it if the window has closed or the value expired in the meantime.
+// The caller must hold the write lock.
+func (s *MemoryStore) pendingDelete(key string, now time.Time) (tombstone, bool) {
+	t, ok := s.tombstones[key]
+	if !ok {
+		return tombstone{}, false
+	}
+
+	if now.After(t.until) || (!t.value.TTL.IsZero() && now.After(t.value.TTL)) {
+		s.dropTombstone(key)
+		return tombstone{}, false
+	}
+	return t, true
+}
+
+// purgeTombstones hard deletes soft deleted values whose window has closed.
+// The caller must hold the write lock.
+func (s *MemoryStore) purgeTombstones(now time.Time) {
+	for k, t := range s.tombstones {
+		if now.After(t.until) {
+			s.dropTombstone(k)
+		}
+	}
+}
+
+// putTombstone keeps t as the soft deleted value of key, replacing any kept before,
+// and counts it in usedBytes. The caller must hold the write lock.
+func (s *MemoryStore) putTombstone(key string, t tombstone) {
+	s.dropTombstone(key)
+	s.tombstones[key] = t
+	s.usedBytes += int64(estimateSize(key, t.value))
+}
+
+// dropTombstone forgets the soft deleted value of key, if any. The caller must hold
+// the write lock.
+func (s *MemoryStore) dropTombstone(key string) {
+	if t, ok := s.tombstones[key]; ok {
+		s.usedBytes -= int64(estimateSize(key, t.value))
+		delete(s.tombstones, key)
+	}
+}
@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"sort"
+)
+
+// KeysByTag returns the live keys tagged with tag, sorted.
+func (s *MemoryStore) KeysByTag(ctx context.Context, tag string) ([]string, error) {
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	now := s.clock.Now()
+	keys := make([]string, 0, len(s.tags[tag]))
+	for k := range s.tags[tag] {
+		if v := s.data[k]; !v.TTL.IsZero() && now.After(v.TTL) {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// DeleteByTag deletes every key tagged with tag under a single write lock and returns
+// the number of live keys deleted. Expired keys are cleaned up but not counted.
+func (s *MemoryStore) DeleteByTag(ctx context.Context, tag string) (int, error) {
+	if err := s.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.tags[tag]))
+	for k := range s.tags[tag] {
+		keys = append(keys, k)
+	}
+
+	now := s.clock.Now()
+	count := 0
+	for _, k := range keys {
+		if v := s.data[k]; v.TTL.IsZero() || !now.After(v.TTL) {
+			count++
+		}
+		s.del(k)
+	}
+	return count, nil
+}
+
+// uniqueTags returns tags without duplicates, sorted, or nil if there are none.
+func uniqueTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(tags))
+	unique := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if !seen[tag] {
+			seen[tag] = true
+			unique = append(unique, tag)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+// retag moves key in the tag index from the old tags to the new ones. The caller must
+// hold the write lock.
+func (s *MemoryStore) retag(key string, old, new []string) {
+	for _, tag := range old {
+		delete(s.tags[tag], key)
+		if len(s.tags[tag]) == 0 {
+			delete(s.tags, tag)
+		}
+	}
+	for _, tag := range new {
+		if s.tags[tag] == nil {
+			s.tags[tag] = make(map[string]struct{})
+		}
+		s.tags[tag][key] = struct{}{}
+	}
+}
@@ -0,0 +1,105 @@
+package memory_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	s "github.com/mo-mohamed/acronis-memory-store/internal/store"
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+func TestTags(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	user := s.Tags("user:123")
+	store.Set(ctx, "profile:123", "alice", 0, user)
+	store.Set(ctx, "orders:123", "[]", 0, s.Tags("user:123", "orders", "user:123"))
+	store.Set(ctx, "profile:456", "bob", 0, s.Tags("user:456"))
+	store.Set(ctx, "untagged", "value", 0)
+
+	keysByTag := func(tag string) string {
+		keys, err := store.KeysByTag(ctx, tag)
+		if err != nil {
+			t.Fatalf("KeysByTag(%q) failed: %v", tag, err)
+		}
+		return strings.Join(keys, ",")
+	}
+
+	if got := keysByTag("user:123"); got != "orders:123,profile:123" {
+		t.Errorf("Expected both keys of user 123, got %q", got)
+	}
+	if got := keysByTag("orders"); got != "orders:123" {
+		t.Errorf("Expected orders:123 tagged orders, got %q", got)
+	}
+
+	// Updates keep tags, while a plain Set replaces them
+	store.Update(ctx, "profile:123", "alice v2")
+	if got := keysByTag("user:123"); got != "orders:123,profile:123" {
+		t.Errorf("Expected tags kept across Update, got %q", got)
+	}
+	store.Set(ctx, "orders:123", "[1]", 0)
+	if got := keysByTag("orders"); got != "" {
+		t.Errorf("Expected a Set without tags to clear them, got %q", got)
+	}
+
+	// Removing a key drops it from the index
+	store.Set(ctx, "cart:123", "{}", 0, user)
+	store.Remove(ctx, "cart:123")
+	if got := keysByTag("user:123"); got != "profile:123" {
+		t.Errorf("Expected removed keys to leave the index, got %q", got)
+	}
+	store.Set(ctx, "cart:123", "{}", 0)
+	if got := keysByTag("user:123"); got != "profile:123" {
+		t.Errorf("Expected a key set again without tags to stay untagged, got %q", got)
+	}
+
+	store.Set(ctx, "session:123", "token", 0, user)
+	deleted, err := store.DeleteByTag(ctx, "user:123")
+	if err != nil {
+		t.Fatalf("DeleteByTag failed: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("Expected 2 keys deleted, got %d", deleted)
+	}
+	for _, key := range []string{"profile:123", "session:123"} {
+		if _, err := store.Get(ctx, key); err != memory.ErrKeyNotFound {
+			t.Errorf("Expected %s to be deleted, got %v", key, err)
+		}
+	}
+	for _, key := range []string{"profile:456", "orders:123", "cart:123", "untagged"} {
+		if _, err := store.Get(ctx, key); err != nil {
+			t.Errorf("Expected %s to be kept, got %v", key, err)
+		}
+	}
+	if got := keysByTag("user:123"); got != "" {
+		t.Errorf("Expected an empty tag after DeleteByTag, got %q", got)
+	}
+	if deleted, _ := store.DeleteByTag(ctx, "user:123"); deleted != 0 {
+		t.Errorf("Expected nothing left to delete, got %d", deleted)
+	}
+}
+
+func TestTagsExpire(t *testing.T) {
+	clock := newFakeClock()
+	store := memory.NewMemoryStoreWithOptions(memory.Options{Clock: clock})
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	store.Set(ctx, "short", "value", 10, s.Tags("batch"))
+	store.Set(ctx, "long", "value", 100, s.Tags("batch"))
+	clock.Advance(11 * time.Second)
+
+	if keys, _ := store.KeysByTag(ctx, "batch"); strings.Join(keys, ",") != "long" {
+		t.Errorf("Expected expired keys to be left out, got %v", keys)
+	}
+	if deleted, _ := store.DeleteByTag(ctx, "batch"); deleted != 1 {
+		t.Errorf("Expected only the live key counted, got %d", deleted)
+	}
+	if keys, _ := store.KeysByTag(ctx, "batch"); len(keys) != 0 {
+		t.Errorf("Expected the index to be empty, got %v", keys)
+	}
+}
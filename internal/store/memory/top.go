@@ -0,0 +1,156 @@
+package memory
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// TopKeysBySize returns the n largest live keys by estimated size, largest first.
+func (s *MemoryStore) TopKeysBySize(ctx context.Context, n int) ([]store.KeySize, error) {
+	return s.topKeys(ctx, n, func(k store.KeySize) bool { return true }, func(a, b store.KeySize) bool {
+		return a.SizeBytes > b.SizeBytes
+	})
+}
+
+// TopKeysByTTL returns the n live keys with the longest remaining TTL, longest first.
+// Keys that do not expire are not included.
+func (s *MemoryStore) TopKeysByTTL(ctx context.Context, n int) ([]store.KeySize, error) {
+	return s.topKeys(ctx, n, func(k store.KeySize) bool { return k.TTLSeconds >= 0 }, func(a, b store.KeySize) bool {
+		return a.TTLSeconds > b.TTLSeconds
+	})
+}
+
+// TopKeysByAccess returns the n most accessed live keys, most accessed first.
+func (s *MemoryStore) TopKeysByAccess(ctx context.Context, n int) ([]store.KeySize, error) {
+	return s.topKeys(ctx, n, func(k store.KeySize) bool { return true }, func(a, b store.KeySize) bool {
+		return a.Hits > b.Hits
+	})
+}
+
+// KeysInfo returns the size, type, TTL and hits of each of keys, in order, under a
+// single read lock. Missing and expired keys are omitted. Unlike reads, it does not
+// count as an access of the keys.
+func (s *MemoryStore) KeysInfo(ctx context.Context, keys []string) ([]store.KeyInfo, error) {
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	now := s.clock.Now()
+	infos := make([]store.KeyInfo, 0, len(keys))
+	for i, k := range keys {
+		if err := checkCtx(ctx, i); err != nil {
+			return nil, err
+		}
+		v, ok := s.data[k]
+		if !ok || (!v.TTL.IsZero() && now.After(v.TTL)) {
+			continue
+		}
+		infos = append(infos, s.keySize(k, v, now))
+	}
+	return infos, nil
+}
+
+// RandomKeys returns up to n distinct live keys chosen uniformly at random, in no
+// particular order. Like KeysInfo, it does not count as an access of the keys.
+func (s *MemoryStore) RandomKeys(ctx context.Context, n int) ([]store.KeySize, error) {
+	if n <= 0 {
+		return []store.KeySize{}, nil
+	}
+
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	// Reservoir sampling keeps every live key equally likely to be picked without
+	// copying the keyspace.
+	now := s.clock.Now()
+	sample := make([]string, 0, n)
+	seen := 0
+	scanned := 0
+	for k, v := range s.data {
+		if err := checkCtx(ctx, scanned); err != nil {
+			return nil, err
+		}
+		scanned++
+		if !v.TTL.IsZero() && now.After(v.TTL) {
+			continue
+		}
+
+		seen++
+		if len(sample) < n {
+			sample = append(sample, k)
+		} else if i := rand.Intn(seen); i < n {
+			sample[i] = k
+		}
+	}
+
+	keys := make([]store.KeySize, len(sample))
+	for i, k := range sample {
+		keys[i] = s.keySize(k, s.data[k], now)
+	}
+	return keys, nil
+}
+
+// topKeys collects the live keys accepted by keep under a read lock and returns the
+// first n of them ordered by less. Ties are ordered by key.
+func (s *MemoryStore) topKeys(ctx context.Context, n int, keep func(store.KeySize) bool, less func(a, b store.KeySize) bool) ([]store.KeySize, error) {
+	if n <= 0 {
+		return []store.KeySize{}, nil
+	}
+
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	now := s.clock.Now()
+	keys := make([]store.KeySize, 0, len(s.data))
+	scanned := 0
+	for k, v := range s.data {
+		if err := checkCtx(ctx, scanned); err != nil {
+			s.mu.RUnlock()
+			return nil, err
+		}
+		scanned++
+		if !v.TTL.IsZero() && now.After(v.TTL) {
+			continue
+		}
+
+		entry := s.keySize(k, v, now)
+		if keep(entry) {
+			keys = append(keys, entry)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if less(keys[i], keys[j]) {
+			return true
+		}
+		if less(keys[j], keys[i]) {
+			return false
+		}
+		return keys[i].Key < keys[j].Key
+	})
+
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys, nil
+}
+
+// keySize describes the live key k. The caller must hold the lock.
+func (s *MemoryStore) keySize(k string, v Value, now time.Time) store.KeySize {
+	entry := store.KeySize{
+		Key:        k,
+		Type:       v.kind(),
+		SizeBytes:  estimateSize(k, v),
+		TTLSeconds: remainingTTLSeconds(v, now),
+		Hits:       s.hits(k),
+	}
+	return entry
+}
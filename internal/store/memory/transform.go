@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// TransformFunc computes the new value of a string key from its current value. exists
+// is false if the key is missing or expired, in which case old is empty. Returning
+// remove deletes the key instead of storing newVal. Returning an error leaves the key
+// unchanged.
+type TransformFunc func(old string, exists bool) (newVal string, remove bool, err error)
+
+// Transform atomically reads, modifies and writes a string key: fn runs while the store
+// write lock is held, so no other operation observes or changes the key in between.
+// This lets embedded users build compound operations, such as increments or
+// conditional deletes, without a dedicated store method. fn must be fast and must not
+// call back into the store, which would deadlock.
+//
+// An existing key keeps its TTL; a key created by fn gets the default TTL of its prefix.
+// Transform returns ErrTypeMismatch for keys not holding a string and the error of fn
+// if it fails.
+func (s *MemoryStore) Transform(ctx context.Context, key string, fn TransformFunc, opts ...store.WriteOption) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	o := store.NewWriteOptions(opts)
+	if err := s.checkFence(key, o); err != nil {
+		return err
+	}
+
+	v, err := s.liveString(key)
+	exists := err == nil
+	if err != nil && err != ErrKeyNotFound {
+		return err
+	}
+
+	newVal, remove, err := fn(v.text(), exists)
+	if err != nil {
+		return err
+	}
+
+	if remove {
+		if exists {
+			s.del(key)
+			s.recordFence(key, o)
+		}
+		return nil
+	}
+
+	if !exists {
+		v.TTL = s.ttlFromSeconds(s.defaultTTL(key, 0))
+	}
+	v.Val, v.Compressed = s.encode(newVal, false)
+	v.IsJSON = false
+	if err := s.reserve(key, v); err != nil {
+		return err
+	}
+
+	s.put(key, v)
+	s.recordFence(key, o)
+	s.touch(key, s.clock.Now())
+	return nil
+}
@@ -0,0 +1,138 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+// incr increments a counter key, treating a missing key as 0.
+func incr(old string, exists bool) (string, bool, error) {
+	n := 0
+	if exists {
+		var err error
+		if n, err = strconv.Atoi(old); err != nil {
+			return "", false, err
+		}
+	}
+	return strconv.Itoa(n + 1), false, nil
+}
+
+func TestTransform(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("increment", func(t *testing.T) {
+		store := memory.NewMemoryStore()
+		defer store.StopTTLWorker()
+
+		for i := 0; i < 3; i++ {
+			if err := store.Transform(ctx, "counter", incr); err != nil {
+				t.Fatalf("Transform failed: %v", err)
+			}
+		}
+		if value, _ := store.Get(ctx, "counter"); value != "3" {
+			t.Errorf("Expected '3', got %q", value)
+		}
+
+		store.Set(ctx, "counter", "abc", 0)
+		if err := store.Transform(ctx, "counter", incr); err == nil {
+			t.Error("Expected the error of the function")
+		}
+		if value, _ := store.Get(ctx, "counter"); value != "abc" {
+			t.Errorf("Expected a failed transform to leave 'abc', got %q", value)
+		}
+	})
+
+	t.Run("conditional delete", func(t *testing.T) {
+		store := memory.NewMemoryStore()
+		defer store.StopTTLWorker()
+
+		deleteIfDone := func(old string, exists bool) (string, bool, error) {
+			return old, exists && old == "done", nil
+		}
+
+		store.Set(ctx, "job", "running", 0)
+		if err := store.Transform(ctx, "job", deleteIfDone); err != nil {
+			t.Fatalf("Transform failed: %v", err)
+		}
+		if value, _ := store.Get(ctx, "job"); value != "running" {
+			t.Errorf("Expected 'running' to be kept, got %q", value)
+		}
+
+		store.Set(ctx, "job", "done", 0)
+		if err := store.Transform(ctx, "job", deleteIfDone); err != nil {
+			t.Fatalf("Transform failed: %v", err)
+		}
+		if _, err := store.Get(ctx, "job"); !errors.Is(err, memory.ErrKeyNotFound) {
+			t.Errorf("Expected 'done' to be deleted, got %v", err)
+		}
+
+		remove := func(old string, exists bool) (string, bool, error) { return "", true, nil }
+		if err := store.Transform(ctx, "missing", remove); err != nil {
+			t.Errorf("Expected deleting a missing key to succeed, got %v", err)
+		}
+	})
+
+	t.Run("append keeps TTL", func(t *testing.T) {
+		store := memory.NewMemoryStore()
+		defer store.StopTTLWorker()
+
+		appendBar := func(old string, exists bool) (string, bool, error) {
+			return old + "bar", false, nil
+		}
+
+		store.Set(ctx, "greeting", "foo", 60)
+		if err := store.Transform(ctx, "greeting", appendBar); err != nil {
+			t.Fatalf("Transform failed: %v", err)
+		}
+
+		entries, _ := store.GetEntries(ctx, []string{"greeting"})
+		if entries[0].Value != "foobar" {
+			t.Errorf("Expected 'foobar', got %v", entries[0].Value)
+		}
+		if entries[0].TTLSeconds <= 0 {
+			t.Errorf("Expected the TTL to be kept, got %d", entries[0].TTLSeconds)
+		}
+	})
+
+	t.Run("list key", func(t *testing.T) {
+		store := memory.NewMemoryStore()
+		defer store.StopTTLWorker()
+
+		store.Push(ctx, "queue", "a")
+		if err := store.Transform(ctx, "queue", incr); !errors.Is(err, memory.ErrTypeMismatch) {
+			t.Errorf("Expected ErrTypeMismatch, got %v", err)
+		}
+	})
+}
+
+func TestTransform_Concurrent(t *testing.T) {
+	store := memory.NewMemoryStore()
+	defer store.StopTTLWorker()
+	ctx := context.Background()
+
+	const workers, increments = 10, 100
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < increments; j++ {
+				if err := store.Transform(ctx, "counter", incr); err != nil {
+					t.Errorf("Transform failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if value, _ := store.Get(ctx, "counter"); value != strconv.Itoa(workers*increments) {
+		t.Errorf("Expected %d, got %s", workers*increments, value)
+	}
+}
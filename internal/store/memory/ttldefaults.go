@@ -0,0 +1,32 @@
+package memory
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
+
+// defaultTTL returns ttlSeconds, or the default TTL for key if ttlSeconds is 0.
+func (s *MemoryStore) defaultTTL(key string, ttlSeconds int) int {
+	if ttlSeconds != 0 {
+		return ttlSeconds
+	}
+
+	for _, rule := range s.ttlDefaults.Rules {
+		if strings.HasPrefix(key, rule.Prefix) {
+			return rule.TTLSeconds
+		}
+	}
+	return s.ttlDefaults.FallbackSeconds
+}
+
+// Config returns the configuration the store is running with.
+func (s *MemoryStore) Config(ctx context.Context) (store.StoreConfig, error) {
+	rules := make([]store.TTLRule, len(s.ttlDefaults.Rules))
+	copy(rules, s.ttlDefaults.Rules)
+
+	return store.StoreConfig{
+		TTLDefaults: store.TTLDefaults{Rules: rules, FallbackSeconds: s.ttlDefaults.FallbackSeconds},
+	}, nil
+}
@@ -1,10 +1,61 @@
 package memory
 
-import "time"
+import (
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/store"
+)
 
 type Value struct {
 	Val    string
 	TTL    time.Time
 	IsList bool
 	List   []string
+	// Seqs holds the sequence number of each item of List, and LastSeq the one most
+	// recently assigned, see store.PushResult.
+	Seqs    []uint64
+	LastSeq uint64
+	// Head is the position of the first item of List. It goes down with each push to
+	// the head and up with each pop from it, so every item keeps its position while it
+	// is in the list, see checksum.
+	Head int64
+	// PushedAt holds when each item of List was pushed if the store records push
+	// times, see Options.ListPushTimes, and is nil otherwise.
+	PushedAt []time.Time
+	IsHash   bool
+	// Hash holds the fields of a hash. Writes replace the map rather than change it,
+	// so a Value read under the lock can be used after the lock is released.
+	Hash  map[string]string
+	IsSet bool
+	// Set holds the members of a set, replaced rather than changed on writes like Hash.
+	Set map[string]struct{}
+	// IsJSON reports whether Val holds the JSON encoding of a value that was not a
+	// string, such as a number or an object, see GetRaw.
+	IsJSON bool
+	// Compressed reports whether Val holds the gzip compressed string value.
+	Compressed bool
+	// Tags are the tags the key was set with, sorted and without duplicates.
+	Tags []string
+	// Checksum is the checksum of Val, List, Hash or Set as of the last write, see
+	// VerifyIntegrity.
+	Checksum uint32
+}
+
+// kind returns the type of v, store.TypeString, store.TypeList, store.TypeHash or
+// store.TypeSet.
+func (v Value) kind() string {
+	switch {
+	case v.IsList:
+		return store.TypeList
+	case v.IsHash:
+		return store.TypeHash
+	case v.IsSet:
+		return store.TypeSet
+	}
+	return store.TypeString
+}
+
+// isString reports whether v holds a string rather than a list, hash or set.
+func (v Value) isString() bool {
+	return !v.IsList && !v.IsHash && !v.IsSet
 }
@@ -0,0 +1,150 @@
+package store
+
+// Pattern is a compiled glob pattern matched against keys. Unlike path.Match,
+// '/' has no special meaning, since keys are not paths. Supported syntax:
+//
+//	'*'      matches any sequence of characters, including none
+//	'?'      matches any single character
+//	'[abc]'  matches one of the listed characters; '[a-z]' matches a range and
+//	         '[^abc]' or '[!abc]' negates the class
+//	'\x'     matches x literally
+type Pattern []rune
+
+// CompilePattern returns the Pattern for pattern, or ErrInvalidPattern if it is malformed.
+func CompilePattern(pattern string) (Pattern, error) {
+	if err := validatePattern(pattern); err != nil {
+		return nil, err
+	}
+	return Pattern(pattern), nil
+}
+
+// Match reports whether key matches the pattern.
+func (p Pattern) Match(key string) bool {
+	return globMatch(p, []rune(key))
+}
+
+// validatePattern checks that every character class is closed and no escape is dangling.
+func validatePattern(pattern string) error {
+	p := []rune(pattern)
+	for i := 0; i < len(p); i++ {
+		switch p[i] {
+		case '\\':
+			if i+1 >= len(p) {
+				return ErrInvalidPattern
+			}
+			i++
+		case '[':
+			end := classEnd(p, i)
+			if end < 0 {
+				return ErrInvalidPattern
+			}
+			i = end
+		}
+	}
+	return nil
+}
+
+// globMatch matches key against a validated pattern, backtracking to the last '*'
+// on mismatch so it runs in O(len(pattern) * len(key)).
+func globMatch(p, k []rune) bool {
+	pi, ki := 0, 0
+	starP, starK := -1, 0
+
+	for ki < len(k) {
+		if pi < len(p) {
+			switch p[pi] {
+			case '*':
+				starP, starK = pi, ki
+				pi++
+				continue
+			case '?':
+				pi++
+				ki++
+				continue
+			case '[':
+				end := classEnd(p, pi)
+				if matchClass(p[pi+1:end], k[ki]) {
+					pi = end + 1
+					ki++
+					continue
+				}
+			case '\\':
+				if p[pi+1] == k[ki] {
+					pi += 2
+					ki++
+					continue
+				}
+			default:
+				if p[pi] == k[ki] {
+					pi++
+					ki++
+					continue
+				}
+			}
+		}
+
+		if starP < 0 {
+			return false
+		}
+		starK++
+		pi, ki = starP+1, starK
+	}
+
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+// classEnd returns the index of the ']' closing the class opened at p[start], or -1.
+// A ']' right after the opening bracket or negation is taken literally.
+func classEnd(p []rune, start int) int {
+	i := start + 1
+	if i < len(p) && (p[i] == '^' || p[i] == '!') {
+		i++
+	}
+	if i < len(p) && p[i] == ']' {
+		i++
+	}
+	for ; i < len(p); i++ {
+		switch p[i] {
+		case '\\':
+			i++
+		case ']':
+			return i
+		}
+	}
+	return -1
+}
+
+// matchClass reports whether c matches the body of a character class.
+func matchClass(class []rune, c rune) bool {
+	negate := false
+	if len(class) > 0 && (class[0] == '^' || class[0] == '!') {
+		negate = true
+		class = class[1:]
+	}
+
+	matched := false
+	for i := 0; i < len(class); i++ {
+		lo := class[i]
+		if lo == '\\' && i+1 < len(class) {
+			i++
+			lo = class[i]
+		}
+		hi := lo
+		if i+2 < len(class) && class[i+1] == '-' {
+			hi = class[i+2]
+			if hi == '\\' && i+3 < len(class) {
+				hi = class[i+3]
+				i++
+			}
+			i += 2
+		}
+		if lo <= c && c <= hi {
+			matched = true
+		}
+	}
+
+	return matched != negate
+}
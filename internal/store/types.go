@@ -0,0 +1,244 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DepthSample is the length of a list observed at a point in time.
+type DepthSample struct {
+	Time  time.Time `json:"time"`
+	Depth int       `json:"depth"`
+}
+
+// Key types reported by the store.
+const (
+	TypeString = "string"
+	TypeList   = "list"
+	TypeHash   = "hash"
+	TypeSet    = "set"
+	// TypeNone is reported for keys that do not exist.
+	TypeNone = "none"
+)
+
+// KeyEntry is a typed snapshot of a key. Value holds a string for string keys, a
+// []string for list keys, a map[string]string for hash keys and the sorted members as
+// a []string for set keys, and is nil for missing keys.
+type KeyEntry struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	Value any    `json:"value,omitempty"`
+	// TTLSeconds is the remaining time to live rounded up, or -1 if the key does not expire.
+	TTLSeconds int `json:"ttl_seconds,omitempty"`
+}
+
+// ListPage is a range of list items along with the length of the whole list.
+// Start and Stop are the resolved indexes of the first and last item returned;
+// Stop is Start-1 when the range is empty. For reversed ranges they index the list
+// tail first.
+type ListPage struct {
+	Items []string `json:"items"`
+	// Seqs are the sequence numbers of Items, see PushResult.
+	Seqs []uint64 `json:"seqs"`
+	// PushedAt are the push times of Items, only read by LRangeWithMeta.
+	PushedAt []time.Time `json:"-"`
+	Total    int         `json:"total"`
+	Start    int         `json:"start"`
+	Stop     int         `json:"stop"`
+}
+
+// PushOptions configures PushItem.
+type PushOptions struct {
+	// Tail adds the item to the end of the list instead of its front.
+	Tail bool
+	// Resurrect restores a list pending soft delete and pushes onto it, see PushResurrect.
+	Resurrect bool
+	// MaxLen trims the list to MaxLen items from the other end after the push.
+	// 0 leaves the list uncapped.
+	MaxLen int
+}
+
+// PushResult is the outcome of PushItem.
+type PushResult struct {
+	// Seq is the sequence number assigned to the pushed item. Each list numbers its
+	// items from 1 in the order they are added, so consumers can detect gaps or
+	// reordering. The numbering starts over when the list is deleted or replaced.
+	Seq uint64 `json:"seq"`
+	// Evicted are the items trimmed by MaxLen, in list order.
+	Evicted []string `json:"evicted,omitempty"`
+}
+
+// ListItem is a list item along with its sequence number, see PushResult.
+type ListItem struct {
+	Value string `json:"value"`
+	Seq   uint64 `json:"seq"`
+	// PushedAt is when the item was pushed, zero unless the store records push times.
+	PushedAt time.Time `json:"pushed_at"`
+}
+
+// LockStats reports contention on the store lock. Counters only advance while
+// lock metrics are enabled. Waits are cumulative over all contended acquisitions.
+type LockStats struct {
+	Enabled          bool          `json:"enabled"`
+	WriteContentions uint64        `json:"write_contentions"`
+	WriteWait        time.Duration `json:"write_wait_ns"`
+	ReadContentions  uint64        `json:"read_contentions"`
+	ReadWait         time.Duration `json:"read_wait_ns"`
+	// MaxWriteHold is the longest the write lock was held at once.
+	MaxWriteHold time.Duration `json:"max_write_hold_ns"`
+}
+
+// StoreStats holds runtime statistics of a store. Key counts and the memory estimate
+// only cover live keys, so keys that expired but were not swept yet are left out.
+type StoreStats struct {
+	Keys        int `json:"keys"`
+	Strings     int `json:"strings"`
+	Lists       int `json:"lists"`
+	Hashes      int `json:"hashes"`
+	Sets        int `json:"sets"`
+	KeysWithTTL int `json:"keys_with_ttl"`
+	// MemoryBytes is the estimated memory taken by the keys and their values, see KeySize.
+	MemoryBytes int64     `json:"memory_bytes"`
+	Locks       LockStats `json:"locks"`
+}
+
+// KeySize describes a live key as reported by the top keys queries.
+type KeySize struct {
+	Key  string `json:"key"`
+	Type string `json:"type"`
+	// SizeBytes is the estimated memory taken by the key and its value.
+	SizeBytes int `json:"size_bytes"`
+	// TTLSeconds is the remaining time to live rounded up, or -1 if the key does not expire.
+	TTLSeconds int `json:"ttl_seconds"`
+	// Hits is the number of reads and writes of the key since it was created.
+	Hits uint64 `json:"hits"`
+}
+
+// KeyInfo describes a live key as reported by KeysInfo, with the same fields as the
+// top keys queries.
+type KeyInfo = KeySize
+
+// KeyDump is a live key as exported by ExportPattern, with its type, value and
+// remaining TTL, in the same form as KeyEntry.
+type KeyDump = KeyEntry
+
+// WorkerHealth is the status of a background worker, such as the TTL worker.
+// A worker is healthy unless its last run failed.
+type WorkerHealth struct {
+	Name    string    `json:"name"`
+	Healthy bool      `json:"healthy"`
+	LastRun time.Time `json:"last_run"`
+	// LastError and LastErrorAt describe the most recent failure, even if the worker has recovered since.
+	LastError           string     `json:"last_error,omitempty"`
+	LastErrorAt         *time.Time `json:"last_error_at,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+}
+
+// TTLRule sets the default TTL of keys starting with Prefix.
+type TTLRule struct {
+	Prefix     string `json:"prefix"`
+	TTLSeconds int    `json:"ttl_seconds"`
+}
+
+// TTLDefaults maps key prefixes to the TTL applied when a key is set without one.
+// Rules are evaluated in order and the first matching prefix wins; keys matching
+// no rule get FallbackSeconds. A TTL of 0 means the key does not expire.
+type TTLDefaults struct {
+	Rules           []TTLRule `json:"rules"`
+	FallbackSeconds int       `json:"fallback_seconds"`
+}
+
+// StoreConfig is the configuration a store is running with.
+type StoreConfig struct {
+	TTLDefaults TTLDefaults `json:"ttl_defaults"`
+}
+
+// Operations accepted by list batches.
+const (
+	ListOpPush = "push"
+	ListOpPop  = "pop"
+)
+
+// ListOp is a single operation of a list batch. Item is only used by pushes.
+type ListOp struct {
+	Key  string `json:"key"`
+	Op   string `json:"op"`
+	Item any    `json:"item,omitempty"`
+}
+
+// ListOpResult is the outcome of a ListOp. Err is nil if the operation succeeded,
+// in which case Item holds the popped item for pops.
+type ListOpResult struct {
+	Item string
+	Err  error
+}
+
+// ReadSpec is a single read of a read pipeline. Without Index it reads the string
+// value of Key; with Index it reads the list item at that index, negative indexes
+// counting from the tail.
+type ReadSpec struct {
+	Key   string `json:"key"`
+	Index *int   `json:"index,omitempty"`
+}
+
+// ReadResult is the outcome of a ReadSpec. Err is nil if the read succeeded, in which
+// case Value holds the string value or list item read.
+type ReadResult struct {
+	Value string
+	Err   error
+}
+
+// Labels of TTL histogram buckets other than the configured durations, which are
+// labeled by their time.Duration string, e.g. "1h0m0s".
+const (
+	// TTLBucketLonger counts keys expiring after the largest bucket.
+	TTLBucketLonger = "longer"
+	// TTLBucketNever counts keys without a TTL.
+	TTLBucketNever = "never"
+)
+
+// Types of keyspace events.
+const (
+	// EventSet is recorded when a key is written, including list changes and TTL updates.
+	EventSet = "set"
+	// EventDel is recorded when a key is removed, expired or evicted.
+	EventDel = "del"
+)
+
+// KeyEvent is a change to the keyspace. Seq numbers events in the order they happened,
+// starting at 1.
+type KeyEvent struct {
+	Seq  uint64    `json:"seq"`
+	Time time.Time `json:"time"`
+	Type string    `json:"type"`
+	Key  string    `json:"key"`
+}
+
+// EventPage is the buffered keyspace events after a cursor.
+type EventPage struct {
+	Events []KeyEvent
+	// Gap reports that events after the cursor were dropped from the buffer before
+	// they were read, or that the cursor is unknown. Events then starts at the oldest
+	// buffered event.
+	Gap bool
+	// Next is closed once an event newer than Events is recorded.
+	Next <-chan struct{}
+}
+
+// Message is a payload published on a pub/sub channel.
+type Message struct {
+	Channel string          `json:"channel"`
+	Payload json.RawMessage `json:"payload"`
+	Time    time.Time       `json:"time"`
+}
+
+// KeyspaceChannelPrefix prefixes the key in the channel keyspace notifications are
+// published on, e.g. "__keyspace__:user:1".
+const KeyspaceChannelPrefix = "__keyspace__:"
+
+// KeyspaceNotification is the payload of a keyspace notification, published when a key
+// is written (EventSet) or removed (EventDel).
+type KeyspaceNotification struct {
+	Event string `json:"event"`
+	Key   string `json:"key"`
+}
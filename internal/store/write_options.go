@@ -0,0 +1,55 @@
+package store
+
+// WriteOption configures an individual write, such as Set or Update. Each option
+// documents the writes it applies to; the others ignore it.
+type WriteOption func(*WriteOptions)
+
+// WriteOptions are the options of a write, collected from its WriteOption arguments by
+// NewWriteOptions.
+type WriteOptions struct {
+	// NoCompress stores the value as is, see NoCompress.
+	NoCompress bool
+	// Tags are the tags of the key, see Tags.
+	Tags []string
+	// FenceToken is the fence token of the write, if Fenced, see Fence.
+	FenceToken uint64
+	Fenced     bool
+}
+
+// NewWriteOptions applies opts, in order, to zero WriteOptions.
+func NewWriteOptions(opts []WriteOption) WriteOptions {
+	var o WriteOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// NoCompress makes a write store its value as is, even if the store compresses values
+// of its size. It suits values known to be incompressible, such as random tokens, where
+// compressing only wastes CPU. It applies to Set, its conditional variants and Update.
+func NoCompress() WriteOption {
+	return func(o *WriteOptions) {
+		o.NoCompress = true
+	}
+}
+
+// Tags makes a write tag the key with tags, replacing any tags it had. Tagged keys can
+// be listed and deleted together, see KeysByTag and DeleteByTag. It applies to Set and
+// its conditional variants; a Set without tags clears the key's tags, while Update
+// keeps them.
+func Tags(tags ...string) WriteOption {
+	return func(o *WriteOptions) {
+		o.Tags = append(o.Tags, tags...)
+	}
+}
+
+// Fence makes a write carry a fence token. Stores reject the write with ErrStaleFence
+// if a higher token was already seen for the key, so a client that lost a lock cannot
+// overwrite the new holder's data. It applies to every write to a key.
+func Fence(token uint64) WriteOption {
+	return func(o *WriteOptions) {
+		o.FenceToken = token
+		o.Fenced = true
+	}
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrTimeout is returned by BLPop when no item was pushed before its timeout.
+var ErrTimeout = errors.New("timed out waiting for an item")
+
+// BLPop takes the item at the front of a list like Pop, waiting up to timeout for one to
+// be pushed if the list is empty or missing. It returns ErrTimeout if none was, and the
+// context's error if ctx is done first. The timeout must be positive, and servers
+// reject timeouts over 5 minutes with a 400 APIError. Keys not holding a list fail with
+// a 409 APIError, and servers that do not support blocking pops with
+// ErrUnsupportedOperation.
+//
+// The request is held open by the server while it waits, so BLPop extends the
+// client's HTTP timeout by timeout for this request. It always waits on the server:
+// with WithLocalFallback, an outage fails BLPop rather than waiting on the local copy.
+//
+// Example:
+//
+//	// Process tasks as they are queued
+//	for {
+//	    task, err := client.BLPop(ctx, "queue:tasks", 30*time.Second)
+//	    if errors.Is(err, client.ErrTimeout) {
+//	        continue
+//	    }
+//	    if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    process(task)
+//	}
+func (c *Client) BLPop(ctx context.Context, key string, timeout time.Duration, opts ...WriteOption) (string, error) {
+	if timeout <= 0 {
+		return "", fmt.Errorf("timeout must be positive")
+	}
+
+	req := BLPopRequest{
+		Key:            key,
+		TimeoutSeconds: timeout.Seconds(),
+	}
+
+	header := newWriteOptions(opts).header()
+	resp, err := c.send(withLongPoll(ctx, timeout), "POST", "/api/v1/lists/blpop", req, header)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusRequestTimeout {
+		return "", ErrTimeout
+	}
+	if err != nil {
+		return "", c.unsupported(ctx, OpBLPop, err)
+	}
+
+	var data struct {
+		Value string `json:"value"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return "", err
+	}
+
+	// Keep the local copy in step with the server, like Pop does.
+	if c.fallback != nil {
+		c.fallback.local(ctx, "POST", "/api/v1/lists/pop", PopRequest{Key: key}, header)
+	}
+
+	return data.Value, nil
+}
+
+// longPollKey is the context key of the time a request is expected to be held open
+// by the server, see withLongPoll.
+type longPollKey struct{}
+
+// withLongPoll returns a context for a request the server holds open for up to wait
+// before responding.
+func withLongPoll(ctx context.Context, wait time.Duration) context.Context {
+	return context.WithValue(ctx, longPollKey{}, wait)
+}
+
+// httpClientFor returns the HTTP client to send a request with ctx. Long polls get a
+// copy of the client's HTTP client whose timeout is extended by the expected wait, so
+// the usual timeout still applies to everything but the wait.
+func (c *Client) httpClientFor(ctx context.Context) *http.Client {
+	wait, ok := ctx.Value(longPollKey{}).(time.Duration)
+	if !ok || c.httpClient.Timeout == 0 {
+		return c.httpClient
+	}
+
+	longPoll := *c.httpClient
+	longPoll.Timeout += wait
+	return &longPoll
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// ErrUnsupportedOperation is returned for operations the server does not support,
+// typically because it runs an older version. It is wrapped with the name of the
+// operation.
+var ErrUnsupportedOperation = errors.New("operation not supported by the server")
+
+// Operations a server may advertise, see Capabilities.
+const (
+	OpIncr          = "incr"
+	OpIncrCeiling   = "incr_ceiling"
+	OpDecr          = "decr"
+	OpDecrFloor     = "decr_floor"
+	OpGetSet        = "getset"
+	OpSetNX         = "setnx"
+	OpSetIfType     = "set_if_type"
+	OpListBatch     = "list_batch"
+	OpPipelineGet   = "pipeline_get"
+	OpRateIncr      = "rate_incr"
+	OpReserve       = "reserve"
+	OpExport        = "export"
+	OpTTLWorkerCtrl = "ttl_worker_control"
+	OpFlush         = "flush"
+	OpMSetChunked   = "mset_chunked"
+	OpVerify        = "verify"
+	OpDeleteMatch   = "delete_matching"
+	OpHashes        = "hashes"
+	OpSets          = "sets"
+	OpBLPop         = "blpop"
+	OpPubSub        = "pubsub"
+)
+
+// Capabilities returns the operations the server supports, such as OpIncr. The list
+// is fetched once and cached for the life of the client. Servers older than the
+// capabilities endpoint advertise nothing, so an empty list is returned for them.
+//
+// Example:
+//
+//	ops, err := client.Capabilities(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println("server supports", ops)
+func (c *Client) Capabilities(ctx context.Context) ([]string, error) {
+	c.capMu.Lock()
+	defer c.capMu.Unlock()
+
+	if c.capabilities != nil {
+		return slices.Clone(c.capabilities), nil
+	}
+
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/capabilities", nil)
+	if err != nil {
+		if !routeMissing(err) {
+			return nil, err
+		}
+		c.capabilities = []string{}
+		return []string{}, nil
+	}
+
+	var data struct {
+		Operations []string `json:"operations"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return nil, err
+	}
+	if data.Operations == nil {
+		data.Operations = []string{}
+	}
+
+	c.capabilities = data.Operations
+	return slices.Clone(c.capabilities), nil
+}
+
+// Supports reports whether the server supports op, one of the Op constants. Use it to
+// branch before calling an operation newer servers added.
+//
+// Example:
+//
+//	ok, err := client.Supports(ctx, client.OpIncr)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if !ok {
+//	    // fall back to a read-modify-write with GetSet
+//	}
+func (c *Client) Supports(ctx context.Context, op string) (bool, error) {
+	ops, err := c.Capabilities(ctx)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(ops, op), nil
+}
+
+// require fails with ErrUnsupportedOperation unless the server advertises op. It
+// guards operations an older server would not reject but silently get wrong, such
+// as a decrement ignoring its floor.
+func (c *Client) require(ctx context.Context, op string) error {
+	ok, err := c.Supports(ctx, op)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s: %w", op, ErrUnsupportedOperation)
+	}
+	return nil
+}
+
+// unsupported turns err, the failure of an op request, into ErrUnsupportedOperation
+// if it is a 404 or 405 from a server that does not advertise op. Other errors,
+// including a 404 for a missing key from a server that supports op, are returned
+// unchanged.
+func (c *Client) unsupported(ctx context.Context, op string, err error) error {
+	if !routeMissing(err) {
+		return err
+	}
+	if ok, capErr := c.Supports(ctx, op); capErr != nil || ok {
+		return err
+	}
+	return fmt.Errorf("%s: %w", op, ErrUnsupportedOperation)
+}
+
+// routeMissing reports whether err is the 404 or 405 a server answers for an
+// endpoint it does not have.
+func routeMissing(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusMethodNotAllowed
+}
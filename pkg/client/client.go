@@ -1,12 +1,87 @@
 // Package client provides a Go client library for the Memory Store API.
 //
-// The client supports all core operations for managing strings and lists with TTL:
+// The client supports all core operations for managing strings, lists, hashes and sets
+// with TTL:
 //   - Set: Store key-value pairs with required TTL
+//   - SetNX: Store a key only if it does not exist
+//   - SetNXFenced: SetNX returning a fence token, see Fence
+//   - SetIfExpiringWithin: Store a key only if it is missing or about to expire
+//   - SetIfType: Store a key only if it is missing or holds the expected type
 //   - Get: Retrieve values by key
+//   - GetOrDefault: Retrieve a value, or a default if the key does not exist
+//   - GetRaw: Retrieve a value in its original JSON structure
+//   - GetInto: Retrieve a value into a Go value
+//   - Exists: Check whether a key exists without fetching its value
+//   - WaitForKey: Wait until a key exists and return its value
+//   - GetAny: Retrieve a key of any type with its type
+//   - MultiGet: Retrieve several keys of any type with their TTLs
+//   - MSet: Store several key-value pairs in one request
+//   - MSetChunked: Store a large batch of pairs a chunk at a time
+//   - MGet: Retrieve the values of several string keys in one request
+//   - KeysInfo: Retrieve the size, type, TTL and hits of several keys
 //   - Update: Modify existing key values
+//   - GetSet: Replace a key's value and return the previous one
 //   - Remove: Delete keys
+//   - Restore: Recover a soft deleted key
+//   - TTL: Get the remaining TTL of a key
+//   - Expire: Change the TTL of an existing key
+//   - ExpireFenced: Expire returning a fence token
+//   - Persist: Remove the expiration of a key
+//   - ExpirePattern: Change the TTL of all keys matching a pattern
+//   - KeysByTag: List the keys tagged with a tag
+//   - DeleteByTag: Delete all keys tagged with a tag
+//   - CountPattern: Count the keys matching a pattern
+//   - DeleteExpiringWithin: Delete the keys about to expire
+//   - DeleteMatching: Delete the keys matching a pattern
+//   - FlushAll: Delete every key, on servers started with ENABLE_FLUSH
+//   - Increment: Atomically add to an integer key
+//   - Decrement: Atomically subtract from an integer key
+//   - DecrWithFloor: Decrement an integer key without going below a floor
+//   - IncrWithCeiling: Increment an integer key without going above a ceiling
 //   - Push: Add items to lists (LPUSH)
+//   - PushSeq: Push an item and return its sequence number
+//   - PushCappedReturn: Push to a bounded list and return the items it overflowed
+//   - RPush: Add items to the end of lists (RPUSH)
+//   - RPushSeq: Push an item to the end of a list and return its sequence number
+//   - LSet: Replace a list with the given items
+//   - LInitNX: Create a list with initial items only if it does not exist
+//   - LMoveAll: Move all items of a list onto the front of another
 //   - Pop: Remove and return items from lists (LPOP)
+//   - PopJSON: Pop a list item into a Go value
+//   - RPop: Remove and return items from the end of lists (RPOP)
+//   - PopItem, RPopItem: Pop an item along with its sequence number
+//   - BLPop: Pop an item, waiting for one to be pushed if the list is empty
+//   - LRange: Read a range of list items
+//   - LRangePage: Read a range of list items with the list length, for paging
+//   - LRangeJSON: Read a range of list items into a Go slice
+//   - LRangeWithMeta: Read a range of list items with their sequence numbers and push times
+//   - LTrim: Trim a list to a range of its items
+//   - LTrimReturn: Trim a list and return the removed items
+//   - LClear: Remove every item of a list, keeping the key and its TTL
+//   - HSet: Set a field of a hash
+//   - HGet: Retrieve a field of a hash
+//   - HDel: Remove a field from a hash
+//   - HGetAll: Retrieve every field of a hash
+//   - SAdd: Add members to a set
+//   - SRem: Remove members from a set
+//   - SMembers: Retrieve the members of a set
+//   - SIsMember: Check whether a value is a member of a set
+//   - SCard: Count the members of a set
+//   - ListBatch: Push to and pop from many lists in one request
+//   - PipelineGet: Read strings and list items of many keys consistently in one request
+//   - Reserve: Take a list item that is redelivered unless acknowledged
+//   - Ack: Acknowledge a reserved list item
+//   - RateIncr: Count requests against a sliding window rate limit
+//   - Publish, Subscribe: Send and receive messages on pub/sub channels
+//   - TopKeys: List the largest, longest lived or most accessed keys
+//   - RandomKeys: Sample random keys with their sizes
+//   - ExportPattern: Dump the keys matching a pattern with their values and TTLs
+//   - VerifyIntegrity: Check every key against its checksum to detect corruption
+//   - TTLHistogram: Count keys by remaining TTL
+//   - Stats: Count keys by type and estimate their memory use
+//   - PauseTTLWorker, ResumeTTLWorker: Put off sweeping expired keys, e.g. during a bulk load
+//   - ServerTime: Read the server clock
+//   - Capabilities, Supports: List the operations the server supports
 //
 // Basic usage:
 //
@@ -38,6 +113,14 @@
 //	}
 //	fmt.Println(item) // Output: process-order
 //
+// Distributed locks built on SetNX and Expire are available through Lock.
+//
+// WithLocalFallback keeps the client working during server outages by serving
+// requests from an embedded store and replaying writes once the server is back.
+//
+// Operations added in newer server versions fail with ErrUnsupportedOperation when
+// the server does not support them; Capabilities lists what it does support.
+//
 // All operations require proper context for cancellation and timeout handling.
 // TTL is required for all Set operations and must be greater than 0.
 package client
@@ -46,9 +129,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -58,26 +146,179 @@ import (
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	// timeout is the timeout of the HTTP client built when none is given, see WithTimeout.
+	timeout time.Duration
+
+	fallback         *fallback
+	rateLimitRetries int
+	// maxRetries and retryBaseDelay configure retries of transient failures, see WithRetries.
+	maxRetries     int
+	retryBaseDelay time.Duration
+	requestHook    RequestHook
+	useNumber      bool
+	// requestIDs generates an X-Request-ID for every request, see WithRequestIDs.
+	requestIDs bool
+
+	// capabilities caches the operations the server supports, see Capabilities.
+	capMu        sync.Mutex
+	capabilities []string
 }
 
+// Option configures a Client.
+type Option func(*Client)
+
+// defaultRateLimitDelay is the retry delay used when a 429 response carries no guidance.
+const defaultRateLimitDelay = time.Second
+
+// defaultTimeout is the timeout of requests unless set with WithTimeout.
+const defaultTimeout = 30 * time.Second
+
 // NewClient creates a new Acronis Memory Store API client.
 // The baseURL should point to the memory store server (e.g., "http://localhost:8080").
 //
 // Example:
 //
 //	client := client.NewClient("http://localhost:8080")
-func NewClient(baseURL string) *Client {
-	return &Client{
+func NewClient(baseURL string, opts ...Option) *Client {
+	c := &Client{
 		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		timeout: defaultTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{
+			Timeout: c.timeout,
+		}
+	}
+
+	return c
+}
+
+// WithTimeout sets the timeout of each HTTP request the client sends, including
+// reading the response, instead of the default of 30 seconds. 0 means no timeout, so
+// only the request context bounds how long a request takes. It is ignored when an
+// HTTP client is given with WithHTTPClient, whose own Timeout applies instead.
+//
+// Example:
+//
+//	c := client.NewClient("http://localhost:8080", client.WithTimeout(500*time.Millisecond))
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithHTTPClient makes the client send its requests with httpClient, e.g. one with a
+// tuned transport for connection pooling or custom TLS settings. It takes precedence
+// over WithTimeout, whatever the order of the options: set the Timeout of httpClient
+// instead.
+//
+// Example:
+//
+//	transport := http.DefaultTransport.(*http.Transport).Clone()
+//	transport.MaxIdleConnsPerHost = 100
+//	c := client.NewClient("https://store.internal:8443", client.WithHTTPClient(&http.Client{
+//	    Transport: transport,
+//	    Timeout:   2 * time.Second,
+//	}))
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRateLimitRetries makes the client retry requests rejected with 429 Too Many
+// Requests up to maxRetries times, waiting the delay the server asks for before
+// each retry. Rejected requests were not processed, so every request is safe to
+// retry. Waiting stops early if the request context is done.
+//
+// Example:
+//
+//	c := client.NewClient("http://localhost:8080", client.WithRateLimitRetries(3))
+func WithRateLimitRetries(maxRetries int) Option {
+	return func(c *Client) {
+		c.rateLimitRetries = maxRetries
+	}
+}
+
+// WithRetries makes the client retry idempotent requests (GET, PUT and DELETE) that
+// fail transiently, because the server could not be reached or answered with a 5xx
+// status, up to maxRetries times. The first retry waits baseDelay, and each further
+// one twice as long as the one before. 4xx responses are never retried, nor are POST
+// requests, which may have been applied before the failure.
+//
+// Waiting stops early if the request context is done, and no retry is attempted when
+// its deadline would pass before the wait is over; the last error is returned then.
+// Retries of 429 responses are configured separately, with WithRateLimitRetries.
+//
+// Example:
+//
+//	c := client.NewClient("http://localhost:8080", client.WithRetries(3, 100*time.Millisecond))
+func WithRetries(maxRetries int, baseDelay time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryBaseDelay = baseDelay
+	}
+}
+
+// RequestHook observes a request sent to the server, see WithRequestHook.
+type RequestHook func(req *http.Request, resp *http.Response, err error)
+
+// WithRequestHook makes the client call fn after every HTTP request it sends,
+// including each retry, for logging or inspecting traffic while debugging. fn gets
+// the request, the response if one was received and the error the request failed
+// with, if any, such as a network failure or an APIError; it is called on errors too.
+// The response body has already been read and closed when fn runs.
+//
+// Example:
+//
+//	c := client.NewClient("http://localhost:8080", client.WithRequestHook(
+//	    func(req *http.Request, resp *http.Response, err error) {
+//	        if resp != nil {
+//	            log.Printf("%s %s: %s", req.Method, req.URL.Path, resp.Status)
+//	        } else {
+//	            log.Printf("%s %s: %v", req.Method, req.URL.Path, err)
+//	        }
+//	    }))
+func WithRequestHook(fn RequestHook) Option {
+	return func(c *Client) {
+		c.requestHook = fn
+	}
+}
+
+// WithUseNumber makes GetInto, PopJSON and LRangeJSON decode numbers into interface
+// values as json.Number instead of float64, so integers beyond 2^53, such as 64-bit
+// IDs, survive the round trip exactly. Numbers decoded into typed fields, such as an
+// int64, are exact either way. The server always keeps numbers as they were sent.
+//
+// Example:
+//
+//	c := client.NewClient("http://localhost:8080", client.WithUseNumber())
+//	var event map[string]any
+//	err := c.GetInto(ctx, "event:1", &event)
+//	id, err := event["id"].(json.Number).Int64()
+func WithUseNumber() Option {
+	return func(c *Client) {
+		c.useNumber = true
+	}
+}
+
+// Close releases resources held by the client, such as the local fallback store.
+// Writes still waiting to be replayed to the server are discarded.
+func (c *Client) Close() {
+	if c.fallback != nil {
+		c.fallback.close()
 	}
 }
 
 // Set stores a key-value pair with the specified TTL in seconds.
 // TTL of 0 means no expiration, TTL > 0 means expires after specified seconds.
 // The value can be any JSON-serializable type.
+// Pass ReturnPrevious to receive the entry the value replaced.
 //
 // Example:
 //
@@ -93,145 +334,209 @@ func NewClient(baseURL string) *Client {
 //	    "age":  30,
 //	}
 //	err := client.Set(ctx, "user:profile:123", user, 1800)
-func (c *Client) Set(ctx context.Context, key string, value any, ttlSeconds int) error {
+func (c *Client) Set(ctx context.Context, key string, value any, ttlSeconds int, opts ...WriteOption) error {
 	if ttlSeconds < 0 {
 		return fmt.Errorf("TTL must be >= 0 (0 = no expiration)")
 	}
 
+	o := newWriteOptions(opts)
 	req := SetRequest{
 		Key:        key,
 		Value:      value,
 		TTLSeconds: ttlSeconds,
 	}
+	if o.noCompress {
+		compress := false
+		req.Compress = &compress
+	}
+	req.Tags = o.tags
 
-	_, err := c.doRequest(ctx, "POST", "/api/v1/keys", req)
-	return err
+	resp, err := c.doWrite(ctx, "POST", "/api/v1/keys"+o.query(), req, o)
+	if err != nil {
+		return err
+	}
+	return o.decode(resp)
 }
 
-// Get retrieves a value by its key. Returns the value as a string.
-// If the key doesn't exist or has expired, returns an error.
+// SetNX stores a key-value pair only if the key does not already exist.
+// It reports whether the value was stored. TTL semantics are the same as Set.
 //
 // Example:
 //
-//	value, err := client.Get(ctx, "user:123")
+//	set, err := client.SetNX(ctx, "job:42:owner", "worker-1", 30)
 //	if err != nil {
-//	    if strings.Contains(err.Error(), "Key not found") {
-//	        fmt.Println("Key doesn't exist")
-//	    } else {
-//	        log.Fatal(err)
-//	    }
+//	    log.Fatal(err)
 //	}
-//	fmt.Println("Value:", value)
-func (c *Client) Get(ctx context.Context, key string) (string, error) {
-	resp, err := c.doRequest(ctx, "GET", "/api/v1/keys/"+key, nil)
+//	if !set {
+//	    fmt.Println("Another worker owns the job")
+//	}
+func (c *Client) SetNX(ctx context.Context, key string, value any, ttlSeconds int, opts ...WriteOption) (bool, error) {
+	if ttlSeconds < 0 {
+		return false, fmt.Errorf("TTL must be >= 0 (0 = no expiration)")
+	}
+
+	req := SetRequest{
+		Key:        key,
+		Value:      value,
+		TTLSeconds: ttlSeconds,
+		NX:         true,
+	}
+
+	resp, err := c.doWrite(ctx, "POST", "/api/v1/keys", req, newWriteOptions(opts))
 	if err != nil {
-		return "", err
+		return false, err
 	}
 
-	// Parse the response data
 	data, ok := resp.Data.(map[string]any)
 	if !ok {
-		return "", fmt.Errorf("unexpected response format")
+		return false, fmt.Errorf("unexpected response format")
 	}
 
-	value, ok := data["value"].(string)
+	set, ok := data["set"].(bool)
 	if !ok {
-		return "", fmt.Errorf("unexpected value format")
+		return false, fmt.Errorf("unexpected set format")
 	}
 
-	return value, nil
+	return set, nil
 }
 
-// Update modifies the value of an existing key. The key must exist.
-// This operation preserves the original TTL of the key.
+// SetNXFenced stores a key-value pair like SetNX and, if it was stored, also returns
+// a fence token for the key. Pass the token with Fence on later writes so
+// they are rejected once another client acquires the key with a newer token.
 //
 // Example:
 //
-//	// Update existing user
-//	err := client.Update(ctx, "user:123", "Jane Doe")
-//	if err != nil {
-//	    if strings.Contains(err.Error(), "Key not found") {
-//	        fmt.Println("Key doesn't exist - use Set instead")
-//	    } else {
-//	        log.Fatal(err)
-//	    }
-//	}
-func (c *Client) Update(ctx context.Context, key string, value any) error {
-	req := UpdateRequest{
-		Value: value,
+//	acquired, token, err := client.SetNXFenced(ctx, "lock:report", "worker-1", 30)
+func (c *Client) SetNXFenced(ctx context.Context, key string, value any, ttlSeconds int, opts ...WriteOption) (bool, uint64, error) {
+	if ttlSeconds < 0 {
+		return false, 0, fmt.Errorf("TTL must be >= 0 (0 = no expiration)")
 	}
 
-	_, err := c.doRequest(ctx, "PUT", "/api/v1/keys/"+key, req)
-	return err
+	req := SetRequest{
+		Key:        key,
+		Value:      value,
+		TTLSeconds: ttlSeconds,
+		NX:         true,
+	}
+
+	resp, err := c.doWrite(ctx, "POST", "/api/v1/keys", req, newWriteOptions(opts))
+	if err != nil {
+		return false, 0, err
+	}
+
+	var data struct {
+		Set        bool   `json:"set"`
+		FenceToken uint64 `json:"fence_token"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return false, 0, err
+	}
+
+	return data.Set, data.FenceToken, nil
 }
 
-// Remove deletes a key and its value from the store.
-// If the key doesn't exist, the operation succeeds without error.
+// SetIfExpiringWithin stores a key-value pair only if the key does not exist or expires
+// in less than thresholdSeconds, and reports whether it was stored. Keys without a TTL
+// are never replaced. When several clients refresh the same cache entry, only the
+// first one to call it once the entry is close to expiring wins.
 //
 // Example:
 //
-//	err := client.Remove(ctx, "user:123")
+//	// Refresh the cached report when it has less than a minute left
+//	refreshed, err := client.SetIfExpiringWithin(ctx, "cache:report", report, 3600, 60)
 //	if err != nil {
 //	    log.Fatal(err)
 //	}
-//	fmt.Println("Key removed successfully")
-func (c *Client) Remove(ctx context.Context, key string) error {
-	_, err := c.doRequest(ctx, "DELETE", "/api/v1/keys/"+key, nil)
-	return err
+func (c *Client) SetIfExpiringWithin(ctx context.Context, key string, value any, ttlSeconds, thresholdSeconds int, opts ...WriteOption) (bool, error) {
+	if ttlSeconds < 0 {
+		return false, fmt.Errorf("TTL must be >= 0 (0 = no expiration)")
+	}
+	if thresholdSeconds < 0 {
+		return false, fmt.Errorf("threshold must be >= 0")
+	}
+
+	req := SetRequest{
+		Key:        key,
+		Value:      value,
+		TTLSeconds: ttlSeconds,
+	}
+
+	endpoint := fmt.Sprintf("/api/v1/keys?if_ttl_below=%d", thresholdSeconds)
+	resp, err := c.doWrite(ctx, "POST", endpoint, req, newWriteOptions(opts))
+	if err != nil {
+		return false, err
+	}
+
+	var data struct {
+		Set bool `json:"set"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return false, err
+	}
+
+	return data.Set, nil
 }
 
-// Push adds an item to the front of a list (LPUSH operation).
-// If the list doesn't exist, it will be created automatically.
-// The item can be any JSON-serializable type.
+// SetIfType stores a key-value pair only if the key does not exist or holds a value of
+// expectedType, and reports whether it was stored. A client that expects a string key
+// uses it so it never silently overwrites a list that took its place. The value is
+// stored as a string, so expectedType must be "string".
 //
 // Example:
 //
-//	// Add tasks to a queue
-//	err := client.Push(ctx, "queue:tasks", "process-order-123")
-//	err = client.Push(ctx, "queue:tasks", "send-email-456")
-//
-//	// Add complex objects
-//	task := map[string]any{
-//	    "id":   "task-789",
-//	    "type": "backup",
-//	    "priority": 1,
+//	// Overwrite the cached profile, but not a list someone stored under its key
+//	set, err := client.SetIfType(ctx, "profile:123", profile, 3600, "string")
+//	if err != nil {
+//	    log.Fatal(err)
 //	}
-//	err = client.Push(ctx, "queue:priority", task)
-func (c *Client) Push(ctx context.Context, key string, item any) error {
-	req := PushRequest{
-		Key:  key,
-		Item: item,
+//	if !set {
+//	    log.Println("profile:123 is not a string, left untouched")
+//	}
+func (c *Client) SetIfType(ctx context.Context, key string, value any, ttlSeconds int, expectedType string, opts ...WriteOption) (bool, error) {
+	if ttlSeconds < 0 {
+		return false, fmt.Errorf("TTL must be >= 0 (0 = no expiration)")
+	}
+	if expectedType != "string" {
+		return false, fmt.Errorf("expected type must be string")
 	}
 
-	_, err := c.doRequest(ctx, "POST", "/api/v1/lists/push", req)
-	return err
+	req := SetRequest{
+		Key:        key,
+		Value:      value,
+		TTLSeconds: ttlSeconds,
+	}
+
+	resp, err := c.doWrite(ctx, "POST", "/api/v1/keys?if_type="+expectedType, req, newWriteOptions(opts))
+	if err != nil {
+		return false, err
+	}
+
+	var data struct {
+		Set bool `json:"set"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return false, err
+	}
+
+	return data.Set, nil
 }
 
-// Pop removes and returns an item from the front of a list (LPOP operation).
-// Returns the item as a string. If the list is empty or doesn't exist,
-// returns an error.
+// Get retrieves a value by its key. Returns the value as a string.
+// If the key doesn't exist or has expired, returns an error.
 //
 // Example:
 //
-//	// Process tasks from queue
-//	for {
-//	    item, err := client.Pop(ctx, "queue:tasks")
-//	    if err != nil {
-//	        if strings.Contains(err.Error(), "list is empty") {
-//	            fmt.Println("No more tasks to process")
-//	            break
-//	        }
+//	value, err := client.Get(ctx, "user:123")
+//	if err != nil {
+//	    if strings.Contains(err.Error(), "Key not found") {
+//	        fmt.Println("Key doesn't exist")
+//	    } else {
 //	        log.Fatal(err)
 //	    }
-//	    fmt.Println("Processing:", item)
-//	    // Process the item...
 //	}
-func (c *Client) Pop(ctx context.Context, key string) (string, error) {
-	req := PopRequest{
-		Key: key,
-	}
-
-	resp, err := c.doRequest(ctx, "POST", "/api/v1/lists/pop", req)
+//	fmt.Println("Value:", value)
+func (c *Client) Get(ctx context.Context, key string) (string, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/keys/"+key, nil)
 	if err != nil {
 		return "", err
 	}
@@ -250,47 +555,2000 @@ func (c *Client) Pop(ctx context.Context, key string) (string, error) {
 	return value, nil
 }
 
-// doRequest performs an HTTP request and handles the response.
-// This is an internal method used by all public client methods.
-func (c *Client) doRequest(ctx context.Context, method, endpoint string, body any) (*Response, error) {
-	url := c.baseURL + endpoint
+// GetRaw retrieves the value of a key in its original JSON structure: values set as
+// numbers, objects and other non-string types come back as that JSON, and values set
+// as strings come back as JSON strings. Unlike Get, the value never needs re-parsing.
+//
+// Example:
+//
+//	raw, err := client.GetRaw(ctx, "user:profile:123")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println(string(raw)) // {"age":30,"name":"John Doe"}
+func (c *Client) GetRaw(ctx context.Context, key string) (json.RawMessage, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/keys/"+key+"/raw", nil)
+	if err != nil {
+		return nil, err
+	}
 
-	var reqBody io.Reader
-	if body != nil {
-		jsonBody, err := json.Marshal(body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal request body: %w", err)
-		}
-		reqBody = bytes.NewReader(jsonBody)
+	var data struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	return data.Value, nil
+}
+
+// GetInto retrieves the value of a key like GetRaw and unmarshals it into out, so a
+// value set as a struct or map can be read back into one. Values set as strings decode
+// into a string.
+//
+// Example:
+//
+//	var profile UserProfile
+//	if err := client.GetInto(ctx, "user:profile:123", &profile); err != nil {
+//	    log.Fatal(err)
+//	}
+func (c *Client) GetInto(ctx context.Context, key string, out any) error {
+	raw, err := c.GetRaw(ctx, key)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return err
 	}
 
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
+	return c.unmarshal(raw, out)
+}
+
+// GetOrDefault retrieves a value by its key like Get, but returns defaultValue instead
+// of an error if the key doesn't exist or has expired. Other errors, such as network
+// failures or the key holding a list, are still returned.
+//
+// Example:
+//
+//	theme, err := client.GetOrDefault(ctx, "settings:theme", "light")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println("Theme:", theme)
+func (c *Client) GetOrDefault(ctx context.Context, key, defaultValue string) (string, error) {
+	value, err := c.Get(ctx, key)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return defaultValue, nil
 	}
+	return value, err
+}
 
-	resp, err := c.httpClient.Do(req)
+// Exists reports whether a string or list key exists, without transferring its value.
+//
+// Example:
+//
+//	cached, err := client.Exists(ctx, "page:/home")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if !cached {
+//	    // render and store the page
+//	}
+func (c *Client) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := c.doRequest(ctx, "HEAD", "/api/v1/keys/"+key, nil)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to perform request: %w", err)
+		return false, err
 	}
-	defer resp.Body.Close()
+	return true, nil
+}
 
-	respBody, err := io.ReadAll(resp.Body)
+// TTL returns the remaining time to live of a string or list key in seconds, rounded
+// up, or -1 if the key does not expire. Missing and expired keys fail with a 404
+// APIError.
+//
+// Example:
+//
+//	ttl, err := client.TTL(ctx, "page:/home")
+//	if err == nil && ttl >= 0 && ttl < 60 {
+//	    // refresh the page before it expires
+//	}
+func (c *Client) TTL(ctx context.Context, key string) (int, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/keys/"+key+"/ttl", nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return 0, err
 	}
 
-	var apiResp Response
-	if err := json.Unmarshal(respBody, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	var data struct {
+		TTLSeconds int `json:"ttl_seconds"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return 0, err
 	}
 
-	if !apiResp.Success {
-		return &apiResp, fmt.Errorf("API error: %s", apiResp.Error)
+	return data.TTLSeconds, nil
+}
+
+// defaultWaitPollInterval is how often WaitForKey polls when no interval is given.
+const defaultWaitPollInterval = 100 * time.Millisecond
+
+// WaitForKey waits until the key exists and returns its value, polling Get every
+// pollInterval (100ms if 0 or less). It returns the context's error once ctx is done,
+// and any error other than the key not existing as soon as it occurs.
+//
+// Example:
+//
+//	// Wait up to a minute for another service to publish its config
+//	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+//	defer cancel()
+//	config, err := client.WaitForKey(ctx, "config:billing", time.Second)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+func (c *Client) WaitForKey(ctx context.Context, key string, pollInterval time.Duration) (string, error) {
+	if pollInterval <= 0 {
+		pollInterval = defaultWaitPollInterval
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		value, err := c.Get(ctx, key)
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+			return value, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetAny retrieves a key of any type in one call, without failing on lists, hashes and
+// sets as Get does. The result's Type tells whether Value, Items or Fields is set.
+//
+// Example:
+//
+//	v, err := client.GetAny(ctx, key)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if v.Type == client.TypeList {
+//	    fmt.Println(key, "=", v.Items)
+//	} else {
+//	    fmt.Println(key, "=", v.Value)
+//	}
+func (c *Client) GetAny(ctx context.Context, key string) (*AnyValue, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/keys/"+key+"/any", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var value AnyValue
+	if err := decodeData(resp, &value); err != nil {
+		return nil, err
+	}
+
+	return &value, nil
+}
+
+// MultiGet retrieves several keys of any type in one request. Entries are returned
+// in the order of keys; missing or expired keys have Type TypeNone.
+//
+// Example:
+//
+//	entries, err := client.MultiGet(ctx, []string{"user:123", "queue:tasks"})
+//	for _, entry := range entries {
+//	    switch entry.Type {
+//	    case client.TypeString:
+//	        fmt.Println(entry.Key, "=", entry.Value)
+//	    case client.TypeList:
+//	        fmt.Println(entry.Key, "=", entry.Items)
+//	    }
+//	}
+func (c *Client) MultiGet(ctx context.Context, keys []string) ([]KeyEntry, error) {
+	req := MultiGetRequest{
+		Keys: keys,
+	}
+
+	resp, err := c.doRequest(ctx, "POST", "/api/v1/keys/multiget", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Entries []KeyEntry `json:"entries"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return nil, err
+	}
+
+	return data.Entries, nil
+}
+
+// MSet stores several key-value pairs with the same TTL in one request, saving the
+// per-request overhead of calling Set for each. TTL semantics are the same as Set.
+//
+// Example:
+//
+//	err := client.MSet(ctx, map[string]any{
+//	    "user:1": "alice",
+//	    "user:2": map[string]any{"name": "bob"},
+//	}, 3600)
+func (c *Client) MSet(ctx context.Context, pairs map[string]any, ttlSeconds int) error {
+	if ttlSeconds < 0 {
+		return fmt.Errorf("TTL must be >= 0 (0 = no expiration)")
+	}
+
+	req := MSetRequest{
+		Pairs:      pairs,
+		TTLSeconds: ttlSeconds,
+	}
+
+	_, err := c.doRequest(ctx, "POST", "/api/v1/keys/mset", req)
+	return err
+}
+
+// MSetChunked stores pairs like MSet, but has the server take its store lock once per
+// chunkSize keys rather than once for the whole batch, so a large import does not
+// stall other requests while it is written. The batch is no longer atomic: other
+// requests may see part of it, and if it fails part way, on a store limit or the
+// server's request timeout, the keys written before the failure are kept. The number
+// of keys written is returned in either case. A chunkSize of 0 writes every key at
+// once, like MSet. Chunking needs a server that supports it: an older one would write
+// the batch at once, so ErrUnsupportedOperation is returned instead.
+//
+// Example:
+//
+//	written, err := client.MSetChunked(ctx, pairs, 0, 1000)
+//	if err != nil {
+//	    log.Printf("import stopped after %d of %d keys: %v", written, len(pairs), err)
+//	}
+func (c *Client) MSetChunked(ctx context.Context, pairs map[string]any, ttlSeconds int, chunkSize int) (int, error) {
+	if ttlSeconds < 0 {
+		return 0, fmt.Errorf("TTL must be >= 0 (0 = no expiration)")
+	}
+	if chunkSize < 0 {
+		return 0, fmt.Errorf("chunk size must be >= 0 (0 = all at once)")
+	}
+	if chunkSize > 0 {
+		if err := c.require(ctx, OpMSetChunked); err != nil {
+			return 0, err
+		}
+	}
+
+	req := MSetRequest{
+		Pairs:      pairs,
+		TTLSeconds: ttlSeconds,
+		ChunkSize:  chunkSize,
+	}
+
+	// Failures carry the count of keys written before them.
+	resp, err := c.doRequest(ctx, "POST", "/api/v1/keys/mset", req)
+	var data MSetResponse
+	if resp != nil && resp.Data != nil {
+		if decodeErr := decodeData(resp, &data); decodeErr != nil && err == nil {
+			return 0, decodeErr
+		}
+	}
+	return data.Count, err
+}
+
+// MGet retrieves the values of several string keys in one request. Missing and
+// expired keys, and keys holding lists, are left out of the result rather than
+// failing the call, so a partial fetch still succeeds.
+//
+// Example:
+//
+//	values, err := client.MGet(ctx, []string{"user:1", "user:2", "user:3"})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if name, ok := values["user:1"]; ok {
+//	    fmt.Println("user:1 =", name)
+//	}
+func (c *Client) MGet(ctx context.Context, keys []string) (map[string]string, error) {
+	req := MGetRequest{
+		Keys: keys,
+	}
+
+	resp, err := c.doRequest(ctx, "POST", "/api/v1/keys/mget", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Values map[string]string `json:"values"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return nil, err
+	}
+
+	return data.Values, nil
+}
+
+// KeysInfo retrieves the size, type, TTL and access count of several keys in one
+// request, e.g. for a watchlist. Keys are returned in the order given; missing or
+// expired keys are left out.
+//
+// Example:
+//
+//	infos, err := client.KeysInfo(ctx, []string{"user:123", "queue:tasks"})
+//	for _, info := range infos {
+//	    fmt.Printf("%s (%s): %d bytes, %d hits\n", info.Key, info.Type, info.SizeBytes, info.Hits)
+//	}
+func (c *Client) KeysInfo(ctx context.Context, keys []string) ([]KeyInfo, error) {
+	req := KeysInfoRequest{
+		Keys: keys,
+	}
+
+	resp, err := c.doRequest(ctx, "POST", "/api/v1/keys/info", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Keys []KeyInfo `json:"keys"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return nil, err
+	}
+
+	return data.Keys, nil
+}
+
+// Update modifies the value of an existing key. The key must exist.
+// This operation preserves the original TTL of the key.
+//
+// Example:
+//
+//	// Update existing user
+//	err := client.Update(ctx, "user:123", "Jane Doe")
+//	if err != nil {
+//	    if strings.Contains(err.Error(), "Key not found") {
+//	        fmt.Println("Key doesn't exist - use Set instead")
+//	    } else {
+//	        log.Fatal(err)
+//	    }
+//	}
+func (c *Client) Update(ctx context.Context, key string, value any, opts ...WriteOption) error {
+	req := UpdateRequest{
+		Value: value,
+	}
+
+	_, err := c.doWrite(ctx, "PUT", "/api/v1/keys/"+key, req, newWriteOptions(opts))
+	return err
+}
+
+// GetSet atomically replaces the value of an existing string key and returns the value
+// it replaced. The key keeps its TTL. It fails with a 404 APIError if the key does not
+// exist, storing nothing, and with a 409 APIError if the key holds a list.
+//
+// Example:
+//
+//	// Rotate a token and revoke the old one
+//	old, err := client.GetSet(ctx, "token:api", newToken)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	revoke(old)
+func (c *Client) GetSet(ctx context.Context, key string, value any, opts ...WriteOption) (string, error) {
+	req := GetSetRequest{
+		Value: value,
+	}
+
+	resp, err := c.doWrite(ctx, "POST", "/api/v1/keys/"+key+"/getset", req, newWriteOptions(opts))
+	if err != nil {
+		return "", err
+	}
+
+	var data struct {
+		Previous string `json:"previous"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return "", err
+	}
+
+	return data.Previous, nil
+}
+
+// Remove deletes a key and its value from the store.
+// If the key doesn't exist, the operation succeeds without error.
+// Pass ReturnPrevious to receive the removed entry, or SoftDelete to allow Restore.
+//
+// Example:
+//
+//	err := client.Remove(ctx, "user:123")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println("Key removed successfully")
+func (c *Client) Remove(ctx context.Context, key string, opts ...WriteOption) error {
+	o := newWriteOptions(opts)
+	resp, err := c.doWrite(ctx, "DELETE", "/api/v1/keys/"+key+o.query(), nil, o)
+	if err != nil {
+		return err
+	}
+	return o.decode(resp)
+}
+
+// Restore brings back a key removed with the SoftDelete option, with its value and
+// original expiration, as long as its recovery window has not closed. It fails with
+// a 404 APIError if there is nothing to restore and a 409 APIError if the key was
+// set again after it was removed.
+//
+// Example:
+//
+//	if err := client.Restore(ctx, "user:123"); err != nil {
+//	    log.Printf("could not restore user:123: %v", err)
+//	}
+func (c *Client) Restore(ctx context.Context, key string, opts ...WriteOption) error {
+	_, err := c.doWrite(ctx, "POST", fmt.Sprintf("/api/v1/keys/%s/restore", key), nil, newWriteOptions(opts))
+	return err
+}
+
+// Expire changes the TTL of an existing key without resending its value.
+// A TTL of 0 removes the expiration so the key persists forever.
+// Pass ReturnPrevious to receive the entry as it was before the change.
+//
+// Example:
+//
+//	// Extend a session by another 30 minutes
+//	err := client.Expire(ctx, "session:abc", 1800)
+func (c *Client) Expire(ctx context.Context, key string, ttlSeconds int, opts ...WriteOption) error {
+	if ttlSeconds < 0 {
+		return fmt.Errorf("TTL must be >= 0 (0 = no expiration)")
+	}
+
+	req := ExpireRequest{
+		TTLSeconds: ttlSeconds,
+	}
+
+	o := newWriteOptions(opts)
+	resp, err := c.doWrite(ctx, "PUT", "/api/v1/keys/"+key+"/ttl"+o.query(), req, o)
+	if err != nil {
+		return err
+	}
+	return o.decode(resp)
+}
+
+// Persist removes the expiration of an existing key, so it is kept until removed.
+// It is a shorthand for Expire with a ttlSeconds of 0.
+//
+// Example:
+//
+//	err := client.Persist(ctx, "session:abc")
+func (c *Client) Persist(ctx context.Context, key string, opts ...WriteOption) error {
+	return c.Expire(ctx, key, 0, opts...)
+}
+
+// ExpireFenced changes the TTL of a key like Expire and returns a new fence token for
+// the key. Writes carrying an older token are rejected from then on.
+//
+// Example:
+//
+//	token, err := client.ExpireFenced(ctx, "lock:report", 30)
+func (c *Client) ExpireFenced(ctx context.Context, key string, ttlSeconds int, opts ...WriteOption) (uint64, error) {
+	if ttlSeconds < 0 {
+		return 0, fmt.Errorf("TTL must be >= 0 (0 = no expiration)")
+	}
+
+	req := ExpireRequest{
+		TTLSeconds: ttlSeconds,
+	}
+
+	resp, err := c.doWrite(ctx, "PUT", "/api/v1/keys/"+key+"/ttl", req, newWriteOptions(opts))
+	if err != nil {
+		return 0, err
+	}
+
+	var data struct {
+		FenceToken uint64 `json:"fence_token"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return 0, err
+	}
+
+	return data.FenceToken, nil
+}
+
+// ExpirePattern sets the TTL of every key matching a glob pattern and returns the
+// number of keys changed. A TTL of 0 removes the expiration. Patterns support
+// '*', '?', character classes such as [a-z] and '\' escapes; '/' is not special.
+//
+// Example:
+//
+//	// Roll back a feature: let its keys expire within a minute
+//	n, err := client.ExpirePattern(ctx, "feature:beta:*", 60)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("%d keys will expire\n", n)
+func (c *Client) ExpirePattern(ctx context.Context, pattern string, ttlSeconds int) (int, error) {
+	if ttlSeconds < 0 {
+		return 0, fmt.Errorf("TTL must be >= 0 (0 = no expiration)")
+	}
+
+	req := ExpirePatternRequest{
+		TTLSeconds: ttlSeconds,
+	}
+
+	resp, err := c.doRequest(ctx, "POST", "/api/v1/keys/expire?pattern="+url.QueryEscape(pattern), req)
+	if err != nil {
+		return 0, err
+	}
+
+	var data struct {
+		Count int `json:"count"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return 0, err
+	}
+
+	return data.Count, nil
+}
+
+// KeysByTag returns the keys tagged with tag, sorted. Keys are tagged when set, see Tags.
+//
+// Example:
+//
+//	keys, err := client.KeysByTag(ctx, "user:123")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println("Keys of user 123:", keys)
+func (c *Client) KeysByTag(ctx context.Context, tag string) ([]string, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/tags/"+tag, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Keys []string `json:"keys"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return nil, err
+	}
+
+	return data.Keys, nil
+}
+
+// DeleteByTag deletes every key tagged with tag in one operation and returns the
+// number of keys deleted. Keys are tagged when set, see Tags.
+//
+// Example:
+//
+//	// Invalidate everything cached for a user
+//	client.Set(ctx, "profile:123", profile, 3600, client.Tags("user:123"))
+//	client.Set(ctx, "orders:123", orders, 3600, client.Tags("user:123"))
+//
+//	n, err := client.DeleteByTag(ctx, "user:123")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("Invalidated %d keys\n", n)
+func (c *Client) DeleteByTag(ctx context.Context, tag string) (int, error) {
+	resp, err := c.doRequest(ctx, "DELETE", "/api/v1/tags/"+tag, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var data struct {
+		Deleted int `json:"deleted"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return 0, err
+	}
+
+	return data.Deleted, nil
+}
+
+// CountPattern returns the number of keys matching a glob pattern without listing
+// them. The pattern syntax is the same as for ExpirePattern.
+//
+// Example:
+//
+//	// Count active sessions
+//	sessions, err := client.CountPattern(ctx, "session:*")
+func (c *Client) CountPattern(ctx context.Context, pattern string) (int, error) {
+	endpoint := "/api/v1/keys/count?pattern=" + url.QueryEscape(pattern)
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var data struct {
+		Count int `json:"count"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return 0, err
+	}
+
+	return data.Count, nil
+}
+
+// DeleteExpiringWithin deletes all keys whose remaining TTL is below threshold, to free
+// memory held by keys that are about to expire anyway, and returns how many were
+// deleted. Keys without a TTL are kept. The threshold is truncated to whole seconds.
+//
+// Example:
+//
+//	// Drop everything expiring within the next minute
+//	deleted, err := client.DeleteExpiringWithin(ctx, time.Minute)
+func (c *Client) DeleteExpiringWithin(ctx context.Context, threshold time.Duration) (int, error) {
+	if threshold < 0 {
+		return 0, fmt.Errorf("threshold must be >= 0")
+	}
+
+	endpoint := fmt.Sprintf("/api/v1/keys?expiring_within=%d", int(threshold/time.Second))
+	resp, err := c.doRequest(ctx, "DELETE", endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var data struct {
+		Deleted int `json:"deleted"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return 0, err
+	}
+
+	return data.Deleted, nil
+}
+
+// DeleteMatching deletes every key matching a glob pattern at once, e.g. to clean up
+// after a tenant, and returns how many were deleted. Expired keys matching the
+// pattern are removed too but not counted. A malformed pattern fails with a 400
+// APIError.
+//
+// Example:
+//
+//	deleted, err := client.DeleteMatching(ctx, "tenant:42:*")
+func (c *Client) DeleteMatching(ctx context.Context, pattern string) (int, error) {
+	if pattern == "" {
+		return 0, fmt.Errorf("pattern is required")
+	}
+
+	endpoint := "/api/v1/keys?pattern=" + url.QueryEscape(pattern)
+	resp, err := c.doRequest(ctx, "DELETE", endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var data struct {
+		Deleted int `json:"deleted"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return 0, err
+	}
+
+	return data.Deleted, nil
+}
+
+// FlushAll deletes every key in the store, e.g. to reset it between integration
+// tests. Servers only allow it when started with ENABLE_FLUSH; others fail with a 403
+// APIError.
+//
+// Example:
+//
+//	if err := client.FlushAll(ctx); err != nil {
+//	    log.Fatal(err)
+//	}
+func (c *Client) FlushAll(ctx context.Context) error {
+	if _, err := c.doRequest(ctx, "POST", "/api/v1/flush", nil); err != nil {
+		return c.unsupported(ctx, OpFlush, err)
+	}
+	return nil
+}
+
+// Increment atomically adds delta, which may be negative, to the integer held by a
+// key and returns the new value. A missing key is created holding delta. Keys not
+// holding an integer fail with a 409 APIError.
+//
+// Example:
+//
+//	// Count a page view
+//	views, err := client.Increment(ctx, "views:/home", 1)
+func (c *Client) Increment(ctx context.Context, key string, delta int64, opts ...WriteOption) (int64, error) {
+	req := IncrRequest{
+		Delta: delta,
+	}
+
+	resp, err := c.doWrite(ctx, "POST", "/api/v1/keys/"+key+"/incr", req, newWriteOptions(opts))
+	if err != nil {
+		return 0, c.unsupported(ctx, OpIncr, err)
+	}
+
+	var data CounterResponse
+	if err := decodeData(resp, &data); err != nil {
+		return 0, err
+	}
+
+	return data.Value, nil
+}
+
+// IncrWithCeiling atomically increments the integer held by a key by delta, unless the
+// result would exceed ceiling. It returns the resulting value and whether the
+// increment was applied; if not, the current value is returned. A missing key counts
+// as 0. Keys not holding an integer fail with a 409 APIError. Servers that do not
+// advertise OpIncrCeiling fail with ErrUnsupportedOperation, rather than incrementing
+// past the ceiling.
+//
+// Example:
+//
+//	// Count an API call against a quota of 1000
+//	used, ok, err := client.IncrWithCeiling(ctx, "quota:tenant-42", 1, 1000)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if !ok {
+//	    fmt.Println("quota exhausted at", used)
+//	}
+func (c *Client) IncrWithCeiling(ctx context.Context, key string, delta, ceiling int64, opts ...WriteOption) (int64, bool, error) {
+	if err := c.require(ctx, OpIncrCeiling); err != nil {
+		return 0, false, err
+	}
+
+	req := IncrRequest{
+		Delta:   delta,
+		Ceiling: &ceiling,
+	}
+
+	resp, err := c.doWrite(ctx, "POST", "/api/v1/keys/"+key+"/incr", req, newWriteOptions(opts))
+	if err != nil {
+		return 0, false, err
+	}
+
+	var data CounterResponse
+	if err := decodeData(resp, &data); err != nil {
+		return 0, false, err
+	}
+
+	return data.Value, data.Applied, nil
+}
+
+// Decrement atomically subtracts delta, which must be greater than 0, from the integer
+// held by a key and returns the new value. A missing key counts as 0. Use
+// DecrWithFloor to keep the value from dropping below a bound.
+//
+// Example:
+//
+//	active, err := client.Decrement(ctx, "connections:active", 1)
+func (c *Client) Decrement(ctx context.Context, key string, delta int64, opts ...WriteOption) (int64, error) {
+	if delta <= 0 {
+		return 0, fmt.Errorf("delta must be greater than 0")
+	}
+
+	req := DecrRequest{
+		Delta: delta,
+	}
+
+	resp, err := c.doWrite(ctx, "POST", "/api/v1/keys/"+key+"/decr", req, newWriteOptions(opts))
+	if err != nil {
+		return 0, c.unsupported(ctx, OpDecr, err)
+	}
+
+	var data CounterResponse
+	if err := decodeData(resp, &data); err != nil {
+		return 0, err
+	}
+
+	return data.Value, nil
+}
+
+// DecrWithFloor atomically decrements the integer held by a key by delta, unless the
+// result would drop below floor. It returns the resulting value and whether the
+// decrement was applied; if not, the current value is returned. A missing key counts
+// as 0. Keys not holding an integer fail with a 409 APIError. Servers that do not
+// advertise OpDecrFloor fail with ErrUnsupportedOperation, rather than decrementing
+// below the floor.
+//
+// Example:
+//
+//	// Take one item from stock, never going below zero
+//	left, ok, err := client.DecrWithFloor(ctx, "stock:sku-42", 1, 0)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if !ok {
+//	    fmt.Println("out of stock")
+//	}
+func (c *Client) DecrWithFloor(ctx context.Context, key string, delta, floor int64, opts ...WriteOption) (int64, bool, error) {
+	if err := c.require(ctx, OpDecrFloor); err != nil {
+		return 0, false, err
+	}
+
+	req := DecrRequest{
+		Delta: delta,
+		Floor: &floor,
+	}
+
+	resp, err := c.doWrite(ctx, "POST", "/api/v1/keys/"+key+"/decr", req, newWriteOptions(opts))
+	if err != nil {
+		return 0, false, err
+	}
+
+	var data CounterResponse
+	if err := decodeData(resp, &data); err != nil {
+		return 0, false, err
+	}
+
+	return data.Value, data.Applied, nil
+}
+
+// Push adds an item to the front of a list (LPUSH operation).
+// If the list doesn't exist, it will be created automatically.
+// The item can be any JSON-serializable type.
+//
+// Example:
+//
+//	// Add tasks to a queue
+//	err := client.Push(ctx, "queue:tasks", "process-order-123")
+//	err = client.Push(ctx, "queue:tasks", "send-email-456")
+//
+//	// Add complex objects
+//	task := map[string]any{
+//	    "id":   "task-789",
+//	    "type": "backup",
+//	    "priority": 1,
+//	}
+//	err = client.Push(ctx, "queue:priority", task)
+//
+// Pushing to a key that was soft deleted and can still be restored fails with a
+// 409 APIError rather than creating a fresh list; pass Resurrect to restore the
+// deleted list and push onto it.
+func (c *Client) Push(ctx context.Context, key string, item any, opts ...WriteOption) error {
+	o := newWriteOptions(opts)
+	req := PushRequest{
+		Key:       key,
+		Item:      item,
+		Resurrect: o.resurrect,
+	}
+
+	_, err := c.doWrite(ctx, "POST", "/api/v1/lists/push", req, o)
+	return err
+}
+
+// PushSeq pushes an item to the front of a list like Push and returns the sequence
+// number the server assigned to it. Each list numbers its items from 1 in the order
+// they are added, and PopItem and LRangePage report the numbers back, so consumers
+// can detect gaps or reordering. The numbering starts over when the list is deleted
+// or replaced.
+//
+// Example:
+//
+//	seq, err := client.PushSeq(ctx, "queue:tasks", "process-order-123")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println("Queued as", seq)
+func (c *Client) PushSeq(ctx context.Context, key string, item any, opts ...WriteOption) (uint64, error) {
+	o := newWriteOptions(opts)
+	req := PushRequest{
+		Key:       key,
+		Item:      item,
+		Resurrect: o.resurrect,
+	}
+
+	return c.pushSeq(ctx, "/api/v1/lists/push", req, o)
+}
+
+// PushCappedReturn pushes an item to the front of a list like Push, then trims the
+// list to its first maxLen items and returns the items dropped from the far end, in
+// list order. The push and trim are atomic, so a bounded buffer learns exactly what
+// overflowed. The result is empty while the list stays within maxLen.
+//
+// Example:
+//
+//	// Keep the 100 most recent events and archive the overflow
+//	evicted, err := client.PushCappedReturn(ctx, "events:recent", event, 100)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	archive(evicted)
+func (c *Client) PushCappedReturn(ctx context.Context, key string, item any, maxLen int, opts ...WriteOption) ([]string, error) {
+	if maxLen <= 0 {
+		return nil, fmt.Errorf("maxLen must be greater than 0")
+	}
+
+	req := PushRequest{
+		Key:           key,
+		Item:          item,
+		MaxLen:        maxLen,
+		ReturnEvicted: true,
+	}
+
+	resp, err := c.doWrite(ctx, "POST", "/api/v1/lists/push", req, newWriteOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Evicted []string `json:"evicted"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return nil, err
+	}
+
+	return data.Evicted, nil
+}
+
+// RPush adds an item to the end of a list (RPUSH operation).
+// If the list doesn't exist, it will be created. Combined with Pop, which takes
+// items from the front, it makes a FIFO queue.
+//
+// Example:
+//
+//	// Producers append to the tail
+//	err := client.RPush(ctx, "queue:jobs", "job-1")
+//	err = client.RPush(ctx, "queue:jobs", "job-2")
+//
+//	// Consumers take from the head, so job-1 comes out first
+//	job, err := client.Pop(ctx, "queue:jobs")
+func (c *Client) RPush(ctx context.Context, key string, item any, opts ...WriteOption) error {
+	req := RPushRequest{
+		Key:  key,
+		Item: item,
+	}
+
+	_, err := c.doWrite(ctx, "POST", "/api/v1/lists/rpush", req, newWriteOptions(opts))
+	return err
+}
+
+// RPushSeq adds an item to the end of a list like RPush and returns the sequence
+// number the server assigned to it, see PushSeq.
+//
+// Example:
+//
+//	// Sequences of a FIFO queue come out of PopItem in increasing order
+//	seq, err := client.RPushSeq(ctx, "queue:jobs", "job-1")
+func (c *Client) RPushSeq(ctx context.Context, key string, item any, opts ...WriteOption) (uint64, error) {
+	req := RPushRequest{
+		Key:  key,
+		Item: item,
+	}
+
+	return c.pushSeq(ctx, "/api/v1/lists/rpush", req, newWriteOptions(opts))
+}
+
+func (c *Client) pushSeq(ctx context.Context, path string, req any, o writeOptions) (uint64, error) {
+	resp, err := c.doWrite(ctx, "POST", path, req, o)
+	if err != nil {
+		return 0, err
+	}
+
+	var data struct {
+		Seq uint64 `json:"seq"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return 0, err
+	}
+
+	return data.Seq, nil
+}
+
+// Pop removes and returns an item from the front of a list (LPOP operation).
+// Returns the item as a string. If the list is empty or doesn't exist,
+// returns an error.
+//
+// Example:
+//
+//	// Process tasks from queue
+//	for {
+//	    item, err := client.Pop(ctx, "queue:tasks")
+//	    if err != nil {
+//	        if strings.Contains(err.Error(), "list is empty") {
+//	            fmt.Println("No more tasks to process")
+//	            break
+//	        }
+//	        log.Fatal(err)
+//	    }
+//	    fmt.Println("Processing:", item)
+//	    // Process the item...
+//	}
+func (c *Client) Pop(ctx context.Context, key string, opts ...WriteOption) (string, error) {
+	req := PopRequest{
+		Key: key,
+	}
+
+	resp, err := c.doWrite(ctx, "POST", "/api/v1/lists/pop", req, newWriteOptions(opts))
+	if err != nil {
+		return "", err
+	}
+
+	// Parse the response data
+	data, ok := resp.Data.(map[string]any)
+	if !ok {
+		return "", fmt.Errorf("unexpected response format")
+	}
+
+	value, ok := data["value"].(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected value format")
+	}
+
+	return value, nil
+}
+
+// PopJSON pops an item from the front of a list like Pop and unmarshals it into out.
+// Items pushed as non-string values are stored as JSON and decode into the original type.
+// Items pushed as plain strings are not JSON; they are decoded as a JSON string, so out
+// must point to a string or an interface for them, otherwise an error is returned.
+// Note that a plain string that happens to be valid JSON, such as "42", decodes as that JSON.
+//
+// Example:
+//
+//	var task Task
+//	if err := client.PopJSON(ctx, "queue:tasks", &task); err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println("Processing task", task.ID)
+func (c *Client) PopJSON(ctx context.Context, key string, out any, opts ...WriteOption) error {
+	item, err := c.Pop(ctx, key, opts...)
+	if err != nil {
+		return err
+	}
+
+	return c.unmarshal(itemJSON(item), out)
+}
+
+// RPop removes and returns an item from the end of a list (RPOP operation).
+//
+// Example:
+//
+//	// Take the most recently appended item
+//	item, err := client.RPop(ctx, "queue:jobs")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println("Last job:", item)
+func (c *Client) RPop(ctx context.Context, key string, opts ...WriteOption) (string, error) {
+	req := PopRequest{
+		Key: key,
+	}
+
+	resp, err := c.doWrite(ctx, "POST", "/api/v1/lists/rpop", req, newWriteOptions(opts))
+	if err != nil {
+		return "", err
+	}
+
+	data, ok := resp.Data.(map[string]any)
+	if !ok {
+		return "", fmt.Errorf("unexpected response format")
+	}
+
+	value, ok := data["value"].(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected value format")
+	}
+
+	return value, nil
+}
+
+// PopItem removes and returns the item at the front of a list like Pop, along with
+// the sequence number it was pushed with, see PushSeq.
+//
+// Example:
+//
+//	var last uint64
+//	for {
+//	    item, err := client.PopItem(ctx, "queue:jobs")
+//	    if err != nil {
+//	        break
+//	    }
+//	    if item.Seq != last+1 {
+//	        log.Printf("expected seq %d, got %d", last+1, item.Seq)
+//	    }
+//	    last = item.Seq
+//	}
+func (c *Client) PopItem(ctx context.Context, key string, opts ...WriteOption) (*ListItem, error) {
+	return c.popItem(ctx, "/api/v1/lists/pop", key, newWriteOptions(opts))
+}
+
+// RPopItem removes and returns the item at the end of a list like RPop, along with
+// the sequence number it was pushed with, see PushSeq.
+//
+// Example:
+//
+//	item, err := client.RPopItem(ctx, "queue:jobs")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println(item.Seq, item.Value)
+func (c *Client) RPopItem(ctx context.Context, key string, opts ...WriteOption) (*ListItem, error) {
+	return c.popItem(ctx, "/api/v1/lists/rpop", key, newWriteOptions(opts))
+}
+
+func (c *Client) popItem(ctx context.Context, path, key string, o writeOptions) (*ListItem, error) {
+	resp, err := c.doWrite(ctx, "POST", path, PopRequest{Key: key}, o)
+	if err != nil {
+		return nil, err
+	}
+
+	var item ListItem
+	if err := decodeData(resp, &item); err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+// LRange returns the items of a list between start and stop, both inclusive,
+// without removing them. Negative indexes count from the end of the list,
+// so LRange(ctx, key, 0, -1) returns the whole list. See Reverse for reading the
+// list tail first.
+//
+// Example:
+//
+//	// Peek at the next 10 tasks
+//	items, err := client.LRange(ctx, "queue:tasks", 0, 9)
+func (c *Client) LRange(ctx context.Context, key string, start, stop int, opts ...RangeOption) ([]string, error) {
+	page, err := c.LRangePage(ctx, key, start, stop, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Items, nil
+}
+
+// LRangePage returns a range of list items like LRange along with the length of the
+// whole list, read atomically, so callers can page through a list.
+//
+// Example:
+//
+//	// Walk a list 100 items at a time
+//	for start := 0; ; start += 100 {
+//	    page, err := client.LRangePage(ctx, "queue:tasks", start, start+99)
+//	    if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    process(page.Items)
+//	    if page.Stop+1 >= page.Total {
+//	        break
+//	    }
+//	}
+func (c *Client) LRangePage(ctx context.Context, key string, start, stop int, opts ...RangeOption) (*ListPage, error) {
+	endpoint := fmt.Sprintf("/api/v1/lists/%s/range?start=%d&stop=%d", key, start, stop)
+	if newRangeOptions(opts).reverse {
+		endpoint += "&reverse=true"
+	}
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var page ListPage
+	if err := decodeData(resp, &page); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// LRangeJSON returns a range of list items like LRange and unmarshals them into out,
+// which must point to a slice. Items are decoded as described for PopJSON.
+//
+// Example:
+//
+//	var tasks []Task
+//	err := client.LRangeJSON(ctx, "queue:tasks", 0, -1, &tasks)
+func (c *Client) LRangeJSON(ctx context.Context, key string, start, stop int, out any, opts ...RangeOption) error {
+	items, err := c.LRange(ctx, key, start, stop, opts...)
+	if err != nil {
+		return err
+	}
+
+	raw := make([]json.RawMessage, len(items))
+	for i, item := range items {
+		raw[i] = itemJSON(item)
+	}
+
+	array, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	return c.unmarshal(array, out)
+}
+
+// LRangeWithMeta returns the items of a list between start and stop, with indexes as
+// for LRange, each with its sequence number and the time it was pushed, e.g. to see
+// how long the items of a stuck queue have been waiting. Push times are only known if
+// the server was started with LIST_PUSH_TIMES; otherwise PushedAt is zero.
+//
+// Example:
+//
+//	// How long has the oldest task been waiting?
+//	items, err := client.LRangeWithMeta(ctx, "queue:tasks", -1, -1)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if len(items) > 0 && !items[0].PushedAt.IsZero() {
+//	    fmt.Println("oldest task waiting for", time.Since(items[0].PushedAt))
+//	}
+func (c *Client) LRangeWithMeta(ctx context.Context, key string, start, stop int) ([]ListItem, error) {
+	endpoint := fmt.Sprintf("/api/v1/lists/%s/range?start=%d&stop=%d&with_meta=true", key, start, stop)
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Items []ListItem `json:"items"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return nil, err
+	}
+
+	return data.Items, nil
+}
+
+// LClear removes every item of a list but keeps the key, so unlike Remove, the list
+// keeps its TTL and its sequence numbers carry on. Missing keys fail with a 404
+// APIError and keys not holding a list with a 409 APIError.
+//
+// Example:
+//
+//	// Drop all pending tasks, keeping the queue and its TTL
+//	err := client.LClear(ctx, "queue:tasks")
+func (c *Client) LClear(ctx context.Context, key string, opts ...WriteOption) error {
+	_, err := c.doWrite(ctx, "POST", "/api/v1/lists/"+key+"/clear", nil, newWriteOptions(opts))
+	return err
+}
+
+// LTrim trims a list so that it only keeps the items between start and stop, both
+// inclusive. Indexes follow LRange. The list is removed if no items are kept.
+//
+// Example:
+//
+//	// Keep only the 100 most recent events
+//	err := client.LTrim(ctx, "events:recent", 0, 99)
+func (c *Client) LTrim(ctx context.Context, key string, start, stop int, opts ...WriteOption) error {
+	endpoint := fmt.Sprintf("/api/v1/lists/%s/trim?start=%d&stop=%d", key, start, stop)
+	_, err := c.doWrite(ctx, "POST", endpoint, nil, newWriteOptions(opts))
+	return err
+}
+
+// LTrimReturn trims a list like LTrim and returns the removed items in list order:
+// those cut from the front followed by those cut from the back.
+//
+// Example:
+//
+//	// Keep the 100 most recent events and archive the rest
+//	removed, err := client.LTrimReturn(ctx, "events:recent", 0, 99)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	archive(removed)
+func (c *Client) LTrimReturn(ctx context.Context, key string, start, stop int, opts ...WriteOption) ([]string, error) {
+	endpoint := fmt.Sprintf("/api/v1/lists/%s/trim?start=%d&stop=%d&return_removed=true", key, start, stop)
+	resp, err := c.doWrite(ctx, "POST", endpoint, nil, newWriteOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Removed []string `json:"removed"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return nil, err
+	}
+
+	return data.Removed, nil
+}
+
+// LSet replaces the list at key with items, the first item at the head. Any existing
+// value is overwritten. A ttlSeconds of 0 applies the server's default TTL for the key,
+// if any, like Set; otherwise the list does not expire.
+//
+// Example:
+//
+//	err := client.LSet(ctx, "queue:tasks", []any{"task-1", "task-2"}, 0)
+func (c *Client) LSet(ctx context.Context, key string, items []any, ttlSeconds int, opts ...WriteOption) error {
+	if ttlSeconds < 0 {
+		return fmt.Errorf("TTL must be >= 0 (0 = no expiration)")
+	}
+
+	req := LSetRequest{
+		Key:        key,
+		Items:      items,
+		TTLSeconds: ttlSeconds,
+	}
+
+	_, err := c.doWrite(ctx, "POST", "/api/v1/lists/set", req, newWriteOptions(opts))
+	return err
+}
+
+// LMoveAll atomically moves all items of the list at src onto the front of the list at
+// dst, keeping their order, and deletes src. It returns the number of items moved.
+// A missing dst is created.
+//
+// Example:
+//
+//	// Drain the old queue into the new one
+//	moved, err := client.LMoveAll(ctx, "queue:v1", "queue:v2")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println("Migrated", moved, "jobs")
+func (c *Client) LMoveAll(ctx context.Context, src, dst string, opts ...WriteOption) (int, error) {
+	req := LMoveAllRequest{Src: src, Dst: dst}
+
+	resp, err := c.doWrite(ctx, "POST", "/api/v1/lists/moveall", req, newWriteOptions(opts))
+	if err != nil {
+		return 0, err
+	}
+
+	var data struct {
+		Moved int `json:"moved"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return 0, err
+	}
+
+	return data.Moved, nil
+}
+
+// LInitNX creates the list at key holding items like LSet, but only if the key does
+// not exist. It reports whether the list was created, so exactly one of several
+// racing initializers sets up the list.
+//
+// Example:
+//
+//	created, err := client.LInitNX(ctx, "queue:shards", []any{"shard-1", "shard-2"}, 0)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if created {
+//	    fmt.Println("Initialized the shard queue")
+//	}
+func (c *Client) LInitNX(ctx context.Context, key string, items []any, ttlSeconds int, opts ...WriteOption) (bool, error) {
+	if ttlSeconds < 0 {
+		return false, fmt.Errorf("TTL must be >= 0 (0 = no expiration)")
+	}
+
+	req := LSetRequest{
+		Key:        key,
+		Items:      items,
+		TTLSeconds: ttlSeconds,
+		NX:         true,
+	}
+
+	resp, err := c.doWrite(ctx, "POST", "/api/v1/lists/set", req, newWriteOptions(opts))
+	if err != nil {
+		return false, err
+	}
+
+	var data struct {
+		Set bool `json:"set"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return false, err
+	}
+
+	return data.Set, nil
+}
+
+// ListBatch runs push and pop operations on any number of lists in one request and
+// returns one result per operation, in order. The server runs the whole batch under
+// a single lock. A failing operation does not stop the batch or undo earlier ones,
+// so check each result; the error is only non-nil if the request itself failed.
+//
+// Example:
+//
+//	results, err := client.ListBatch(ctx, []client.ListOp{
+//	    {Key: "queue:eu", Op: client.ListOpPush, Item: "job-1"},
+//	    {Key: "queue:us", Op: client.ListOpPush, Item: "job-1"},
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, r := range results {
+//	    if !r.Success {
+//	        log.Printf("%s on %s failed: %s (%s)", r.Op, r.Key, r.Error, r.Code)
+//	    }
+//	}
+func (c *Client) ListBatch(ctx context.Context, ops []ListOp, opts ...WriteOption) ([]ListOpResult, error) {
+	resp, err := c.doWrite(ctx, "POST", "/api/v1/lists/batch", ops, newWriteOptions(opts))
+	if err != nil {
+		return nil, c.unsupported(ctx, OpListBatch, err)
+	}
+
+	var data struct {
+		Results []ListOpResult `json:"results"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return nil, err
+	}
+
+	return data.Results, nil
+}
+
+// PipelineGet runs string reads and list index reads on any number of keys in one
+// request and returns one result per read, in order. The server runs all reads under
+// a single lock, so the results are a consistent view of the store. A failing read,
+// e.g. of a missing key, does not fail the others, so check each result; the error is
+// only non-nil if the request itself failed.
+//
+// Example:
+//
+//	latest := 0
+//	results, err := client.PipelineGet(ctx, []client.ReadSpec{
+//	    {Key: "user:123:name"},
+//	    {Key: "user:123:events", Index: &latest},
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, r := range results {
+//	    if r.Success {
+//	        fmt.Println(r.Key, *r.Value)
+//	    }
+//	}
+func (c *Client) PipelineGet(ctx context.Context, specs []ReadSpec) ([]ReadResult, error) {
+	resp, err := c.doRequest(ctx, "POST", "/api/v1/read/pipeline", specs)
+	if err != nil {
+		return nil, c.unsupported(ctx, OpPipelineGet, err)
+	}
+
+	var data struct {
+		Results []ReadResult `json:"results"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return nil, err
+	}
+
+	return data.Results, nil
+}
+
+// Reserve takes the item at the head of a list like Pop, but the server keeps it in
+// flight under the returned receipt. Ack the receipt once the item is processed; if
+// it is not acknowledged within visibilityTimeout, the item goes back to the head of
+// the list and is delivered again. The timeout is truncated to whole milliseconds.
+//
+// Example:
+//
+//	item, receipt, err := client.Reserve(ctx, "queue:tasks", 30*time.Second)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	process(item)
+//	if err := client.Ack(ctx, receipt); err != nil {
+//	    log.Printf("Item was redelivered: %v", err)
+//	}
+func (c *Client) Reserve(ctx context.Context, key string, visibilityTimeout time.Duration, opts ...WriteOption) (string, string, error) {
+	endpoint := fmt.Sprintf("/api/v1/lists/%s/reserve?visibility_timeout_ms=%d", key, visibilityTimeout.Milliseconds())
+	resp, err := c.doWrite(ctx, "POST", endpoint, nil, newWriteOptions(opts))
+	if err != nil {
+		return "", "", err
+	}
+
+	var data struct {
+		Item    string `json:"item"`
+		Receipt string `json:"receipt"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return "", "", err
+	}
+
+	return data.Item, data.Receipt, nil
+}
+
+// Ack deletes the item reserved under receipt. It fails with a 404 APIError if the
+// receipt is unknown, was already acknowledged or its visibility timeout has passed.
+//
+// Example:
+//
+//	err := client.Ack(ctx, receipt)
+func (c *Client) Ack(ctx context.Context, receipt string) error {
+	_, err := c.doRequest(ctx, "POST", "/api/v1/lists/ack", AckRequest{Receipt: receipt})
+	return err
+}
+
+// RateIncr counts a request against a sliding window rate limit stored at key.
+// It returns the number of requests counted in the last window and whether this
+// request is within limit. Denied requests are not counted. The window is
+// truncated to whole milliseconds.
+//
+// Example:
+//
+//	// Allow at most 100 requests per minute per user
+//	count, allowed, err := client.RateIncr(ctx, "rate:user:123", time.Minute, 100)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if !allowed {
+//	    fmt.Printf("Rate limited after %d requests\n", count)
+//	}
+func (c *Client) RateIncr(ctx context.Context, key string, window time.Duration, limit int) (int, bool, error) {
+	req := RateIncrRequest{
+		Key:      key,
+		WindowMs: window.Milliseconds(),
+		Limit:    limit,
+	}
+
+	resp, err := c.doRequest(ctx, "POST", "/api/v1/ratelimit", req)
+	if err != nil {
+		return 0, false, c.unsupported(ctx, OpRateIncr, err)
+	}
+
+	var data struct {
+		Count   int  `json:"count"`
+		Allowed bool `json:"allowed"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return 0, false, err
+	}
+
+	return data.Count, data.Allowed, nil
+}
+
+// TopKeys returns up to n keys ranked by one of TopBySize, TopByTTL or TopByAccess,
+// highest first. Sizes are estimates made by the server.
+//
+// Example:
+//
+//	// Find the 10 keys taking up the most memory
+//	keys, err := client.TopKeys(ctx, client.TopBySize, 10)
+//	for _, k := range keys {
+//	    fmt.Println(k.Key, k.SizeBytes)
+//	}
+func (c *Client) TopKeys(ctx context.Context, by string, n int) ([]KeySize, error) {
+	endpoint := fmt.Sprintf("/api/v1/admin/top?by=%s&n=%d", url.QueryEscape(by), n)
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Keys []KeySize `json:"keys"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return nil, err
+	}
+
+	return data.Keys, nil
+}
+
+// RandomKeys returns up to n distinct live keys picked uniformly at random, with their
+// estimated sizes, in no particular order. Sampling is cheaper than listing every key
+// for estimating how sizes or TTLs are distributed across a large keyspace.
+//
+// Example:
+//
+//	// Estimate the average key size from a sample
+//	keys, err := client.RandomKeys(ctx, 100)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	total := 0
+//	for _, k := range keys {
+//	    total += k.SizeBytes
+//	}
+//	fmt.Println("Average size:", total/max(len(keys), 1))
+func (c *Client) RandomKeys(ctx context.Context, n int) ([]KeySize, error) {
+	resp, err := c.doRequest(ctx, "GET", fmt.Sprintf("/api/v1/admin/sample?n=%d", n), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Keys []KeySize `json:"keys"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return nil, err
+	}
+
+	return data.Keys, nil
+}
+
+// ExportPattern dumps every key matching a glob pattern, sorted by key, with its type,
+// value and remaining TTL, e.g. to back up or migrate the keys of one tenant. The
+// pattern syntax is the same as for ExpirePattern.
+//
+// Example:
+//
+//	dump, err := client.ExportPattern(ctx, "tenant:42:*")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, k := range dump {
+//	    if k.Type == client.TypeString {
+//	        target.Set(ctx, k.Key, k.Value, max(k.TTLSeconds, 0))
+//	    }
+//	}
+func (c *Client) ExportPattern(ctx context.Context, pattern string) ([]KeyDump, error) {
+	endpoint := "/api/v1/admin/export?pattern=" + url.QueryEscape(pattern)
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Keys []KeyDump `json:"keys"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return nil, err
+	}
+
+	return data.Keys, nil
+}
+
+// VerifyIntegrity has the server check every live key against the checksum taken when
+// the key was last written, and returns a description of each key that no longer
+// matches, so is corrupted. An empty result means every key is intact.
+//
+// Example:
+//
+//	problems, err := client.VerifyIntegrity(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, p := range problems {
+//	    log.Printf("corrupted key %s", p)
+//	}
+func (c *Client) VerifyIntegrity(ctx context.Context) ([]string, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/admin/verify", nil)
+	if err != nil {
+		return nil, c.unsupported(ctx, OpVerify, err)
+	}
+
+	var data VerifyResponse
+	if err := decodeData(resp, &data); err != nil {
+		return nil, err
+	}
+
+	return data.Problems, nil
+}
+
+// TTLHistogram counts live keys by remaining TTL. Each key is counted under the smallest
+// bucket its TTL does not exceed, labeled by the bucket's duration string, e.g.
+// "1h0m0s", or under TTLBucketLonger or TTLBucketNever. Without buckets the server
+// uses 1m, 1h and 24h.
+//
+// Example:
+//
+//	histogram, err := client.TTLHistogram(ctx, time.Minute, time.Hour)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println("Expiring within a minute:", histogram[time.Minute.String()])
+func (c *Client) TTLHistogram(ctx context.Context, buckets ...time.Duration) (map[string]int, error) {
+	endpoint := "/api/v1/admin/ttl-histogram"
+	if len(buckets) > 0 {
+		bounds := make([]string, len(buckets))
+		for i, b := range buckets {
+			bounds[i] = b.String()
+		}
+		endpoint += "?buckets=" + url.QueryEscape(strings.Join(bounds, ","))
+	}
+
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Buckets map[string]int `json:"buckets"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return nil, err
+	}
+
+	return data.Buckets, nil
+}
+
+// Stats returns runtime statistics of the server's store: the number of live keys by
+// type and with a TTL, their estimated memory use and lock contention counters.
+//
+// Example:
+//
+//	stats, err := client.Stats(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Printf("%d keys (%d lists), ~%d bytes\n", stats.Keys, stats.Lists, stats.MemoryBytes)
+func (c *Client) Stats(ctx context.Context) (*StoreStats, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/stats", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats StoreStats
+	if err := decodeData(resp, &stats); err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// PauseTTLWorker stops the server from sweeping expired keys until ResumeTTLWorker is
+// called, e.g. during a bulk load of keys with short TTLs. Reads still treat expired
+// keys as missing; only their deletion is put off.
+//
+// Example:
+//
+//	if err := client.PauseTTLWorker(ctx); err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer client.ResumeTTLWorker(ctx)
+//	load(ctx, client)
+func (c *Client) PauseTTLWorker(ctx context.Context) error {
+	_, err := c.doRequest(ctx, "POST", "/api/v1/admin/ttl-worker/pause", nil)
+	return err
+}
+
+// ResumeTTLWorker resumes sweeping expired keys after PauseTTLWorker. Keys that
+// expired in the meantime are deleted by the next sweep.
+//
+// Example:
+//
+//	err := client.ResumeTTLWorker(ctx)
+func (c *Client) ResumeTTLWorker(ctx context.Context) error {
+	_, err := c.doRequest(ctx, "POST", "/api/v1/admin/ttl-worker/resume", nil)
+	return err
+}
+
+// ServerTime returns the server's current time. Compare it with the local clock to
+// account for clock skew when turning relative TTLs into absolute deadlines. The
+// result is as of some point during the request, so it is off by up to the round trip.
+//
+// Example:
+//
+//	serverNow, err := client.ServerTime(ctx)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	skew := serverNow.Sub(time.Now())
+func (c *Client) ServerTime(ctx context.Context) (time.Time, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/time", nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var data struct {
+		UnixMs int64 `json:"unix_ms"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return time.Time{}, err
+	}
+
+	return time.UnixMilli(data.UnixMs), nil
+}
+
+// itemJSON returns a list item as JSON. Items that are not valid JSON were pushed
+// as plain strings and are encoded as a JSON string.
+func itemJSON(item string) json.RawMessage {
+	if json.Valid([]byte(item)) {
+		return json.RawMessage(item)
+	}
+
+	b, _ := json.Marshal(item)
+	return b
+}
+
+// decodeData decodes the data payload of a response into out.
+func decodeData(resp *Response, out any) error {
+	raw, err := json.Marshal(resp.Data)
+	if err != nil {
+		return fmt.Errorf("unexpected response format: %w", err)
+	}
+
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("unexpected response format: %w", err)
+	}
+
+	return nil
+}
+
+// unmarshal decodes a value read from the store into out, keeping numbers as
+// json.Number if the client was created WithUseNumber.
+func (c *Client) unmarshal(data []byte, out any) error {
+	if !c.useNumber {
+		return json.Unmarshal(data, out)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(out)
+}
+
+// doRequest performs an HTTP request and handles the response.
+// This is an internal method used by all public client methods.
+// With a local fallback configured, requests the server cannot be reached for
+// are served by the fallback store instead.
+func (c *Client) doRequest(ctx context.Context, method, endpoint string, body any) (*Response, error) {
+	return c.doWrite(ctx, method, endpoint, body, writeOptions{})
+}
+
+// doWrite performs a request like doRequest, sending the headers of the write
+// options o, such as the fence token.
+func (c *Client) doWrite(ctx context.Context, method, endpoint string, body any, o writeOptions) (*Response, error) {
+	header := o.header()
+	if c.fallback != nil {
+		return c.fallback.do(ctx, c, method, endpoint, body, header)
+	}
+	return c.send(ctx, method, endpoint, body, header)
+}
+
+// send performs an HTTP request against the server, retrying rate limited requests
+// as configured by WithRateLimitRetries and transient failures as configured by
+// WithRetries. The body is encoded once and sent again as is on every attempt, with
+// header added to the request.
+func (c *Client) send(ctx context.Context, method, endpoint string, body any, header http.Header) (*Response, error) {
+	payload, err := encodeBody(body)
+	if err != nil {
+		return nil, err
+	}
+	ctx = c.withGeneratedRequestID(ctx)
+
+	rateLimited, failed := 0, 0
+	for {
+		resp, err := c.sendOnce(ctx, method, endpoint, payload, header)
+
+		var delay time.Duration
+		var apiErr *APIError
+		switch {
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests:
+			if rateLimited >= c.rateLimitRetries {
+				return resp, err
+			}
+			rateLimited++
+
+			delay = apiErr.RetryAfter
+			if delay <= 0 {
+				delay = defaultRateLimitDelay
+			}
+		case failed < c.maxRetries && idempotent(method) && transient(ctx, err):
+			delay = c.retryBaseDelay << failed
+			failed++
+
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+				return resp, err
+			}
+		default:
+			return resp, err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		case <-timer.C:
+		}
+	}
+}
+
+// idempotent reports whether requests of method can be sent again safely.
+func idempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodPut || method == http.MethodDelete
+}
+
+// transient reports whether err is a failure that may not happen again on a retry:
+// the server could not be reached or failed with a 5xx status. Nothing is transient
+// once ctx is done.
+func transient(ctx context.Context, err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	return unreachable(ctx, err)
+}
+
+// sendOnce performs a single HTTP request against the server.
+func (c *Client) sendOnce(ctx context.Context, method, endpoint string, payload []byte, header http.Header) (apiResp *Response, err error) {
+	req, err := newRequest(ctx, method, c.baseURL+endpoint, payload, header)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *http.Response
+	if c.requestHook != nil {
+		defer func() { c.requestHook(req, resp, err) }()
+	}
+
+	resp, err = c.httpClientFor(ctx).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to perform request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	apiResp, err = parseResponse(resp.StatusCode, respBody)
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		apiErr.RequestID = resp.Header.Get(requestIDHeader)
+	}
+
+	// Prefer the precise delay from the body, fall back to the Retry-After header.
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests && apiErr.RetryAfter == 0 {
+		if seconds, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil {
+			apiErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+	}
+
+	return apiResp, err
+}
+
+// encodeBody returns the JSON encoding of a request body, nil if there is none.
+func encodeBody(body any) ([]byte, error) {
+	if body == nil {
+		return nil, nil
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+	return payload, nil
+}
+
+// newRequest builds a request with payload, the encoded body, if not nil, and header.
+// The payload is only read, so it can be reused for another request.
+func newRequest(ctx context.Context, method, url string, payload []byte, header http.Header) (*http.Request, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if id := requestID(ctx); id != "" {
+		req.Header.Set(requestIDHeader, id)
+	}
+
+	return req, nil
+}
+
+// parseResponse decodes an API response, returning an *APIError for unsuccessful ones.
+// Responses without a body, such as those to HEAD requests, are judged by status code.
+func parseResponse(statusCode int, respBody []byte) (*Response, error) {
+	if len(respBody) == 0 {
+		if statusCode < 200 || statusCode > 299 {
+			return &Response{}, &APIError{StatusCode: statusCode, Message: http.StatusText(statusCode)}
+		}
+		return &Response{Success: true}, nil
+	}
+
+	// Numbers are kept as json.Number so decodeData marshals them back exactly.
+	var apiResp Response
+	dec := json.NewDecoder(bytes.NewReader(respBody))
+	dec.UseNumber()
+	if err := dec.Decode(&apiResp); err != nil {
+		// Errors answered outside the API, such as the plain text 404 of a route an
+		// older server does not have, keep their status code.
+		if statusCode < 200 || statusCode > 299 {
+			return &Response{}, &APIError{StatusCode: statusCode, Message: strings.TrimSpace(string(respBody))}
+		}
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if !apiResp.Success {
+		return &apiResp, &APIError{
+			StatusCode: statusCode,
+			Message:    apiResp.Error,
+			Code:       apiResp.Code,
+			RetryAfter: time.Duration(apiResp.RetryAfterMs) * time.Millisecond,
+		}
 	}
 
 	return &apiResp, nil
@@ -3,11 +3,18 @@ package client_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/mo-mohamed/acronis-memory-store/internal/api"
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
 	"github.com/mo-mohamed/acronis-memory-store/pkg/client"
 )
 
@@ -154,6 +161,19 @@ func TestClient_Pop_EmptyList(t *testing.T) {
 	}
 }
 
+// storeServer runs the real API on top of a fresh memory store.
+func storeServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	memoryStore := memory.NewMemoryStore()
+	server := httptest.NewServer(api.NewHandler(memoryStore).SetupRoutes())
+	t.Cleanup(func() {
+		server.Close()
+		memoryStore.StopTTLWorker()
+	})
+	return server
+}
+
 // mockServer mimics the memory store API
 func mockServer() *httptest.Server {
 	mux := http.NewServeMux()
@@ -266,3 +286,2119 @@ func mockServer() *httptest.Server {
 
 	return httptest.NewServer(mux)
 }
+
+func TestClient_MultiGet(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "user:1", "Alice", 60)
+	c.Push(ctx, "queue", "job2")
+	c.Push(ctx, "queue", "job1")
+
+	entries, err := c.MultiGet(ctx, []string{"user:1", "queue", "missing"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+
+	if entries[0].Type != client.TypeString || entries[0].Value != "Alice" || entries[0].TTLSeconds <= 0 {
+		t.Errorf("Unexpected string entry: %+v", entries[0])
+	}
+
+	if entries[1].Type != client.TypeList || len(entries[1].Items) != 2 || entries[1].Items[0] != "job1" {
+		t.Errorf("Unexpected list entry: %+v", entries[1])
+	}
+	if entries[1].TTLSeconds != -1 {
+		t.Errorf("Expected TTL -1 for list without expiration, got %d", entries[1].TTLSeconds)
+	}
+
+	if entries[2].Type != client.TypeNone {
+		t.Errorf("Expected missing key to have type none, got %+v", entries[2])
+	}
+}
+
+func TestClient_MSetMGet(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	pairs := map[string]any{}
+	for i := 0; i < 100; i++ {
+		pairs[fmt.Sprintf("user:%d", i)] = fmt.Sprintf("name-%d", i)
+	}
+	if err := c.MSet(ctx, pairs, 0); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	values, err := c.MGet(ctx, []string{"user:0", "user:99", "user:100"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(values) != 2 || values["user:0"] != "name-0" || values["user:99"] != "name-99" {
+		t.Errorf("Expected the two existing keys, got %v", values)
+	}
+
+	var apiErr *client.APIError
+	if err := c.MSet(ctx, map[string]any{}, 0); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for no pairs, got %v", err)
+	}
+	if err := c.MSet(ctx, map[string]any{"": "x"}, 0); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an empty key, got %v", err)
+	}
+	if _, err := c.MGet(ctx, nil); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for no keys, got %v", err)
+	}
+}
+
+func TestClient_MSetChunked(t *testing.T) {
+	memoryStore := memory.NewMemoryStoreWithOptions(memory.Options{MaxKeys: 150})
+	defer memoryStore.StopTTLWorker()
+	server := httptest.NewServer(api.NewHandler(memoryStore).SetupRoutes())
+	defer server.Close()
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	pairs := map[string]any{}
+	for i := 0; i < 100; i++ {
+		pairs[fmt.Sprintf("user:%03d", i)] = fmt.Sprintf("name-%d", i)
+	}
+	written, err := c.MSetChunked(ctx, pairs, 0, 10)
+	if err != nil || written != 100 {
+		t.Fatalf("Expected 100 keys written, got %d, %v", written, err)
+	}
+	if values, _ := c.MGet(ctx, []string{"user:000", "user:099"}); len(values) != 2 {
+		t.Errorf("Expected every chunk written, got %v", values)
+	}
+
+	// The key limit stops the batch part way, and the keys written before it are reported
+	more := map[string]any{}
+	for i := 100; i < 200; i++ {
+		more[fmt.Sprintf("user:%03d", i)] = "x"
+	}
+	var apiErr *client.APIError
+	written, err = c.MSetChunked(ctx, more, 0, 10)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInsufficientStorage {
+		t.Errorf("Expected 507 at the key limit, got %v", err)
+	}
+	if written != 50 {
+		t.Errorf("Expected 50 keys written before the limit, got %d", written)
+	}
+
+	if _, err := c.MSetChunked(ctx, pairs, 0, -1); err == nil {
+		t.Error("Expected an error for a negative chunk size")
+	}
+}
+
+func TestClient_ReturnPrevious(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	var previous *client.KeyEntry
+	if err := c.Set(ctx, "mode", "normal", 0, client.ReturnPrevious(&previous)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if previous != nil {
+		t.Errorf("Expected no previous entry for new key, got %+v", previous)
+	}
+
+	if err := c.Set(ctx, "mode", "maintenance", 0, client.ReturnPrevious(&previous)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if previous == nil || previous.Type != client.TypeString || previous.Value != "normal" {
+		t.Errorf("Expected previous value 'normal', got %+v", previous)
+	}
+
+	if err := c.Expire(ctx, "mode", 60, client.ReturnPrevious(&previous)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if previous == nil || previous.Value != "maintenance" || previous.TTLSeconds != -1 {
+		t.Errorf("Expected previous value 'maintenance' without TTL, got %+v", previous)
+	}
+
+	if err := c.Remove(ctx, "mode", client.ReturnPrevious(&previous)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if previous == nil || previous.Value != "maintenance" || previous.TTLSeconds <= 0 {
+		t.Errorf("Expected removed value 'maintenance' with TTL, got %+v", previous)
+	}
+
+	err := c.Remove(ctx, "mode", client.ReturnPrevious(&previous))
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 removing missing key, got %v", err)
+	}
+}
+
+func TestClient_RateIncr(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	for i := 1; i <= 3; i++ {
+		count, allowed, err := c.RateIncr(ctx, "rate:user:1", time.Second, 3)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !allowed || count != i {
+			t.Errorf("Expected request %d to be allowed, got allowed=%v count=%d", i, allowed, count)
+		}
+	}
+
+	if _, allowed, _ := c.RateIncr(ctx, "rate:user:1", time.Second, 3); allowed {
+		t.Error("Expected request over the limit to be denied")
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	if _, allowed, _ := c.RateIncr(ctx, "rate:user:1", time.Second, 3); !allowed {
+		t.Error("Expected request to be allowed once the window has passed")
+	}
+
+	_, _, err := c.RateIncr(ctx, "rate:user:1", time.Second, 0)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for zero limit, got %v", err)
+	}
+}
+
+type testTask struct {
+	ID       string   `json:"id"`
+	Priority int      `json:"priority"`
+	Tags     []string `json:"tags"`
+}
+
+func TestClient_PopJSON(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	pushed := testTask{ID: "task-1", Priority: 2, Tags: []string{"backup"}}
+	if err := c.Push(ctx, "queue", pushed); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var popped testTask
+	if err := c.PopJSON(ctx, "queue", &popped); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if popped.ID != pushed.ID || popped.Priority != pushed.Priority || len(popped.Tags) != 1 || popped.Tags[0] != "backup" {
+		t.Errorf("Expected %+v, got %+v", pushed, popped)
+	}
+
+	// Plain strings decode into a string but not into a struct.
+	c.Push(ctx, "queue", "plain text")
+	c.Push(ctx, "queue", "plain text")
+
+	var s string
+	if err := c.PopJSON(ctx, "queue", &s); err != nil || s != "plain text" {
+		t.Errorf("Expected 'plain text', got %q (err %v)", s, err)
+	}
+
+	if err := c.PopJSON(ctx, "queue", &popped); err == nil {
+		t.Error("Expected error decoding a plain string into a struct")
+	}
+}
+
+func TestClient_SoftDelete(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "user:1", "Alice", 0)
+	if err := c.Remove(ctx, "user:1", client.SoftDelete()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := c.Get(ctx, "user:1"); err == nil {
+		t.Error("Expected soft deleted key to be gone")
+	}
+
+	if err := c.Restore(ctx, "user:1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if value, _ := c.Get(ctx, "user:1"); value != "Alice" {
+		t.Errorf("Expected restored value Alice, got %s", value)
+	}
+
+	// Keys removed without SoftDelete cannot be restored.
+	c.Remove(ctx, "user:1")
+	err := c.Restore(ctx, "user:1")
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 restoring a hard deleted key, got %v", err)
+	}
+}
+
+func TestClient_SetIfExpiringWithin(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	set, err := c.SetIfExpiringWithin(ctx, "cache", "v1", 60, 10)
+	if err != nil || !set {
+		t.Fatalf("Expected missing key to be set, got %v (err %v)", set, err)
+	}
+
+	if set, _ := c.SetIfExpiringWithin(ctx, "cache", "v2", 60, 10); set {
+		t.Error("Expected key far from expiring not to be set")
+	}
+
+	c.Set(ctx, "cache", "v3", 2)
+	if set, _ := c.SetIfExpiringWithin(ctx, "cache", "v4", 60, 10); !set {
+		t.Error("Expected key about to expire to be set")
+	}
+	if value, _ := c.Get(ctx, "cache"); value != "v4" {
+		t.Errorf("Expected v4, got %s", value)
+	}
+}
+
+func TestClient_SetNX(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	if set, err := c.SetNX(ctx, "mutex", "owner-1", 60); err != nil || !set {
+		t.Fatalf("Expected missing key to be set, got %v (err %v)", set, err)
+	}
+	if set, err := c.SetNX(ctx, "mutex", "owner-2", 60); err != nil || set {
+		t.Errorf("Expected existing key not to be set, got %v (err %v)", set, err)
+	}
+	if value, _ := c.Get(ctx, "mutex"); value != "owner-1" {
+		t.Errorf("Expected the first owner to keep the key, got %s", value)
+	}
+
+	c.Remove(ctx, "mutex")
+	if set, err := c.SetNX(ctx, "mutex", "owner-2", 60); err != nil || !set {
+		t.Errorf("Expected released key to be set again, got %v (err %v)", set, err)
+	}
+
+	if _, err := c.SetNX(ctx, "mutex", "owner-3", -1); err == nil {
+		t.Error("Expected an error for a negative TTL")
+	}
+}
+
+func TestClient_SetIfType(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	if set, err := c.SetIfType(ctx, "profile", "alice", 0, "string"); err != nil || !set {
+		t.Fatalf("Expected missing key to be set, got %v (err %v)", set, err)
+	}
+	if set, err := c.SetIfType(ctx, "profile", "bob", 0, "string"); err != nil || !set {
+		t.Errorf("Expected string key to be replaced, got %v (err %v)", set, err)
+	}
+
+	c.Push(ctx, "queue", "job")
+	if set, err := c.SetIfType(ctx, "queue", "bob", 0, "string"); err != nil || set {
+		t.Errorf("Expected list not to be overwritten, got %v (err %v)", set, err)
+	}
+	if items, _ := c.LRange(ctx, "queue", 0, -1); len(items) != 1 {
+		t.Errorf("Expected list to stay intact, got %v", items)
+	}
+
+	for _, expectedType := range []string{"list", "hash"} {
+		if _, err := c.SetIfType(ctx, "queue", "bob", 0, expectedType); err == nil {
+			t.Errorf("Expected an error for %s", expectedType)
+		}
+	}
+}
+
+func TestClient_GetSet(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "token", "old", 0)
+	previous, err := c.GetSet(ctx, "token", "new")
+	if err != nil || previous != "old" {
+		t.Fatalf("Expected previous value old, got %q (err %v)", previous, err)
+	}
+	if value, _ := c.Get(ctx, "token"); value != "new" {
+		t.Errorf("Expected new, got %q", value)
+	}
+
+	var apiErr *client.APIError
+	if _, err := c.GetSet(ctx, "missing", "v"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing key, got %v", err)
+	}
+	c.Push(ctx, "queue", "job")
+	if _, err := c.GetSet(ctx, "queue", "v"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 for a list, got %v", err)
+	}
+}
+
+func TestClient_LRangePage(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	for i := 0; i < 7; i++ {
+		c.Push(ctx, "queue", fmt.Sprintf("job%d", i))
+	}
+
+	var items []string
+	for start := 0; ; start += 3 {
+		page, err := c.LRangePage(ctx, "queue", start, start+2)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if page.Total != 7 {
+			t.Errorf("Expected total 7 for page at %d, got %d", start, page.Total)
+		}
+		if page.Start != start {
+			t.Errorf("Expected page to start at %d, got %d", start, page.Start)
+		}
+		items = append(items, page.Items...)
+		if page.Stop+1 >= page.Total {
+			break
+		}
+	}
+
+	if len(items) != 7 || items[0] != "job6" || items[6] != "job0" {
+		t.Errorf("Expected all 7 jobs newest first, got %v", items)
+	}
+}
+
+func TestClient_LRangeWithMeta(t *testing.T) {
+	memoryStore := memory.NewMemoryStoreWithOptions(memory.Options{ListPushTimes: true})
+	defer memoryStore.StopTTLWorker()
+	server := httptest.NewServer(api.NewHandler(memoryStore).SetupRoutes())
+	defer server.Close()
+
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	before := time.Now()
+	c.RPush(ctx, "queue", "a")
+	c.RPush(ctx, "queue", "b")
+	c.Pop(ctx, "queue")
+	c.RPush(ctx, "queue", "c")
+
+	items, err := c.LRangeWithMeta(ctx, "queue", 0, -1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(items) != 2 || items[0].Value != "b" || items[1].Value != "c" || items[0].Seq != 2 || items[1].Seq != 3 {
+		t.Fatalf("Expected b and c with seqs 2 and 3, got %+v", items)
+	}
+	if items[0].PushedAt.Before(before) || items[1].PushedAt.Before(items[0].PushedAt) {
+		t.Errorf("Expected increasing push times since the test started, got %v and %v", items[0].PushedAt, items[1].PushedAt)
+	}
+
+	// The plain range is unchanged
+	if got, _ := c.LRange(ctx, "queue", 0, -1); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("Expected [b c], got %v", got)
+	}
+}
+
+func TestClient_LClear(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.LSet(ctx, "queue", []any{"a", "b"}, 3600)
+	c.Set(ctx, "name", "alice", 0)
+
+	if err := c.LClear(ctx, "queue"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	page, err := c.LRangePage(ctx, "queue", 0, -1)
+	if err != nil || page.Total != 0 {
+		t.Errorf("Expected the list to exist with length 0, got %+v, %v", page, err)
+	}
+	if ttl, _ := c.TTL(ctx, "queue"); ttl <= 0 {
+		t.Errorf("Expected the TTL to be kept, got %d", ttl)
+	}
+
+	var apiErr *client.APIError
+	if err := c.LClear(ctx, "name"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected a 409 APIError, got %v", err)
+	}
+	if err := c.LClear(ctx, "missing"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected a 404 APIError, got %v", err)
+	}
+}
+
+func TestClient_LTrim(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	for _, item := range []string{"e", "d", "c", "b", "a"} {
+		c.Push(ctx, "events", item)
+	}
+
+	removed, err := c.LTrimReturn(ctx, "events", 1, -2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.Join(removed, ",") != "a,e" {
+		t.Errorf("Expected removed [a e], got %v", removed)
+	}
+
+	if err := c.LTrim(ctx, "events", 0, 0); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	items, _ := c.LRange(ctx, "events", 0, -1)
+	if strings.Join(items, ",") != "b" {
+		t.Errorf("Expected [b] after trimming, got %v", items)
+	}
+
+	err = c.LTrim(ctx, "missing", 0, 0)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for missing list, got %v", err)
+	}
+}
+
+func TestClient_LRangeJSON(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Push(ctx, "queue", testTask{ID: "task-2", Priority: 1})
+	c.Push(ctx, "queue", testTask{ID: "task-1", Priority: 5})
+
+	var tasks []testTask
+	if err := c.LRangeJSON(ctx, "queue", 0, -1, &tasks); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].ID != "task-1" || tasks[0].Priority != 5 || tasks[1].ID != "task-2" {
+		t.Errorf("Unexpected tasks: %+v", tasks)
+	}
+
+	// LRange does not remove items.
+	items, err := c.LRange(ctx, "queue", -1, -1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(items) != 1 || !strings.Contains(items[0], "task-2") {
+		t.Errorf("Expected the last item to be task-2, got %v", items)
+	}
+
+	c.Push(ctx, "words", "b")
+	c.Push(ctx, "words", "a")
+	var words []string
+	if err := c.LRangeJSON(ctx, "words", 0, -1, &words); err != nil || len(words) != 2 || words[0] != "a" {
+		t.Errorf("Expected [a b], got %v (err %v)", words, err)
+	}
+
+	err = c.LRangeJSON(ctx, "missing", 0, -1, &tasks)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for missing list, got %v", err)
+	}
+}
+
+func TestClient_ExpirePattern(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "feature:beta:search", "on", 0)
+	c.Set(ctx, "feature:beta:export", "on", 0)
+	c.Set(ctx, "feature:stable:search", "on", 0)
+
+	count, err := c.ExpirePattern(ctx, "feature:beta:*", 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 keys to be expired, got %d", count)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	if _, err := c.Get(ctx, "feature:beta:search"); err == nil {
+		t.Error("Expected matching key to have expired")
+	}
+	if value, err := c.Get(ctx, "feature:stable:search"); err != nil || value != "on" {
+		t.Errorf("Expected non-matching key to remain, got %q (err %v)", value, err)
+	}
+
+	_, err = c.ExpirePattern(ctx, "feature:[beta", 1)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid pattern, got %v", err)
+	}
+}
+
+func TestClient_Tags(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "profile:123", "alice", 0, client.Tags("user:123"))
+	c.Set(ctx, "orders:123", "[]", 0, client.Tags("user:123", "orders"))
+	c.Set(ctx, "profile:456", "bob", 0, client.Tags("user:456"))
+
+	keys, err := c.KeysByTag(ctx, "user:123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.Join(keys, ",") != "orders:123,profile:123" {
+		t.Errorf("Expected [orders:123 profile:123], got %v", keys)
+	}
+
+	c.Remove(ctx, "orders:123")
+	deleted, err := c.DeleteByTag(ctx, "user:123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("Expected 1 key deleted after the individual removal, got %d", deleted)
+	}
+	if _, err := c.Get(ctx, "profile:456"); err != nil {
+		t.Errorf("Expected keys with other tags to be kept, got %v", err)
+	}
+	if keys, _ := c.KeysByTag(ctx, "user:123"); len(keys) != 0 {
+		t.Errorf("Expected no keys left, got %v", keys)
+	}
+
+	err = c.Set(ctx, "key", "value", 0, client.Tags(""))
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an empty tag, got %v", err)
+	}
+}
+
+func TestClient_CountPattern(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "session:1", "a", 0)
+	c.Set(ctx, "session:2", "b", 0)
+	c.Set(ctx, "user:1", "c", 0)
+
+	count, err := c.CountPattern(ctx, "session:*")
+	if err != nil || count != 2 {
+		t.Errorf("Expected 2 sessions, got %d (err %v)", count, err)
+	}
+
+	if count, _ := c.CountPattern(ctx, "*"); count != 3 {
+		t.Errorf("Expected 3 keys, got %d", count)
+	}
+
+	// A key named like the endpoint stays reachable.
+	c.Set(ctx, "count", "value", 0)
+	if value, err := c.Get(ctx, "count"); err != nil || value != "value" {
+		t.Errorf("Expected key 'count' to be readable, got %q (err %v)", value, err)
+	}
+}
+
+func TestClient_GetAny(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "user:1", "Alice", 0)
+	c.Push(ctx, "queue", "job2")
+	c.Push(ctx, "queue", "job1")
+
+	v, err := c.GetAny(ctx, "user:1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if v.Type != client.TypeString || v.Value != "Alice" || v.Items != nil {
+		t.Errorf("Unexpected string value: %+v", v)
+	}
+
+	v, err = c.GetAny(ctx, "queue")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if v.Type != client.TypeList || len(v.Items) != 2 || v.Items[0] != "job1" || v.Value != "" {
+		t.Errorf("Unexpected list value: %+v", v)
+	}
+
+	_, err = c.GetAny(ctx, "missing")
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for missing key, got %v", err)
+	}
+}
+
+// rateLimitedServer rejects the first rejections requests with a 429 asking to retry after retryAfterMs.
+func rateLimitedServer(rejections int, retryAfterMs int64) (*httptest.Server, *atomic.Int32) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if int(attempts.Add(1)) <= rejections {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(client.Response{Success: false, Error: "Too many requests", Code: client.CodeRateLimited, RetryAfterMs: retryAfterMs})
+			return
+		}
+		json.NewEncoder(w).Encode(client.Response{Success: true, Data: map[string]string{"key": "k", "value": "v"}})
+	}))
+	return server, &attempts
+}
+
+func TestClient_RateLimitRetry(t *testing.T) {
+	server, attempts := rateLimitedServer(2, 100)
+	defer server.Close()
+
+	c := client.NewClient(server.URL, client.WithRateLimitRetries(3))
+
+	start := time.Now()
+	value, err := c.Get(context.Background(), "k")
+	elapsed := time.Since(start)
+
+	if err != nil || value != "v" {
+		t.Fatalf("Expected retries to succeed, got %q (err %v)", value, err)
+	}
+	if n := attempts.Load(); n != 3 {
+		t.Errorf("Expected 3 attempts, got %d", n)
+	}
+
+	// The client waits for retry_after_ms rather than the whole-second Retry-After header.
+	if elapsed < 200*time.Millisecond || elapsed >= time.Second {
+		t.Errorf("Expected two waits of 100ms, took %v", elapsed)
+	}
+}
+
+func TestClient_RateLimitError(t *testing.T) {
+	server, attempts := rateLimitedServer(5, 100)
+	defer server.Close()
+
+	c := client.NewClient(server.URL, client.WithRateLimitRetries(1))
+
+	_, err := c.Get(context.Background(), "k")
+
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests || apiErr.Code != client.CodeRateLimited || apiErr.RetryAfter != 100*time.Millisecond {
+		t.Errorf("Unexpected rate limit error: %+v", apiErr)
+	}
+	if n := attempts.Load(); n != 2 {
+		t.Errorf("Expected 2 attempts with 1 retry, got %d", n)
+	}
+
+	// Without retries the error is returned right away.
+	attempts.Store(0)
+	if _, err := client.NewClient(server.URL).Get(context.Background(), "k"); err == nil || attempts.Load() != 1 {
+		t.Errorf("Expected a single failed attempt without retries, got %d (err %v)", attempts.Load(), err)
+	}
+}
+
+// flakyServer serves a store, failing the next failures requests either with a 503
+// or, if drop is set, by closing the connection without a response. It counts every
+// request in attempts.
+func flakyServer(t *testing.T, drop bool) (server *httptest.Server, attempts, failures *atomic.Int32) {
+	memoryStore := memory.NewMemoryStore()
+	t.Cleanup(memoryStore.StopTTLWorker)
+	handler := api.NewHandler(memoryStore).SetupRoutes()
+
+	attempts, failures = new(atomic.Int32), new(atomic.Int32)
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		if failures.Add(-1) < 0 {
+			failures.Store(0)
+			handler.ServeHTTP(w, r)
+			return
+		}
+		if drop {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+	return server, attempts, failures
+}
+
+func TestClient_Retries(t *testing.T) {
+	ctx := context.Background()
+
+	for _, drop := range []bool{false, true} {
+		server, attempts, failures := flakyServer(t, drop)
+		c := client.NewClient(server.URL, client.WithRetries(3, 10*time.Millisecond))
+		if err := c.Set(ctx, "k", "v", 0); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+
+		// The body is sent again on every attempt
+		attempts.Store(0)
+		failures.Store(2)
+		if err := c.Update(ctx, "k", "updated"); err != nil {
+			t.Fatalf("Expected the update to succeed on the third attempt, got %v (drop %v)", err, drop)
+		}
+		if n := attempts.Load(); n != 3 {
+			t.Errorf("Expected 3 attempts, got %d (drop %v)", n, drop)
+		}
+		if value, _ := c.Get(ctx, "k"); value != "updated" {
+			t.Errorf("Expected updated, got %q (drop %v)", value, drop)
+		}
+
+		// POST requests may have been applied, so they are not retried
+		attempts.Store(0)
+		failures.Store(1)
+		if err := c.Set(ctx, "k", "v", 0); err == nil || attempts.Load() != 1 {
+			t.Errorf("Expected a single failed POST attempt, got %d (err %v, drop %v)", attempts.Load(), err, drop)
+		}
+	}
+
+	server, attempts, failures := flakyServer(t, false)
+	c := client.NewClient(server.URL, client.WithRetries(3, 10*time.Millisecond))
+
+	// Retries back off exponentially, waiting 10ms, 20ms and 40ms before giving up
+	failures.Store(10)
+	start := time.Now()
+	_, err := c.Get(ctx, "k")
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected the last 503 once retries are exhausted, got %v", err)
+	}
+	if n := attempts.Load(); n != 4 {
+		t.Errorf("Expected 4 attempts, got %d", n)
+	}
+	if elapsed := time.Since(start); elapsed < 70*time.Millisecond {
+		t.Errorf("Expected waits of 70ms in total, took %v", elapsed)
+	}
+
+	// No retry is attempted when the deadline would pass before it
+	attempts.Store(0)
+	slow := client.NewClient(server.URL, client.WithRetries(3, time.Second))
+	deadline, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if _, err := slow.Get(deadline, "k"); err == nil || attempts.Load() != 1 {
+		t.Errorf("Expected a single attempt within the deadline, got %d (err %v)", attempts.Load(), err)
+	}
+
+	// 4xx responses are not retried
+	attempts.Store(0)
+	failures.Store(0)
+	if _, err := c.Get(ctx, "missing"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404, got %v", err)
+	}
+	if n := attempts.Load(); n != 1 {
+		t.Errorf("Expected a single attempt for a 404, got %d", n)
+	}
+}
+
+// countingTransport counts the requests sent through it.
+type countingTransport struct {
+	requests atomic.Int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClient_HTTPOptions(t *testing.T) {
+	ctx := context.Background()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		json.NewEncoder(w).Encode(client.Response{Success: true, Data: map[string]string{"key": "k", "value": "v"}})
+	}))
+	defer slow.Close()
+
+	if _, err := client.NewClient(slow.URL, client.WithTimeout(50*time.Millisecond)).Get(ctx, "k"); err == nil {
+		t.Error("Expected the request to time out")
+	}
+	if value, err := client.NewClient(slow.URL).Get(ctx, "k"); err != nil || value != "v" {
+		t.Errorf("Expected the default timeout to wait for the response, got %q (err %v)", value, err)
+	}
+
+	// The injected client sends every request, and its settings win over WithTimeout
+	transport := &countingTransport{}
+	injected := &http.Client{Transport: transport, Timeout: time.Second}
+	for _, c := range []*client.Client{
+		client.NewClient(slow.URL, client.WithHTTPClient(injected), client.WithTimeout(50*time.Millisecond)),
+		client.NewClient(slow.URL, client.WithTimeout(50*time.Millisecond), client.WithHTTPClient(injected)),
+	} {
+		if value, err := c.Get(ctx, "k"); err != nil || value != "v" {
+			t.Errorf("Expected the injected client's timeout to apply, got %q (err %v)", value, err)
+		}
+	}
+	if n := transport.requests.Load(); n != 2 {
+		t.Errorf("Expected 2 requests through the injected transport, got %d", n)
+	}
+}
+
+func TestClient_TopKeys(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "small", "x", 0)
+	c.Set(ctx, "large", strings.Repeat("x", 500), 0)
+	c.Set(ctx, "medium", strings.Repeat("x", 50), 0)
+
+	keys, err := c.TopKeys(ctx, client.TopBySize, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(keys) != 2 || keys[0].Key != "large" || keys[1].Key != "medium" {
+		t.Fatalf("Expected [large medium], got %+v", keys)
+	}
+	if keys[0].SizeBytes < 500 || keys[0].TTLSeconds != -1 || keys[0].Type != client.TypeString {
+		t.Errorf("Unexpected key details: %+v", keys[0])
+	}
+
+	c.Get(ctx, "small")
+	keys, err = c.TopKeys(ctx, client.TopByAccess, 1)
+	if err != nil || len(keys) != 1 || keys[0].Key != "small" || keys[0].Hits != 2 {
+		t.Errorf("Expected small with 2 hits, got %+v (err %v)", keys, err)
+	}
+
+	_, err = c.TopKeys(ctx, "name", 1)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown ordering, got %v", err)
+	}
+}
+
+func TestClient_RandomKeys(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		c.Set(ctx, fmt.Sprintf("key%d", i), "value", 0)
+	}
+
+	keys, err := c.RandomKeys(ctx, 3)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(keys) != 3 {
+		t.Fatalf("Expected 3 keys, got %+v", keys)
+	}
+	if keys[0].SizeBytes <= 0 || keys[0].TTLSeconds != -1 {
+		t.Errorf("Unexpected key details: %+v", keys[0])
+	}
+
+	_, err = c.RandomKeys(ctx, 0)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for n 0, got %v", err)
+	}
+}
+
+func TestClient_Stats(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "name", "alice", 0)
+	c.Set(ctx, "session", "token", 60)
+	c.RPush(ctx, "queue", "job")
+
+	stats, err := c.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if stats.Keys != 3 || stats.Strings != 2 || stats.Lists != 1 || stats.KeysWithTTL != 1 {
+		t.Errorf("Expected 3 keys, 2 strings, 1 list and 1 with a TTL, got %+v", stats)
+	}
+	if stats.MemoryBytes <= 0 {
+		t.Errorf("Expected a positive memory estimate, got %d", stats.MemoryBytes)
+	}
+}
+
+func TestClient_FlushAll(t *testing.T) {
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+	server := httptest.NewServer(api.NewHandler(memoryStore, api.WithFlush(true)).SetupRoutes())
+	defer server.Close()
+
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "name", "alice", 0)
+	c.RPush(ctx, "queue", "job")
+	if err := c.FlushAll(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := c.Get(ctx, "name"); err == nil {
+		t.Error("Expected the key to be flushed")
+	}
+
+	// Servers not started with ENABLE_FLUSH refuse
+	var apiErr *client.APIError
+	err := client.NewClient(storeServer(t).URL).FlushAll(ctx)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected a 403 APIError, got %v", err)
+	}
+}
+
+func TestClient_PauseTTLWorker(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	if err := c.PauseTTLWorker(ctx); err != nil {
+		t.Fatalf("Expected no error pausing, got %v", err)
+	}
+	if err := c.Set(ctx, "loading", "row", 60); err != nil {
+		t.Errorf("Expected writes to work while paused, got %v", err)
+	}
+	if err := c.ResumeTTLWorker(ctx); err != nil {
+		t.Errorf("Expected no error resuming, got %v", err)
+	}
+}
+
+func TestClient_ExportPattern(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "tenant:42:name", "acme", 0)
+	c.Set(ctx, "tenant:42:session", "token", 60)
+	c.RPush(ctx, "tenant:42:queue", "a")
+	c.Set(ctx, "tenant:7:name", "other", 0)
+
+	dump, err := c.ExportPattern(ctx, "tenant:42:*")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(dump) != 3 {
+		t.Fatalf("Expected 3 keys, got %+v", dump)
+	}
+	if dump[0].Key != "tenant:42:name" || dump[0].Value != "acme" || dump[0].TTLSeconds != -1 {
+		t.Errorf("Unexpected string key: %+v", dump[0])
+	}
+	if dump[1].Key != "tenant:42:queue" || dump[1].Type != client.TypeList || len(dump[1].Items) != 1 || dump[1].Items[0] != "a" {
+		t.Errorf("Unexpected list key: %+v", dump[1])
+	}
+	if dump[2].Key != "tenant:42:session" || dump[2].TTLSeconds <= 0 || dump[2].TTLSeconds > 60 {
+		t.Errorf("Unexpected TTL: %+v", dump[2])
+	}
+
+	_, err = c.ExportPattern(ctx, "")
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a pattern, got %v", err)
+	}
+}
+
+func TestClient_VerifyIntegrity(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "name", "alice", 0)
+	c.RPush(ctx, "queue", "a")
+
+	problems, err := c.VerifyIntegrity(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if problems == nil || len(problems) != 0 {
+		t.Errorf("Expected an empty list of problems, got %v", problems)
+	}
+}
+
+func TestClient_ServerTime(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+
+	serverTime, err := c.ServerTime(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if skew := time.Since(serverTime); skew < -time.Second || skew > time.Second {
+		t.Errorf("Expected server time close to now, got %v", serverTime)
+	}
+}
+
+func TestClient_FenceTokens(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	acquired, oldToken, err := c.SetNXFenced(ctx, "lock:report", "worker-1", 60)
+	if err != nil || !acquired || oldToken == 0 {
+		t.Fatalf("Expected to acquire the lock with a token, got %v, %d (err %v)", acquired, oldToken, err)
+	}
+
+	// The lock is lost and taken over by another worker.
+	c.Remove(ctx, "lock:report")
+	_, newToken, _ := c.SetNXFenced(ctx, "lock:report", "worker-2", 60)
+
+	if err := c.Set(ctx, "report", "fresh result", 0, client.Fence(newToken)); err != nil {
+		t.Fatalf("Expected the current holder to write, got %v", err)
+	}
+
+	// The stalled worker wakes up and tries to write with its old token.
+	err = c.Set(ctx, "report", "stale result", 0, client.Fence(oldToken))
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 for the stale writer, got %v", err)
+	}
+	if value, _ := c.Get(ctx, "report"); value != "fresh result" {
+		t.Errorf("Expected fresh result, got %s", value)
+	}
+
+	renewed, err := c.ExpireFenced(ctx, "lock:report", 60)
+	if err != nil || renewed <= newToken {
+		t.Errorf("Expected a higher token on renewal, got %d (err %v)", renewed, err)
+	}
+}
+
+func TestClient_LInitNX(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	created, err := c.LInitNX(ctx, "queue", []any{"a", "b"}, 0)
+	if err != nil || !created {
+		t.Fatalf("Expected the list to be created, got %v (err %v)", created, err)
+	}
+	if created, _ := c.LInitNX(ctx, "queue", []any{"c"}, 0); created {
+		t.Error("Expected a second LInitNX not to create the list")
+	}
+
+	items, _ := c.LRange(ctx, "queue", 0, -1)
+	if strings.Join(items, ",") != "a,b" {
+		t.Errorf("Expected [a b], got %v", items)
+	}
+
+	if err := c.LSet(ctx, "queue", []any{"c"}, 0); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	items, _ = c.LRange(ctx, "queue", 0, -1)
+	if strings.Join(items, ",") != "c" {
+		t.Errorf("Expected [c] after LSet, got %v", items)
+	}
+}
+
+func TestClient_ReserveAndAck(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Push(ctx, "jobs", "job-1")
+
+	item, receipt, err := c.Reserve(ctx, "jobs", 50*time.Millisecond)
+	if err != nil || item != "job-1" {
+		t.Fatalf("Expected job-1, got %q (err %v)", item, err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	item, redelivered, err := c.Reserve(ctx, "jobs", time.Minute)
+	if err != nil || item != "job-1" {
+		t.Fatalf("Expected job-1 to be redelivered, got %q (err %v)", item, err)
+	}
+
+	var apiErr *client.APIError
+	if err := c.Ack(ctx, receipt); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for an expired receipt, got %v", err)
+	}
+	if err := c.Ack(ctx, redelivered); err != nil {
+		t.Errorf("Expected ack to succeed, got %v", err)
+	}
+	if err := c.Ack(ctx, redelivered); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for a double ack, got %v", err)
+	}
+}
+
+func TestClient_LRangeReverse(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	for _, item := range []string{"c", "b", "a"} {
+		c.Push(ctx, "list", item)
+	}
+
+	forward, _ := c.LRange(ctx, "list", 0, -1)
+	reversed, err := c.LRange(ctx, "list", 0, -1, client.Reverse())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.Join(forward, ",") != "a,b,c" || strings.Join(reversed, ",") != "c,b,a" {
+		t.Errorf("Expected [a b c] and [c b a], got %v and %v", forward, reversed)
+	}
+
+	page, _ := c.LRangePage(ctx, "list", 0, 0, client.Reverse())
+	if len(page.Items) != 1 || page.Items[0] != "c" || page.Total != 3 {
+		t.Errorf("Expected the tail item, got %+v", page)
+	}
+}
+
+func TestClient_PushResurrect(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Push(ctx, "queue", "old")
+	c.Remove(ctx, "queue", client.SoftDelete())
+
+	var apiErr *client.APIError
+	if err := c.Push(ctx, "queue", "new"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 for a push to a soft deleted key, got %v", err)
+	}
+
+	if err := c.Push(ctx, "queue", "new", client.Resurrect()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	items, _ := c.LRange(ctx, "queue", 0, -1)
+	if strings.Join(items, ",") != "new,old" {
+		t.Errorf("Expected [new old], got %v", items)
+	}
+}
+
+func TestClient_PushCappedReturn(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	for _, item := range []string{"a", "b", "c"} {
+		if _, err := c.PushCappedReturn(ctx, "recent", item, 3); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	evicted, err := c.PushCappedReturn(ctx, "recent", "d", 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.Join(evicted, ",") != "b,a" {
+		t.Errorf("Expected evicted [b a], got %v", evicted)
+	}
+	items, _ := c.LRange(ctx, "recent", 0, -1)
+	if strings.Join(items, ",") != "d,c" {
+		t.Errorf("Expected [d c], got %v", items)
+	}
+
+	if _, err := c.PushCappedReturn(ctx, "recent", "e", 0); err == nil {
+		t.Error("Expected an error for a zero max length")
+	}
+}
+
+func TestClient_RPushRPop(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	for _, job := range []string{"job-1", "job-2", "job-3"} {
+		if err := c.RPush(ctx, "queue", job); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if item, err := c.Pop(ctx, "queue"); err != nil || item != "job-1" {
+		t.Errorf("Expected job-1 from the head, got %q, %v", item, err)
+	}
+	if item, err := c.RPop(ctx, "queue"); err != nil || item != "job-3" {
+		t.Errorf("Expected job-3 from the tail, got %q, %v", item, err)
+	}
+
+	var apiErr *client.APIError
+	if _, err := c.RPop(ctx, "missing"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing list, got %v", err)
+	}
+}
+
+func TestClient_PushSeq(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	for i, job := range []string{"job-1", "job-2", "job-3"} {
+		seq, err := c.RPushSeq(ctx, "queue", job)
+		if err != nil || seq != uint64(i+1) {
+			t.Fatalf("Expected seq %d, got %d, %v", i+1, seq, err)
+		}
+	}
+	if seq, err := c.PushSeq(ctx, "queue", "urgent"); err != nil || seq != 4 {
+		t.Errorf("Expected seq 4, got %d, %v", seq, err)
+	}
+
+	page, err := c.LRangePage(ctx, "queue", 0, -1)
+	if err != nil || !reflect.DeepEqual(page.Seqs, []uint64{4, 1, 2, 3}) {
+		t.Errorf("Expected range seqs [4 1 2 3], got %+v, %v", page, err)
+	}
+
+	if item, err := c.RPopItem(ctx, "queue"); err != nil || item.Value != "job-3" || item.Seq != 3 {
+		t.Errorf("Expected job-3 with seq 3, got %+v, %v", item, err)
+	}
+	for _, want := range []uint64{4, 1, 2} {
+		if item, err := c.PopItem(ctx, "queue"); err != nil || item.Seq != want {
+			t.Errorf("Expected seq %d, got %+v, %v", want, item, err)
+		}
+	}
+
+	var apiErr *client.APIError
+	if _, err := c.PopItem(ctx, "missing"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing list, got %v", err)
+	}
+}
+
+func TestClient_ListBatch(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "config", "value", 0)
+
+	results, err := c.ListBatch(ctx, []client.ListOp{
+		{Key: "queue:eu", Op: client.ListOpPush, Item: "job-1"},
+		{Key: "config", Op: client.ListOpPush, Item: "job-1"},
+		{Key: "", Op: client.ListOpPush, Item: "job-1"},
+		{Key: "queue:us", Op: client.ListOpPush, Item: map[string]int{"id": 2}},
+		{Key: "queue:eu", Op: client.ListOpPop},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(results) != 5 {
+		t.Fatalf("Expected 5 results, got %d", len(results))
+	}
+
+	wantCodes := []string{"", "TYPE_MISMATCH", "KEY_REQUIRED", "", ""}
+	for i, want := range wantCodes {
+		if results[i].Success != (want == "") || results[i].Code != want {
+			t.Errorf("Result %d: expected code %q, got %+v", i, want, results[i])
+		}
+	}
+	if results[4].Item == nil || *results[4].Item != "job-1" {
+		t.Errorf("Expected the pop to return job-1, got %+v", results[4])
+	}
+
+	items, _ := c.LRange(ctx, "queue:us", 0, -1)
+	if len(items) != 1 || items[0] != `{"id":2}` {
+		t.Errorf("Expected the object pushed to queue:us, got %v", items)
+	}
+}
+
+func TestClient_PipelineGet(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "page:title", "Home", 0)
+	c.RPush(ctx, "page:feed", "first")
+	c.RPush(ctx, "page:feed", "second")
+
+	first, last, past := 0, -1, 5
+	results, err := c.PipelineGet(ctx, []client.ReadSpec{
+		{Key: "page:title"},
+		{Key: "page:feed", Index: &first},
+		{Key: "page:feed", Index: &last},
+		{Key: "page:missing"},
+		{Key: "page:feed"},
+		{Key: "page:feed", Index: &past},
+		{Key: ""},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(results) != 7 {
+		t.Fatalf("Expected 7 results, got %d", len(results))
+	}
+
+	wantValues := []string{"Home", "first", "second"}
+	for i, want := range wantValues {
+		if !results[i].Success || results[i].Value == nil || *results[i].Value != want {
+			t.Errorf("Result %d: expected %q, got %+v", i, want, results[i])
+		}
+	}
+	wantCodes := []string{"KEY_NOT_FOUND", "TYPE_MISMATCH", "INDEX_OUT_OF_RANGE", "KEY_REQUIRED"}
+	for i, want := range wantCodes {
+		if r := results[len(wantValues)+i]; r.Success || r.Code != want || r.Value != nil {
+			t.Errorf("Result %d: expected code %q, got %+v", len(wantValues)+i, want, r)
+		}
+	}
+	if results[1].Index == nil || *results[1].Index != 0 {
+		t.Errorf("Expected the index echoed back, got %+v", results[1])
+	}
+
+	_, err = c.PipelineGet(ctx, nil)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an empty pipeline, got %v", err)
+	}
+}
+
+func TestClient_GetOrDefault(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "theme", "dark", 60); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	value, err := c.GetOrDefault(ctx, "theme", "light")
+	if err != nil || value != "dark" {
+		t.Errorf("Expected stored value 'dark', got %q (err %v)", value, err)
+	}
+
+	value, err = c.GetOrDefault(ctx, "missing", "light")
+	if err != nil || value != "light" {
+		t.Errorf("Expected default 'light' for missing key, got %q (err %v)", value, err)
+	}
+
+	// A list key is a real error, not a missing key.
+	if err := c.Push(ctx, "queue", "job"); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if _, err := c.GetOrDefault(ctx, "queue", "light"); err == nil {
+		t.Error("Expected an error reading a list key")
+	}
+
+	// Network errors are propagated too.
+	offline := client.NewClient("http://127.0.0.1:1")
+	if _, err := offline.GetOrDefault(ctx, "theme", "light"); err == nil {
+		t.Error("Expected an error when the server is unreachable")
+	}
+}
+
+func TestClient_RequestHook(t *testing.T) {
+	server := storeServer(t)
+	ctx := context.Background()
+
+	type observed struct {
+		method, path string
+		status       int
+		err          error
+	}
+	var calls []observed
+	hook := func(req *http.Request, resp *http.Response, err error) {
+		o := observed{method: req.Method, path: req.URL.Path, err: err}
+		if resp != nil {
+			o.status = resp.StatusCode
+		}
+		calls = append(calls, o)
+	}
+
+	c := client.NewClient(server.URL, client.WithRequestHook(hook))
+	c.Set(ctx, "user", "alice", 0)
+	calls = nil
+
+	if _, err := c.Get(ctx, "user"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(calls) != 1 || calls[0].method != "GET" || calls[0].path != "/api/v1/keys/user" || calls[0].status != http.StatusOK || calls[0].err != nil {
+		t.Errorf("Expected the hook to observe GET /api/v1/keys/user 200, got %+v", calls)
+	}
+
+	calls = nil
+	c.Get(ctx, "missing")
+	var apiErr *client.APIError
+	if len(calls) != 1 || calls[0].status != http.StatusNotFound || !errors.As(calls[0].err, &apiErr) {
+		t.Errorf("Expected the hook to observe the 404 and its error, got %+v", calls)
+	}
+
+	// Requests that never get a response are observed too
+	calls = nil
+	unreachable := client.NewClient("http://127.0.0.1:1", client.WithRequestHook(hook))
+	unreachable.Get(ctx, "user")
+	if len(calls) != 1 || calls[0].status != 0 || calls[0].err == nil {
+		t.Errorf("Expected the hook to observe the network error, got %+v", calls)
+	}
+}
+
+func TestClient_TTLHistogram(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "session:1", "a", 30)
+	c.Set(ctx, "session:2", "b", 1800)
+	c.Set(ctx, "cache", "c", 7200)
+	c.Push(ctx, "queue", "job")
+
+	histogram, err := c.TTLHistogram(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if histogram["1m0s"] != 1 || histogram["1h0m0s"] != 1 || histogram["24h0m0s"] != 1 || histogram[client.TTLBucketNever] != 1 {
+		t.Errorf("Unexpected default histogram: %v", histogram)
+	}
+
+	histogram, err = c.TTLHistogram(ctx, time.Hour)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if histogram["1h0m0s"] != 2 || histogram[client.TTLBucketLonger] != 1 || len(histogram) != 3 {
+		t.Errorf("Unexpected histogram for a single bucket: %v", histogram)
+	}
+
+	_, err = c.TTLHistogram(ctx, time.Hour, time.Hour)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for duplicate buckets, got %v", err)
+	}
+}
+
+func TestClient_LMoveAll(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Push(ctx, "queue:v1", "b")
+	c.Push(ctx, "queue:v1", "a")
+	c.Push(ctx, "queue:v2", "c")
+
+	moved, err := c.LMoveAll(ctx, "queue:v1", "queue:v2")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if moved != 2 {
+		t.Errorf("Expected 2 items moved, got %d", moved)
+	}
+
+	items, err := c.LRange(ctx, "queue:v2", 0, -1)
+	if err != nil || strings.Join(items, ",") != "a,b,c" {
+		t.Errorf("Expected [a b c], got %v (err %v)", items, err)
+	}
+
+	_, err = c.LMoveAll(ctx, "queue:v1", "queue:v2")
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 once src is drained, got %v", err)
+	}
+}
+
+func TestClient_DeleteExpiringWithin(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "soon", "a", 30)
+	c.Set(ctx, "later", "b", 3600)
+	c.Set(ctx, "never", "c", 0)
+
+	deleted, err := c.DeleteExpiringWithin(ctx, time.Minute)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("Expected 1 key deleted, got %d", deleted)
+	}
+
+	if _, err := c.Get(ctx, "soon"); err == nil {
+		t.Error("Expected the near-expiry key to be deleted")
+	}
+	for _, key := range []string{"later", "never"} {
+		if _, err := c.Get(ctx, key); err != nil {
+			t.Errorf("Expected %s to be kept, got %v", key, err)
+		}
+	}
+}
+
+func TestClient_DeleteMatching(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "tenant:42:name", "acme", 0)
+	c.Set(ctx, "tenant:42:plan", "gold", 0)
+	c.Set(ctx, "tenant:7:name", "other", 0)
+
+	deleted, err := c.DeleteMatching(ctx, "tenant:42:*")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("Expected 2 keys deleted, got %d", deleted)
+	}
+	if _, err := c.Get(ctx, "tenant:42:name"); err == nil {
+		t.Error("Expected the matching key to be deleted")
+	}
+	if _, err := c.Get(ctx, "tenant:7:name"); err != nil {
+		t.Errorf("Expected the other tenant to be kept, got %v", err)
+	}
+
+	var apiErr *client.APIError
+	if _, err := c.DeleteMatching(ctx, "tenant:[42"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected a 400 for an invalid pattern, got %v", err)
+	}
+}
+
+func TestClient_WaitForKey(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		c.Set(ctx, "config:billing", "ready", 60)
+	}()
+
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	value, err := c.WaitForKey(waitCtx, "config:billing", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if value != "ready" {
+		t.Errorf("Expected 'ready', got %q", value)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Expected to wait for the key to appear, returned after %v", elapsed)
+	}
+
+	// An existing key is returned right away.
+	if value, err := c.WaitForKey(ctx, "config:billing", time.Hour); err != nil || value != "ready" {
+		t.Errorf("Expected 'ready' immediately, got %q (err %v)", value, err)
+	}
+
+	shortCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.WaitForKey(shortCtx, "missing", 10*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context deadline to end the wait, got %v", err)
+	}
+
+	// Errors other than a missing key end the wait.
+	c.Push(ctx, "queue", "job")
+	if _, err := c.WaitForKey(ctx, "queue", 10*time.Millisecond); err == nil {
+		t.Error("Expected an error waiting on a list key")
+	}
+}
+
+func TestClient_Exists(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "page:/home", "<html>", 60)
+	c.Push(ctx, "queue", "job")
+
+	for _, key := range []string{"page:/home", "queue"} {
+		if exists, err := c.Exists(ctx, key); err != nil || !exists {
+			t.Errorf("Expected %s to exist, got %v (err %v)", key, exists, err)
+		}
+	}
+	if exists, err := c.Exists(ctx, "missing"); err != nil || exists {
+		t.Errorf("Expected missing key not to exist, got %v (err %v)", exists, err)
+	}
+}
+
+func TestClient_TTL(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "session", "abc", 60)
+	c.Set(ctx, "config", "on", 0)
+
+	if ttl, err := c.TTL(ctx, "session"); err != nil || ttl != 60 {
+		t.Errorf("Expected TTL 60, got %d, %v", ttl, err)
+	}
+	if ttl, err := c.TTL(ctx, "config"); err != nil || ttl != -1 {
+		t.Errorf("Expected TTL -1 for a key without expiration, got %d, %v", ttl, err)
+	}
+
+	var apiErr *client.APIError
+	if _, err := c.TTL(ctx, "missing"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing key, got %v", err)
+	}
+}
+
+func TestClient_GetInto(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	type profile struct {
+		Name string   `json:"name"`
+		Age  int      `json:"age"`
+		Tags []string `json:"tags"`
+	}
+	c.Set(ctx, "profile", profile{Name: "alice", Age: 30, Tags: []string{"admin"}}, 0)
+	c.Set(ctx, "name", "bob", 0)
+
+	var got profile
+	if err := c.GetInto(ctx, "profile", &got); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got.Name != "alice" || got.Age != 30 || len(got.Tags) != 1 || got.Tags[0] != "admin" {
+		t.Errorf("Expected the original struct, got %+v", got)
+	}
+
+	var name string
+	if err := c.GetInto(ctx, "name", &name); err != nil || name != "bob" {
+		t.Errorf("Expected a string value to decode into a string, got %q, %v", name, err)
+	}
+
+	if raw, err := c.GetRaw(ctx, "name"); err != nil || string(raw) != `"bob"` {
+		t.Errorf("Expected a JSON string, got %s, %v", raw, err)
+	}
+
+	var apiErr *client.APIError
+	if err := c.GetInto(ctx, "missing", &got); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing key, got %v", err)
+	}
+}
+
+func TestClient_LargeIntegers(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL, client.WithUseNumber())
+	ctx := context.Background()
+
+	// 12345678901234567 is not representable as a float64
+	type event struct {
+		ID int64 `json:"id"`
+	}
+	const id = 12345678901234567
+	c.Set(ctx, "event", event{ID: id}, 0)
+
+	if raw, err := c.GetRaw(ctx, "event"); err != nil || string(raw) != `{"id":12345678901234567}` {
+		t.Errorf("Expected the exact integer in the raw value, got %s, %v", raw, err)
+	}
+
+	var typed event
+	if err := c.GetInto(ctx, "event", &typed); err != nil || typed.ID != id {
+		t.Errorf("Expected ID %d, got %d, %v", int64(id), typed.ID, err)
+	}
+
+	var untyped map[string]any
+	if err := c.GetInto(ctx, "event", &untyped); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if n, ok := untyped["id"].(json.Number); !ok || n.String() != "12345678901234567" {
+		t.Errorf("Expected json.Number 12345678901234567, got %#v", untyped["id"])
+	}
+
+	c.Push(ctx, "events", map[string]any{"id": json.Number("12345678901234567")})
+	var popped map[string]any
+	if err := c.PopJSON(ctx, "events", &popped); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if n, ok := popped["id"].(json.Number); !ok || n.String() != "12345678901234567" {
+		t.Errorf("Expected the popped json.Number 12345678901234567, got %#v", popped["id"])
+	}
+
+	// Without the option numbers in interface values are float64, as with encoding/json
+	plain := client.NewClient(server.URL)
+	var rounded map[string]any
+	plain.GetInto(ctx, "event", &rounded)
+	if _, ok := rounded["id"].(float64); !ok {
+		t.Errorf("Expected a float64 without WithUseNumber, got %#v", rounded["id"])
+	}
+}
+
+func TestClient_ExpireAndPersist(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "session", "abc", 0)
+
+	if err := c.Expire(ctx, "session", 120); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ttl, _ := c.TTL(ctx, "session"); ttl != 120 {
+		t.Errorf("Expected TTL 120 after Expire, got %d", ttl)
+	}
+
+	if err := c.Persist(ctx, "session"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ttl, _ := c.TTL(ctx, "session"); ttl != -1 {
+		t.Errorf("Expected no expiration after Persist, got %d", ttl)
+	}
+
+	var apiErr *client.APIError
+	if err := c.Persist(ctx, "missing"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing key, got %v", err)
+	}
+}
+
+func TestClient_IncrementDecrement(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	if value, err := c.Increment(ctx, "hits", 10); err != nil || value != 10 {
+		t.Errorf("Expected 10, got %d, %v", value, err)
+	}
+	if value, err := c.Decrement(ctx, "hits", 4); err != nil || value != 6 {
+		t.Errorf("Expected 6, got %d, %v", value, err)
+	}
+	if _, err := c.Decrement(ctx, "hits", 0); err == nil {
+		t.Error("Expected an error for a delta of 0")
+	}
+
+	c.Set(ctx, "name", "alice", 0)
+	var apiErr *client.APIError
+	if _, err := c.Increment(ctx, "name", 1); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 for a non-integer value, got %v", err)
+	}
+}
+
+func TestClient_DecrWithFloor(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "stock", 3, 0)
+
+	if left, ok, err := c.DecrWithFloor(ctx, "stock", 2, 0); err != nil || !ok || left != 1 {
+		t.Errorf("Expected 1 left, got %d, %v, %v", left, ok, err)
+	}
+	if left, ok, err := c.DecrWithFloor(ctx, "stock", 2, 0); err != nil || ok || left != 1 {
+		t.Errorf("Expected the decrement to be refused with 1 left, got %d, %v, %v", left, ok, err)
+	}
+
+	c.Set(ctx, "name", "alice", 0)
+	var apiErr *client.APIError
+	if _, _, err := c.DecrWithFloor(ctx, "name", 1, 0); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 for a non-integer value, got %v", err)
+	}
+}
+
+func TestClient_IncrWithCeiling(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	if used, ok, err := c.IncrWithCeiling(ctx, "quota", 4, 5); err != nil || !ok || used != 4 {
+		t.Errorf("Expected 4 used, got %d, %v, %v", used, ok, err)
+	}
+	if used, ok, err := c.IncrWithCeiling(ctx, "quota", 2, 5); err != nil || ok || used != 4 {
+		t.Errorf("Expected the increment to be refused with 4 used, got %d, %v, %v", used, ok, err)
+	}
+	if used, err := c.Increment(ctx, "quota", 2); err != nil || used != 6 {
+		t.Errorf("Expected a plain increment to ignore the ceiling, got %d, %v", used, err)
+	}
+
+	c.Set(ctx, "name", "alice", 0)
+	var apiErr *client.APIError
+	if _, _, err := c.IncrWithCeiling(ctx, "name", 1, 10); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 for a non-integer value, got %v", err)
+	}
+}
+
+func TestClient_Capabilities(t *testing.T) {
+	server := storeServer(t)
+	var fetches atomic.Int32
+	c := client.NewClient(server.URL, client.WithRequestHook(func(req *http.Request, resp *http.Response, err error) {
+		if req.URL.Path == "/api/v1/capabilities" {
+			fetches.Add(1)
+		}
+	}))
+	ctx := context.Background()
+
+	ops, err := c.Capabilities(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(ops) == 0 {
+		t.Fatal("Expected the server to advertise operations")
+	}
+	for _, op := range []string{client.OpIncr, client.OpIncrCeiling, client.OpPipelineGet} {
+		if ok, _ := c.Supports(ctx, op); !ok {
+			t.Errorf("Expected %s to be supported", op)
+		}
+	}
+	if ok, _ := c.Supports(ctx, "teleport"); ok {
+		t.Error("Expected an unknown operation not to be supported")
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("Expected the capabilities to be fetched once, got %d", n)
+	}
+
+	// A 404 for a missing key is not mistaken for a missing operation
+	_, err = c.GetSet(ctx, "missing", "x")
+	if errors.Is(err, client.ErrUnsupportedOperation) {
+		t.Errorf("Expected a key not found error, got %v", err)
+	}
+}
+
+func TestClient_UnsupportedOperation(t *testing.T) {
+	ctx := context.Background()
+
+	// A server that omits capabilities
+	memoryStore := memory.NewMemoryStore()
+	defer memoryStore.StopTTLWorker()
+	limited := httptest.NewServer(api.NewHandler(memoryStore, api.WithCapabilities([]string{api.CapIncr})).SetupRoutes())
+	defer limited.Close()
+
+	c := client.NewClient(limited.URL)
+	if got, err := c.Increment(ctx, "hits", 1); err != nil || got != 1 {
+		t.Errorf("Expected an advertised operation to work, got %d, %v", got, err)
+	}
+	if _, _, err := c.DecrWithFloor(ctx, "stock", 1, 0); !errors.Is(err, client.ErrUnsupportedOperation) {
+		t.Errorf("Expected ErrUnsupportedOperation, got %v", err)
+	}
+	if _, _, err := c.IncrWithCeiling(ctx, "hits", 1, 10); !errors.Is(err, client.ErrUnsupportedOperation) {
+		t.Errorf("Expected ErrUnsupportedOperation, got %v", err)
+	}
+	if got, _ := memoryStore.Get(ctx, "hits"); got != "1" {
+		t.Errorf("Expected the unsupported increment not to be sent, got %q", got)
+	}
+
+	// An older server without the capabilities endpoint or the pipeline
+	old := httptest.NewServer(http.NotFoundHandler())
+	defer old.Close()
+
+	c = client.NewClient(old.URL)
+	if ops, err := c.Capabilities(ctx); err != nil || len(ops) != 0 {
+		t.Errorf("Expected no capabilities, got %v, %v", ops, err)
+	}
+	_, err := c.PipelineGet(ctx, []client.ReadSpec{{Key: "name"}})
+	if !errors.Is(err, client.ErrUnsupportedOperation) {
+		t.Errorf("Expected ErrUnsupportedOperation, got %v", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), client.OpPipelineGet) {
+		t.Errorf("Expected the error to name the operation, got %v", err)
+	}
+}
+
+func TestClient_SetNoCompress(t *testing.T) {
+	memoryStore := memory.NewMemoryStoreWithOptions(memory.Options{CompressThreshold: 16})
+	server := httptest.NewServer(api.NewHandler(memoryStore).SetupRoutes())
+	defer server.Close()
+	defer memoryStore.StopTTLWorker()
+
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+	value := strings.Repeat("token", 100)
+
+	if err := c.Set(ctx, "compressed", value, 0); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := c.Set(ctx, "plain", value, 0, client.NoCompress()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	keys, _ := memoryStore.TopKeysBySize(ctx, 10)
+	for _, k := range keys {
+		if k.Key == "plain" && k.SizeBytes < len(value) {
+			t.Errorf("Expected NoCompress to store the value uncompressed, got size %d", k.SizeBytes)
+		}
+		if k.Key == "compressed" && k.SizeBytes >= len(value) {
+			t.Errorf("Expected the value to be stored compressed, got size %d", k.SizeBytes)
+		}
+	}
+
+	for _, key := range []string{"compressed", "plain"} {
+		if got, err := c.Get(ctx, key); err != nil || got != value {
+			t.Errorf("Expected %s to round-trip, got %q, %v", key, got, err)
+		}
+	}
+}
+
+func TestClient_KeysInfo(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	c.Set(ctx, "user:1", "Alice", 60)
+	c.Get(ctx, "user:1")
+	c.Push(ctx, "queue", "job")
+
+	infos, err := c.KeysInfo(ctx, []string{"user:1", "missing", "queue"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("Expected the missing key to be omitted, got %+v", infos)
+	}
+	if infos[0].Key != "user:1" || infos[0].Type != client.TypeString || infos[0].TTLSeconds <= 0 || infos[0].Hits != 2 || infos[0].SizeBytes == 0 {
+		t.Errorf("Unexpected string info: %+v", infos[0])
+	}
+	if infos[1].Key != "queue" || infos[1].Type != client.TypeList || infos[1].TTLSeconds != -1 {
+		t.Errorf("Unexpected list info: %+v", infos[1])
+	}
+
+	var apiErr *client.APIError
+	if _, err := c.KeysInfo(ctx, nil); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 without keys, got %v", err)
+	}
+}
+
+func TestClient_Hashes(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	if created, err := c.HSet(ctx, "user:123", "name", "Alice"); err != nil || !created {
+		t.Fatalf("Expected a new field, got %v (err %v)", created, err)
+	}
+	c.HSet(ctx, "user:123", "age", 30)
+	if created, _ := c.HSet(ctx, "user:123", "name", "Bob"); created {
+		t.Error("Expected overwriting a field not to report it as new")
+	}
+
+	if got, err := c.HGet(ctx, "user:123", "name"); err != nil || got != "Bob" {
+		t.Errorf("Expected Bob, got %q (err %v)", got, err)
+	}
+	fields, err := c.HGetAll(ctx, "user:123")
+	if err != nil || !reflect.DeepEqual(fields, map[string]string{"name": "Bob", "age": "30"}) {
+		t.Errorf("Expected both fields, got %v (err %v)", fields, err)
+	}
+	if v, err := c.GetAny(ctx, "user:123"); err != nil || v.Type != client.TypeHash || v.Fields["name"] != "Bob" {
+		t.Errorf("Expected GetAny to return the hash, got %+v (err %v)", v, err)
+	}
+
+	if deleted, err := c.HDel(ctx, "user:123", "age"); err != nil || !deleted {
+		t.Errorf("Expected the field deleted, got %v (err %v)", deleted, err)
+	}
+
+	var apiErr *client.APIError
+	if _, err := c.HGet(ctx, "user:123", "age"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected a 404 for a missing field, got %v", err)
+	}
+	if _, err := c.HGetAll(ctx, "user:456"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected a 404 for a missing key, got %v", err)
+	}
+
+	c.Set(ctx, "name", "Alice", 60)
+	if _, err := c.HSet(ctx, "name", "first", "Alice"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected a 409 for a string key, got %v", err)
+	}
+	if _, err := c.Get(ctx, "user:123"); err == nil {
+		t.Error("Expected Get to fail on a hash")
+	}
+}
+
+func TestClient_Sets(t *testing.T) {
+	server := storeServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	if added, err := c.SAdd(ctx, "visitors:/home", []string{"v1", "v2", "v1"}); err != nil || added != 2 {
+		t.Fatalf("Expected 2 members added, got %d (err %v)", added, err)
+	}
+	if added, _ := c.SAdd(ctx, "visitors:/home", []string{"v2", "v3"}); added != 1 {
+		t.Errorf("Expected only the new member counted, got %d", added)
+	}
+
+	if members, err := c.SMembers(ctx, "visitors:/home"); err != nil || !reflect.DeepEqual(members, []string{"v1", "v2", "v3"}) {
+		t.Errorf("Expected the sorted members, got %v (err %v)", members, err)
+	}
+	if n, err := c.SCard(ctx, "visitors:/home"); err != nil || n != 3 {
+		t.Errorf("Expected 3 members, got %d (err %v)", n, err)
+	}
+	if ok, err := c.SIsMember(ctx, "visitors:/home", "v2"); err != nil || !ok {
+		t.Errorf("Expected v2 to be a member, got %v (err %v)", ok, err)
+	}
+	if removed, err := c.SRem(ctx, "visitors:/home", []string{"v2", "v9"}); err != nil || removed != 1 {
+		t.Errorf("Expected 1 member removed, got %d (err %v)", removed, err)
+	}
+	if ok, _ := c.SIsMember(ctx, "visitors:/home", "v2"); ok {
+		t.Error("Expected v2 to be removed")
+	}
+	if v, err := c.GetAny(ctx, "visitors:/home"); err != nil || v.Type != client.TypeSet || !reflect.DeepEqual(v.Items, []string{"v1", "v3"}) {
+		t.Errorf("Expected GetAny to return the set, got %+v (err %v)", v, err)
+	}
+
+	var apiErr *client.APIError
+	if _, err := c.SCard(ctx, "visitors:/about"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected a 404 for a missing key, got %v", err)
+	}
+	c.Push(ctx, "queue", "job")
+	if _, err := c.SAdd(ctx, "queue", []string{"job"}); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected a 409 for a list key, got %v", err)
+	}
+}
+
+func TestClient_BLPop(t *testing.T) {
+	server := storeServer(t)
+	// The wait is added to the HTTP timeout, so a short one does not cut it off
+	c := client.NewClient(server.URL, client.WithTimeout(100*time.Millisecond))
+	ctx := context.Background()
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		client.NewClient(server.URL).Push(ctx, "queue:tasks", "task-1")
+	}()
+	if item, err := c.BLPop(ctx, "queue:tasks", 5*time.Second); err != nil || item != "task-1" {
+		t.Fatalf("Expected task-1, got %q (err %v)", item, err)
+	}
+
+	if _, err := c.BLPop(ctx, "queue:tasks", 50*time.Millisecond); !errors.Is(err, client.ErrTimeout) {
+		t.Errorf("Expected ErrTimeout, got %v", err)
+	}
+
+	var apiErr *client.APIError
+	if _, err := c.BLPop(ctx, "queue:tasks", time.Hour); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected a 400 for a timeout over the limit, got %v", err)
+	}
+	c.Set(ctx, "string", "value", 60)
+	if _, err := c.BLPop(ctx, "string", time.Second); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+		t.Errorf("Expected a 409 for a string key, got %v", err)
+	}
+}
+
+func TestClient_RequestIDs(t *testing.T) {
+	var ids []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		ids = append(ids, id)
+		w.Header().Set("X-Request-ID", id)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"success":false,"error":"Key not found"}`))
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	// No header unless asked for
+	client.NewClient(server.URL).Get(ctx, "user:1")
+	if ids[0] != "" {
+		t.Errorf("Expected no request ID by default, got %q", ids[0])
+	}
+
+	c := client.NewClient(server.URL, client.WithRequestIDs(), client.WithRetries(1, time.Millisecond))
+	_, err := c.Get(ctx, "user:1")
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.RequestID == "" || apiErr.RequestID != ids[1] {
+		t.Errorf("Expected the generated ID %q on the error, got %v", ids[1], err)
+	}
+	c.Get(ctx, "user:2")
+	if ids[2] == ids[1] {
+		t.Errorf("Expected a new ID per request, got %q twice", ids[1])
+	}
+
+	if _, err := c.Get(client.WithRequestID(ctx, "trace-7"), "user:1"); !errors.As(err, &apiErr) || apiErr.RequestID != "trace-7" {
+		t.Errorf("Expected the ID set on the context, got %v", err)
+	}
+}
+
+func TestClient_PubSub(t *testing.T) {
+	server := storeServer(t)
+	// The HTTP timeout does not cut the stream off
+	c := client.NewClient(server.URL, client.WithTimeout(100*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	messages, err := c.Subscribe(ctx, "orders")
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if n, err := c.Publish(ctx, "orders", map[string]any{"id": 42}); err != nil || n != 1 {
+		t.Fatalf("Expected 1 receiver, got %d (err %v)", n, err)
+	}
+	select {
+	case msg := <-messages:
+		if msg.Channel != "orders" || string(msg.Payload) != `{"id":42}` {
+			t.Errorf("Expected the published message, got %+v", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the published message")
+	}
+
+	cancel()
+	select {
+	case _, open := <-messages:
+		if open {
+			t.Error("Expected no more messages")
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("Expected the channel closed once ctx is done")
+	}
+
+	var apiErr *client.APIError
+	if _, err := c.Subscribe(context.Background(), ""); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected a 400 without a channel, got %v", err)
+	}
+}
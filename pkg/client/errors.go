@@ -0,0 +1,32 @@
+package client
+
+import (
+	"fmt"
+	"time"
+)
+
+// CodeRateLimited is the APIError code of requests rejected because the server
+// is rate limiting or overloaded.
+const CodeRateLimited = "RATE_LIMITED"
+
+// APIError is returned when the server responds with an unsuccessful API response.
+// It carries the HTTP status code so callers can distinguish, for example, a missing
+// key (404) from a conflict (409).
+type APIError struct {
+	StatusCode int
+	Message    string
+
+	// Code is the server's machine-readable error code, if any, e.g. CodeRateLimited.
+	Code string
+
+	// RetryAfter is how long the server asked the client to wait before retrying.
+	// It is set for 429 responses.
+	RetryAfter time.Duration
+
+	// RequestID is the X-Request-ID the server handled the request under, if any.
+	RequestID string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error: %s", e.Message)
+}
@@ -0,0 +1,214 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/api"
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+)
+
+// maxPendingWrites bounds the number of writes buffered for replay during an outage.
+const maxPendingWrites = 10000
+
+// ErrFallbackFull is returned for writes made during an outage once maxPendingWrites
+// writes are already waiting to be replayed.
+var ErrFallbackFull = errors.New("local fallback write buffer is full")
+
+// WithLocalFallback makes the client keep a local in-memory copy of its writes and
+// serve requests from it while the server is unreachable.
+//
+// While the server is reachable, every successful write is mirrored to the local
+// store. When a request fails because the server cannot be reached (a network error
+// connecting or talking to it), it is served by the local store instead, and writes
+// are buffered. Once the server is reachable again, buffered writes are replayed in
+// order before the next request is sent.
+//
+// Consistency caveats:
+//   - The local store only holds writes made through this client. During an outage,
+//     reads do not see other clients' writes and may return stale or missing values.
+//   - Replayed writes are applied after any writes other clients made during the
+//     outage, so the last replayed write wins. TTLs restart when a write is replayed.
+//   - A Pop served locally removes the item the local store holds; its replay pops
+//     whatever item is at the front of the server's list at that time.
+//   - A write whose connection failed after the server received it is applied
+//     again when replayed, so non-idempotent writes such as Push may be duplicated.
+//   - Replayed writes the server rejects, such as an Update of a key that no longer
+//     exists, are dropped. Writes still buffered when the process exits are lost.
+//   - At most 10000 writes are buffered; further writes fail with ErrFallbackFull.
+//
+// Call Close to release the local store.
+//
+// Example:
+//
+//	c := client.NewClient("http://localhost:8080", client.WithLocalFallback())
+//	defer c.Close()
+func WithLocalFallback() Option {
+	return func(c *Client) {
+		c.fallback = newFallback()
+	}
+}
+
+// fallback serves requests from an embedded store running the server's own handlers.
+type fallback struct {
+	store   *memory.MemoryStore
+	handler http.Handler
+
+	mu      sync.Mutex
+	pending []pendingWrite
+}
+
+// pendingWrite is a write served locally that still has to be sent to the server.
+type pendingWrite struct {
+	method   string
+	endpoint string
+	body     any
+	header   http.Header
+}
+
+func newFallback() *fallback {
+	s := memory.NewMemoryStore()
+	return &fallback{
+		store:   s,
+		handler: api.NewHandler(s).SetupRoutes(),
+	}
+}
+
+func (f *fallback) close() {
+	f.store.StopTTLWorker()
+
+	f.mu.Lock()
+	f.pending = nil
+	f.mu.Unlock()
+}
+
+// do sends a request to the server, replaying buffered writes first, and falls back
+// to the local store if the server is unreachable.
+func (f *fallback) do(ctx context.Context, c *Client, method, endpoint string, body any, header http.Header) (*Response, error) {
+	write := isWrite(method, endpoint)
+
+	f.mu.Lock()
+	if err := f.replay(ctx, c); err != nil {
+		defer f.mu.Unlock()
+		return f.serveLocally(ctx, method, endpoint, body, header, write)
+	}
+	f.mu.Unlock()
+
+	resp, err := c.send(ctx, method, endpoint, body, header)
+	if unreachable(ctx, err) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return f.serveLocally(ctx, method, endpoint, body, header, write)
+	}
+
+	if err == nil && write {
+		// Mirror the write so the local copy is current when the server goes away.
+		f.local(ctx, method, endpoint, body, header)
+	}
+
+	return resp, err
+}
+
+// replay sends buffered writes to the server in order. It stops at the first write
+// the server cannot be reached for and returns that error, keeping the rest buffered.
+// The caller must hold f.mu.
+func (f *fallback) replay(ctx context.Context, c *Client) error {
+	for len(f.pending) > 0 {
+		w := f.pending[0]
+		if _, err := c.send(ctx, w.method, w.endpoint, w.body, w.header); unreachable(ctx, err) {
+			return err
+		}
+		f.pending = f.pending[1:]
+	}
+	f.pending = nil
+	return nil
+}
+
+// serveLocally serves a request from the local store, buffering it for replay if it is a write.
+// The caller must hold f.mu.
+func (f *fallback) serveLocally(ctx context.Context, method, endpoint string, body any, header http.Header, write bool) (*Response, error) {
+	if write {
+		if len(f.pending) >= maxPendingWrites {
+			return nil, ErrFallbackFull
+		}
+		f.pending = append(f.pending, pendingWrite{
+			method:   method,
+			endpoint: endpoint,
+			body:     body,
+			header:   header,
+		})
+	}
+	return f.local(ctx, method, endpoint, body, header)
+}
+
+// local runs a request against the local store's handlers.
+func (f *fallback) local(ctx context.Context, method, endpoint string, body any, header http.Header) (*Response, error) {
+	payload, err := encodeBody(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := newRequest(ctx, method, endpoint, payload, header)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := httptest.NewRecorder()
+	f.handler.ServeHTTP(rec, req)
+
+	return parseResponse(rec.Code, rec.Body.Bytes())
+}
+
+// pendingWrites returns the number of writes waiting to be replayed.
+func (f *fallback) pendingWrites() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.pending)
+}
+
+// PendingWrites returns the number of writes made during an outage that have not
+// been replayed to the server yet. It is always 0 without WithLocalFallback.
+func (c *Client) PendingWrites() int {
+	if c.fallback == nil {
+		return 0
+	}
+	return c.fallback.pendingWrites()
+}
+
+// readOnlyPosts are POST endpoints that do not modify the store.
+var readOnlyPosts = map[string]bool{
+	"/api/v1/keys/get":      true,
+	"/api/v1/keys/multiget": true,
+	"/api/v1/keys/mget":     true,
+	"/api/v1/keys/info":     true,
+	"/api/v1/read/pipeline": true,
+}
+
+// isWrite reports whether a request modifies the store.
+func isWrite(method, endpoint string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return false
+	case http.MethodPost:
+		path, _, _ := strings.Cut(endpoint, "?")
+		return !readOnlyPosts[path]
+	}
+	return true
+}
+
+// unreachable reports whether err means the server could not be reached, as opposed
+// to the server rejecting the request or the caller cancelling it.
+func unreachable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+
+	// The HTTP client reports transport failures as *url.Error.
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
@@ -0,0 +1,140 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mo-mohamed/acronis-memory-store/internal/api"
+	"github.com/mo-mohamed/acronis-memory-store/internal/store/memory"
+	"github.com/mo-mohamed/acronis-memory-store/pkg/client"
+)
+
+// outageServer runs the real API and drops every connection while down is set.
+func outageServer(t *testing.T) (*httptest.Server, *memory.MemoryStore, *atomic.Bool) {
+	t.Helper()
+
+	var down atomic.Bool
+	memoryStore := memory.NewMemoryStore()
+	routes := api.NewHandler(memoryStore).SetupRoutes()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			panic(http.ErrAbortHandler)
+		}
+		routes.ServeHTTP(w, r)
+	}))
+	t.Cleanup(func() {
+		server.Close()
+		memoryStore.StopTTLWorker()
+	})
+	return server, memoryStore, &down
+}
+
+func TestClient_LocalFallback(t *testing.T) {
+	server, serverStore, down := outageServer(t)
+	c := client.NewClient(server.URL, client.WithLocalFallback())
+	defer c.Close()
+	ctx := context.Background()
+
+	c.Set(ctx, "user:1", "Alice", 0)
+	c.Push(ctx, "queue", "job1")
+
+	down.Store(true)
+
+	// Reads are served from the mirrored writes.
+	if value, err := c.Get(ctx, "user:1"); err != nil || value != "Alice" {
+		t.Errorf("Expected 'Alice' from the fallback, got %q (err %v)", value, err)
+	}
+
+	// Writes are applied locally and queued.
+	if err := c.Set(ctx, "user:2", "Bob", 0); err != nil {
+		t.Fatalf("Expected write during outage to succeed, got %v", err)
+	}
+	if err := c.Update(ctx, "user:1", "Alicia"); err != nil {
+		t.Fatalf("Expected write during outage to succeed, got %v", err)
+	}
+	if err := c.Push(ctx, "queue", "job2"); err != nil {
+		t.Fatalf("Expected write during outage to succeed, got %v", err)
+	}
+
+	if n := c.PendingWrites(); n != 3 {
+		t.Errorf("Expected 3 pending writes, got %d", n)
+	}
+
+	if value, err := c.Get(ctx, "user:2"); err != nil || value != "Bob" {
+		t.Errorf("Expected 'Bob' from the fallback, got %q (err %v)", value, err)
+	}
+
+	_, err := c.Get(ctx, "missing")
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for missing key during outage, got %v", err)
+	}
+
+	if _, err := serverStore.Get(ctx, "user:2"); err == nil {
+		t.Error("Expected the server not to have received writes during the outage")
+	}
+
+	down.Store(false)
+
+	// The next request replays the queued writes first.
+	if value, err := c.Get(ctx, "user:1"); err != nil || value != "Alicia" {
+		t.Errorf("Expected 'Alicia' from the server after replay, got %q (err %v)", value, err)
+	}
+
+	if n := c.PendingWrites(); n != 0 {
+		t.Errorf("Expected no pending writes after replay, got %d", n)
+	}
+
+	if value, err := serverStore.Get(ctx, "user:2"); err != nil || value != "Bob" {
+		t.Errorf("Expected replayed 'Bob' on the server, got %q (err %v)", value, err)
+	}
+
+	items, _ := serverStore.LRange(ctx, "queue", 0, -1)
+	if len(items) != 2 || items[0] != "job2" || items[1] != "job1" {
+		t.Errorf("Expected replayed queue [job2 job1] on the server, got %v", items)
+	}
+}
+
+func TestClient_LocalFallbackReads(t *testing.T) {
+	server, _, down := outageServer(t)
+	c := client.NewClient(server.URL, client.WithLocalFallback())
+	defer c.Close()
+	ctx := context.Background()
+
+	c.Set(ctx, "user:1", "Alice", 0)
+
+	down.Store(true)
+
+	// Reads sent as POST are served locally without being queued as writes.
+	values, err := c.MGet(ctx, []string{"user:1", "missing"})
+	if err != nil || len(values) != 1 || values["user:1"] != "Alice" {
+		t.Errorf("Expected only user:1 from the fallback, got %v (err %v)", values, err)
+	}
+	infos, err := c.KeysInfo(ctx, []string{"user:1"})
+	if err != nil || len(infos) != 1 || infos[0].Key != "user:1" {
+		t.Errorf("Expected the info of user:1 from the fallback, got %+v (err %v)", infos, err)
+	}
+
+	if n := c.PendingWrites(); n != 0 {
+		t.Errorf("Expected reads not to be queued as writes, got %d pending", n)
+	}
+}
+
+func TestClient_WithoutFallback(t *testing.T) {
+	server, _, down := outageServer(t)
+	c := client.NewClient(server.URL)
+	ctx := context.Background()
+
+	down.Store(true)
+
+	if err := c.Set(ctx, "key", "value", 0); err == nil {
+		t.Error("Expected an error without a fallback during an outage")
+	}
+	if n := c.PendingWrites(); n != 0 {
+		t.Errorf("Expected no pending writes without a fallback, got %d", n)
+	}
+}
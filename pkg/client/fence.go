@@ -0,0 +1,4 @@
+package client
+
+// fenceTokenHeader carries the fence token of a write, see Fence.
+const fenceTokenHeader = "X-Fence-Token"
@@ -0,0 +1,103 @@
+package client
+
+import "context"
+
+// HSet sets a field of a hash, creating the hash if needed, and reports whether the
+// field is new to the hash. Values that are not strings are stored as their JSON
+// encoding. A new hash does not expire. Keys not holding a hash fail with a 409
+// APIError, and servers that do not support hashes with ErrUnsupportedOperation.
+//
+// Example:
+//
+//	// Update one field of a user profile without rewriting the others
+//	created, err := client.HSet(ctx, "user:123", "email", "john@example.com")
+func (c *Client) HSet(ctx context.Context, key, field string, value any, opts ...WriteOption) (bool, error) {
+	req := HSetRequest{
+		Value: value,
+	}
+
+	resp, err := c.doWrite(ctx, "PUT", "/api/v1/hashes/"+key+"/fields/"+field, req, newWriteOptions(opts))
+	if err != nil {
+		return false, c.unsupported(ctx, OpHashes, err)
+	}
+
+	var data struct {
+		Created bool `json:"created"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return false, err
+	}
+
+	return data.Created, nil
+}
+
+// HGet retrieves the value of a field of a hash. Missing keys and fields fail with a
+// 404 APIError and keys not holding a hash with a 409 APIError.
+//
+// Example:
+//
+//	email, err := client.HGet(ctx, "user:123", "email")
+func (c *Client) HGet(ctx context.Context, key, field string) (string, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/hashes/"+key+"/fields/"+field, nil)
+	if err != nil {
+		return "", c.unsupported(ctx, OpHashes, err)
+	}
+
+	var data struct {
+		Value string `json:"value"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return "", err
+	}
+
+	return data.Value, nil
+}
+
+// HDel removes a field from a hash and reports whether it was there. The hash is kept
+// even once it has no fields left. Missing keys fail with a 404 APIError and keys not
+// holding a hash with a 409 APIError.
+//
+// Example:
+//
+//	deleted, err := client.HDel(ctx, "user:123", "email")
+func (c *Client) HDel(ctx context.Context, key, field string, opts ...WriteOption) (bool, error) {
+	resp, err := c.doWrite(ctx, "DELETE", "/api/v1/hashes/"+key+"/fields/"+field, nil, newWriteOptions(opts))
+	if err != nil {
+		return false, c.unsupported(ctx, OpHashes, err)
+	}
+
+	var data struct {
+		Deleted bool `json:"deleted"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return false, err
+	}
+
+	return data.Deleted, nil
+}
+
+// HGetAll retrieves every field of a hash with its value. Missing keys fail with a
+// 404 APIError and keys not holding a hash with a 409 APIError.
+//
+// Example:
+//
+//	profile, err := client.HGetAll(ctx, "user:123")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	fmt.Println(profile["name"], profile["email"])
+func (c *Client) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/hashes/"+key, nil)
+	if err != nil {
+		return nil, c.unsupported(ctx, OpHashes, err)
+	}
+
+	var data struct {
+		Fields map[string]string `json:"fields"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return nil, err
+	}
+
+	return data.Fields, nil
+}
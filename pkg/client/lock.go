@@ -0,0 +1,212 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrLockHeld is returned by Lock when the lock is currently held by someone else.
+	ErrLockHeld = errors.New("lock is held by another owner")
+
+	// ErrLockNotHeld is returned by Renew and Release when the lock has expired
+	// or has been acquired by another owner.
+	ErrLockNotHeld = errors.New("lock is not held")
+)
+
+// Lock is a distributed lock backed by a key in the memory store.
+// The key holds a random token identifying the owner, so a Lock can only
+// renew or release the key while it still holds its own token.
+type Lock struct {
+	client *Client
+	key    string
+	token  string
+	ttl    time.Duration
+
+	mu       sync.Mutex
+	stop     chan struct{}
+	done     chan struct{}
+	renewErr error
+}
+
+// Lock acquires the lock stored at key for the given TTL.
+// It does not wait: if the lock is already held, it returns ErrLockHeld.
+// The TTL is rounded up to whole seconds.
+//
+// Example:
+//
+//	lock, err := client.Lock(ctx, "lock:report", 30*time.Second)
+//	if errors.Is(err, client.ErrLockHeld) {
+//	    fmt.Println("Report is being generated by another worker")
+//	    return
+//	}
+//	defer lock.Release(ctx)
+func (c *Client) Lock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	token, err := newLockToken()
+	if err != nil {
+		return nil, err
+	}
+
+	acquired, err := c.SetNX(ctx, key, token, ttlSeconds(ttl))
+	if err != nil {
+		return nil, err
+	}
+
+	if !acquired {
+		return nil, ErrLockHeld
+	}
+
+	return &Lock{
+		client: c,
+		key:    key,
+		token:  token,
+		ttl:    ttl,
+	}, nil
+}
+
+// Key returns the key backing the lock.
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// Token returns the random token identifying this lock owner.
+func (l *Lock) Token() string {
+	return l.token
+}
+
+// Renew resets the lock TTL. It returns ErrLockNotHeld if the lock expired
+// or was acquired by another owner in the meantime.
+func (l *Lock) Renew(ctx context.Context) error {
+	req := ExpireRequest{
+		TTLSeconds: ttlSeconds(l.ttl),
+		IfValue:    &l.token,
+	}
+
+	_, err := l.client.doRequest(ctx, "PUT", "/api/v1/keys/"+l.key+"/ttl", req)
+	return lockError(err)
+}
+
+// Release stops auto-renewal and deletes the lock if it is still held by this owner.
+// It returns ErrLockNotHeld if the lock expired or was acquired by another owner,
+// in which case the other owner's lock is left untouched.
+func (l *Lock) Release(ctx context.Context) error {
+	l.stopAutoRenew()
+
+	_, err := l.client.doRequest(ctx, "DELETE", "/api/v1/keys/"+l.key+"?if_value="+url.QueryEscape(l.token), nil)
+	return lockError(err)
+}
+
+// AutoRenew renews the lock in the background every interval until Release is called
+// or a renewal fails. The interval should be comfortably shorter than the lock TTL.
+// Calling AutoRenew while auto-renewal is already running has no effect.
+//
+// Example:
+//
+//	lock.AutoRenew(10 * time.Second)
+//	select {
+//	case <-lock.Done():
+//	    log.Printf("lost lock: %v", lock.Err())
+//	case <-workFinished:
+//	}
+func (l *Lock) AutoRenew(interval time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.stop != nil {
+		return
+	}
+
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+	l.renewErr = nil
+
+	go l.renewLoop(interval, l.stop, l.done)
+}
+
+// Done returns a channel that is closed when auto-renewal stops, either because
+// Release was called or because a renewal failed. It returns nil if AutoRenew
+// was never called.
+func (l *Lock) Done() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.done
+}
+
+// Err returns the renewal error that stopped auto-renewal, if any.
+func (l *Lock) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.renewErr
+}
+
+func (l *Lock) renewLoop(interval time.Duration, stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			err := l.Renew(ctx)
+			cancel()
+
+			if err != nil {
+				l.mu.Lock()
+				l.renewErr = err
+				l.stop = nil
+				l.mu.Unlock()
+				return
+			}
+		}
+	}
+}
+
+func (l *Lock) stopAutoRenew() {
+	l.mu.Lock()
+	stop, done := l.stop, l.done
+	l.stop = nil
+	l.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// lockError maps conflict and not-found API errors to ErrLockNotHeld.
+func lockError(err error) error {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusConflict) {
+		return ErrLockNotHeld
+	}
+	return err
+}
+
+// ttlSeconds rounds a duration up to whole seconds, with a minimum of one second.
+func ttlSeconds(d time.Duration) int {
+	seconds := int((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+func newLockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,108 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mo-mohamed/acronis-memory-store/pkg/client"
+)
+
+func TestLock_MutualExclusion(t *testing.T) {
+	server := storeServer(t)
+	ctx := context.Background()
+
+	first := client.NewClient(server.URL)
+	second := client.NewClient(server.URL)
+
+	lock, err := first.Lock(ctx, "lock:job", 10*time.Second)
+	if err != nil {
+		t.Fatalf("Expected lock to be acquired, got %v", err)
+	}
+
+	if _, err := second.Lock(ctx, "lock:job", 10*time.Second); !errors.Is(err, client.ErrLockHeld) {
+		t.Fatalf("Expected ErrLockHeld while lock is held, got %v", err)
+	}
+
+	if err := lock.Renew(ctx); err != nil {
+		t.Errorf("Expected renew to succeed, got %v", err)
+	}
+
+	if err := lock.Release(ctx); err != nil {
+		t.Fatalf("Expected release to succeed, got %v", err)
+	}
+
+	other, err := second.Lock(ctx, "lock:job", 10*time.Second)
+	if err != nil {
+		t.Fatalf("Expected lock to be acquired after release, got %v", err)
+	}
+	defer other.Release(ctx)
+}
+
+func TestLock_ReleaseDoesNotFreeOthersLock(t *testing.T) {
+	server := storeServer(t)
+	ctx := context.Background()
+	c := client.NewClient(server.URL)
+
+	stale, err := c.Lock(ctx, "lock:stale", time.Second)
+	if err != nil {
+		t.Fatalf("Expected lock to be acquired, got %v", err)
+	}
+
+	// Let the first lock expire and have someone else take it over.
+	time.Sleep(1100 * time.Millisecond)
+
+	current, err := c.Lock(ctx, "lock:stale", 10*time.Second)
+	if err != nil {
+		t.Fatalf("Expected lock to be acquired after expiry, got %v", err)
+	}
+
+	if err := stale.Renew(ctx); !errors.Is(err, client.ErrLockNotHeld) {
+		t.Errorf("Expected ErrLockNotHeld on stale renew, got %v", err)
+	}
+
+	if err := stale.Release(ctx); !errors.Is(err, client.ErrLockNotHeld) {
+		t.Errorf("Expected ErrLockNotHeld on stale release, got %v", err)
+	}
+
+	value, err := c.Get(ctx, "lock:stale")
+	if err != nil {
+		t.Fatalf("Expected current lock to still exist, got %v", err)
+	}
+	if value != current.Token() {
+		t.Errorf("Expected current owner's token %q, got %q", current.Token(), value)
+	}
+}
+
+func TestLock_AutoRenew(t *testing.T) {
+	server := storeServer(t)
+	ctx := context.Background()
+	c := client.NewClient(server.URL)
+
+	lock, err := c.Lock(ctx, "lock:renewed", time.Second)
+	if err != nil {
+		t.Fatalf("Expected lock to be acquired, got %v", err)
+	}
+	lock.AutoRenew(300 * time.Millisecond)
+
+	// Without renewal the lock would have expired by now.
+	time.Sleep(1500 * time.Millisecond)
+
+	if _, err := c.Lock(ctx, "lock:renewed", time.Second); !errors.Is(err, client.ErrLockHeld) {
+		t.Errorf("Expected auto-renewed lock to still be held, got %v", err)
+	}
+
+	if err := lock.Release(ctx); err != nil {
+		t.Fatalf("Expected release to succeed, got %v", err)
+	}
+
+	select {
+	case <-lock.Done():
+	default:
+		t.Error("Expected auto-renewal to stop after release")
+	}
+	if lock.Err() != nil {
+		t.Errorf("Expected no renewal error, got %v", lock.Err())
+	}
+}
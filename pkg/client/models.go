@@ -1,21 +1,65 @@
 // Package client provides data structures and models for the Memory Store API client.
 package client
 
+import (
+	"encoding/json"
+	"time"
+)
+
+// Key types reported by the server.
+const (
+	TypeString = "string"
+	TypeList   = "list"
+	TypeHash   = "hash"
+	TypeSet    = "set"
+	TypeNone   = "none"
+)
+
+// Orderings accepted by TopKeys.
+const (
+	TopBySize   = "size"
+	TopByTTL    = "ttl"
+	TopByAccess = "access"
+)
+
+// Labels of TTLHistogram buckets besides the bucket durations, which are labeled by
+// their time.Duration string.
+const (
+	// TTLBucketLonger counts keys expiring after the largest bucket.
+	TTLBucketLonger = "longer"
+	// TTLBucketNever counts keys without a TTL.
+	TTLBucketNever = "never"
+)
+
+// Operations accepted by ListBatch.
+const (
+	ListOpPush = "push"
+	ListOpPop  = "pop"
+)
+
 // Response represents the standard API response structure returned by all endpoints.
 // It contains a success flag, optional data payload, and optional error message.
 type Response struct {
-	Success bool   `json:"success"`
-	Data    any    `json:"data,omitempty"`
-	Error   string `json:"error,omitempty"`
+	Success      bool   `json:"success"`
+	Data         any    `json:"data,omitempty"`
+	Error        string `json:"error,omitempty"`
+	Code         string `json:"code,omitempty"`
+	RetryAfterMs int64  `json:"retry_after_ms,omitempty"`
 }
 
 // SetRequest represents the request payload for SET operations.
 // It contains the key to store, the value to associate with the key,
-// and the TTL in seconds.
+// and the TTL in seconds. When NX is set, the key is only stored if it does not exist.
 type SetRequest struct {
 	Key        string `json:"key"`
 	Value      any    `json:"value"`
 	TTLSeconds int    `json:"ttl_seconds"`
+	NX         bool   `json:"nx,omitempty"`
+	// Compress set to false stores the value uncompressed even if the server
+	// compresses values of its size.
+	Compress *bool `json:"compress,omitempty"`
+	// Tags replace the tags of the key. Setting a key without tags clears them.
+	Tags []string `json:"tags,omitempty"`
 }
 
 // UpdateRequest represents the request payload for UPDATE operations.
@@ -25,15 +69,319 @@ type UpdateRequest struct {
 	Value any `json:"value"`
 }
 
+// GetSetRequest represents the request payload for GETSET operations.
+// The key is specified in the URL path.
+type GetSetRequest struct {
+	Value any `json:"value"`
+}
+
+// ExpireRequest represents the request payload for changing the TTL of a key.
+// When IfValue is set, the TTL is only changed if the key currently holds that value.
+type ExpireRequest struct {
+	TTLSeconds int     `json:"ttl_seconds"`
+	IfValue    *string `json:"if_value,omitempty"`
+}
+
+// IncrRequest represents the request payload for Increment and IncrWithCeiling.
+// Ceiling is only set by IncrWithCeiling.
+type IncrRequest struct {
+	Delta   int64  `json:"delta"`
+	Ceiling *int64 `json:"ceiling,omitempty"`
+}
+
+// DecrRequest represents the request payload for Decrement and DecrWithFloor.
+// Floor is only set by DecrWithFloor.
+type DecrRequest struct {
+	Delta int64  `json:"delta"`
+	Floor *int64 `json:"floor,omitempty"`
+}
+
+// CounterResponse is the value of a counter after an operation. Applied is false if
+// the operation was refused by its bound, in which case Value is unchanged.
+type CounterResponse struct {
+	Value   int64 `json:"value"`
+	Applied bool  `json:"applied"`
+}
+
+// ExpirePatternRequest represents the request payload for changing the TTL of all keys matching a pattern.
+type ExpirePatternRequest struct {
+	TTLSeconds int `json:"ttl_seconds"`
+}
+
+// LSetRequest represents the request payload for replacing or initializing a list.
+type LSetRequest struct {
+	Key        string `json:"key"`
+	Items      []any  `json:"items"`
+	TTLSeconds int    `json:"ttl_seconds"`
+	NX         bool   `json:"nx,omitempty"`
+}
+
+// LMoveAllRequest represents the request payload for moving all items between lists.
+type LMoveAllRequest struct {
+	Src string `json:"src"`
+	Dst string `json:"dst"`
+}
+
+// AckRequest represents the request payload for acknowledging a reserved list item.
+type AckRequest struct {
+	Receipt string `json:"receipt"`
+}
+
 // PushRequest represents the request payload for PUSH operations on lists.
 // It contains the list key and the item to add to the front of the list.
 type PushRequest struct {
+	Key           string `json:"key"`
+	Item          any    `json:"item"`
+	Resurrect     bool   `json:"resurrect,omitempty"`
+	MaxLen        int    `json:"max_len,omitempty"`
+	ReturnEvicted bool   `json:"return_evicted,omitempty"`
+}
+
+// ListItem is a list item returned by PopItem and RPopItem, along with the
+// sequence number the server assigned to it when it was pushed.
+type ListItem struct {
+	Value string `json:"value"`
+	Seq   uint64 `json:"seq"`
+	// PushedAt is when the item was pushed. It is only set by LRangeWithMeta, and
+	// only if the server records push times; it is zero otherwise.
+	PushedAt time.Time `json:"pushed_at"`
+}
+
+// RPushRequest represents the request payload for RPUSH operations on lists.
+// It contains the list key and the item to add to the end of the list.
+type RPushRequest struct {
 	Key  string `json:"key"`
 	Item any    `json:"item"`
 }
 
-// PopRequest represents the request payload for POP operations on lists.
-// It contains only the list key from which to remove and return the front item.
+// PopRequest represents the request payload for POP and RPOP operations on lists.
+// It contains only the list key from which to remove and return an item.
 type PopRequest struct {
 	Key string `json:"key"`
 }
+
+// PublishRequest represents the request payload for publishing a message on a channel.
+type PublishRequest struct {
+	Channel string `json:"channel"`
+	Payload any    `json:"payload"`
+}
+
+// BLPopRequest represents the request payload for blocking POP operations on lists.
+// TimeoutSeconds is how long the server waits for an item, and may be fractional.
+type BLPopRequest struct {
+	Key            string  `json:"key"`
+	TimeoutSeconds float64 `json:"timeout_seconds"`
+}
+
+// RateIncrRequest represents the request payload for counting a hit against a rate limit.
+type RateIncrRequest struct {
+	Key      string `json:"key"`
+	WindowMs int64  `json:"window_ms"`
+	Limit    int    `json:"limit"`
+}
+
+// MultiGetRequest represents the request payload for fetching several keys at once.
+type MultiGetRequest struct {
+	Keys []string `json:"keys"`
+}
+
+// MSetRequest represents the request payload for setting several keys at once.
+type MSetRequest struct {
+	Pairs      map[string]any `json:"pairs"`
+	TTLSeconds int            `json:"ttl_seconds"`
+	ChunkSize  int            `json:"chunk_size,omitempty"`
+}
+
+// MSetResponse reports how many keys an MSet wrote.
+type MSetResponse struct {
+	Count int `json:"count"`
+}
+
+// MGetRequest represents the request payload for fetching several string values at once.
+type MGetRequest struct {
+	Keys []string `json:"keys"`
+}
+
+// KeysInfoRequest represents the request payload for KeysInfo.
+type KeysInfoRequest struct {
+	Keys []string `json:"keys"`
+}
+
+// KeyEntry describes a key returned by MultiGet. Type is TypeString, TypeList,
+// TypeHash, TypeSet or TypeNone for missing keys. Value is set for strings, Items for
+// lists and the sorted members of sets, and Fields for hashes. TTLSeconds is the remaining time to live, or -1 if the key does
+// not expire.
+type KeyEntry struct {
+	Key        string            `json:"key"`
+	Type       string            `json:"type"`
+	Value      string            `json:"-"`
+	Items      []string          `json:"-"`
+	Fields     map[string]string `json:"-"`
+	TTLSeconds int               `json:"ttl_seconds,omitempty"`
+}
+
+// UnmarshalJSON decodes the server's "value" field into Value, Items or Fields
+// depending on Type.
+func (e *KeyEntry) UnmarshalJSON(data []byte) error {
+	type entry KeyEntry
+	aux := struct {
+		*entry
+		Value json.RawMessage `json:"value"`
+	}{entry: (*entry)(e)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	return decodeTypedValue(e.Type, aux.Value, &e.Value, &e.Items, &e.Fields)
+}
+
+// KeyDump describes a key returned by ExportPattern, in the same form as KeyEntry.
+type KeyDump = KeyEntry
+
+// VerifyResponse represents the result of VerifyIntegrity.
+type VerifyResponse struct {
+	OK       bool     `json:"ok"`
+	Problems []string `json:"problems"`
+}
+
+// AnyValue is the value of a key of any type, returned by GetAny. Type is
+// TypeString, TypeList, TypeHash or TypeSet; Value is set for strings, Items for lists
+// and the sorted members of sets, and Fields for hashes.
+type AnyValue struct {
+	Key    string            `json:"key"`
+	Type   string            `json:"type"`
+	Value  string            `json:"-"`
+	Items  []string          `json:"-"`
+	Fields map[string]string `json:"-"`
+}
+
+// UnmarshalJSON decodes the server's "value" field into Value, Items or Fields
+// depending on Type.
+func (a *AnyValue) UnmarshalJSON(data []byte) error {
+	type anyValue AnyValue
+	aux := struct {
+		*anyValue
+		Value json.RawMessage `json:"value"`
+	}{anyValue: (*anyValue)(a)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	return decodeTypedValue(a.Type, aux.Value, &a.Value, &a.Items, &a.Fields)
+}
+
+// decodeTypedValue decodes raw into value for strings, into items for lists and sets
+// or into fields for hashes.
+func decodeTypedValue(kind string, raw json.RawMessage, value *string, items *[]string, fields *map[string]string) error {
+	switch kind {
+	case TypeString:
+		return json.Unmarshal(raw, value)
+	case TypeList, TypeSet:
+		return json.Unmarshal(raw, items)
+	case TypeHash:
+		return json.Unmarshal(raw, fields)
+	}
+	return nil
+}
+
+// StoreStats holds runtime statistics of the server's store, returned by Stats. Key
+// counts and MemoryBytes only cover live keys.
+type StoreStats struct {
+	Keys        int `json:"keys"`
+	Strings     int `json:"strings"`
+	Lists       int `json:"lists"`
+	Hashes      int `json:"hashes"`
+	Sets        int `json:"sets"`
+	KeysWithTTL int `json:"keys_with_ttl"`
+	// MemoryBytes is the estimated memory taken by the keys and their values.
+	MemoryBytes int64     `json:"memory_bytes"`
+	Locks       LockStats `json:"locks"`
+}
+
+// LockStats reports contention on the store lock. Counters only advance while lock
+// metrics are enabled on the server.
+type LockStats struct {
+	Enabled          bool          `json:"enabled"`
+	WriteContentions uint64        `json:"write_contentions"`
+	WriteWait        time.Duration `json:"write_wait_ns"`
+	ReadContentions  uint64        `json:"read_contentions"`
+	ReadWait         time.Duration `json:"read_wait_ns"`
+	// MaxWriteHold is the longest the write lock was held at once.
+	MaxWriteHold time.Duration `json:"max_write_hold_ns"`
+}
+
+// KeySize describes a key returned by TopKeys.
+type KeySize struct {
+	Key  string `json:"key"`
+	Type string `json:"type"`
+	// SizeBytes is the estimated memory taken by the key and its value.
+	SizeBytes int `json:"size_bytes"`
+	// TTLSeconds is the remaining time to live, or -1 if the key does not expire.
+	TTLSeconds int `json:"ttl_seconds"`
+	// Hits is the number of reads and writes of the key since it was created.
+	Hits uint64 `json:"hits"`
+}
+
+// KeyInfo describes a key returned by KeysInfo.
+type KeyInfo = KeySize
+
+// ListOp is a single operation of a ListBatch. Item is only used by pushes.
+type ListOp struct {
+	Key  string `json:"key"`
+	Op   string `json:"op"`
+	Item any    `json:"item,omitempty"`
+}
+
+// ListOpResult is the outcome of a ListOp. Item holds the popped item of successful
+// pops. Code and Error describe why an operation failed, e.g. "TYPE_MISMATCH".
+type ListOpResult struct {
+	Key     string  `json:"key"`
+	Op      string  `json:"op"`
+	Success bool    `json:"success"`
+	Item    *string `json:"item,omitempty"`
+	Code    string  `json:"code,omitempty"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// ReadSpec is a single read of a PipelineGet. Without Index it reads the string value
+// of Key; with Index it reads the list item at that index, negative indexes counting
+// from the tail.
+type ReadSpec struct {
+	Key   string `json:"key"`
+	Index *int   `json:"index,omitempty"`
+}
+
+// ReadResult is the outcome of a ReadSpec. Value holds the value or item read by
+// successful reads. Code and Error describe why a read failed, e.g. "KEY_NOT_FOUND".
+type ReadResult struct {
+	Key     string  `json:"key"`
+	Index   *int    `json:"index,omitempty"`
+	Success bool    `json:"success"`
+	Value   *string `json:"value,omitempty"`
+	Code    string  `json:"code,omitempty"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// ListPage is a range of list items returned by LRangePage. Total is the length of the
+// whole list. Start and Stop are the resolved indexes of the first and last item
+// returned; Stop is Start-1 when the range is empty.
+type ListPage struct {
+	Items []string `json:"items"`
+	// Seqs are the sequence numbers of Items, see Client.PushSeq.
+	Seqs  []uint64 `json:"seqs"`
+	Total int      `json:"total"`
+	Start int      `json:"start"`
+	Stop  int      `json:"stop"`
+}
+
+// HSetRequest represents the request payload for HSet.
+type HSetRequest struct {
+	Value any `json:"value"`
+}
+
+// SetMembersRequest represents the request payload for SAdd and SRem.
+type SetMembersRequest struct {
+	Members []string `json:"members"`
+}
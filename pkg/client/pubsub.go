@@ -0,0 +1,121 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Message is a message published on a pub/sub channel. Payload is the JSON encoding
+// of the published value.
+type Message struct {
+	Channel string          `json:"channel"`
+	Payload json.RawMessage `json:"payload"`
+	Time    time.Time       `json:"time"`
+}
+
+// Publish sends payload to the current subscribers of channel and returns how many of
+// them got it. Messages are not stored, so a channel without subscribers drops them.
+// Servers that do not support pub/sub fail with ErrUnsupportedOperation.
+//
+// Example:
+//
+//	receivers, err := client.Publish(ctx, "orders", Order{ID: 42, Status: "shipped"})
+func (c *Client) Publish(ctx context.Context, channel string, payload any) (int, error) {
+	req := PublishRequest{
+		Channel: channel,
+		Payload: payload,
+	}
+
+	resp, err := c.doRequest(ctx, "POST", "/api/v1/publish", req)
+	if err != nil {
+		return 0, c.unsupported(ctx, OpPubSub, err)
+	}
+
+	var data struct {
+		Receivers int `json:"receivers"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return 0, err
+	}
+
+	return data.Receivers, nil
+}
+
+// Subscribe returns the messages published on channel from now on, streamed by the
+// server until ctx is done or the connection is lost, which closes the returned
+// channel. The client's HTTP timeout does not apply to the stream; cancel ctx to
+// unsubscribe. The server drops messages for subscribers that fall far behind, so
+// read the channel promptly.
+//
+// With the server started with KEYSPACE_NOTIFICATIONS, subscribing to
+// "__keyspace__:" followed by a key delivers a message whenever the key changes.
+//
+// Example:
+//
+//	ctx, cancel := context.WithCancel(ctx)
+//	defer cancel()
+//	messages, err := client.Subscribe(ctx, "orders")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for msg := range messages {
+//	    fmt.Println("received", string(msg.Payload))
+//	}
+func (c *Client) Subscribe(ctx context.Context, channel string) (<-chan Message, error) {
+	req, err := newRequest(c.withGeneratedRequestID(ctx), "GET", c.baseURL+"/api/v1/subscribe?channel="+url.QueryEscape(channel), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	stream := *c.httpClient
+	stream.Timeout = 0
+	resp, err := stream.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to perform request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		if _, err = parseResponse(resp.StatusCode, respBody); err == nil {
+			err = fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		return nil, c.unsupported(ctx, OpPubSub, err)
+	}
+
+	messages := make(chan Message)
+	go func() {
+		defer close(messages)
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(nil, 16<<20)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			var msg Message
+			if json.Unmarshal([]byte(data), &msg) != nil {
+				continue
+			}
+			select {
+			case messages <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return messages, nil
+}
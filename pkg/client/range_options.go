@@ -0,0 +1,30 @@
+package client
+
+// RangeOption configures an individual LRange, LRangePage or LRangeJSON call.
+type RangeOption func(*rangeOptions)
+
+type rangeOptions struct {
+	reverse bool
+}
+
+// Reverse reads the list tail first. start and stop then index the reversed list:
+// 0 is the last item and -1 the first, so LRange(ctx, key, 0, 9, Reverse()) returns
+// the 10 items at the tail, last one first. The stored order is not changed.
+//
+// Example:
+//
+//	// Read the 20 oldest tasks, oldest first, since Push adds to the head
+//	tasks, err := client.LRange(ctx, "queue:tasks", 0, 19, client.Reverse())
+func Reverse() RangeOption {
+	return func(o *rangeOptions) {
+		o.reverse = true
+	}
+}
+
+func newRangeOptions(opts []RangeOption) rangeOptions {
+	var o rangeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
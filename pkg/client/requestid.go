@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+)
+
+// requestIDHeader carries the ID of a request, echoed back by the server and written
+// to its access log.
+const requestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context that makes the requests sent with it carry id in the
+// X-Request-ID header, so they can be found in the server's access log. Retries of a
+// request carry the same ID.
+//
+// Example:
+//
+//	ctx = client.WithRequestID(ctx, incoming.Header.Get("X-Request-ID"))
+//	value, err := c.Get(ctx, "user:123")
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// WithRequestIDs makes the client generate a random X-Request-ID for every request
+// whose context does not carry one set with WithRequestID. Retries of a request carry
+// the same ID. The ID the server used is available as APIError.RequestID for failed
+// requests, and through WithRequestHook for all of them.
+//
+// Example:
+//
+//	c := client.NewClient("http://localhost:8080", client.WithRequestIDs())
+func WithRequestIDs() Option {
+	return func(c *Client) {
+		c.requestIDs = true
+	}
+}
+
+// requestID returns the request ID carried by ctx, or "" if it carries none.
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// withGeneratedRequestID returns ctx carrying a new request ID, unless the client
+// does not generate them or ctx already carries one.
+func (c *Client) withGeneratedRequestID(ctx context.Context) context.Context {
+	if !c.requestIDs || requestID(ctx) != "" {
+		return ctx
+	}
+	return WithRequestID(ctx, newRequestID())
+}
+
+// newRequestID returns a random version 4 UUID.
+func newRequestID() string {
+	var b [16]byte
+	// crypto/rand.Read does not fail on supported platforms.
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
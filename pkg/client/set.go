@@ -0,0 +1,140 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// SAdd adds members to a set, creating the set if needed, and returns how many of them
+// were not already members. Adding members again changes nothing, so SAdd is safe to
+// retry. A new set does not expire. Keys not holding a set fail with a 409 APIError,
+// and servers that do not support sets with ErrUnsupportedOperation.
+//
+// Example:
+//
+//	// Track unique visitors of a page
+//	added, err := client.SAdd(ctx, "visitors:/home", []string{"visitor-123"})
+func (c *Client) SAdd(ctx context.Context, key string, members []string, opts ...WriteOption) (int, error) {
+	if len(members) == 0 {
+		return 0, fmt.Errorf("at least one member is required")
+	}
+
+	req := SetMembersRequest{
+		Members: members,
+	}
+
+	resp, err := c.doWrite(ctx, "POST", "/api/v1/sets/"+key+"/add", req, newWriteOptions(opts))
+	if err != nil {
+		return 0, c.unsupported(ctx, OpSets, err)
+	}
+
+	var data struct {
+		Added int `json:"added"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return 0, err
+	}
+
+	return data.Added, nil
+}
+
+// SRem removes members from a set and returns how many of them were members. The set
+// is kept even once it has no members left. Missing keys fail with a 404 APIError and
+// keys not holding a set with a 409 APIError.
+//
+// Example:
+//
+//	removed, err := client.SRem(ctx, "visitors:/home", []string{"visitor-123"})
+func (c *Client) SRem(ctx context.Context, key string, members []string, opts ...WriteOption) (int, error) {
+	if len(members) == 0 {
+		return 0, fmt.Errorf("at least one member is required")
+	}
+
+	req := SetMembersRequest{
+		Members: members,
+	}
+
+	resp, err := c.doWrite(ctx, "POST", "/api/v1/sets/"+key+"/remove", req, newWriteOptions(opts))
+	if err != nil {
+		return 0, c.unsupported(ctx, OpSets, err)
+	}
+
+	var data struct {
+		Removed int `json:"removed"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return 0, err
+	}
+
+	return data.Removed, nil
+}
+
+// SMembers retrieves the members of a set, sorted. Missing keys fail with a 404
+// APIError and keys not holding a set with a 409 APIError.
+//
+// Example:
+//
+//	visitors, err := client.SMembers(ctx, "visitors:/home")
+func (c *Client) SMembers(ctx context.Context, key string) ([]string, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/sets/"+key+"/members", nil)
+	if err != nil {
+		return nil, c.unsupported(ctx, OpSets, err)
+	}
+
+	var data struct {
+		Members []string `json:"members"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return nil, err
+	}
+
+	return data.Members, nil
+}
+
+// SIsMember reports whether member belongs to a set, without fetching the other
+// members. Missing keys fail with a 404 APIError and keys not holding a set with a 409
+// APIError.
+//
+// Example:
+//
+//	seen, err := client.SIsMember(ctx, "visitors:/home", "visitor-123")
+func (c *Client) SIsMember(ctx context.Context, key, member string) (bool, error) {
+	endpoint := "/api/v1/sets/" + key + "/contains?member=" + url.QueryEscape(member)
+	resp, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return false, c.unsupported(ctx, OpSets, err)
+	}
+
+	var data struct {
+		IsMember bool `json:"is_member"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return false, err
+	}
+
+	return data.IsMember, nil
+}
+
+// SCard returns the number of members of a set. Missing keys fail with a 404 APIError
+// and keys not holding a set with a 409 APIError.
+//
+// Example:
+//
+//	// Count unique visitors
+//	visitors, err := client.SCard(ctx, "visitors:/home")
+func (c *Client) SCard(ctx context.Context, key string) (int, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/sets/"+key+"/card", nil)
+	if err != nil {
+		return 0, c.unsupported(ctx, OpSets, err)
+	}
+
+	var data struct {
+		Card int `json:"card"`
+	}
+	if err := decodeData(resp, &data); err != nil {
+		return 0, err
+	}
+
+	return data.Card, nil
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// WriteOption configures an individual write, such as a Set, Remove, Expire or Push call.
+type WriteOption func(*writeOptions)
+
+type writeOptions struct {
+	previous   **KeyEntry
+	soft       bool
+	resurrect  bool
+	noCompress bool
+	tags       []string
+	fenceToken uint64
+	fenced     bool
+}
+
+// ReturnPrevious makes the write report the entry it replaced, removed or re-timed
+// by storing it in dst. dst is set to nil if the key did not exist.
+// The previous entry is captured atomically with the write, saving a separate Get.
+//
+// Example:
+//
+//	var previous *client.KeyEntry
+//	err := client.Set(ctx, "config:mode", "maintenance", 0, client.ReturnPrevious(&previous))
+//	if err == nil && previous != nil {
+//	    log.Printf("mode changed from %q", previous.Value)
+//	}
+func ReturnPrevious(dst **KeyEntry) WriteOption {
+	return func(o *writeOptions) {
+		o.previous = dst
+	}
+}
+
+// SoftDelete makes Remove keep the removed value on the server for a recovery window,
+// during which Restore brings the key back. It only applies to Remove and cannot be
+// combined with ReturnPrevious.
+//
+// Example:
+//
+//	err := client.Remove(ctx, "user:123", client.SoftDelete())
+//	// ...
+//	err = client.Restore(ctx, "user:123")
+func SoftDelete() WriteOption {
+	return func(o *writeOptions) {
+		o.soft = true
+	}
+}
+
+// Resurrect makes Push restore a soft deleted list and push onto it, instead of
+// failing while the key can still be restored. It only applies to Push.
+//
+// Example:
+//
+//	err := client.Push(ctx, "queue:tasks", "task-1", client.Resurrect())
+func Resurrect() WriteOption {
+	return func(o *writeOptions) {
+		o.resurrect = true
+	}
+}
+
+// NoCompress makes Set store the value uncompressed even if the server compresses
+// values of its size. It suits incompressible values, such as random tokens, where
+// compression only wastes server CPU. It only applies to Set.
+//
+// Example:
+//
+//	err := client.Set(ctx, "session:abc", token, 3600, client.NoCompress())
+func NoCompress() WriteOption {
+	return func(o *writeOptions) {
+		o.noCompress = true
+	}
+}
+
+// Tags makes Set tag the key with tags, replacing any tags it had, so related keys
+// can be listed with KeysByTag and deleted together with DeleteByTag. A Set without
+// Tags clears the key's tags, while Update keeps them. It only applies to Set.
+//
+// Example:
+//
+//	err := client.Set(ctx, "profile:123", profile, 3600, client.Tags("user:123", "profiles"))
+func Tags(tags ...string) WriteOption {
+	return func(o *writeOptions) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// Fence makes the write carry a fence token, as returned by SetNXFenced or
+// ExpireFenced. The server rejects the write with a 409 APIError if a higher token
+// has since been issued or used for the key, so a client whose lock expired cannot
+// overwrite the data of the lock's new holder. It applies to every write.
+//
+// Example:
+//
+//	acquired, token, err := client.SetNXFenced(ctx, "lock:report", "worker-1", 30)
+//	if err != nil || !acquired {
+//	    return
+//	}
+//	err = client.Set(ctx, "report", result, 0, client.Fence(token))
+func Fence(token uint64) WriteOption {
+	return func(o *writeOptions) {
+		o.fenceToken = token
+		o.fenced = true
+	}
+}
+
+func newWriteOptions(opts []WriteOption) writeOptions {
+	var o writeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// query returns the query string to append to the write endpoint.
+func (o writeOptions) query() string {
+	q := url.Values{}
+	if o.previous != nil {
+		q.Set("return", "previous")
+	}
+	if o.soft {
+		q.Set("soft", "true")
+	}
+	if len(q) == 0 {
+		return ""
+	}
+	return "?" + q.Encode()
+}
+
+// header returns the headers to send with the write, or nil if there are none.
+func (o writeOptions) header() http.Header {
+	if !o.fenced {
+		return nil
+	}
+	return http.Header{fenceTokenHeader: {strconv.FormatUint(o.fenceToken, 10)}}
+}
+
+// decode stores the previous entry from a write response if it was requested.
+func (o writeOptions) decode(resp *Response) error {
+	if o.previous == nil {
+		return nil
+	}
+
+	var out struct {
+		Previous *KeyEntry `json:"previous"`
+	}
+	if err := decodeData(resp, &out); err != nil {
+		return err
+	}
+
+	*o.previous = out.Previous
+	return nil
+}